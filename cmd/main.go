@@ -28,6 +28,7 @@ func main() {
 		markdownContent string
 		exportDoc       string
 		exportFormat    string
+		exportTOC       bool
 		debugMarkdown   string
 		
 		// New block operations
@@ -60,6 +61,7 @@ func main() {
 	flag.StringVar(&markdownContent, "content", "", "Markdown content")
 	flag.StringVar(&exportDoc, "export", "", "Export document (specify doc ID)")
 	flag.StringVar(&exportFormat, "format", "html", "Export format (pdf, docx, html)")
+	flag.BoolVar(&exportTOC, "toc", false, "Include a table of contents in the export")
 	flag.StringVar(&debugMarkdown, "debug-markdown", "", "Show generated markdown for document (specify doc ID)")
 	
 	// New block operation flags
@@ -137,10 +139,14 @@ func main() {
 	}
 	
 	if exportDoc != "" {
-		runTerminalCommand(ctx, h, "export_document", map[string]interface{}{
+		exportArgs := map[string]interface{}{
 			"document_id": exportDoc,
 			"format":      exportFormat,
-		})
+		}
+		if exportTOC {
+			exportArgs["toc"] = true
+		}
+		runTerminalCommand(ctx, h, "export_document", exportArgs)
 		return
 	}
 	
@@ -1,9 +1,10 @@
 module github.com/savant/mcp-servers/docgen2
 
-go 1.23
+go 1.23.0
 
 require (
 	github.com/gomcpgo/mcp v0.0.0-00010101000000-000000000000
+	golang.org/x/image v0.25.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -1,5 +1,15 @@
 package blocks
 
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+)
+
 // BlockType represents the type of content block
 type BlockType string
 
@@ -9,6 +19,14 @@ const (
 	TypeImage     BlockType = "image"
 	TypeTable     BlockType = "table"
 	TypePageBreak BlockType = "page_break"
+	TypeChart     BlockType = "chart"
+	TypeDiagram   BlockType = "diagram"
+	TypeGallery   BlockType = "gallery"
+	TypeInclude   BlockType = "include"
+	TypeRaw       BlockType = "raw"
+	TypeList      BlockType = "list"
+	TypeQuote     BlockType = "quote"
+	TypeLayout    BlockType = "layout"
 )
 
 // Block is the interface for all block types
@@ -57,6 +75,58 @@ type ImageBlock struct {
 	Path      string `yaml:"path"`     // Relative path to image in assets folder
 	Caption   string `yaml:"caption,omitempty"`
 	AltText   string `yaml:"alt_text,omitempty"`
+	Width     string `yaml:"width,omitempty"` // e.g. "50%", "8cm", "3in", "300px"; full width when empty
+	Align     string `yaml:"align,omitempty"` // left, center or right
+	Float     string `yaml:"float,omitempty"` // left or right: text wraps around the image
+}
+
+// Image alignment and float values
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+// widthPattern matches a number followed by a supported unit
+var widthPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)(%|px|cm|mm|in|pt|em)$`)
+
+// ParseWidth splits an image width such as "50%" into its value and unit
+func ParseWidth(width string) (float64, string, error) {
+	match := widthPattern.FindStringSubmatch(strings.TrimSpace(width))
+	if match == nil {
+		return 0, "", fmt.Errorf("invalid width %q (use a number with %%, px, cm, mm, in, pt or em)", width)
+	}
+	value, _ := strconv.ParseFloat(match[1], 64)
+	if value <= 0 {
+		return 0, "", fmt.Errorf("width %q must be positive", width)
+	}
+	if match[2] == "%" && value > 100 {
+		return 0, "", fmt.Errorf("width %q cannot exceed 100%%", width)
+	}
+	return value, match[2], nil
+}
+
+// Validate checks the image layout settings
+func (i *ImageBlock) Validate() error {
+	if i.Width != "" {
+		if _, _, err := ParseWidth(i.Width); err != nil {
+			return err
+		}
+	}
+	switch i.Align {
+	case "", AlignLeft, AlignCenter, AlignRight:
+	default:
+		return fmt.Errorf("invalid align %q (must be left, center or right)", i.Align)
+	}
+	switch i.Float {
+	case "", AlignLeft, AlignRight:
+	default:
+		return fmt.Errorf("invalid float %q (must be left or right)", i.Float)
+	}
+	if i.Float != "" && i.Align != "" {
+		return fmt.Errorf("an image cannot both float and be aligned")
+	}
+	return nil
 }
 
 func (i *ImageBlock) GetID() string       { return i.ID }
@@ -73,11 +143,196 @@ func (i *ImageBlock) ToMarkdown() string {
 	return markdown
 }
 
+// GalleryImage is one image of a gallery
+type GalleryImage struct {
+	Path    string `yaml:"path"` // Relative path to image in assets folder
+	Caption string `yaml:"caption,omitempty"`
+	AltText string `yaml:"alt_text,omitempty"`
+}
+
+// GalleryBlock lays out several images side by side in a grid
+type GalleryBlock struct {
+	BaseBlock `yaml:",inline"`
+	Images    []GalleryImage `yaml:"images"`
+	Columns   int            `yaml:"columns,omitempty"` // Images per row; defaults to up to 3
+	Caption   string         `yaml:"caption,omitempty"`
+}
+
+// MaxGalleryColumns bounds the images per gallery row
+const MaxGalleryColumns = 6
+
+// ColumnCount returns the number of images per row
+func (g *GalleryBlock) ColumnCount() int {
+	if g.Columns > 0 {
+		return g.Columns
+	}
+	if len(g.Images) < 3 {
+		return len(g.Images)
+	}
+	return 3
+}
+
+// Validate checks that the gallery has images and a sensible column count
+func (g *GalleryBlock) Validate() error {
+	if len(g.Images) == 0 {
+		return fmt.Errorf("gallery must contain at least one image")
+	}
+	for i, img := range g.Images {
+		if img.Path == "" {
+			return fmt.Errorf("images[%d] is missing a path", i)
+		}
+	}
+	if g.Columns < 0 || g.Columns > MaxGalleryColumns {
+		return fmt.Errorf("columns must be between 1 and %d", MaxGalleryColumns)
+	}
+	return nil
+}
+
+func (g *GalleryBlock) GetID() string       { return g.ID }
+func (g *GalleryBlock) GetType() BlockType  { return TypeGallery }
+func (g *GalleryBlock) ToMarkdown() string {
+	var images []string
+	for _, img := range g.Images {
+		alt := img.AltText
+		if alt == "" {
+			alt = img.Caption
+		}
+		images = append(images, "!["+alt+"]("+img.Path+")")
+	}
+	markdown := strings.Join(images, " ")
+	if g.Caption != "" {
+		markdown += "\n\n*" + g.Caption + "*"
+	}
+	return markdown
+}
+
 // TableBlock represents a table with structured data
 type TableBlock struct {
-	BaseBlock `yaml:",inline"`
-	Headers   []string   `yaml:"headers"`
-	Rows      [][]string `yaml:"rows"`
+	BaseBlock    `yaml:",inline"`
+	Headers      []string      `yaml:"headers"`
+	Rows         [][]string    `yaml:"rows"`
+	Caption      string        `yaml:"caption,omitempty"`
+	Columns      []TableColumn `yaml:"columns,omitempty"`       // Per-column alignment and relative width
+	HeaderRows   int           `yaml:"header_rows,omitempty"`   // Leading rows of Rows that belong to the header
+	Spans        []CellSpan    `yaml:"spans,omitempty"`         // Merged cells
+	RepeatHeader *bool         `yaml:"repeat_header,omitempty"` // Repeat header rows on page breaks (default true)
+	Source       *TableSource  `yaml:"source,omitempty"`        // File the table was imported from, for refresh_table
+}
+
+// TableSource records where an imported table came from and how it was shaped
+type TableSource struct {
+	Path         string       `yaml:"path" json:"path"`
+	Format       string       `yaml:"format,omitempty" json:"format,omitempty"`               // csv, tsv or xlsx (default: from extension)
+	Sheet        string       `yaml:"sheet,omitempty" json:"sheet,omitempty"`                 // XLSX sheet name (default: first sheet)
+	Range        string       `yaml:"range,omitempty" json:"range,omitempty"`                 // Cell range such as "B2:F40"
+	Header       string       `yaml:"header,omitempty" json:"header,omitempty"`               // auto, first_row or none
+	Columns      []string     `yaml:"columns,omitempty" json:"columns,omitempty"`             // Header names or column letters to keep, in order
+	NumberFormat NumberFormat `yaml:"number_format,omitempty" json:"number_format,omitempty"` // Formatting for numeric cells
+}
+
+// NumberFormat controls how numeric cells of an imported table are written
+type NumberFormat struct {
+	Decimals           *int   `yaml:"decimals,omitempty" json:"decimals,omitempty"`                       // Fixed decimal places (unset keeps the source value)
+	ThousandsSeparator string `yaml:"thousands_separator,omitempty" json:"thousands_separator,omitempty"` // e.g. "," for 1,234
+	DecimalSeparator   string `yaml:"decimal_separator,omitempty" json:"decimal_separator,omitempty"`     // e.g. "," for 3,14 (default ".")
+}
+
+// TableColumn holds layout settings for one table column
+type TableColumn struct {
+	Align string  `yaml:"align,omitempty"` // left, center, right (empty for default)
+	Width float64 `yaml:"width,omitempty"` // Relative width; columns without a width share the remainder
+}
+
+// CellSpan merges a cell with its neighbours. Row 0 is the Headers row and
+// row 1 is the first entry of Rows.
+type CellSpan struct {
+	Row     int `yaml:"row" json:"row"`
+	Col     int `yaml:"col" json:"col"`
+	RowSpan int `yaml:"rowspan,omitempty" json:"rowspan,omitempty"`
+	ColSpan int `yaml:"colspan,omitempty" json:"colspan,omitempty"`
+}
+
+// ShouldRepeatHeader reports whether header rows repeat on each page
+func (t *TableBlock) ShouldRepeatHeader() bool {
+	return t.RepeatHeader == nil || *t.RepeatHeader
+}
+
+// IsSimple reports whether the table can be written as a plain pipe table
+func (t *TableBlock) IsSimple() bool {
+	if len(t.Spans) > 0 || t.HeaderRows > 0 || !t.ShouldRepeatHeader() {
+		return false
+	}
+	for _, col := range t.Columns {
+		if col.Width > 0 {
+			return false
+		}
+	}
+	for _, cell := range t.Headers {
+		if strings.Contains(cell, "\n") {
+			return false
+		}
+	}
+	for _, row := range t.Rows {
+		for _, cell := range row {
+			if strings.Contains(cell, "\n") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Validate checks column settings, header rows and spans against the table's dimensions
+func (t *TableBlock) Validate() error {
+	numCols := len(t.Headers)
+	numRows := len(t.Rows) + 1
+	
+	if len(t.Columns) > numCols {
+		return fmt.Errorf("table has %d columns but %d column settings", numCols, len(t.Columns))
+	}
+	for i, col := range t.Columns {
+		switch col.Align {
+		case "", "left", "center", "right":
+		default:
+			return fmt.Errorf("column %d: invalid alignment %q (supported: left, center, right)", i, col.Align)
+		}
+		if col.Width < 0 {
+			return fmt.Errorf("column %d: width cannot be negative", i)
+		}
+	}
+	
+	if t.HeaderRows < 0 || t.HeaderRows > len(t.Rows) {
+		return fmt.Errorf("header_rows must be between 0 and %d", len(t.Rows))
+	}
+	
+	covered := make(map[[2]int]bool)
+	for _, span := range t.Spans {
+		rowSpan, colSpan := span.RowSpan, span.ColSpan
+		if rowSpan < 1 {
+			rowSpan = 1
+		}
+		if colSpan < 1 {
+			colSpan = 1
+		}
+		if span.Row < 0 || span.Col < 0 || span.Row+rowSpan > numRows || span.Col+colSpan > numCols {
+			return fmt.Errorf("span at row %d, col %d extends outside the table", span.Row, span.Col)
+		}
+		// Spans may not cross the boundary between header and body rows
+		headerEnd := 1 + t.HeaderRows
+		if span.Row < headerEnd && span.Row+rowSpan > headerEnd {
+			return fmt.Errorf("span at row %d, col %d crosses the header boundary", span.Row, span.Col)
+		}
+		for r := span.Row; r < span.Row+rowSpan; r++ {
+			for c := span.Col; c < span.Col+colSpan; c++ {
+				if covered[[2]int{r, c}] {
+					return fmt.Errorf("span at row %d, col %d overlaps another span", span.Row, span.Col)
+				}
+				covered[[2]int{r, c}] = true
+			}
+		}
+	}
+	
+	return nil
 }
 
 func (t *TableBlock) GetID() string       { return t.ID }
@@ -110,6 +365,523 @@ func (t *TableBlock) ToMarkdown() string {
 	return markdown
 }
 
+// Chart types
+const (
+	ChartBar     = "bar"
+	ChartLine    = "line"
+	ChartPie     = "pie"
+	ChartScatter = "scatter"
+)
+
+// ChartBlock represents a chart rendered from data at export time
+type ChartBlock struct {
+	BaseBlock  `yaml:",inline"`
+	ChartType  string        `yaml:"chart_type"` // bar, line, pie or scatter
+	Title      string        `yaml:"title,omitempty"`
+	XLabel     string        `yaml:"x_label,omitempty"`
+	YLabel     string        `yaml:"y_label,omitempty"`
+	Categories []string      `yaml:"categories,omitempty"` // X axis categories (bar, line) or slice names (pie)
+	Series     []ChartSeries `yaml:"series"`
+	Caption    string        `yaml:"caption,omitempty"`
+}
+
+// ChartSeries is one named set of values. Scatter charts pair Values with X.
+type ChartSeries struct {
+	Name   string    `yaml:"name,omitempty" json:"name,omitempty"`
+	Values []float64 `yaml:"values" json:"values"`
+	X      []float64 `yaml:"x,omitempty" json:"x,omitempty"`
+}
+
+// Validate checks that the series fit the chart type
+func (c *ChartBlock) Validate() error {
+	switch c.ChartType {
+	case ChartBar, ChartLine, ChartPie, ChartScatter:
+	default:
+		return fmt.Errorf("invalid chart type %q (supported: bar, line, pie, scatter)", c.ChartType)
+	}
+	
+	if len(c.Series) == 0 {
+		return fmt.Errorf("chart needs at least one series")
+	}
+	
+	for i, series := range c.Series {
+		if len(series.Values) == 0 {
+			return fmt.Errorf("series %d has no values", i)
+		}
+		switch c.ChartType {
+		case ChartScatter:
+			if len(series.X) != len(series.Values) {
+				return fmt.Errorf("series %d: scatter charts need one x value per value", i)
+			}
+		default:
+			if len(c.Categories) > 0 && len(series.Values) != len(c.Categories) {
+				return fmt.Errorf("series %d has %d values but there are %d categories", i, len(series.Values), len(c.Categories))
+			}
+		}
+	}
+	
+	if c.ChartType == ChartPie {
+		if len(c.Series) > 1 {
+			return fmt.Errorf("pie charts take a single series")
+		}
+		total := 0.0
+		for _, v := range c.Series[0].Values {
+			if v < 0 {
+				return fmt.Errorf("pie chart values cannot be negative")
+			}
+			total += v
+		}
+		if total == 0 {
+			return fmt.Errorf("pie chart values must not all be zero")
+		}
+	}
+	
+	return nil
+}
+
+func (c *ChartBlock) GetID() string       { return c.ID }
+func (c *ChartBlock) GetType() BlockType  { return TypeChart }
+func (c *ChartBlock) ToMarkdown() string {
+	if c.Title != "" {
+		return fmt.Sprintf("*[%s chart: %s]*", c.ChartType, c.Title)
+	}
+	return fmt.Sprintf("*[%s chart]*", c.ChartType)
+}
+
+// Diagram engines
+const (
+	EngineMermaid  = "mermaid"
+	EngineGraphviz = "graphviz"
+	EnginePlantUML = "plantuml"
+)
+
+// DiagramBlock holds diagram source text that is rendered at export time
+type DiagramBlock struct {
+	BaseBlock `yaml:",inline"`
+	Engine    string `yaml:"engine"` // mermaid, graphviz or plantuml
+	Source    string `yaml:"source"`
+	Caption   string `yaml:"caption,omitempty"`
+	AltText   string `yaml:"alt_text,omitempty"`
+}
+
+// Validate checks the engine and that there is source to render
+func (d *DiagramBlock) Validate() error {
+	switch d.Engine {
+	case EngineMermaid, EngineGraphviz, EnginePlantUML:
+	default:
+		return fmt.Errorf("invalid diagram engine %q (supported: mermaid, graphviz, plantuml)", d.Engine)
+	}
+	if strings.TrimSpace(d.Source) == "" {
+		return fmt.Errorf("diagram source cannot be empty")
+	}
+	return nil
+}
+
+func (d *DiagramBlock) GetID() string       { return d.ID }
+func (d *DiagramBlock) GetType() BlockType  { return TypeDiagram }
+func (d *DiagramBlock) ToMarkdown() string {
+	return fmt.Sprintf("```%s\n%s\n```", d.Engine, strings.TrimRight(d.Source, "\n"))
+}
+
+// IncludeBlock transcludes a workspace snippet or a block of another document.
+// The content is resolved at export time, so edits to the source show up in every
+// document that includes it.
+type IncludeBlock struct {
+	BaseBlock `yaml:",inline"`
+	Snippet   string `yaml:"snippet,omitempty"`  // Snippet ID
+	Document  string `yaml:"document,omitempty"` // Source document ID, with Block
+	Block     string `yaml:"block,omitempty"`    // Block ID within Document
+}
+
+// Validate checks that the include names exactly one source
+func (i *IncludeBlock) Validate() error {
+	if i.Snippet != "" {
+		if i.Document != "" || i.Block != "" {
+			return fmt.Errorf("an include references either a snippet or a document block, not both")
+		}
+		return nil
+	}
+	if i.Document == "" || i.Block == "" {
+		return fmt.Errorf("an include needs a snippet, or both a document and a block")
+	}
+	return nil
+}
+
+// Source identifies what the block includes, e.g. "snippet:disclaimer" or "report/md-003"
+func (i *IncludeBlock) Source() string {
+	if i.Snippet != "" {
+		return "snippet:" + i.Snippet
+	}
+	return i.Document + "/" + i.Block
+}
+
+func (i *IncludeBlock) GetID() string       { return i.ID }
+func (i *IncludeBlock) GetType() BlockType  { return TypeInclude }
+func (i *IncludeBlock) ToMarkdown() string  { return fmt.Sprintf("*[include: %s]*", i.Source()) }
+
+// Raw formats, named as Pandoc names them in raw attributes
+const (
+	RawHTML    = "html"
+	RawLaTeX   = "latex"
+	RawOpenXML = "openxml"
+)
+
+// RawBlock passes content through to one output format untouched, such as
+// hand-written LaTeX for PDF exports. Other formats leave it out.
+type RawBlock struct {
+	BaseBlock `yaml:",inline"`
+	Format    string `yaml:"format"` // html, latex or openxml
+	Content   string `yaml:"content"`
+}
+
+// Validate checks the target format and that there is content
+func (r *RawBlock) Validate() error {
+	switch r.Format {
+	case RawHTML, RawLaTeX, RawOpenXML:
+	default:
+		return fmt.Errorf("invalid raw format %q (supported: html, latex, openxml)", r.Format)
+	}
+	if strings.TrimSpace(r.Content) == "" {
+		return fmt.Errorf("raw content cannot be empty")
+	}
+	return nil
+}
+
+func (r *RawBlock) GetID() string       { return r.ID }
+func (r *RawBlock) GetType() BlockType  { return TypeRaw }
+func (r *RawBlock) ToMarkdown() string {
+	fence := "```"
+	for strings.Contains(r.Content, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("%s{=%s}\n%s\n%s", fence, r.Format, strings.TrimRight(r.Content, "\n"), fence)
+}
+
+// List styles
+const (
+	ListUnordered = "unordered"
+	ListOrdered   = "ordered"
+	ListTask      = "task"
+)
+
+// ListItem is one entry of a list, with an optional nested list of the same style
+type ListItem struct {
+	Text    string     `yaml:"text"`
+	Checked bool       `yaml:"checked,omitempty"` // Task lists only
+	Items   []ListItem `yaml:"items,omitempty"`
+}
+
+// ListBlock is a bulleted, numbered or task list whose items can be edited one
+// at a time. Items are addressed by 1-based paths such as "2" or "2.1".
+type ListBlock struct {
+	BaseBlock `yaml:",inline"`
+	Style     string     `yaml:"style"`           // unordered, ordered or task
+	Start     int        `yaml:"start,omitempty"` // First number of ordered lists (default 1)
+	Items     []ListItem `yaml:"items"`
+}
+
+// Validate checks the list style and that every item has text
+func (l *ListBlock) Validate() error {
+	switch l.Style {
+	case ListUnordered, ListOrdered, ListTask:
+	default:
+		return fmt.Errorf("invalid list style %q (supported: unordered, ordered, task)", l.Style)
+	}
+	if l.Start < 0 {
+		return fmt.Errorf("start must not be negative")
+	}
+	if len(l.Items) == 0 {
+		return fmt.Errorf("list must have at least one item")
+	}
+	return validateListItems(l.Items, "")
+}
+
+func validateListItems(items []ListItem, parent string) error {
+	for i, item := range items {
+		path := listPath(parent, i)
+		if strings.TrimSpace(item.Text) == "" {
+			return fmt.Errorf("list item %s has no text", path)
+		}
+		if err := validateListItems(item.Items, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listPath(parent string, i int) string {
+	if parent == "" {
+		return strconv.Itoa(i + 1)
+	}
+	return parent + "." + strconv.Itoa(i+1)
+}
+
+// parseListPath converts "2.1" to zero-based indexes
+func parseListPath(path string) ([]int, error) {
+	parts := strings.Split(path, ".")
+	indexes := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid item path %q: use 1-based numbers such as 2 or 2.1", path)
+		}
+		indexes[i] = n - 1
+	}
+	return indexes, nil
+}
+
+// siblings returns the item list holding the item at path, and its index there
+func (l *ListBlock) siblings(path string) (*[]ListItem, int, error) {
+	indexes, err := parseListPath(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := &l.Items
+	for depth, index := range indexes {
+		if index >= len(*items) {
+			return nil, 0, fmt.Errorf("list item %s not found", path)
+		}
+		if depth == len(indexes)-1 {
+			return items, index, nil
+		}
+		items = &(*items)[index].Items
+	}
+	return nil, 0, fmt.Errorf("list item %s not found", path)
+}
+
+// Item returns the item at a path such as "2.1"
+func (l *ListBlock) Item(path string) (*ListItem, error) {
+	items, index, err := l.siblings(path)
+	if err != nil {
+		return nil, err
+	}
+	return &(*items)[index], nil
+}
+
+// InsertItem adds an item under parent (empty for the top level) at a 1-based
+// position; 0 appends. It returns the new item's path.
+func (l *ListBlock) InsertItem(parent string, position int, item ListItem) (string, error) {
+	items := &l.Items
+	if parent != "" {
+		parentItem, err := l.Item(parent)
+		if err != nil {
+			return "", err
+		}
+		items = &parentItem.Items
+	}
+	if position < 0 || position > len(*items)+1 {
+		return "", fmt.Errorf("position %d is out of range (1-%d)", position, len(*items)+1)
+	}
+	if position == 0 {
+		position = len(*items) + 1
+	}
+
+	*items = append(*items, ListItem{})
+	copy((*items)[position:], (*items)[position-1:])
+	(*items)[position-1] = item
+	return listPath(parent, position-1), nil
+}
+
+// MoveItem moves an item to a 1-based position among its siblings and returns its new path
+func (l *ListBlock) MoveItem(path string, position int) (string, error) {
+	items, index, err := l.siblings(path)
+	if err != nil {
+		return "", err
+	}
+	if position < 1 || position > len(*items) {
+		return "", fmt.Errorf("position %d is out of range (1-%d)", position, len(*items))
+	}
+
+	item := (*items)[index]
+	*items = append((*items)[:index], (*items)[index+1:]...)
+	*items = append(*items, ListItem{})
+	copy((*items)[position:], (*items)[position-1:])
+	(*items)[position-1] = item
+
+	parent := ""
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		parent = path[:i]
+	}
+	return listPath(parent, position-1), nil
+}
+
+// Progress counts checked and total items of a task list, including nested items
+func (l *ListBlock) Progress() (checked, total int) {
+	var count func(items []ListItem)
+	count = func(items []ListItem) {
+		for _, item := range items {
+			total++
+			if item.Checked {
+				checked++
+			}
+			count(item.Items)
+		}
+	}
+	count(l.Items)
+	return checked, total
+}
+
+func (l *ListBlock) GetID() string       { return l.ID }
+func (l *ListBlock) GetType() BlockType  { return TypeList }
+func (l *ListBlock) ToMarkdown() string {
+	start := l.Start
+	if start == 0 {
+		start = 1
+	}
+	var sb strings.Builder
+	l.writeItems(&sb, l.Items, "", start)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// writeItems writes items as a markdown list. Nested lists and continuation
+// lines are indented by four spaces, which Pandoc accepts under any marker.
+// Nested ordered lists always count from 1.
+func (l *ListBlock) writeItems(sb *strings.Builder, items []ListItem, indent string, start int) {
+	for i, item := range items {
+		var marker string
+		switch l.Style {
+		case ListOrdered:
+			marker = fmt.Sprintf("%d. ", start+i)
+		case ListTask:
+			if item.Checked {
+				marker = "- [x] "
+			} else {
+				marker = "- [ ] "
+			}
+		default:
+			marker = "- "
+		}
+		lines := strings.Split(strings.TrimSpace(item.Text), "\n")
+		sb.WriteString(indent + marker + lines[0] + "\n")
+		for _, line := range lines[1:] {
+			sb.WriteString(indent + "    " + line + "\n")
+		}
+		if len(item.Items) > 0 {
+			l.writeItems(sb, item.Items, indent+"    ", 1)
+		}
+	}
+}
+
+// Quote styles
+const (
+	QuoteBlockquote = "blockquote"
+	QuotePullQuote  = "pullquote"
+	QuoteEpigraph   = "epigraph"
+)
+
+// QuoteBlock is a quotation with optional attribution, shown as an indented
+// blockquote, a large pull-quote or a chapter-opening epigraph
+type QuoteBlock struct {
+	BaseBlock   `yaml:",inline"`
+	Style       string `yaml:"style"` // blockquote, pullquote or epigraph
+	Text        string `yaml:"text"`
+	Attribution string `yaml:"attribution,omitempty"` // Who said it
+	Source      string `yaml:"source,omitempty"`      // Where it comes from, e.g. a book title
+}
+
+// Validate checks the quote style and that there is text
+func (q *QuoteBlock) Validate() error {
+	switch q.Style {
+	case QuoteBlockquote, QuotePullQuote, QuoteEpigraph:
+	default:
+		return fmt.Errorf("invalid quote style %q (supported: blockquote, pullquote, epigraph)", q.Style)
+	}
+	if strings.TrimSpace(q.Text) == "" {
+		return fmt.Errorf("quote text cannot be empty")
+	}
+	return nil
+}
+
+// AttributionText returns the attribution line in markdown, e.g. "— Seneca, *Letters*"
+func (q *QuoteBlock) AttributionText() string {
+	var parts []string
+	if q.Attribution != "" {
+		parts = append(parts, q.Attribution)
+	}
+	if q.Source != "" {
+		parts = append(parts, "*"+q.Source+"*")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "— " + strings.Join(parts, ", ")
+}
+
+func (q *QuoteBlock) GetID() string       { return q.ID }
+func (q *QuoteBlock) GetType() BlockType  { return TypeQuote }
+func (q *QuoteBlock) ToMarkdown() string {
+	lines := strings.Split(strings.TrimSpace(q.Text), "\n")
+	if attribution := q.AttributionText(); attribution != "" {
+		lines = append(lines, "", attribution)
+	}
+	return "> " + strings.Join(lines, "\n> ")
+}
+
+// LayoutBlock sets other blocks of the same chapter in two or three columns.
+// The child blocks keep their place in the manifest but are exported inside the
+// layout, in the order listed here.
+type LayoutBlock struct {
+	BaseBlock    `yaml:",inline"`
+	Columns      int      `yaml:"columns"`
+	Gap          string   `yaml:"gap,omitempty"`           // Space between columns, e.g. "2em" or "8mm"
+	Blocks       []string `yaml:"blocks"`                  // IDs of the child blocks
+	ColumnBreaks []string `yaml:"column_breaks,omitempty"` // Child blocks that start a new column; none balances the columns
+}
+
+// Validate checks the column count, gap and column breaks
+func (l *LayoutBlock) Validate() error {
+	if l.Columns < 2 || l.Columns > 3 {
+		return fmt.Errorf("invalid column count %d (must be 2 or 3)", l.Columns)
+	}
+	if l.Gap != "" {
+		_, unit, err := ParseWidth(l.Gap)
+		if err != nil || unit == "%" || unit == "px" {
+			return fmt.Errorf("invalid gap %q (use a number with cm, mm, in, pt or em)", l.Gap)
+		}
+	}
+
+	seen := make(map[string]bool, len(l.Blocks))
+	for _, id := range l.Blocks {
+		if id == "" {
+			return fmt.Errorf("child block ID cannot be empty")
+		}
+		if seen[id] {
+			return fmt.Errorf("block %s is listed twice", id)
+		}
+		seen[id] = true
+	}
+
+	if len(l.ColumnBreaks) > l.Columns-1 {
+		return fmt.Errorf("%d column breaks do not fit in %d columns", len(l.ColumnBreaks), l.Columns)
+	}
+	for _, id := range l.ColumnBreaks {
+		if !seen[id] {
+			return fmt.Errorf("column break %s is not a child block", id)
+		}
+		if id == l.Blocks[0] {
+			return fmt.Errorf("column break %s is the first child block", id)
+		}
+	}
+	return nil
+}
+
+// IsColumnBreak reports whether a child block starts a new column
+func (l *LayoutBlock) IsColumnBreak(id string) bool {
+	for _, b := range l.ColumnBreaks {
+		if b == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *LayoutBlock) GetID() string       { return l.ID }
+func (l *LayoutBlock) GetType() BlockType  { return TypeLayout }
+func (l *LayoutBlock) ToMarkdown() string {
+	// The children are exported in place of the layout; see the export package
+	return ""
+}
+
 // PageBreakBlock represents a page break
 type PageBreakBlock struct {
 	BaseBlock `yaml:",inline"`
@@ -121,7 +893,148 @@ func (p *PageBreakBlock) ToMarkdown() string  { return "\\newpage" }
 
 // BlockReference stores the reference to a block in the manifest
 type BlockReference struct {
-	ID   string    `yaml:"id"`
-	Type BlockType `yaml:"type"`
-	File string    `yaml:"file"`
-}
\ No newline at end of file
+	ID    string    `yaml:"id"`
+	Type  BlockType `yaml:"type"`
+	File  string    `yaml:"file"`
+	Label string    `yaml:"label,omitempty"` // Stable cross-reference label, e.g. "fig-arch"
+	Tags  []string  `yaml:"tags,omitempty"`  // Sorted tags naming the block's role, e.g. "executive-summary"
+
+	// Content is only exported for profiles that satisfy its conditions
+	Conditions Conditions `yaml:"conditions,omitempty"`
+
+	// Review workflow: no status means draft, and locked content refuses edits
+	Status string `yaml:"status,omitempty"`
+	Locked bool   `yaml:"locked,omitempty"`
+
+	// Overrides the document style for this block, e.g. landscape pages for a wide table
+	Style *style.StyleOverride `yaml:"style,omitempty"`
+}
+
+// Conditions restrict content to some export profiles, e.g. audience=internal.
+// Each key lists the values it may take; content without conditions is always exported.
+type Conditions map[string][]string
+
+// Profile selects conditional content on export, e.g. audience=external, edition=pro
+type Profile map[string]string
+
+// conditionNamePattern and conditionValuePattern keep conditions simple to write and compare
+var (
+	conditionNamePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	conditionValuePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// Validate checks condition names and values
+func (c Conditions) Validate() error {
+	for name, values := range c {
+		if !conditionNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid condition name %q", name)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("condition %s needs at least one value", name)
+		}
+		for _, value := range values {
+			if !conditionValuePattern.MatchString(value) {
+				return fmt.Errorf("invalid value %q for condition %s", value, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Matches reports whether content with these conditions is exported for a profile.
+// A profile only filters on the names it sets, so an empty profile matches everything.
+func (c Conditions) Matches(profile Profile) bool {
+	for name, values := range c {
+		selected, ok := profile[name]
+		if !ok {
+			continue
+		}
+		matched := false
+		for _, value := range values {
+			if value == selected {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders conditions as "audience=internal; edition=pro|enterprise", sorted by name
+func (c Conditions) String() string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+strings.Join(c[name], "|"))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validate checks profile names and values
+func (p Profile) Validate() error {
+	for name, value := range p {
+		if !conditionNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid profile key %q", name)
+		}
+		if !conditionValuePattern.MatchString(value) {
+			return fmt.Errorf("invalid value %q for profile key %s", value, name)
+		}
+	}
+	return nil
+}
+
+// Workflow statuses of blocks and chapters
+const (
+	StatusDraft       = "draft" // Stored as no status
+	StatusNeedsReview = "needs-review"
+	StatusApproved    = "approved"
+)
+
+// ValidateStatus checks a workflow status
+func ValidateStatus(status string) error {
+	switch status {
+	case StatusDraft, StatusNeedsReview, StatusApproved:
+		return nil
+	default:
+		return fmt.Errorf("invalid status %q (supported: draft, needs-review, approved)", status)
+	}
+}
+
+// StatusOf returns a stored workflow status, reading no status as draft
+func StatusOf(status string) string {
+	if status == "" {
+		return StatusDraft
+	}
+	return status
+}
+
+// tagPattern keeps tags short words that are easy to write and compare
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// NormalizeTag lowercases a tag, so tags match regardless of case, and checks it
+func NormalizeTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(normalized) {
+		return "", fmt.Errorf("invalid tag %q: use letters, digits, '-', '_' or '.'", tag)
+	}
+	return normalized, nil
+}
+
+// HasTag reports whether the block carries a tag
+func (r BlockReference) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,444 @@
+// Package chart renders chart blocks to SVG or PNG using only the standard library.
+// Both outputs share one layout; they differ only in the canvas that draws it.
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// Output formats
+const (
+	FormatSVG = "svg"
+	FormatPNG = "png"
+)
+
+// Logical chart size. PNG output is rendered at pngScale times this size so
+// it stays sharp in print.
+const (
+	chartWidth  = 800
+	chartHeight = 500
+	pngScale    = 2
+)
+
+// Font sizes in logical units
+const (
+	titleSize = 20
+	labelSize = 14
+	tickSize  = 12
+)
+
+// palette holds the series colours, cycled when there are more series
+var palette = []color.RGBA{
+	{0x4e, 0x79, 0xa7, 0xff},
+	{0xf2, 0x8e, 0x2b, 0xff},
+	{0xe1, 0x57, 0x59, 0xff},
+	{0x76, 0xb7, 0xb2, 0xff},
+	{0x59, 0xa1, 0x4f, 0xff},
+	{0xed, 0xc9, 0x48, 0xff},
+	{0xb0, 0x7a, 0xa1, 0xff},
+	{0xff, 0x9d, 0xa7, 0xff},
+	{0x9c, 0x75, 0x5f, 0xff},
+	{0xba, 0xb0, 0xac, 0xff},
+}
+
+var (
+	white     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	textColor = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	axisColor = color.RGBA{0x66, 0x66, 0x66, 0xff}
+	gridColor = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+)
+
+// textAnchor aligns text horizontally around its x coordinate
+type textAnchor int
+
+const (
+	anchorStart textAnchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+// canvas is the drawing surface shared by the SVG and PNG backends.
+// Coordinates are logical units with the origin at the top left; text y is the baseline.
+type canvas interface {
+	rect(x, y, w, h float64, fill color.RGBA)
+	line(x1, y1, x2, y2, width float64, stroke color.RGBA)
+	circle(cx, cy, r float64, fill color.RGBA)
+	wedge(cx, cy, r, start, end float64, fill color.RGBA) // Angles in radians, clockwise from 12 o'clock
+	text(x, y float64, s string, size float64, anchor textAnchor, vertical bool, fill color.RGBA)
+	textWidth(s string, size float64) float64
+	bytes() ([]byte, error)
+}
+
+// Render draws a chart block in the given format (svg or png)
+func Render(c *blocks.ChartBlock, format string) ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var cv canvas
+	switch format {
+	case FormatSVG:
+		cv = newSVGCanvas(chartWidth, chartHeight)
+	case FormatPNG:
+		cv = newPNGCanvas(chartWidth, chartHeight, pngScale)
+	default:
+		return nil, fmt.Errorf("unsupported chart format %q (supported: svg, png)", format)
+	}
+
+	draw(cv, c)
+	return cv.bytes()
+}
+
+// seriesColor returns the palette colour for series i
+func seriesColor(i int) color.RGBA {
+	return palette[i%len(palette)]
+}
+
+// plotArea is the rectangle inside the axes
+type plotArea struct {
+	left, top, right, bottom float64
+}
+
+func (p plotArea) width() float64  { return p.right - p.left }
+func (p plotArea) height() float64 { return p.bottom - p.top }
+
+// draw lays out and draws the title, legend, axes and data
+func draw(cv canvas, c *blocks.ChartBlock) {
+	cv.rect(0, 0, chartWidth, chartHeight, white)
+
+	area := plotArea{left: 70, top: 20, right: chartWidth - 20, bottom: chartHeight - 45}
+	if c.Title != "" {
+		cv.text(chartWidth/2, 32, c.Title, titleSize, anchorMiddle, false, textColor)
+		area.top = 55
+	}
+	if c.YLabel != "" && c.ChartType != blocks.ChartPie {
+		cv.text(20, (area.top+area.bottom)/2, c.YLabel, labelSize, anchorMiddle, true, textColor)
+		area.left += 15
+	}
+	if c.XLabel != "" && c.ChartType != blocks.ChartPie {
+		cv.text((area.left+area.right)/2, chartHeight-12, c.XLabel, labelSize, anchorMiddle, false, textColor)
+		area.bottom -= 20
+	}
+
+	// Legend on the right: series names, or slice names for pie charts
+	var legend []string
+	switch {
+	case c.ChartType == blocks.ChartPie:
+		legend = pieLegend(c)
+	case len(c.Series) > 1:
+		for i, series := range c.Series {
+			legend = append(legend, seriesName(series, i))
+		}
+	}
+	if len(legend) > 0 {
+		area.right = drawLegend(cv, legend, area)
+	}
+
+	switch c.ChartType {
+	case blocks.ChartBar:
+		drawBar(cv, c, area)
+	case blocks.ChartLine:
+		drawLine(cv, c, area)
+	case blocks.ChartPie:
+		drawPie(cv, c, area)
+	case blocks.ChartScatter:
+		drawScatter(cv, c, area)
+	}
+}
+
+// seriesName returns a display name for a series
+func seriesName(series blocks.ChartSeries, i int) string {
+	if series.Name != "" {
+		return series.Name
+	}
+	return fmt.Sprintf("Series %d", i+1)
+}
+
+// categoryNames returns the category labels, numbering positions when none are given
+func categoryNames(c *blocks.ChartBlock) []string {
+	count := 0
+	for _, series := range c.Series {
+		if len(series.Values) > count {
+			count = len(series.Values)
+		}
+	}
+	names := make([]string, count)
+	for i := range names {
+		if i < len(c.Categories) {
+			names[i] = c.Categories[i]
+		} else {
+			names[i] = strconv.Itoa(i + 1)
+		}
+	}
+	return names
+}
+
+// drawLegend draws colour swatches with names at the right and returns the new plot right edge
+func drawLegend(cv canvas, names []string, area plotArea) float64 {
+	widest := 0.0
+	for _, name := range names {
+		if w := cv.textWidth(name, tickSize); w > widest {
+			widest = w
+		}
+	}
+	if widest > 180 {
+		widest = 180
+	}
+
+	x := chartWidth - 20 - widest - 20
+	y := area.top + 10
+	for i, name := range names {
+		cv.rect(x, y-10, 12, 12, seriesColor(i))
+		cv.text(x+18, y, name, tickSize, anchorStart, false, textColor)
+		y += 20
+	}
+	return x - 20
+}
+
+// valueRange returns the minimum and maximum of all series values
+func valueRange(c *blocks.ChartBlock) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, series := range c.Series {
+		for _, v := range series.Values {
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	return lo, hi
+}
+
+// axis maps data values onto a pixel range using rounded tick values
+type axis struct {
+	min, max, step float64
+}
+
+// niceAxis picks rounded bounds and a tick step covering lo..hi with about five ticks
+func niceAxis(lo, hi float64) axis {
+	if lo == hi {
+		if lo == 0 {
+			hi = 1
+		} else {
+			lo, hi = lo-math.Abs(lo)/2, hi+math.Abs(hi)/2
+		}
+	}
+	step := niceNumber((hi - lo) / 4)
+	return axis{
+		min:  math.Floor(lo/step) * step,
+		max:  math.Ceil(hi/step) * step,
+		step: step,
+	}
+}
+
+// niceNumber rounds x to 1, 2, 5 or 10 times a power of ten
+func niceNumber(x float64) float64 {
+	exp := math.Floor(math.Log10(x))
+	fraction := x / math.Pow(10, exp)
+	var nice float64
+	switch {
+	case fraction < 1.5:
+		nice = 1
+	case fraction < 3:
+		nice = 2
+	case fraction < 7:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exp)
+}
+
+// ticks returns the tick values of the axis
+func (a axis) ticks() []float64 {
+	var values []float64
+	for v := a.min; v <= a.max+a.step/2; v += a.step {
+		values = append(values, v)
+	}
+	return values
+}
+
+// label formats a tick value with as many decimals as the step needs
+func (a axis) label(v float64) string {
+	decimals := 0
+	if a.step < 1 {
+		decimals = int(math.Ceil(-math.Log10(a.step)))
+	}
+	if math.Abs(v) < a.step/1e6 {
+		v = 0
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// scale maps a value onto the pixel range from..to
+func (a axis) scale(v, from, to float64) float64 {
+	return from + (v-a.min)/(a.max-a.min)*(to-from)
+}
+
+// drawValueAxis draws horizontal grid lines with value labels on the left
+func drawValueAxis(cv canvas, a axis, area plotArea) {
+	for _, v := range a.ticks() {
+		y := a.scale(v, area.bottom, area.top)
+		cv.line(area.left, y, area.right, y, 1, gridColor)
+		cv.text(area.left-8, y+4, a.label(v), tickSize, anchorEnd, false, axisColor)
+	}
+	cv.line(area.left, area.top, area.left, area.bottom, 1, axisColor)
+}
+
+// drawCategoryLabels writes category names under their slots, skipping labels when they would overlap
+func drawCategoryLabels(cv canvas, names []string, area plotArea) {
+	if len(names) == 0 {
+		return
+	}
+	slot := area.width() / float64(len(names))
+	widest := 0.0
+	for _, name := range names {
+		widest = math.Max(widest, cv.textWidth(name, tickSize))
+	}
+	every := int(math.Ceil((widest + 8) / slot))
+	if every < 1 {
+		every = 1
+	}
+	for i, name := range names {
+		if i%every != 0 {
+			continue
+		}
+		x := area.left + slot*(float64(i)+0.5)
+		cv.text(x, area.bottom+18, name, tickSize, anchorMiddle, false, axisColor)
+	}
+}
+
+// baseline returns the y pixel of the zero line, clamped into the plot
+func baseline(a axis, area plotArea) float64 {
+	zero := math.Max(a.min, math.Min(0, a.max))
+	return a.scale(zero, area.bottom, area.top)
+}
+
+// drawBar draws grouped vertical bars, one group per category
+func drawBar(cv canvas, c *blocks.ChartBlock, area plotArea) {
+	lo, hi := valueRange(c)
+	a := niceAxis(math.Min(lo, 0), math.Max(hi, 0))
+	drawValueAxis(cv, a, area)
+
+	names := categoryNames(c)
+	slot := area.width() / float64(len(names))
+	barWidth := slot * 0.8 / float64(len(c.Series))
+	zero := baseline(a, area)
+
+	for s, series := range c.Series {
+		for i, v := range series.Values {
+			x := area.left + slot*float64(i) + slot*0.1 + barWidth*float64(s)
+			y := a.scale(v, area.bottom, area.top)
+			top, height := math.Min(y, zero), math.Abs(zero-y)
+			cv.rect(x, top, barWidth, height, seriesColor(s))
+		}
+	}
+
+	cv.line(area.left, zero, area.right, zero, 1, axisColor)
+	drawCategoryLabels(cv, names, area)
+}
+
+// drawLine draws one polyline with point markers per series
+func drawLine(cv canvas, c *blocks.ChartBlock, area plotArea) {
+	lo, hi := valueRange(c)
+	a := niceAxis(lo, hi)
+	drawValueAxis(cv, a, area)
+
+	names := categoryNames(c)
+	slot := area.width() / float64(len(names))
+	cv.line(area.left, area.bottom, area.right, area.bottom, 1, axisColor)
+
+	for s, series := range c.Series {
+		col := seriesColor(s)
+		var prevX, prevY float64
+		for i, v := range series.Values {
+			x := area.left + slot*(float64(i)+0.5)
+			y := a.scale(v, area.bottom, area.top)
+			if i > 0 {
+				cv.line(prevX, prevY, x, y, 2.5, col)
+			}
+			prevX, prevY = x, y
+		}
+		for i, v := range series.Values {
+			cv.circle(area.left+slot*(float64(i)+0.5), a.scale(v, area.bottom, area.top), 4, col)
+		}
+	}
+
+	drawCategoryLabels(cv, names, area)
+}
+
+// drawScatter draws points on numeric x and y axes
+func drawScatter(cv canvas, c *blocks.ChartBlock, area plotArea) {
+	lo, hi := valueRange(c)
+	ya := niceAxis(lo, hi)
+	drawValueAxis(cv, ya, area)
+
+	xlo, xhi := math.Inf(1), math.Inf(-1)
+	for _, series := range c.Series {
+		for _, x := range series.X {
+			xlo = math.Min(xlo, x)
+			xhi = math.Max(xhi, x)
+		}
+	}
+	xa := niceAxis(xlo, xhi)
+	for _, v := range xa.ticks() {
+		x := xa.scale(v, area.left, area.right)
+		cv.line(x, area.top, x, area.bottom, 1, gridColor)
+		cv.text(x, area.bottom+18, xa.label(v), tickSize, anchorMiddle, false, axisColor)
+	}
+	cv.line(area.left, area.bottom, area.right, area.bottom, 1, axisColor)
+
+	for s, series := range c.Series {
+		for i, v := range series.Values {
+			cv.circle(xa.scale(series.X[i], area.left, area.right), ya.scale(v, area.bottom, area.top), 4.5, seriesColor(s))
+		}
+	}
+}
+
+// pieLegend labels each slice with its name and share of the total
+func pieLegend(c *blocks.ChartBlock) []string {
+	values := c.Series[0].Values
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	names := categoryNames(c)
+	legend := make([]string, len(values))
+	for i, v := range values {
+		legend[i] = fmt.Sprintf("%s (%.0f%%)", names[i], v/total*100)
+	}
+	return legend
+}
+
+// drawPie draws the single series as slices starting at 12 o'clock
+func drawPie(cv canvas, c *blocks.ChartBlock, area plotArea) {
+	values := c.Series[0].Values
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+
+	cx, cy := (area.left+area.right)/2, (area.top+area.bottom)/2
+	r := math.Min(area.width(), area.height())/2 - 10
+
+	angle := 0.0
+	for i, v := range values {
+		sweep := v / total * 2 * math.Pi
+		if sweep > 0 {
+			cv.wedge(cx, cy, r, angle, angle+sweep, seriesColor(i))
+		}
+		angle += sweep
+	}
+
+	// White separators between slices
+	if len(values) > 1 {
+		angle = 0
+		for _, v := range values {
+			cv.line(cx, cy, cx+r*math.Sin(angle), cy-r*math.Cos(angle), 2, white)
+			angle += v / total * 2 * math.Pi
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+func TestRenderFormats(t *testing.T) {
+	charts := []*blocks.ChartBlock{
+		{
+			ChartType:  blocks.ChartBar,
+			Title:      "Revenue & Costs",
+			YLabel:     "USD",
+			Categories: []string{"Q1", "Q2"},
+			Series: []blocks.ChartSeries{
+				{Name: "Revenue", Values: []float64{10, 12}},
+				{Name: "Costs", Values: []float64{8, -2}},
+			},
+		},
+		{ChartType: blocks.ChartLine, Series: []blocks.ChartSeries{{Values: []float64{1, 3, 2}}}},
+		{ChartType: blocks.ChartPie, Categories: []string{"A", "B"}, Series: []blocks.ChartSeries{{Values: []float64{3, 1}}}},
+		{ChartType: blocks.ChartScatter, Series: []blocks.ChartSeries{{Values: []float64{1, 2}, X: []float64{5, 7}}}},
+	}
+
+	for _, c := range charts {
+		svg, err := Render(c, FormatSVG)
+		if err != nil {
+			t.Fatalf("Failed to render %s chart as SVG: %v", c.ChartType, err)
+		}
+		if !strings.HasPrefix(string(svg), "<svg") {
+			t.Errorf("Expected SVG document for %s chart", c.ChartType)
+		}
+
+		data, err := Render(c, FormatPNG)
+		if err != nil {
+			t.Fatalf("Failed to render %s chart as PNG: %v", c.ChartType, err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Invalid PNG for %s chart: %v", c.ChartType, err)
+		}
+		if img.Bounds().Dx() != chartWidth*pngScale {
+			t.Errorf("Unexpected PNG width %d", img.Bounds().Dx())
+		}
+	}
+
+	// Titles are escaped in SVG
+	svg, _ := Render(charts[0], FormatSVG)
+	if !strings.Contains(string(svg), "Revenue &amp; Costs") {
+		t.Error("Expected escaped title in SVG")
+	}
+
+	if _, err := Render(charts[0], "gif"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestRenderRejectsInvalidCharts(t *testing.T) {
+	invalid := []*blocks.ChartBlock{
+		{ChartType: "radar", Series: []blocks.ChartSeries{{Values: []float64{1}}}},
+		{ChartType: blocks.ChartBar},
+		{ChartType: blocks.ChartBar, Categories: []string{"A", "B"}, Series: []blocks.ChartSeries{{Values: []float64{1}}}},
+		{ChartType: blocks.ChartScatter, Series: []blocks.ChartSeries{{Values: []float64{1, 2}, X: []float64{1}}}},
+		{ChartType: blocks.ChartPie, Series: []blocks.ChartSeries{{Values: []float64{1, -1}}}},
+	}
+
+	for i, c := range invalid {
+		if _, err := Render(c, FormatSVG); err == nil {
+			t.Errorf("Expected chart %d to be rejected", i)
+		}
+	}
+}
+
+func TestNiceAxis(t *testing.T) {
+	a := niceAxis(-20, 190)
+	if a.min != -50 || a.max != 200 || a.step != 50 {
+		t.Errorf("Unexpected axis for -20..190: %+v", a)
+	}
+
+	a = niceAxis(1.5, 2.25)
+	if a.label(a.min) != "1.4" {
+		t.Errorf("Expected one decimal in tick labels, got %q", a.label(a.min))
+	}
+
+	// A flat series still gets a usable range
+	a = niceAxis(5, 5)
+	if a.max <= a.min {
+		t.Errorf("Expected non-empty axis for constant values: %+v", a)
+	}
+}
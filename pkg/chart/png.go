@@ -0,0 +1,168 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// pngCanvas rasterises drawing operations with simple coverage anti-aliasing
+type pngCanvas struct {
+	img   *image.RGBA
+	scale float64
+}
+
+func newPNGCanvas(width, height, scale float64) *pngCanvas {
+	return &pngCanvas{
+		img:   image.NewRGBA(image.Rect(0, 0, int(width*scale), int(height*scale))),
+		scale: scale,
+	}
+}
+
+// blend mixes a colour into a pixel with the given coverage (0..1)
+func (p *pngCanvas) blend(x, y int, c color.RGBA, coverage float64) {
+	if coverage <= 0 || !(image.Point{x, y}.In(p.img.Rect)) {
+		return
+	}
+	if coverage > 1 {
+		coverage = 1
+	}
+	dst := p.img.RGBAAt(x, y)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a)*(1-coverage) + float64(b)*coverage + 0.5)
+	}
+	p.img.SetRGBA(x, y, color.RGBA{mix(dst.R, c.R), mix(dst.G, c.G), mix(dst.B, c.B), 0xff})
+}
+
+// bounds returns the pixel range covering lo..hi, clamped to the image
+func (p *pngCanvas) bounds(lo, hi float64, max int) (int, int) {
+	from := int(math.Floor(lo))
+	to := int(math.Ceil(hi))
+	if from < 0 {
+		from = 0
+	}
+	if to > max {
+		to = max
+	}
+	return from, to
+}
+
+func (p *pngCanvas) rect(x, y, w, h float64, fill color.RGBA) {
+	x0, y0 := x*p.scale, y*p.scale
+	x1, y1 := (x+w)*p.scale, (y+h)*p.scale
+	fromX, toX := p.bounds(x0, x1, p.img.Rect.Dx())
+	fromY, toY := p.bounds(y0, y1, p.img.Rect.Dy())
+	for py := fromY; py < toY; py++ {
+		coverY := math.Min(float64(py+1), y1) - math.Max(float64(py), y0)
+		for px := fromX; px < toX; px++ {
+			coverX := math.Min(float64(px+1), x1) - math.Max(float64(px), x0)
+			p.blend(px, py, fill, coverX*coverY)
+		}
+	}
+}
+
+func (p *pngCanvas) line(x1, y1, x2, y2, width float64, stroke color.RGBA) {
+	x1, y1, x2, y2 = x1*p.scale, y1*p.scale, x2*p.scale, y2*p.scale
+	half := width * p.scale / 2
+	fromX, toX := p.bounds(math.Min(x1, x2)-half-1, math.Max(x1, x2)+half+1, p.img.Rect.Dx())
+	fromY, toY := p.bounds(math.Min(y1, y2)-half-1, math.Max(y1, y2)+half+1, p.img.Rect.Dy())
+
+	dx, dy := x2-x1, y2-y1
+	length2 := dx*dx + dy*dy
+	for py := fromY; py < toY; py++ {
+		for px := fromX; px < toX; px++ {
+			cx, cy := float64(px)+0.5, float64(py)+0.5
+			t := 0.0
+			if length2 > 0 {
+				t = math.Max(0, math.Min(1, ((cx-x1)*dx+(cy-y1)*dy)/length2))
+			}
+			d := math.Hypot(cx-(x1+t*dx), cy-(y1+t*dy))
+			p.blend(px, py, stroke, half+0.5-d)
+		}
+	}
+}
+
+func (p *pngCanvas) circle(cx, cy, r float64, fill color.RGBA) {
+	p.wedge(cx, cy, r, 0, 2*math.Pi, fill)
+}
+
+func (p *pngCanvas) wedge(cx, cy, r, start, end float64, fill color.RGBA) {
+	cx, cy, r = cx*p.scale, cy*p.scale, r*p.scale
+	fromX, toX := p.bounds(cx-r-1, cx+r+1, p.img.Rect.Dx())
+	fromY, toY := p.bounds(cy-r-1, cy+r+1, p.img.Rect.Dy())
+	full := end-start >= 2*math.Pi-1e-9
+
+	for py := fromY; py < toY; py++ {
+		for px := fromX; px < toX; px++ {
+			x, y := float64(px)+0.5-cx, float64(py)+0.5-cy
+			d := math.Hypot(x, y)
+			if d > r+0.5 {
+				continue
+			}
+			if !full {
+				// Angle clockwise from 12 o'clock
+				angle := math.Atan2(x, -y)
+				if angle < 0 {
+					angle += 2 * math.Pi
+				}
+				if angle < start || angle >= end {
+					continue
+				}
+			}
+			p.blend(px, py, fill, r+0.5-d)
+		}
+	}
+}
+
+func (p *pngCanvas) text(x, y float64, s string, size float64, anchor textAnchor, vertical bool, fill color.RGBA) {
+	// Glyphs are 5x7 cells on a 6x8 grid; one cell is size/8 logical units
+	cell := size / 8
+	width := p.textWidth(s, size)
+	offset := 0.0
+	switch anchor {
+	case anchorMiddle:
+		offset = -width / 2
+	case anchorEnd:
+		offset = -width
+	}
+
+	for i, r := range []rune(s) {
+		glyph := glyphFor(r)
+		advance := offset + float64(i)*6*cell
+		for row, bits := range glyph {
+			for col := 0; col < 5; col++ {
+				if bits&(1<<(4-col)) == 0 {
+					continue
+				}
+				// Cell position relative to the text origin, baseline at row 7
+				gx := advance + float64(col)*cell
+				gy := float64(row-7) * cell
+				if vertical {
+					// Rotate 90 degrees counter-clockwise around the origin
+					p.rect(x+gy, y-gx-cell, cell, cell, fill)
+				} else {
+					p.rect(x+gx, y+gy, cell, cell, fill)
+				}
+			}
+		}
+	}
+}
+
+func (p *pngCanvas) textWidth(s string, size float64) float64 {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (float64(n)*6 - 1) * size / 8
+}
+
+func (p *pngCanvas) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, p.img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,78 @@
+package chart
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// svgCanvas writes drawing operations as SVG elements
+type svgCanvas struct {
+	width, height float64
+	body          strings.Builder
+}
+
+func newSVGCanvas(width, height float64) *svgCanvas {
+	return &svgCanvas{width: width, height: height}
+}
+
+// hex formats a colour as #rrggbb
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (s *svgCanvas) rect(x, y, w, h float64, fill color.RGBA) {
+	fmt.Fprintf(&s.body, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`+"\n", x, y, w, h, hex(fill))
+}
+
+func (s *svgCanvas) line(x1, y1, x2, y2, width float64, stroke color.RGBA) {
+	fmt.Fprintf(&s.body, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%.1f" stroke-linecap="round"/>`+"\n",
+		x1, y1, x2, y2, hex(stroke), width)
+}
+
+func (s *svgCanvas) circle(cx, cy, r float64, fill color.RGBA) {
+	fmt.Fprintf(&s.body, `<circle cx="%.2f" cy="%.2f" r="%.2f" fill="%s"/>`+"\n", cx, cy, r, hex(fill))
+}
+
+func (s *svgCanvas) wedge(cx, cy, r, start, end float64, fill color.RGBA) {
+	// A full circle cannot be drawn as a single arc
+	if end-start >= 2*math.Pi-1e-9 {
+		s.circle(cx, cy, r, fill)
+		return
+	}
+	x1, y1 := cx+r*math.Sin(start), cy-r*math.Cos(start)
+	x2, y2 := cx+r*math.Sin(end), cy-r*math.Cos(end)
+	large := 0
+	if end-start > math.Pi {
+		large = 1
+	}
+	fmt.Fprintf(&s.body, `<path d="M %.2f %.2f L %.2f %.2f A %.2f %.2f 0 %d 1 %.2f %.2f Z" fill="%s"/>`+"\n",
+		cx, cy, x1, y1, r, r, large, x2, y2, hex(fill))
+}
+
+func (s *svgCanvas) text(x, y float64, str string, size float64, anchor textAnchor, vertical bool, fill color.RGBA) {
+	anchors := map[textAnchor]string{anchorStart: "start", anchorMiddle: "middle", anchorEnd: "end"}
+	transform := ""
+	if vertical {
+		transform = fmt.Sprintf(` transform="rotate(-90 %.2f %.2f)"`, x, y)
+	}
+	fmt.Fprintf(&s.body, `<text x="%.2f" y="%.2f" font-size="%.0f" text-anchor="%s" fill="%s"%s>%s</text>`+"\n",
+		x, y, size, anchors[anchor], hex(fill), transform, html.EscapeString(str))
+}
+
+// textWidth estimates the rendered width of sans-serif text
+func (s *svgCanvas) textWidth(str string, size float64) float64 {
+	return float64(utf8.RuneCountInString(str)) * size * 0.55
+}
+
+func (s *svgCanvas) bytes() ([]byte, error) {
+	var out strings.Builder
+	fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		s.width, s.height, s.width, s.height)
+	out.WriteString(s.body.String())
+	out.WriteString("</svg>\n")
+	return []byte(out.String()), nil
+}
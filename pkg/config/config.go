@@ -4,13 +4,28 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 // Config holds the configuration for the DocGen2 server
 type Config struct {
 	RootFolder string
+	
+	// Images wider than ImagePageWidth inches at ImageDPI are downscaled on ingest
+	ImageDPI       int
+	ImagePageWidth float64
+	
+	// Largest image file accepted, in bytes
+	MaxImageSize int64
 }
 
+// Defaults for image downscaling: print resolution across a Letter/A4 text block
+const (
+	DefaultImageDPI       = 300
+	DefaultImagePageWidth = 6.5
+	DefaultMaxImageSize   = 25 << 20
+)
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
 	cfg := &Config{}
@@ -33,6 +48,30 @@ func LoadConfig() (*Config, error) {
 	}
 	cfg.RootFolder = absPath
 	
+	// Image downscaling settings
+	if dpi := os.Getenv("DOCGEN_IMAGE_DPI"); dpi != "" {
+		value, err := strconv.Atoi(dpi)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_IMAGE_DPI: %s", dpi)
+		}
+		cfg.ImageDPI = value
+	}
+	if width := os.Getenv("DOCGEN_IMAGE_PAGE_WIDTH"); width != "" {
+		value, err := strconv.ParseFloat(width, 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_IMAGE_PAGE_WIDTH: %s", width)
+		}
+		cfg.ImagePageWidth = value
+	}
+	
+	if size := os.Getenv("DOCGEN_MAX_IMAGE_MB"); size != "" {
+		value, err := strconv.ParseFloat(size, 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_MAX_IMAGE_MB: %s", size)
+		}
+		cfg.MaxImageSize = int64(value * (1 << 20))
+	}
+	
 	// Create root folder if it doesn't exist
 	if err := os.MkdirAll(cfg.RootFolder, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create root folder: %w", err)
@@ -55,4 +94,30 @@ func (c *Config) GetDocumentsFolder() string {
 // GetDocumentFolder returns the path to a specific document folder
 func (c *Config) GetDocumentFolder(docID string) string {
 	return filepath.Join(c.GetDocumentsFolder(), docID)
-}
\ No newline at end of file
+}
+
+// GetSnippetsFolder returns the path to the workspace snippet library
+func (c *Config) GetSnippetsFolder() string {
+	return filepath.Join(c.RootFolder, "snippets")
+}
+
+// MaxImageWidth returns the widest image, in pixels, kept on ingest
+func (c *Config) MaxImageWidth() int {
+	dpi := c.ImageDPI
+	if dpi <= 0 {
+		dpi = DefaultImageDPI
+	}
+	width := c.ImagePageWidth
+	if width <= 0 {
+		width = DefaultImagePageWidth
+	}
+	return int(float64(dpi) * width)
+}
+
+// MaxImageBytes returns the largest image file accepted, in bytes
+func (c *Config) MaxImageBytes() int64 {
+	if c.MaxImageSize <= 0 {
+		return DefaultMaxImageSize
+	}
+	return c.MaxImageSize
+}
@@ -0,0 +1,167 @@
+// Package diagram renders Mermaid, Graphviz and PlantUML source with locally
+// installed tools, caching the output by content hash.
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// Output formats
+const (
+	FormatSVG = "svg"
+	FormatPNG = "png"
+)
+
+// CacheDir is the document-relative folder holding rendered diagrams
+const CacheDir = "diagrams"
+
+// renderTimeout bounds a single renderer invocation
+const renderTimeout = 60 * time.Second
+
+// commands maps each engine to the executable that renders it
+var commands = map[string]string{
+	blocks.EngineMermaid:  "mmdc",
+	blocks.EngineGraphviz: "dot",
+	blocks.EnginePlantUML: "plantuml",
+}
+
+// lookPath is swapped out in tests
+var (
+	execLookPath = exec.LookPath
+	lookPath     = execLookPath
+)
+
+// Available reports whether the renderer for an engine is installed
+func Available(engine string) bool {
+	command, ok := commands[engine]
+	if !ok {
+		return false
+	}
+	_, err := lookPath(command)
+	return err == nil
+}
+
+// ClientSide reports whether an engine can be rendered in the browser for HTML
+// export when no local renderer is installed
+func ClientSide(engine string) bool {
+	return engine == blocks.EngineMermaid || engine == blocks.EngineGraphviz
+}
+
+// CachePath returns the document-relative path a diagram renders to. The name is
+// a hash of the engine and source, so edited diagrams render afresh and unchanged
+// ones are reused.
+func CachePath(d *blocks.DiagramBlock, format string) string {
+	sum := sha256.Sum256([]byte(d.Engine + "\x00" + d.Source))
+	return filepath.Join(CacheDir, hex.EncodeToString(sum[:])[:16]+"."+format)
+}
+
+// Render runs the engine's local renderer and writes the output to outPath
+func Render(d *blocks.DiagramBlock, format, outPath string) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if format != FormatSVG && format != FormatPNG {
+		return fmt.Errorf("unsupported diagram format: %s", format)
+	}
+	command, err := lookPath(commands[d.Engine])
+	if err != nil {
+		return fmt.Errorf("%s renderer %q is not installed", d.Engine, commands[d.Engine])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("failed to create diagrams directory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), renderTimeout)
+	defer cancel()
+
+	var data []byte
+	switch d.Engine {
+	case blocks.EngineGraphviz:
+		data, err = run(ctx, command, d.Source, "-T"+format)
+	case blocks.EnginePlantUML:
+		data, err = run(ctx, command, plantUMLSource(d.Source), "-t"+format, "-pipe")
+	case blocks.EngineMermaid:
+		// mmdc only works with files
+		data, err = renderMermaid(ctx, command, d.Source, format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render %s diagram: %w", d.Engine, err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("%s renderer produced no output", d.Engine)
+	}
+
+	// Write atomically so an interrupted render never leaves a bad cache entry
+	tmpPath := outPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write diagram: %w", err)
+	}
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write diagram: %w", err)
+	}
+	return nil
+}
+
+// run feeds source to a command on stdin and returns its stdout
+func run(ctx context.Context, command, source string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stdin = strings.NewReader(source)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, commandError(err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+func renderMermaid(ctx context.Context, command, source, format string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "docgen2-mermaid-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "diagram.mmd")
+	output := filepath.Join(dir, "diagram."+format)
+	if err := os.WriteFile(input, []byte(source), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write diagram source: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, command, "-i", input, "-o", output, "-b", "white")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, commandError(err, stderr.String())
+	}
+	return os.ReadFile(output)
+}
+
+// plantUMLSource wraps bare PlantUML source in @startuml/@enduml
+func plantUMLSource(source string) string {
+	trimmed := strings.TrimSpace(source)
+	if strings.HasPrefix(trimmed, "@start") {
+		return source
+	}
+	return "@startuml\n" + trimmed + "\n@enduml\n"
+}
+
+func commandError(err error, stderr string) error {
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package diagram
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+func TestRenderWithLocalTool(t *testing.T) {
+	dir := t.TempDir()
+
+	// A stand-in for dot that echoes its format flag and input
+	script := filepath.Join(dir, "dot")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$1\"\ncat\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	lookPath = func(name string) (string, error) {
+		if name == "dot" {
+			return script, nil
+		}
+		return "", os.ErrNotExist
+	}
+	defer func() { lookPath = execLookPath }()
+
+	if !Available(blocks.EngineGraphviz) || Available(blocks.EngineMermaid) {
+		t.Fatal("Unexpected renderer availability")
+	}
+
+	d := &blocks.DiagramBlock{Engine: blocks.EngineGraphviz, Source: "digraph { a -> b }"}
+	out := filepath.Join(dir, CachePath(d, FormatSVG))
+	if err := Render(d, FormatSVG, out); err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "-Tsvg\ndigraph { a -> b }" {
+		t.Errorf("Unexpected renderer output: %q", data)
+	}
+
+	missing := &blocks.DiagramBlock{Engine: blocks.EngineMermaid, Source: "graph TD"}
+	if err := Render(missing, FormatSVG, out); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("Expected missing renderer error, got %v", err)
+	}
+}
+
+func TestPlantUMLSource(t *testing.T) {
+	if got := plantUMLSource("A -> B"); got != "@startuml\nA -> B\n@enduml\n" {
+		t.Errorf("Unexpected wrapped source: %q", got)
+	}
+	wrapped := "@startuml\nA -> B\n@enduml"
+	if got := plantUMLSource(wrapped); got != wrapped {
+		t.Errorf("Expected source to be left alone, got %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package document
 
 import (
+	"fmt"
 	"time"
 	
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
@@ -15,8 +16,23 @@ type Document struct {
 	UpdatedAt   time.Time `yaml:"updated_at"`
 	HasChapters bool      `yaml:"has_chapters"`
 	
-	// Styling configuration
-	Style *style.StyleConfig `yaml:"style,omitempty"`
+	// Styling configuration: a full style, or a named theme with the settings
+	// the document changes kept sparse so later changes to the theme reach it
+	Theme         string                 `yaml:"theme,omitempty"`
+	Style         *style.StyleConfig     `yaml:"style,omitempty"`
+	StyleSettings map[string]interface{} `yaml:"style_settings,omitempty"`
+	
+	// Values substituted for {{name}} placeholders in block text on export
+	Variables map[string]string `yaml:"variables,omitempty"`
+	
+	// Named export profiles selecting conditional content, e.g. "internal"
+	Profiles map[string]blocks.Profile `yaml:"profiles,omitempty"`
+	
+	// Title page exported before the content
+	Cover *CoverPage `yaml:"cover,omitempty"`
+	
+	// Terms defined in the exported glossary, sorted by term
+	Glossary []GlossaryTerm `yaml:"glossary,omitempty"`
 	
 	// For flat documents
 	Blocks []blocks.BlockReference `yaml:"blocks,omitempty"`
@@ -25,6 +41,44 @@ type Document struct {
 	Chapters []ChapterReference `yaml:"chapters,omitempty"`
 }
 
+// Cover page layouts
+const (
+	CoverClassic = "classic" // Centered, with the title in the middle of the page
+	CoverModern  = "modern"  // Left-aligned, with a rule under the title
+	CoverMinimal = "minimal" // Title and details at the top, logo at the bottom
+)
+
+// CoverPage configures the cover page: a dedicated first page in PDF and DOCX
+// and a hero section in HTML. Text may use {{name}} placeholders.
+type CoverPage struct {
+	Layout       string `yaml:"layout" json:"layout"`
+	Title        string `yaml:"title,omitempty" json:"title,omitempty"` // Defaults to the document title
+	Subtitle     string `yaml:"subtitle,omitempty" json:"subtitle,omitempty"`
+	Author       string `yaml:"author,omitempty" json:"author,omitempty"` // Defaults to the document author
+	Organization string `yaml:"organization,omitempty" json:"organization,omitempty"`
+	Date         string `yaml:"date,omitempty" json:"date,omitempty"`             // "today" is the date of export
+	Logo         string `yaml:"logo,omitempty" json:"logo,omitempty"`             // Asset path
+	Background   string `yaml:"background,omitempty" json:"background,omitempty"` // Asset path; PDF and HTML only
+}
+
+// Validate checks the cover page layout
+func (c *CoverPage) Validate() error {
+	switch c.Layout {
+	case CoverClassic, CoverModern, CoverMinimal:
+		return nil
+	default:
+		return fmt.Errorf("invalid cover layout %q (supported: classic, modern, minimal)", c.Layout)
+	}
+}
+
+// GlossaryTerm is a term defined in the document's glossary. On export, the first
+// use of the term or one of its aliases in markdown text links to the definition.
+type GlossaryTerm struct {
+	Term       string   `yaml:"term" json:"term"`
+	Definition string   `yaml:"definition" json:"definition"`
+	Aliases    []string `yaml:"aliases,omitempty" json:"aliases,omitempty"` // Other forms, e.g. plurals
+}
+
 // Chapter represents a chapter in a document
 type Chapter struct {
 	ID     string                  `yaml:"id"`
@@ -37,30 +91,56 @@ type ChapterReference struct {
 	ID     string `yaml:"id"`
 	Title  string `yaml:"title"`
 	Folder string `yaml:"folder"`
+	
+	// The chapter is only exported for profiles that satisfy its conditions
+	Conditions blocks.Conditions `yaml:"conditions,omitempty"`
+	
+	// Review workflow: no status means draft, and a locked chapter's blocks refuse edits
+	Status string `yaml:"status,omitempty"`
+	Locked bool   `yaml:"locked,omitempty"`
+	
+	// Overrides the document style for the whole chapter
+	Style *style.StyleOverride `yaml:"style,omitempty"`
 }
 
 // DocumentOverview provides a tree structure of the document
 type DocumentOverview struct {
-	ID          string             `json:"id"`
-	Title       string             `json:"title"`
-	Author      string             `json:"author,omitempty"`
-	HasChapters bool               `json:"has_chapters"`
-	Blocks      []BlockOverview    `json:"blocks"`
-	Chapters    []ChapterOverview  `json:"chapters"`
+	ID           string                    `json:"id"`
+	Title        string                    `json:"title"`
+	Author       string                    `json:"author,omitempty"`
+	HasChapters  bool                      `json:"has_chapters"`
+	Variables    map[string]string         `json:"variables,omitempty"`
+	Profiles     map[string]blocks.Profile `json:"profiles,omitempty"`
+	Cover        *CoverPage                `json:"cover,omitempty"`
+	Glossary     []GlossaryTerm            `json:"glossary,omitempty"`
+	OpenComments int                       `json:"open_comments,omitempty"` // Unresolved review comments
+	Blocks       []BlockOverview           `json:"blocks"`
+	Chapters     []ChapterOverview         `json:"chapters"`
 }
 
 // ChapterOverview provides overview of a chapter
 type ChapterOverview struct {
-	ID     string          `json:"id"`
-	Title  string          `json:"title"`
-	Blocks []BlockOverview `json:"blocks"`
+	ID         string               `json:"id"`
+	Title      string               `json:"title"`
+	Conditions string               `json:"conditions,omitempty"`
+	Status     string               `json:"status"`
+	Locked     bool                 `json:"locked,omitempty"`
+	Style      *style.StyleOverride `json:"style,omitempty"`
+	Blocks     []BlockOverview      `json:"blocks"`
 }
 
 // BlockOverview provides overview of a block
 type BlockOverview struct {
-	ID      string `json:"id"`
-	Type    string `json:"type"`
-	Preview string `json:"preview"` // First 100 chars or summary
+	ID         string               `json:"id"`
+	Type       string               `json:"type"`
+	Preview    string               `json:"preview"` // First 100 chars or summary
+	Label      string               `json:"label,omitempty"`
+	Tags       []string             `json:"tags,omitempty"`
+	Conditions string               `json:"conditions,omitempty"` // e.g. "audience=internal; edition=pro"
+	Comments   int                  `json:"comments,omitempty"`   // Unresolved review comments
+	Status     string               `json:"status"`               // draft, needs-review or approved
+	Locked     bool                 `json:"locked,omitempty"`     // Set on the block or its chapter
+	Style      *style.StyleOverride `json:"style,omitempty"`      // Overrides of the document style
 }
 
 // Position represents where to add a new block/chapter
@@ -97,9 +177,11 @@ func ParsePosition(pos string) Position {
 
 // SearchResult represents a search result
 type SearchResult struct {
-	BlockID   string `json:"block_id"`
-	BlockType string `json:"block_type"`
-	ChapterID string `json:"chapter_id,omitempty"`
-	Snippet   string `json:"snippet"`
-	Position  int    `json:"position"` // Position in document/chapter
+	BlockID   string   `json:"block_id"`
+	BlockType string   `json:"block_type"`
+	ChapterID string   `json:"chapter_id,omitempty"`
+	Status    string   `json:"status"`
+	Tags      []string `json:"tags,omitempty"`
+	Snippet   string   `json:"snippet"`
+	Position  int      `json:"position"` // Position in document/chapter
 }
\ No newline at end of file
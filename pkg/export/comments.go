@@ -0,0 +1,174 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/storage"
+)
+
+// Comment export modes
+const (
+	CommentsNone = "none" // Strip comments (default)
+	CommentsOpen = "open" // Export unresolved comments
+	CommentsAll  = "all"  // Export resolved comments as well
+)
+
+// ValidateCommentsMode checks a comment export mode
+func ValidateCommentsMode(mode string) error {
+	switch mode {
+	case "", CommentsNone, CommentsOpen, CommentsAll:
+		return nil
+	default:
+		return fmt.Errorf("invalid comments mode %q (supported: none, open, all)", mode)
+	}
+}
+
+// commentsByBlock groups the comments to export by block. Block IDs are only
+// unique within a chapter, so the key includes the chapter ID.
+type commentsByBlock map[string][]storage.Comment
+
+func commentKey(chapterID, blockID string) string {
+	return chapterID + "/" + blockID
+}
+
+// loadExportComments returns the comments an export in the given mode shows
+func loadExportComments(stor *storage.Storage, docID, mode string) (commentsByBlock, error) {
+	if mode == "" || mode == CommentsNone {
+		return nil, nil
+	}
+
+	comments, err := stor.ListComments(docID)
+	if err != nil {
+		return nil, err
+	}
+	byBlock := make(commentsByBlock)
+	for _, comment := range comments {
+		if comment.Resolved && mode != CommentsAll {
+			continue
+		}
+		key := commentKey(comment.ChapterID, comment.BlockID)
+		byBlock[key] = append(byBlock[key], comment)
+	}
+	return byBlock, nil
+}
+
+// annotateComments anchors a block's comments on passages of a markdown block
+// at the quoted text. It returns the other comments, and those whose passage has
+// since been edited away, as notes to place before the block.
+// boxed tells whether the block's passages are set inside a box of its own.
+func (bc *buildContext) annotateComments(blockID string, block blocks.Block, boxed bool) string {
+	comments := bc.comments[commentKey(bc.chapterID, blockID)]
+	if len(comments) == 0 {
+		return ""
+	}
+
+	markdown, _ := block.(*blocks.MarkdownBlock)
+	var notes []string
+	for _, comment := range comments {
+		if comment.Quote != "" && markdown != nil {
+			if i := strings.Index(markdown.Content, comment.Quote); i >= 0 {
+				markdown.Content = markdown.Content[:i] + bc.commentRange(comment, boxed) + markdown.Content[i+len(comment.Quote):]
+				continue
+			}
+		}
+		notes = append(notes, bc.commentNote(comment))
+	}
+	return strings.Join(notes, "\n\n")
+}
+
+// commentRange renders a comment anchored at a passage of text
+func (bc *buildContext) commentRange(comment storage.Comment, boxed bool) string {
+	quote := comment.Quote
+	switch bc.format {
+	case "pdf":
+		return quote + bc.latexNote(comment, boxed)
+	case "docx":
+		start, end := bc.docxComment(comment)
+		return start + quote + end
+	default:
+		return fmt.Sprintf("[%s]{.commented}[%s]{.margin-note}", quote, commentThread(comment, " "))
+	}
+}
+
+// commentNote renders a comment on a whole block as its own paragraph
+func (bc *buildContext) commentNote(comment storage.Comment) string {
+	switch bc.format {
+	case "pdf":
+		return bc.latexNote(comment, false)
+	case "docx":
+		start, end := bc.docxComment(comment)
+		return start + end
+	default:
+		return fmt.Sprintf("::: {.margin-note}\n%s\n:::", commentThread(comment, "\n\n"))
+	}
+}
+
+// commentThread writes a comment and its replies as markdown, one entry per author
+func commentThread(comment storage.Comment, separator string) string {
+	entries := []string{commentEntry(comment.Author, comment.Text)}
+	for _, reply := range comment.Replies {
+		entries = append(entries, commentEntry(reply.Author, reply.Text))
+	}
+	if comment.Resolved {
+		entries = append(entries, "*(resolved)*")
+	}
+	return strings.Join(entries, separator)
+}
+
+// commentEscaper keeps comment text on one line and stops it from closing the
+// span around it
+var commentEscaper = strings.NewReplacer("[", `\[`, "]", `\]`, "\n", " ")
+
+// commentEntry writes one message of a thread
+func commentEntry(author, text string) string {
+	return fmt.Sprintf("**%s:** %s", commentEscaper.Replace(author), commentEscaper.Replace(strings.TrimSpace(text)))
+}
+
+// latexNote writes a comment thread as a \marginpar. Margin notes are floats,
+// which LaTeX cannot set inside multicols or a minipage, so a note in a layout
+// or a kept-together box becomes a footnote instead.
+func (bc *buildContext) latexNote(comment storage.Comment, boxed bool) string {
+	var note strings.Builder
+	if boxed || bc.nested > 0 {
+		note.WriteString("\\footnote{")
+	} else {
+		note.WriteString("\\marginpar{\\footnotesize\\raggedright ")
+	}
+	entries := append([]storage.CommentReply{{Author: comment.Author, Text: comment.Text}}, comment.Replies...)
+	for i, entry := range entries {
+		if i > 0 {
+			note.WriteString("\\par ")
+		}
+		text := strings.ReplaceAll(strings.TrimSpace(entry.Text), "\n", " ")
+		note.WriteString(fmt.Sprintf("\\textbf{%s:} %s", latexEscaper.Replace(entry.Author), latexEscaper.Replace(text)))
+	}
+	if comment.Resolved {
+		note.WriteString("\\par\\textit{(resolved)}")
+	}
+	note.WriteString("}")
+	return "`" + note.String() + "`{=latex}"
+}
+
+// docxComment returns the Pandoc spans that open and close a Word comment. Each
+// reply is a comment of its own on the same range, as Word shows a thread.
+func (bc *buildContext) docxComment(comment storage.Comment) (string, string) {
+	entries := append([]storage.CommentReply{{Author: comment.Author, Text: comment.Text, CreatedAt: comment.CreatedAt}}, comment.Replies...)
+	attribute := strings.NewReplacer(`"`, "'", "\n", " ")
+
+	var start, end strings.Builder
+	ids := make([]int, len(entries))
+	for i, entry := range entries {
+		ids[i] = bc.commentCount
+		bc.commentCount++
+		start.WriteString(fmt.Sprintf(`[%s]{.comment-start id="%d" author="%s" date="%s"}`,
+			commentEscaper.Replace(strings.TrimSpace(entry.Text)), ids[i],
+			attribute.Replace(entry.Author), entry.CreatedAt.UTC().Format(time.RFC3339)))
+	}
+	for i := len(ids) - 1; i >= 0; i-- {
+		end.WriteString(fmt.Sprintf(`[]{.comment-end id="%d"}`, ids[i]))
+	}
+	return start.String(), end.String()
+}
@@ -0,0 +1,344 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/document"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+)
+
+// A cover page is exported three ways. PDF and HTML receive it through Pandoc's
+// include-before metadata, which their templates place ahead of the table of
+// contents: a titlepage environment for LaTeX and a hero section for HTML. The
+// DOCX writer has no such slot, so the cover opens the body as raw OpenXML
+// paragraphs, followed by a page break and the table of contents field.
+
+// coverDateToday is the cover date that stands for the date of export
+const coverDateToday = "today"
+
+// coverLogoHeight is the height of the logo on PDF and DOCX cover pages
+const coverLogoHeight = "2cm"
+
+// coverPage is a cover page ready to render: placeholders are filled in, the
+// document's title and author stand in for missing ones and images have
+// absolute paths
+type coverPage struct {
+	layout       string
+	title        string
+	subtitle     string
+	author       string
+	organization string
+	date         string
+	logo         string
+	background   string
+}
+
+// resolveCover prepares the document's cover page, if it has one
+func (mb *MarkdownBuilder) resolveCover(bc *buildContext, doc *document.Document) *coverPage {
+	if doc.Cover == nil {
+		return nil
+	}
+
+	cover := &coverPage{
+		layout:       doc.Cover.Layout,
+		title:        doc.Cover.Title,
+		subtitle:     doc.Cover.Subtitle,
+		author:       doc.Cover.Author,
+		organization: doc.Cover.Organization,
+		date:         doc.Cover.Date,
+	}
+	if cover.title == "" {
+		cover.title = doc.Title
+	}
+	if cover.author == "" {
+		cover.author = doc.Author
+	}
+	for _, text := range []*string{&cover.title, &cover.subtitle, &cover.author, &cover.organization, &cover.date} {
+		*text = bc.vars.expand(*text)
+	}
+	if strings.EqualFold(cover.date, coverDateToday) {
+		cover.date = time.Now().Format("January 2, 2006")
+	}
+
+	if doc.Cover.Logo != "" {
+		cover.logo = mb.absoluteImagePath(bc.docID, doc.Cover.Logo)
+	}
+	if doc.Cover.Background != "" {
+		cover.background = mb.absoluteImagePath(bc.docID, doc.Cover.Background)
+	}
+	return cover
+}
+
+// details returns the author, organization and date lines that are set
+func (c *coverPage) details() []string {
+	var lines []string
+	for _, line := range []string{c.author, c.organization, c.date} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// metadata writes the YAML metadata block for a document with a cover page.
+// The title and author only become document properties so that Pandoc does not
+// print its own title block next to the cover. Format-neutral markdown keeps
+// Pandoc's title block instead.
+func (c *coverPage) metadata(bc *buildContext) string {
+	var meta strings.Builder
+	meta.WriteString("---\n")
+
+	if bc.format == "" {
+		meta.WriteString(fmt.Sprintf("title: \"%s\"\n", escapeYAMLString(c.title)))
+		if c.subtitle != "" {
+			meta.WriteString(fmt.Sprintf("subtitle: \"%s\"\n", escapeYAMLString(c.subtitle)))
+		}
+		if c.author != "" {
+			meta.WriteString(fmt.Sprintf("author: \"%s\"\n", escapeYAMLString(c.author)))
+		}
+		if c.date != "" {
+			meta.WriteString(fmt.Sprintf("date: \"%s\"\n", escapeYAMLString(c.date)))
+		}
+		meta.WriteString("---\n\n")
+		return meta.String()
+	}
+
+	meta.WriteString(fmt.Sprintf("title-meta: \"%s\"\n", escapeYAMLString(c.title)))
+	if c.author != "" {
+		meta.WriteString(fmt.Sprintf("author-meta: \"%s\"\n", escapeYAMLString(c.author)))
+	}
+	switch bc.format {
+	case "pdf":
+		meta.WriteString("include-before: " + yamlLiteral(rawBlock(blocks.RawLaTeX, c.latex())))
+	case "html":
+		meta.WriteString(fmt.Sprintf("pagetitle: \"%s\"\n", escapeYAMLString(c.title)))
+		meta.WriteString("include-before: " + yamlLiteral(rawBlock(blocks.RawHTML, c.html())))
+	}
+	meta.WriteString("---\n\n")
+	return meta.String()
+}
+
+// yamlLiteral formats text as a YAML literal block scalar, which needs no escaping
+func yamlLiteral(text string) string {
+	var literal strings.Builder
+	literal.WriteString("|\n")
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" {
+			literal.WriteString("\n")
+			continue
+		}
+		literal.WriteString("  " + line + "\n")
+	}
+	return literal.String()
+}
+
+// latex sets the cover in a titlepage environment, which has no header or
+// footer. The background is drawn behind the page with eso-pic.
+func (c *coverPage) latex() string {
+	var tex strings.Builder
+	tex.WriteString("\\begin{titlepage}\n")
+	if c.background != "" {
+		tex.WriteString(fmt.Sprintf("\\AddToShipoutPictureBG*{\\AtPageLowerLeft{\\includegraphics[width=\\paperwidth,height=\\paperheight]{%s}}}\n", c.background))
+	}
+	logo := ""
+	if c.logo != "" {
+		logo = fmt.Sprintf("\\includegraphics[height=%s,keepaspectratio]{%s}\\par\n", coverLogoHeight, c.logo)
+	}
+	title := latexEscaper.Replace(c.title)
+	subtitle := latexEscaper.Replace(c.subtitle)
+
+	switch c.layout {
+	case document.CoverModern:
+		tex.WriteString("\\raggedright\n")
+		tex.WriteString(logo)
+		tex.WriteString("\\vspace*{0.2\\textheight}\n")
+		tex.WriteString(fmt.Sprintf("{\\sffamily\\Huge\\bfseries\\color{headingcolor} %s\\par}\n", title))
+		tex.WriteString("\\vspace{0.5em}{\\color{headingcolor}\\rule{\\linewidth}{2pt}\\par}\n")
+		if subtitle != "" {
+			tex.WriteString(fmt.Sprintf("\\vspace{0.5em}{\\Large %s\\par}\n", subtitle))
+		}
+		tex.WriteString("\\vfill\n")
+		for i, line := range c.details() {
+			if i == 0 {
+				tex.WriteString(fmt.Sprintf("{\\large\\bfseries %s\\par}\n", latexEscaper.Replace(line)))
+				continue
+			}
+			tex.WriteString(fmt.Sprintf("{%s\\par}\n", latexEscaper.Replace(line)))
+		}
+
+	case document.CoverMinimal:
+		tex.WriteString("\\raggedright\n")
+		tex.WriteString(fmt.Sprintf("{\\sffamily\\LARGE\\bfseries %s\\par}\n", title))
+		if subtitle != "" {
+			tex.WriteString(fmt.Sprintf("\\vspace{0.5em}{\\large %s\\par}\n", subtitle))
+		}
+		tex.WriteString("\\vspace{2em}\n")
+		for _, line := range c.details() {
+			tex.WriteString(fmt.Sprintf("{\\small %s\\par}\n", latexEscaper.Replace(line)))
+		}
+		tex.WriteString("\\vfill\n")
+		if logo != "" {
+			tex.WriteString("{\\raggedleft " + strings.TrimSuffix(logo, "\n") + "}\n")
+		}
+
+	default:
+		tex.WriteString("\\centering\n")
+		tex.WriteString(logo)
+		tex.WriteString("\\vspace*{\\fill}\n")
+		tex.WriteString(fmt.Sprintf("{\\sffamily\\Huge\\bfseries\\color{headingcolor} %s\\par}\n", title))
+		if subtitle != "" {
+			tex.WriteString(fmt.Sprintf("\\vspace{1em}{\\Large %s\\par}\n", subtitle))
+		}
+		tex.WriteString("\\vspace*{\\fill}\n")
+		for _, line := range c.details() {
+			tex.WriteString(fmt.Sprintf("{\\large %s\\par}\n", latexEscaper.Replace(line)))
+		}
+	}
+
+	tex.WriteString("\\end{titlepage}")
+	return tex.String()
+}
+
+// html writes the cover as a hero section; the stylesheet lays out each layout
+func (c *coverPage) html() string {
+	var hero strings.Builder
+	hero.WriteString(fmt.Sprintf("<section class=\"cover cover-%s\">\n", c.layout))
+	if c.background != "" {
+		hero.WriteString(fmt.Sprintf("<img class=\"cover-background\" src=\"%s\" alt=\"\">\n", c.background))
+	}
+	logo := ""
+	if c.logo != "" {
+		logo = fmt.Sprintf("<img class=\"cover-logo\" src=\"%s\" alt=\"\">\n", c.logo)
+	}
+	if c.layout != document.CoverMinimal {
+		hero.WriteString(logo)
+	}
+
+	hero.WriteString(fmt.Sprintf("<div class=\"cover-title\">%s</div>\n", html.EscapeString(c.title)))
+	if c.subtitle != "" {
+		hero.WriteString(fmt.Sprintf("<div class=\"cover-subtitle\">%s</div>\n", html.EscapeString(c.subtitle)))
+	}
+	if details := c.details(); len(details) > 0 {
+		hero.WriteString("<div class=\"cover-details\">\n")
+		for _, line := range details {
+			hero.WriteString(fmt.Sprintf("<div>%s</div>\n", html.EscapeString(line)))
+		}
+		hero.WriteString("</div>\n")
+	}
+
+	if c.layout == document.CoverMinimal {
+		hero.WriteString(logo)
+	}
+	hero.WriteString("</section>")
+	return hero.String()
+}
+
+// openXML writes the cover as the first page of a DOCX body. Text paragraphs
+// use the Title, Subtitle, Author and Date styles Pandoc uses for its own title
+// block; the logo is a regular image in a "Cover Logo" paragraph. Word has no
+// equivalent of a page background for one page, so the background is left out.
+func (c *coverPage) openXML(styleConfig style.StyleConfig) string {
+	align := "left"
+	titleSpace, detailsSpace := 2880, 2880 // twips
+	border := ""
+	switch c.layout {
+	case document.CoverModern:
+		border = fmt.Sprintf(`<w:pBdr><w:bottom w:val="single" w:sz="16" w:space="4" w:color="%s"/></w:pBdr>`, rgbToHex(styleConfig.Colors.HeadingText))
+	case document.CoverMinimal:
+		titleSpace, detailsSpace = 0, 480
+	default:
+		align = "center"
+		titleSpace, detailsSpace = 3600, 3600
+	}
+
+	var parts []string
+	logo := ""
+	if c.logo != "" {
+		logo = fmt.Sprintf("::: {custom-style=\"Cover Logo\"}\n![](<%s>){height=%s}\n:::", c.logo, coverLogoHeight)
+	}
+	if logo != "" && c.layout != document.CoverMinimal {
+		parts = append(parts, logo)
+	}
+
+	var text strings.Builder
+	text.WriteString(openXMLCoverParagraph("Title", c.title, align, titleSpace, border))
+	if c.subtitle != "" {
+		text.WriteString(openXMLCoverParagraph("Subtitle", c.subtitle, align, 0, ""))
+	}
+	for i, line := range []string{c.author, c.organization, c.date} {
+		if line == "" {
+			continue
+		}
+		paragraphStyle := "Author"
+		if i == 2 {
+			paragraphStyle = "Date"
+		}
+		text.WriteString(openXMLCoverParagraph(paragraphStyle, line, align, detailsSpace, ""))
+		detailsSpace = 0
+	}
+	parts = append(parts, rawBlock(blocks.RawOpenXML, text.String()))
+
+	if logo != "" && c.layout == document.CoverMinimal {
+		parts = append(parts, logo)
+	}
+
+	parts = append(parts, rawBlock(blocks.RawOpenXML, openXMLPageBreak))
+	if styleConfig.TOC.Enabled {
+		parts = append(parts, rawBlock(blocks.RawOpenXML, openXMLTOC(styleConfig.TOC)))
+	}
+	return strings.Join(parts, "\n\n") + "\n\n"
+}
+
+// openXMLCoverParagraph writes one paragraph of cover text in a Word style
+func openXMLCoverParagraph(paragraphStyle, text, align string, spaceBefore int, border string) string {
+	spacing := ""
+	if spaceBefore > 0 {
+		spacing = fmt.Sprintf(`<w:spacing w:before="%d"/>`, spaceBefore)
+	}
+	return fmt.Sprintf(`<w:p><w:pPr><w:pStyle w:val="%s"/>%s%s<w:jc w:val="%s"/></w:pPr><w:r><w:t xml:space="preserve">%s</w:t></w:r></w:p>`,
+		paragraphStyle, border, spacing, align, html.EscapeString(text))
+}
+
+// openXMLTOC is the table of contents field Pandoc writes for --toc, used when
+// the cover has to come first. Word fills it in when its fields are updated.
+func openXMLTOC(toc style.TOCConfig) string {
+	depth := toc.Depth
+	if depth < 1 || depth > 6 {
+		depth = 3
+	}
+
+	var field strings.Builder
+	field.WriteString(`<w:sdt><w:sdtPr><w:docPartObj><w:docPartGallery w:val="Table of Contents"/><w:docPartUnique/></w:docPartObj></w:sdtPr><w:sdtContent>`)
+	if toc.Title != "" {
+		field.WriteString(fmt.Sprintf(`<w:p><w:pPr><w:pStyle w:val="TOCHeading"/></w:pPr><w:r><w:t xml:space="preserve">%s</w:t></w:r></w:p>`, html.EscapeString(toc.Title)))
+	}
+	field.WriteString(`<w:p><w:r><w:fldChar w:fldCharType="begin" w:dirty="true"/></w:r>`)
+	field.WriteString(fmt.Sprintf(`<w:r><w:instrText xml:space="preserve">TOC \o "1-%d" \h \z \u</w:instrText></w:r>`, depth))
+	field.WriteString(`<w:r><w:fldChar w:fldCharType="separate"/></w:r>`)
+	field.WriteString(`<w:r><w:t>Update this field to show the table of contents.</w:t></w:r>`)
+	field.WriteString(`<w:r><w:fldChar w:fldCharType="end"/></w:r></w:p>`)
+	field.WriteString(`</w:sdtContent></w:sdt>`)
+	return field.String()
+}
+
+// rgbToHex converts a style color such as "0,51,102" to Word's "003366"
+func rgbToHex(rgb string) string {
+	parts := strings.Split(rgb, ",")
+	if len(parts) != 3 {
+		return "000000"
+	}
+	var hex strings.Builder
+	for _, part := range parts {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value < 0 || value > 255 {
+			return "000000"
+		}
+		hex.WriteString(fmt.Sprintf("%02X", value))
+	}
+	return hex.String()
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+)
+
+var (
+	// sectPrGeometryPattern matches the page size and margins of a section
+	sectPrGeometryPattern = regexp.MustCompile(`<w:pgSz\b[^>]*/>|<w:pgMar\b[^>]*/>`)
+
+	// sectPrLeadPattern matches the empty elements that come before the page size in a section
+	sectPrLeadPattern = regexp.MustCompile(`<w:(?:headerReference|footerReference|type)\b[^>]*/>`)
+)
+
+// writeReferenceDoc writes Pandoc's default reference.docx with the style's page
+// size and margins. Pandoc takes the final section of a DOCX from the reference
+// document, and the sections that column layouts and landscape overrides close
+// declare the style's geometry, so both have to agree.
+func (p *PandocWrapper) writeReferenceDoc(styleConfig style.StyleConfig, path string) error {
+	reference, err := exec.Command("pandoc", "--print-default-data-file", "reference.docx").Output()
+	if err != nil {
+		return fmt.Errorf("failed to read pandoc's reference.docx: %w", err)
+	}
+	data, err := withPageGeometry(reference, docxPageGeometry(styleConfig))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write reference.docx: %w", err)
+	}
+	return nil
+}
+
+// withPageGeometry returns a copy of a DOCX whose final section has the given
+// page size and margins
+func withPageGeometry(docx []byte, geometry string) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open reference.docx: %w", err)
+	}
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s in reference.docx: %w", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s in reference.docx: %w", file.Name, err)
+		}
+		if file.Name == "word/document.xml" {
+			content = []byte(setSectionGeometry(string(content), geometry))
+		}
+
+		w, err := writer.CreateHeader(&zip.FileHeader{Name: file.Name, Method: file.Method, Modified: file.Modified})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write reference.docx: %w", err)
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, fmt.Errorf("failed to write reference.docx: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write reference.docx: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// setSectionGeometry replaces the page size and margins of the body's final
+// sectPr, keeping the schema order: headers, footers and type come first
+func setSectionGeometry(documentXML, geometry string) string {
+	start := strings.LastIndex(documentXML, "<w:sectPr")
+	if start < 0 {
+		end := strings.LastIndex(documentXML, "</w:body>")
+		if end < 0 {
+			return documentXML
+		}
+		return documentXML[:end] + "<w:sectPr>" + geometry + "</w:sectPr>" + documentXML[end:]
+	}
+
+	openEnd := start + strings.Index(documentXML[start:], ">") + 1
+	if strings.HasSuffix(documentXML[:openEnd], "/>") {
+		return documentXML[:start] + "<w:sectPr>" + geometry + "</w:sectPr>" + documentXML[openEnd:]
+	}
+	closeStart := openEnd + strings.Index(documentXML[openEnd:], "</w:sectPr>")
+	if closeStart < openEnd {
+		return documentXML
+	}
+
+	inner := sectPrGeometryPattern.ReplaceAllString(documentXML[openEnd:closeStart], "")
+	insertAt := 0
+	for _, loc := range sectPrLeadPattern.FindAllStringIndex(inner, -1) {
+		insertAt = loc[1]
+	}
+	inner = inner[:insertAt] + geometry + inner[insertAt:]
+	return documentXML[:openEnd] + inner + documentXML[closeStart:]
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/chart"
+	"github.com/savant/mcp-servers/docgen2/pkg/diagram"
+	"github.com/savant/mcp-servers/docgen2/pkg/imageproc"
 	"github.com/savant/mcp-servers/docgen2/pkg/config"
 	"github.com/savant/mcp-servers/docgen2/pkg/storage"
 	"github.com/savant/mcp-servers/docgen2/pkg/style"
@@ -34,8 +36,29 @@ func NewExporter(cfg *config.Config, storage *storage.Storage) *Exporter {
 	}
 }
 
+// ExportOptions holds per-export overrides of the document configuration
+type ExportOptions struct {
+	TOC      *bool // Overrides the style's table of contents setting when set
+	TOCDepth int   // Overrides the style's table of contents depth when non-zero
+
+	Variables        map[string]string // Overrides the document's variables, e.g. one mail-merge row
+	RequireVariables bool              // Fail when a {{name}} placeholder has no value
+	OutputName       string            // Output file name without extension (default: from the title)
+
+	Profile     blocks.Profile // Selects conditional content; nil exports everything
+	ProfileName string         // Name of a saved profile, appended to the default output name
+
+	Comments    string // Review comments to show: none (default), open or all
+	Suggestions string // Pending suggestions: none (default) or tracked as changes
+}
+
 // ExportDocument exports a document to the specified format
 func (e *Exporter) ExportDocument(docID string, format string) (string, error) {
+	return e.ExportDocumentWithOptions(docID, format, ExportOptions{})
+}
+
+// ExportDocumentWithOptions exports a document to the specified format with per-export overrides
+func (e *Exporter) ExportDocumentWithOptions(docID string, format string, opts ExportOptions) (string, error) {
 	// Validate format
 	format = strings.ToLower(format)
 	if format != "pdf" && format != "docx" && format != "html" {
@@ -54,23 +77,67 @@ func (e *Exporter) ExportDocument(docID string, format string) (string, error) {
 		return "", fmt.Errorf("failed to create exports directory: %w", err)
 	}
 
+	vars := newVariableSet(doc.Variables, opts.Variables)
+	title := vars.expand(doc.Title)
+	author := vars.expand(doc.Author)
+
+	// Render chart blocks to images before the markdown references them
+	if err := e.renderCharts(docID, format, vars); err != nil {
+		return "", err
+	}
+	if err := e.renderDiagrams(docID, format); err != nil {
+		return "", err
+	}
+
 	// Copy images to export directory and get updated markdown with relative paths
-	markdownContent, err := e.prepareMarkdownWithImages(docID, exportsPath)
+	buildOpts := BuildOptions{Variables: opts.Variables, Profile: opts.Profile, TOC: opts.TOC, TOCDepth: opts.TOCDepth, Comments: opts.Comments, Suggestions: opts.Suggestions}
+	markdownContent, undefined, err := e.prepareMarkdownWithImages(docID, format, exportsPath, buildOpts)
 	if err != nil {
 		return "", fmt.Errorf("failed to prepare markdown with images: %w", err)
 	}
 
-	// Generate output filename (without timestamp - will overwrite existing)
-	sanitizedTitle := e.sanitizeFilename(doc.Title)
-	outputFilename := fmt.Sprintf("%s.%s", sanitizedTitle, format)
+	// Generate output filename (without timestamp - will overwrite existing).
+	// Exports for different profiles get distinct names so they can coexist.
+	outputName := opts.OutputName
+	if outputName == "" {
+		outputName = e.sanitizeFilename(title)
+		if opts.ProfileName != "" {
+			outputName += "-" + e.sanitizeFilename(opts.ProfileName)
+		}
+	}
+	outputFilename := fmt.Sprintf("%s.%s", outputName, format)
 	outputPath := filepath.Join(exportsPath, outputFilename)
 
-	// Load style configuration for the document
-	documentStyle := e.styleLoader.LoadStyleForDocument(doc.Style)
+	// Load style configuration for the document; header and footer templates may use variables
+	documentStyle, err := e.styleLoader.LoadStyleForThemedDocument(doc.Theme, doc.Style, doc.StyleSettings)
+	if err != nil {
+		return "", err
+	}
+	documentStyle = vars.expandStyle(documentStyle)
+	
+	// Placeholders in the header and footer count as much as those in the text
+	if opts.RequireVariables {
+		for _, name := range undefined {
+			vars.undefined[name] = true
+		}
+		if missing := vars.Undefined(); len(missing) > 0 {
+			return "", fmt.Errorf("no value for variables: %s", strings.Join(missing, ", "))
+		}
+	}
+	if opts.TOC != nil {
+		documentStyle.TOC.Enabled = *opts.TOC
+	}
+	if opts.TOCDepth != 0 {
+		documentStyle.TOC.Depth = opts.TOCDepth
+	}
+	if doc.Cover != nil && format == "docx" {
+		// The cover page writes the table of contents after itself
+		documentStyle.TOC.Enabled = false
+	}
 	
 	// Convert markdown to target format using Pandoc with styling
 	tempDir := "/tmp/docgen2-images"
-	if err := e.pandoc.ConvertMarkdownToFormatWithStyle(markdownContent, outputPath, format, tempDir, documentStyle, doc.Title, doc.Author); err != nil {
+	if err := e.pandoc.ConvertMarkdownToFormatWithStyle(markdownContent, outputPath, format, tempDir, documentStyle, title, author); err != nil {
 		return "", fmt.Errorf("failed to convert document: %w", err)
 	}
 
@@ -78,6 +145,48 @@ func (e *Exporter) ExportDocument(docID string, format string) (string, error) {
 	return outputPath, nil
 }
 
+// ExportMerged exports a document once per dataset row (mail merge). Each row's
+// values override the document's variables and every placeholder must have a
+// value. Outputs are named by nameTemplate, which may use placeholders, or by the
+// title; names shared by several rows get the row number appended.
+func (e *Exporter) ExportMerged(docID, format string, rows []map[string]string, nameTemplate string, opts ExportOptions) ([]string, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("dataset has no rows")
+	}
+	doc, err := e.storage.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	if nameTemplate == "" {
+		nameTemplate = doc.Title
+	}
+
+	names := make([]string, len(rows))
+	counts := make(map[string]int)
+	for i, row := range rows {
+		names[i] = e.sanitizeFilename(newVariableSet(doc.Variables, row).expand(nameTemplate))
+		counts[names[i]]++
+	}
+
+	outputs := make([]string, 0, len(rows))
+	for i, row := range rows {
+		rowOpts := opts
+		rowOpts.Variables = row
+		rowOpts.RequireVariables = true
+		rowOpts.OutputName = names[i]
+		if counts[names[i]] > 1 {
+			rowOpts.OutputName = fmt.Sprintf("%s-%d", names[i], i+1)
+		}
+
+		outputPath, err := e.ExportDocumentWithOptions(docID, format, rowOpts)
+		if err != nil {
+			return outputs, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		outputs = append(outputs, outputPath)
+	}
+	return outputs, nil
+}
+
 // ExportChapter exports a specific chapter to the specified format
 func (e *Exporter) ExportChapter(docID, chapterID string, format string) (string, error) {
 	// Validate format
@@ -107,25 +216,50 @@ func (e *Exporter) ExportChapter(docID, chapterID string, format string) (string
 		return "", fmt.Errorf("failed to create exports directory: %w", err)
 	}
 
-	// Build markdown content for chapter
-	markdownContent, err := e.markdownBuilder.BuildChapterMarkdown(docID, chapterID)
+	vars := newVariableSet(doc.Variables, nil)
+	if err := e.renderCharts(docID, format, vars); err != nil {
+		return "", err
+	}
+	if err := e.renderDiagrams(docID, format); err != nil {
+		return "", err
+	}
+
+	// Build markdown content for chapter, with images copied as for a full export
+	markdownContent, err := e.markdownBuilder.BuildChapterMarkdownForFormat(docID, chapterID, format)
 	if err != nil {
 		return "", fmt.Errorf("failed to build chapter markdown: %w", err)
 	}
+	markdownContent, err = e.localizeImages(docID, format, markdownContent)
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare markdown with images: %w", err)
+	}
 
 	// Generate output filename (without timestamp - will overwrite existing)
 	sanitizedTitle := e.sanitizeFilename(chapter.Title)
 	outputFilename := fmt.Sprintf("%s-%s.%s", sanitizedTitle, chapterID, format)
 	outputPath := filepath.Join(exportsPath, outputFilename)
 
-	// Convert markdown to target format
-	if err := e.pandoc.ConvertMarkdownToFormat(markdownContent, outputPath, format); err != nil {
+	// The chapter is styled like the full export, whose LaTeX header stops
+	// captions from getting a second "Figure N:" prefix
+	documentStyle, err := e.styleLoader.LoadStyleForThemedDocument(doc.Theme, doc.Style, doc.StyleSettings)
+	if err != nil {
+		return "", err
+	}
+	documentStyle = vars.expandStyle(documentStyle)
+
+	tempDir := "/tmp/docgen2-images"
+	if err := e.pandoc.ConvertMarkdownToFormatWithStyle(markdownContent, outputPath, format, tempDir, documentStyle, vars.expand(doc.Title), vars.expand(doc.Author)); err != nil {
 		return "", fmt.Errorf("failed to convert chapter: %w", err)
 	}
 
 	return outputPath, nil
 }
 
+// ValidateReferences reports cross-references that point at missing labels
+func (e *Exporter) ValidateReferences(docID string) ([]DanglingReference, error) {
+	return e.markdownBuilder.ValidateReferences(docID)
+}
+
 // GetSupportedFormats returns the list of supported export formats
 func (e *Exporter) GetSupportedFormats() []string {
 	return []string{"pdf", "docx", "html"}
@@ -171,14 +305,25 @@ func (e *Exporter) sanitizeFilename(name string) string {
 	return sanitized
 }
 
-// prepareMarkdownWithImages copies images to export directory and returns markdown with relative paths
-func (e *Exporter) prepareMarkdownWithImages(docID, exportsPath string) (string, error) {
+// prepareMarkdownWithImages copies images to export directory and returns markdown with relative paths,
+// along with the names of variables that had no value
+func (e *Exporter) prepareMarkdownWithImages(docID, format, exportsPath string, buildOpts BuildOptions) (string, []string, error) {
 	// Get the original markdown
-	markdownContent, err := e.markdownBuilder.BuildMarkdown(docID)
+	markdownContent, undefined, err := e.markdownBuilder.BuildMarkdownWithOptions(docID, format, buildOpts)
 	if err != nil {
-		return "", fmt.Errorf("failed to build markdown: %w", err)
+		return "", nil, fmt.Errorf("failed to build markdown: %w", err)
 	}
 
+	markdownContent, err = e.localizeImages(docID, format, markdownContent)
+	if err != nil {
+		return "", nil, err
+	}
+	return markdownContent, undefined, nil
+}
+
+// localizeImages copies the document's images to the temporary images directory
+// and points the markdown at the copies, whose paths have no spaces
+func (e *Exporter) localizeImages(docID, format, markdownContent string) (string, error) {
 	// Create temporary images directory with no spaces in path
 	tempImagesDir := "/tmp/docgen2-images"
 	if err := os.RemoveAll(tempImagesDir); err != nil && !os.IsNotExist(err) {
@@ -198,7 +343,7 @@ func (e *Exporter) prepareMarkdownWithImages(docID, exportsPath string) (string,
 	imagePaths := make(map[string]string) // original path -> relative path
 	
 	// Process document-level blocks
-	if err := e.collectImagePaths(docID, doc.Blocks, imagePaths, tempImagesDir); err != nil {
+	if err := e.collectImagePaths(docID, "", format, doc.Blocks, imagePaths, tempImagesDir); err != nil {
 		return "", fmt.Errorf("failed to collect document images: %w", err)
 	}
 
@@ -208,16 +353,44 @@ func (e *Exporter) prepareMarkdownWithImages(docID, exportsPath string) (string,
 		if err != nil {
 			continue // Skip if chapter can't be loaded
 		}
-		if err := e.collectImagePaths(docID, chapter.Blocks, imagePaths, tempImagesDir); err != nil {
+		if err := e.collectImagePaths(docID, chapterRef.ID, format, chapter.Blocks, imagePaths, tempImagesDir); err != nil {
 			return "", fmt.Errorf("failed to collect chapter images: %w", err)
 		}
 	}
 
+	// Cover page logo and background
+	if doc.Cover != nil {
+		docFolder := e.config.GetDocumentFolder(docID)
+		for _, path := range []string{doc.Cover.Logo, doc.Cover.Background} {
+			if path == "" {
+				continue
+			}
+			sourcePath := filepath.Join(docFolder, path)
+			simpleName := unusedImageName(tempImagesDir, exportImageExt(sourcePath))
+			if err := e.copyFile(sourcePath, filepath.Join(tempImagesDir, simpleName)); err != nil {
+				continue
+			}
+			imagePaths[sourcePath] = simpleName
+		}
+	}
+
+	// Blocks included from other documents bring their images with them
+	for _, included := range e.includedBlocks(docID) {
+		refs := []blocks.BlockReference{included.Ref}
+		if err := e.collectImagePaths(included.DocID, included.ChapterID, format, refs, imagePaths, tempImagesDir); err != nil {
+			return "", fmt.Errorf("failed to collect included images: %w", err)
+		}
+	}
+
 	// Replace absolute paths with relative paths in markdown
 	for originalPath, relativePath := range imagePaths {
 		// Replace both angle bracket and normal formats
 		markdownContent = strings.ReplaceAll(markdownContent, fmt.Sprintf("](<%s>)", originalPath), fmt.Sprintf("](%s)", relativePath))
 		markdownContent = strings.ReplaceAll(markdownContent, fmt.Sprintf("](%s)", originalPath), fmt.Sprintf("](%s)", relativePath))
+		// Raw LaTeX figures reference images as \includegraphics[...]{path}
+		markdownContent = strings.ReplaceAll(markdownContent, fmt.Sprintf("]{%s}", originalPath), fmt.Sprintf("]{%s}", relativePath))
+		// and raw HTML (the cover page) as src="path"
+		markdownContent = strings.ReplaceAll(markdownContent, fmt.Sprintf("src=\"%s\"", originalPath), fmt.Sprintf("src=\"%s\"", relativePath))
 	}
 
 	// Image path replacement completed successfully
@@ -226,15 +399,57 @@ func (e *Exporter) prepareMarkdownWithImages(docID, exportsPath string) (string,
 }
 
 // collectImagePaths processes blocks and copies images, building the path mapping
-func (e *Exporter) collectImagePaths(docID string, blockRefs []blocks.BlockReference, imagePaths map[string]string, imagesDir string) error {
-	imageCounter := 1
-	
+func (e *Exporter) collectImagePaths(docID, chapterID, format string, blockRefs []blocks.BlockReference, imagePaths map[string]string, imagesDir string) error {
 	for _, blockRef := range blockRefs {
 		block, err := e.storage.LoadBlock(docID, blockRef)
 		if err != nil {
 			continue // Skip if block can't be loaded
 		}
 
+		if _, ok := block.(*blocks.ChartBlock); ok {
+			// Rendered charts are named after their chapter and block, so they never collide
+			chartPath := chartImagePath(chapterID, blockRef.ID, format)
+			sourcePath := filepath.Join(e.storage.GetConfig().GetDocumentFolder(docID), chartPath)
+			simpleName := "chart_" + filepath.Base(chartPath)
+			if _, err := os.Stat(filepath.Join(imagesDir, simpleName)); err == nil {
+				// A chart included from another document with the same block ID
+				simpleName = "chart_" + docID + "-" + filepath.Base(chartPath)
+			}
+			if err := e.copyFile(sourcePath, filepath.Join(imagesDir, simpleName)); err != nil {
+				continue
+			}
+			imagePaths[sourcePath] = simpleName
+			continue
+		}
+
+		if diagramBlock, ok := block.(*blocks.DiagramBlock); ok {
+			// Cached diagrams are named by content hash; missing ones were left unrendered
+			diagramPath := diagramImagePath(diagramBlock, format)
+			sourcePath := filepath.Join(e.storage.GetConfig().GetDocumentFolder(docID), diagramPath)
+			simpleName := "diagram_" + filepath.Base(diagramPath)
+			if err := e.copyFile(sourcePath, filepath.Join(imagesDir, simpleName)); err != nil {
+				continue
+			}
+			imagePaths[sourcePath] = simpleName
+			continue
+		}
+
+		if gallery, ok := block.(*blocks.GalleryBlock); ok {
+			docFolder := e.storage.GetConfig().GetDocumentFolder(docID)
+			for _, img := range gallery.Images {
+				sourcePath := filepath.Join(docFolder, img.Path)
+				if _, ok := imagePaths[sourcePath]; ok {
+					continue
+				}
+				simpleName := unusedImageName(imagesDir, exportImageExt(sourcePath))
+				if err := e.copyFile(sourcePath, filepath.Join(imagesDir, simpleName)); err != nil {
+					continue
+				}
+				imagePaths[sourcePath] = simpleName
+			}
+			continue
+		}
+
 		if imgBlock, ok := block.(*blocks.ImageBlock); ok {
 			// Build the absolute source path
 			config := e.storage.GetConfig()
@@ -242,12 +457,13 @@ func (e *Exporter) collectImagePaths(docID string, blockRefs []blocks.BlockRefer
 			sourcePath := filepath.Join(docFolder, imgBlock.Path)
 
 			// Create simple destination filename without spaces
-			// Extract file extension
-			originalName := filepath.Base(imgBlock.Path)
-			ext := filepath.Ext(originalName)
-			simpleName := fmt.Sprintf("img_%03d%s", imageCounter, ext)
+			// Extract file extension (PNG when the image needs converting)
+			ext := exportImageExt(sourcePath)
+			if _, ok := imagePaths[sourcePath]; ok {
+				continue
+			}
+			simpleName := unusedImageName(imagesDir, ext)
 			destPath := filepath.Join(imagesDir, simpleName)
-			imageCounter++
 
 			// Copy the image file
 			if err := e.copyFile(sourcePath, destPath); err != nil {
@@ -262,12 +478,163 @@ func (e *Exporter) collectImagePaths(docID string, blockRefs []blocks.BlockRefer
 	return nil
 }
 
-// copyFile copies a file from source to destination, converting WebP to PNG if needed
+// unusedImageName returns the first img_NNN name not yet taken in the images
+// directory. Chapters and included documents are collected separately, so a
+// per-call counter would reuse names.
+func unusedImageName(imagesDir, ext string) string {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("img_%03d%s", i, ext)
+		if _, err := os.Stat(filepath.Join(imagesDir, name)); os.IsNotExist(err) {
+			return name
+		}
+	}
+}
+
+// renderCharts renders every chart block into the document's charts folder,
+// at the paths MarkdownBuilder uses for the format
+func (e *Exporter) renderCharts(docID, format string, vars *variableSet) error {
+	doc, err := e.storage.GetDocument(docID)
+	if err != nil {
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+
+	render := func(docID, chapterID string, refs []blocks.BlockReference) error {
+		for _, ref := range refs {
+			if ref.Type != blocks.TypeChart {
+				continue
+			}
+			block, err := e.storage.LoadBlock(docID, ref)
+			if err != nil {
+				continue
+			}
+
+			vars.expandBlock(block)
+
+			chartPath := chartImagePath(chapterID, ref.ID, format)
+			data, err := chart.Render(block.(*blocks.ChartBlock), strings.TrimPrefix(filepath.Ext(chartPath), "."))
+			if err != nil {
+				return fmt.Errorf("failed to render chart %s: %w", ref.ID, err)
+			}
+
+			fullPath := filepath.Join(e.config.GetDocumentFolder(docID), chartPath)
+			if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+				return fmt.Errorf("failed to create charts directory: %w", err)
+			}
+			if err := os.WriteFile(fullPath, data, 0644); err != nil {
+				return fmt.Errorf("failed to write chart %s: %w", ref.ID, err)
+			}
+		}
+		return nil
+	}
+
+	if err := render(docID, "", doc.Blocks); err != nil {
+		return err
+	}
+	for _, chapterRef := range doc.Chapters {
+		chapter, err := e.storage.GetChapter(docID, chapterRef.ID)
+		if err != nil {
+			continue
+		}
+		if err := render(docID, chapterRef.ID, chapter.Blocks); err != nil {
+			return err
+		}
+	}
+	for _, included := range e.includedBlocks(docID) {
+		if err := render(included.DocID, included.ChapterID, []blocks.BlockReference{included.Ref}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderDiagrams renders diagram blocks with their locally installed renderer into
+// the document's diagram cache. Cached output is reused; diagrams whose renderer is
+// missing are skipped and MarkdownBuilder falls back to client-side rendering or source.
+func (e *Exporter) renderDiagrams(docID, format string) error {
+	doc, err := e.storage.GetDocument(docID)
+	if err != nil {
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+
+	render := func(docID string, refs []blocks.BlockReference) error {
+		for _, ref := range refs {
+			if ref.Type != blocks.TypeDiagram {
+				continue
+			}
+			block, err := e.storage.LoadBlock(docID, ref)
+			if err != nil {
+				continue
+			}
+			diagramBlock := block.(*blocks.DiagramBlock)
+
+			diagramPath := diagramImagePath(diagramBlock, format)
+			fullPath := filepath.Join(e.config.GetDocumentFolder(docID), diagramPath)
+			if _, err := os.Stat(fullPath); err == nil {
+				continue
+			}
+			if !diagram.Available(diagramBlock.Engine) {
+				continue
+			}
+			ext := strings.TrimPrefix(filepath.Ext(diagramPath), ".")
+			if err := diagram.Render(diagramBlock, ext, fullPath); err != nil {
+				return fmt.Errorf("failed to render diagram %s: %w", ref.ID, err)
+			}
+		}
+		return nil
+	}
+
+	if err := render(docID, doc.Blocks); err != nil {
+		return err
+	}
+	for _, chapterRef := range doc.Chapters {
+		chapter, err := e.storage.GetChapter(docID, chapterRef.ID)
+		if err != nil {
+			continue
+		}
+		if err := render(docID, chapter.Blocks); err != nil {
+			return err
+		}
+	}
+	for _, included := range e.includedBlocks(docID) {
+		if err := render(included.DocID, []blocks.BlockReference{included.Ref}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includedBlocks returns the blocks of other documents that a document's include
+// blocks resolve to, so their charts, diagrams and images can be prepared for
+// export. Unresolvable includes are skipped here; MarkdownBuilder reports them.
+func (e *Exporter) includedBlocks(docID string) []*storage.IncludeTarget {
+	refs, err := e.storage.GetAllBlockReferences(docID)
+	if err != nil {
+		return nil
+	}
+
+	var targets []*storage.IncludeTarget
+	for _, ref := range refs {
+		if ref.Type != blocks.TypeInclude {
+			continue
+		}
+		block, err := e.storage.LoadBlock(docID, ref)
+		if err != nil {
+			continue
+		}
+		target, err := e.storage.ResolveInclude(block.(*blocks.IncludeBlock))
+		if err != nil || target.DocID == "" {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// copyFile copies a file from source to destination, converting images Pandoc
+// cannot embed (such as WebP assets stored before ingest-time conversion) to PNG
 func (e *Exporter) copyFile(src, dst string) error {
-	// Check if the source file is actually a WebP file
-	if e.isWebPFile(src) {
-		// Convert WebP to PNG using ImageMagick
-		return e.convertWebPToPNG(src, dst)
+	if !imageproc.Embeddable(src) {
+		return imageproc.ConvertToPNG(src, dst)
 	}
 	
 	// Regular file copy for other formats
@@ -287,27 +654,10 @@ func (e *Exporter) copyFile(src, dst string) error {
 	return err
 }
 
-// isWebPFile checks if a file is actually a WebP image
-func (e *Exporter) isWebPFile(filePath string) bool {
-	cmd := exec.Command("file", filePath)
-	output, err := cmd.Output()
-	if err != nil {
-		return false
-	}
-	
-	outputStr := string(output)
-	return strings.Contains(outputStr, "Web/P image") || strings.Contains(outputStr, "WebP")
-}
-
-// convertWebPToPNG converts a WebP image to PNG using ImageMagick
-func (e *Exporter) convertWebPToPNG(src, dst string) error {
-	cmd := exec.Command("convert", src, dst)
-	
-	// Capture stderr for error messages
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to convert WebP to PNG: %s: %w", string(output), err)
+// exportImageExt returns the extension an image has once copied for export
+func exportImageExt(path string) string {
+	if !imageproc.Embeddable(path) {
+		return ".png"
 	}
-	
-	return nil
+	return filepath.Ext(path)
 }
\ No newline at end of file
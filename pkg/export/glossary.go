@@ -0,0 +1,207 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/document"
+)
+
+// glossaryTitle heads the generated glossary section
+const glossaryTitle = "Glossary"
+
+// protectedMarkdown matches text that glossary links must not be inserted into:
+// code, links, images, attributes, raw HTML, placeholders, URLs, headings and fences
+var protectedMarkdown = regexp.MustCompile("(?ms)^```.*?^```[^\\n]*$|^~~~.*?^~~~[^\\n]*$|`[^`\\n]+`|!?\\[[^\\]]*\\]\\([^)]*\\)|\\[[^\\]]*\\]\\{[^}]*\\}|\\{[#.][^}]*\\}|<[^>\\n]+>|\\{@[^}]*\\}|https?://\\S+|^#{1,6} [^\\n]*$|^:::[^\\n]*$")
+
+// glossary links the first use of each glossary term to its definition
+type glossary struct {
+	terms   []document.GlossaryTerm
+	anchors []string       // Anchor of each term's definition
+	names   map[string]int // Term or alias, lowercased, to its term
+	pattern *regexp.Regexp // Matches any term or alias; nil without terms
+	linked  map[int]bool
+}
+
+// newGlossary prepares the terms for linking, sorted the way the section lists them
+func newGlossary(terms []document.GlossaryTerm) *glossary {
+	g := &glossary{names: make(map[string]int), linked: make(map[int]bool)}
+	g.terms = append([]document.GlossaryTerm(nil), terms...)
+	sort.SliceStable(g.terms, func(i, j int) bool {
+		return strings.ToLower(g.terms[i].Term) < strings.ToLower(g.terms[j].Term)
+	})
+
+	used := make(map[string]bool)
+	var alternatives []string
+	for i, term := range g.terms {
+		anchor := "glossary-" + anchorSlug(term.Term)
+		for n := 2; used[anchor]; n++ {
+			anchor = fmt.Sprintf("glossary-%s-%d", anchorSlug(term.Term), n)
+		}
+		used[anchor] = true
+		g.anchors = append(g.anchors, anchor)
+
+		for _, name := range append([]string{term.Term}, term.Aliases...) {
+			key := strings.ToLower(name)
+			if _, ok := g.names[key]; ok || name == "" {
+				continue
+			}
+			g.names[key] = i
+			alternatives = append(alternatives, regexp.QuoteMeta(name))
+		}
+	}
+	if len(alternatives) == 0 {
+		return g
+	}
+
+	// Longer names first, so "REST API" wins over "API"
+	sort.SliceStable(alternatives, func(i, j int) bool { return len(alternatives[i]) > len(alternatives[j]) })
+	g.pattern = regexp.MustCompile("(?i)" + strings.Join(alternatives, "|"))
+	return g
+}
+
+// link turns the first use of each term in text into a link to its definition.
+// Code, existing links and other markup are left alone.
+func (g *glossary) link(text string) string {
+	if g.pattern == nil || len(g.linked) == len(g.terms) {
+		return text
+	}
+
+	var result strings.Builder
+	last := 0
+	for _, span := range protectedMarkdown.FindAllStringIndex(text, -1) {
+		result.WriteString(g.linkPlain(text[last:span[0]]))
+		result.WriteString(text[span[0]:span[1]])
+		last = span[1]
+	}
+	result.WriteString(g.linkPlain(text[last:]))
+	return result.String()
+}
+
+// linkPlain links terms in text that contains no protected markup
+func (g *glossary) linkPlain(text string) string {
+	var result strings.Builder
+	last, pos := 0, 0
+	for pos < len(text) {
+		span := g.pattern.FindStringIndex(text[pos:])
+		if span == nil {
+			break
+		}
+		start := pos + span[0]
+		if i, end, ok := g.linkable(text, start, pos+span[1]); ok {
+			g.linked[i] = true
+			result.WriteString(text[last:start])
+			result.WriteString(fmt.Sprintf("[%s](#%s)", text[start:end], g.anchors[i]))
+			last, pos = end, end
+			continue
+		}
+		// Terms may still start inside a match that cannot be linked
+		_, size := utf8.DecodeRuneInString(text[start:])
+		pos = start + size
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// linkable returns the term to link at text[start:end], the longest match there.
+// When that term cannot be linked, because it is linked already or is part of
+// a longer word, shorter names starting at the same place are tried in turn.
+func (g *glossary) linkable(text string, start, end int) (int, int, bool) {
+	for end > start {
+		match := text[start:end]
+		i, ok := g.names[strings.ToLower(match)]
+		if ok && !g.linked[i] && wordBoundary(text, start, end) && sameCase(match, g.terms[i]) {
+			return i, end, true
+		}
+		end = g.shorterMatch(text, start, end)
+	}
+	return 0, 0, false
+}
+
+// shorterMatch returns the end of the longest name that matches text from start
+// and is shorter than text[start:end], or start when there is none
+func (g *glossary) shorterMatch(text string, start, end int) int {
+	best := start
+	for name := range g.names {
+		stop := start + len(name)
+		if stop < end && stop > best && strings.EqualFold(text[start:stop], name) {
+			best = stop
+		}
+	}
+	return best
+}
+
+// wordBoundary reports whether text[start:end] is not part of a longer word
+func wordBoundary(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// sameCase reports whether a match is written like one of the term's names,
+// allowing a different case for the first letter only. This keeps acronyms such
+// as "IT" from matching ordinary words.
+func sameCase(match string, term document.GlossaryTerm) bool {
+	for _, name := range append([]string{term.Term}, term.Aliases...) {
+		if match == name || firstLetterFolded(match) == firstLetterFolded(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstLetterFolded lowercases the first letter of s
+func firstLetterFolded(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+// section returns the glossary section listing every term with its definition
+func (g *glossary) section(bc *buildContext) string {
+	if len(g.terms) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("# %s {#glossary .unnumbered}\n\n::: {.glossary}\n", glossaryTitle))
+	for i, term := range g.terms {
+		definition := strings.TrimSpace(bc.vars.expand(term.Definition))
+		definition = strings.ReplaceAll(definition, "\n", "\n    ")
+		result.WriteString(fmt.Sprintf("[%s]{#%s}\n:   %s\n\n", bc.vars.expand(term.Term), g.anchors[i], definition))
+	}
+	result.WriteString(":::\n\n")
+	return result.String()
+}
+
+// anchorSlug turns text into an identifier such as "rest-api"
+func anchorSlug(text string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if slug.Len() == 0 {
+		return "term"
+	}
+	return slug.String()
+}
@@ -0,0 +1,409 @@
+package export
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// Layout limits for generated grid tables. Pandoc derives relative column
+// widths from the dash counts, so the total only needs to be a sensible line length.
+const (
+	gridTableWidth    = 72
+	gridMinColWidth   = 3
+	gridMaxNaturalCol = 40
+)
+
+// gridCell is a logical table cell, possibly spanning several grid positions
+type gridCell struct {
+	row, col         int
+	rowSpan, colSpan int
+	text             string
+	lines            []string
+}
+
+// gridLayout holds the cell ownership map and measurements of a grid table
+type gridLayout struct {
+	rows, cols int
+	owner      [][]*gridCell
+	cells      []*gridCell
+	widths     []int
+	heights    []int
+	aligns     []string
+	headerEnd  int // Boundary index drawn with '=' (0 when the table has no header)
+	alignLine  int // Boundary index carrying alignment colons
+}
+
+// alignmentMarker returns the pipe table separator for a column alignment
+func alignmentMarker(align string) string {
+	switch align {
+	case "left":
+		return ":---"
+	case "center":
+		return ":---:"
+	case "right":
+		return "---:"
+	default:
+		return "---"
+	}
+}
+
+// columnAlign returns the configured alignment of column i
+func columnAlign(table *blocks.TableBlock, i int) string {
+	if i < len(table.Columns) {
+		return table.Columns[i].Align
+	}
+	return ""
+}
+
+// gridTableToMarkdown renders a table as a Pandoc grid table, which supports
+// relative column widths, multi-line cells and row/column spans.
+// When the header should not repeat across pages it is emitted as a bold
+// body row, because Pandoc always repeats real header rows in PDF and DOCX.
+func gridTableToMarkdown(table *blocks.TableBlock) string {
+	layout := newGridLayout(table)
+	layout.measure(table)
+
+	var lines []string
+	lineOf := 0
+	for r := 0; r <= layout.rows; r++ {
+		lines = append(lines, layout.boundaryLine(r, lineOf))
+		if r == layout.rows {
+			break
+		}
+		for i := 0; i < layout.heights[r]; i++ {
+			lineOf++
+			lines = append(lines, layout.contentLine(r, lineOf))
+		}
+		lineOf++
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// newGridLayout builds the cell ownership map from headers, rows and spans
+func newGridLayout(table *blocks.TableBlock) *gridLayout {
+	cols := len(table.Headers)
+	grid := [][]string{table.Headers}
+	grid = append(grid, table.Rows...)
+
+	layout := &gridLayout{
+		rows:   len(grid),
+		cols:   cols,
+		owner:  make([][]*gridCell, len(grid)),
+		aligns: make([]string, cols),
+	}
+	for r := range layout.owner {
+		layout.owner[r] = make([]*gridCell, cols)
+	}
+	for c := 0; c < cols; c++ {
+		layout.aligns[c] = columnAlign(table, c)
+	}
+
+	headerRows := 1 + table.HeaderRows
+	if table.ShouldRepeatHeader() {
+		layout.headerEnd = headerRows
+		layout.alignLine = headerRows
+	}
+
+	// Span origins claim their area first; remaining positions become single cells
+	for _, span := range table.Spans {
+		cell := &gridCell{row: span.Row, col: span.Col, rowSpan: span.RowSpan, colSpan: span.ColSpan}
+		if cell.rowSpan < 1 {
+			cell.rowSpan = 1
+		}
+		if cell.colSpan < 1 {
+			cell.colSpan = 1
+		}
+		layout.claim(cell)
+	}
+	for r := 0; r < layout.rows; r++ {
+		for c := 0; c < cols; c++ {
+			if layout.owner[r][c] == nil {
+				layout.claim(&gridCell{row: r, col: c, rowSpan: 1, colSpan: 1})
+			}
+		}
+	}
+
+	for _, cell := range layout.cells {
+		if cell.col < len(grid[cell.row]) {
+			cell.text = grid[cell.row][cell.col]
+		}
+		if cell.row < headerRows && !table.ShouldRepeatHeader() && strings.TrimSpace(cell.text) != "" {
+			cell.text = boldLines(cell.text)
+		}
+	}
+
+	return layout
+}
+
+// claim assigns every grid position covered by a cell to it
+func (gl *gridLayout) claim(cell *gridCell) {
+	for r := cell.row; r < cell.row+cell.rowSpan && r < gl.rows; r++ {
+		for c := cell.col; c < cell.col+cell.colSpan && c < gl.cols; c++ {
+			gl.owner[r][c] = cell
+		}
+	}
+	gl.cells = append(gl.cells, cell)
+}
+
+// boldLines wraps each non-empty line of a cell in strong emphasis
+func boldLines(text string) string {
+	parts := strings.Split(text, "\n")
+	for i, part := range parts {
+		if strings.TrimSpace(part) != "" {
+			parts[i] = "**" + strings.TrimSpace(part) + "**"
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// measure computes column widths, wraps cell text and computes row heights
+func (gl *gridLayout) measure(table *blocks.TableBlock) {
+	gl.widths = make([]int, gl.cols)
+
+	// Longest word per column sets a floor so words are never split
+	minWidths := make([]int, gl.cols)
+	natural := make([]int, gl.cols)
+	for _, cell := range gl.cells {
+		if cell.colSpan != 1 {
+			continue
+		}
+		for _, line := range strings.Split(cell.text, "\n") {
+			if utf8.RuneCountInString(line) > natural[cell.col] {
+				natural[cell.col] = utf8.RuneCountInString(line)
+			}
+			for _, word := range strings.Fields(line) {
+				if utf8.RuneCountInString(word) > minWidths[cell.col] {
+					minWidths[cell.col] = utf8.RuneCountInString(word)
+				}
+			}
+		}
+	}
+
+	// Columns without a width count as width 1 once any column has one
+	weights := make([]float64, gl.cols)
+	totalWeight := 0.0
+	weighted := false
+	for c := 0; c < gl.cols; c++ {
+		weights[c] = 1
+		if c < len(table.Columns) && table.Columns[c].Width > 0 {
+			weights[c] = table.Columns[c].Width
+			weighted = true
+		}
+		totalWeight += weights[c]
+	}
+
+	available := gridTableWidth - (3*gl.cols + 1)
+	for c := 0; c < gl.cols; c++ {
+		var width int
+		if weighted {
+			width = int(float64(available) * weights[c] / totalWeight)
+		} else {
+			width = natural[c]
+			if width > gridMaxNaturalCol {
+				width = gridMaxNaturalCol
+			}
+		}
+		if width < minWidths[c] {
+			width = minWidths[c]
+		}
+		if width < gridMinColWidth {
+			width = gridMinColWidth
+		}
+		gl.widths[c] = width
+	}
+
+	// Spanning cells widen their last column if a word would not fit
+	for _, cell := range gl.cells {
+		if cell.colSpan == 1 {
+			continue
+		}
+		for _, word := range strings.Fields(cell.text) {
+			if extra := utf8.RuneCountInString(word) - gl.spanWidth(cell); extra > 0 {
+				gl.widths[cell.col+cell.colSpan-1] += extra
+			}
+		}
+	}
+
+	for _, cell := range gl.cells {
+		cell.lines = wrapCellText(cell.text, gl.spanWidth(cell))
+	}
+
+	// Row heights come from single-row cells; spanning cells stretch their last row
+	gl.heights = make([]int, gl.rows)
+	for r := range gl.heights {
+		gl.heights[r] = 1
+	}
+	for _, cell := range gl.cells {
+		if cell.rowSpan == 1 && len(cell.lines) > gl.heights[cell.row] {
+			gl.heights[cell.row] = len(cell.lines)
+		}
+	}
+	for _, cell := range gl.cells {
+		if cell.rowSpan == 1 {
+			continue
+		}
+		last := cell.row + cell.rowSpan - 1
+		room := cell.rowSpan - 1
+		for r := cell.row; r <= last; r++ {
+			room += gl.heights[r]
+		}
+		if len(cell.lines) > room {
+			gl.heights[last] += len(cell.lines) - room
+		}
+	}
+}
+
+// spanWidth returns the number of content characters available to a cell
+func (gl *gridLayout) spanWidth(cell *gridCell) int {
+	width := 3 * (cell.colSpan - 1)
+	for c := cell.col; c < cell.col+cell.colSpan; c++ {
+		width += gl.widths[c]
+	}
+	return width
+}
+
+// wrapCellText splits cell text on explicit newlines and word-wraps each line to width
+func wrapCellText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		current := words[0]
+		for _, word := range words[1:] {
+			if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+				lines = append(lines, current)
+				current = word
+				continue
+			}
+			current += " " + word
+		}
+		lines = append(lines, current)
+	}
+	// Trailing blank lines would only pad the row
+	for len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// ownerAt returns the cell at a grid position, or nil outside the table
+func (gl *gridLayout) ownerAt(r, c int) *gridCell {
+	if r < 0 || r >= gl.rows || c < 0 || c >= gl.cols {
+		return nil
+	}
+	return gl.owner[r][c]
+}
+
+// hasHorizontal reports whether boundary b has a border segment above column c
+func (gl *gridLayout) hasHorizontal(b, c int) bool {
+	if c < 0 || c >= gl.cols {
+		return false
+	}
+	return b == 0 || b == gl.rows || gl.ownerAt(b-1, c) != gl.ownerAt(b, c)
+}
+
+// hasVertical reports whether row r has a border segment left of column k
+func (gl *gridLayout) hasVertical(r, k int) bool {
+	if r < 0 || r >= gl.rows {
+		return false
+	}
+	return k == 0 || k == gl.cols || gl.ownerAt(r, k-1) != gl.ownerAt(r, k)
+}
+
+// cellLine returns the text a cell shows on a given output line, padded to its width
+func (gl *gridLayout) cellLine(cell *gridCell, line int) string {
+	text := ""
+	if line >= 0 && line < len(cell.lines) {
+		text = cell.lines[line]
+	}
+	padding := gl.spanWidth(cell) - utf8.RuneCountInString(text)
+	if padding < 0 {
+		padding = 0
+	}
+	return " " + text + strings.Repeat(" ", padding+1)
+}
+
+// cellTop returns the output line index of a cell's first content line
+func (gl *gridLayout) cellTop(cell *gridCell) int {
+	line := 1
+	for r := 0; r < cell.row; r++ {
+		line += gl.heights[r] + 1
+	}
+	return line
+}
+
+// boundaryLine renders the border above row b (or the bottom border when b == rows)
+func (gl *gridLayout) boundaryLine(b, lineIndex int) string {
+	var sb strings.Builder
+	fill := "-"
+	if gl.headerEnd > 0 && b == gl.headerEnd {
+		fill = "="
+	}
+
+	for k := 0; k <= gl.cols; k++ {
+		left := gl.hasHorizontal(b, k-1)
+		right := gl.hasHorizontal(b, k)
+		vertical := gl.hasVertical(b-1, k) || gl.hasVertical(b, k)
+		switch {
+		case (left || right) && vertical:
+			sb.WriteString("+")
+		case left || right:
+			sb.WriteString(fill)
+		case vertical:
+			sb.WriteString("|")
+		default:
+			// Only the content of a rowspan that continues across this boundary
+		}
+
+		if k == gl.cols {
+			break
+		}
+		if gl.hasHorizontal(b, k) {
+			segment := []byte(strings.Repeat(fill, gl.widths[k]+2))
+			if b == gl.alignLine {
+				switch gl.aligns[k] {
+				case "left":
+					segment[0] = ':'
+				case "center":
+					segment[0], segment[len(segment)-1] = ':', ':'
+				case "right":
+					segment[len(segment)-1] = ':'
+				}
+			}
+			sb.Write(segment)
+			continue
+		}
+
+		// Inside a rowspan: emit its content once, at its first column
+		cell := gl.ownerAt(b, k)
+		if cell.col != k {
+			continue
+		}
+		sb.WriteString(gl.cellLine(cell, lineIndex-gl.cellTop(cell)))
+	}
+
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// contentLine renders one output line of row r
+func (gl *gridLayout) contentLine(r, lineIndex int) string {
+	var sb strings.Builder
+	for c := 0; c < gl.cols; c++ {
+		if gl.hasVertical(r, c) {
+			sb.WriteString("|")
+		}
+		cell := gl.owner[r][c]
+		if cell.col != c {
+			continue
+		}
+		sb.WriteString(gl.cellLine(cell, lineIndex-gl.cellTop(cell)))
+	}
+	sb.WriteString("|")
+	return sb.String()
+}
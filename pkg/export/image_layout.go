@@ -0,0 +1,278 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// Image layout is expressed three ways: Pandoc attributes ({width=50%}) that every
+// writer understands, raw LaTeX (aligned and wrapped figures, subfigures) for PDF,
+// and fenced divs whose classes the HTML stylesheet lays out with floats and flexbox.
+// DOCX keeps widths but has no Pandoc equivalent for alignment or text wrap.
+
+// defaultFloatWidth is used for floating images that have no width of their own
+const defaultFloatWidth = "50%"
+
+// latexEscaper escapes LaTeX special characters in a single pass
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"{", `\{`,
+	"}", `\}`,
+	"$", `\$`,
+	"&", `\&`,
+	"%", `\%`,
+	"#", `\#`,
+	"^", `\textasciicircum{}`,
+	"_", `\_`,
+	"~", `\textasciitilde{}`,
+)
+
+// imageAttributes returns the Pandoc attribute block for an image, if any
+func imageAttributes(anchor, width string) string {
+	var attrs []string
+	if anchor != "" {
+		attrs = append(attrs, "#"+anchor)
+	}
+	if width != "" {
+		attrs = append(attrs, "width="+width)
+	}
+	if len(attrs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(attrs, " ") + "}"
+}
+
+// latexLength converts an image width to a LaTeX length. Percentages are taken
+// of the line width; pixels are converted at 96 DPI.
+func latexLength(width string) string {
+	value, unit, err := blocks.ParseWidth(width)
+	if err != nil {
+		return `\linewidth`
+	}
+	switch unit {
+	case "%":
+		return fmt.Sprintf(`%.3g\linewidth`, value/100)
+	case "px":
+		return fmt.Sprintf("%.3gin", value/96)
+	default:
+		return width
+	}
+}
+
+// latexText escapes caption text for raw LaTeX, writing its references as
+// \hyperref links and its index markers as \index commands
+func (bc *buildContext) latexText(text string) string {
+	var result strings.Builder
+	last := 0
+	for _, loc := range latexMarkupPattern.FindAllStringSubmatchIndex(text, -1) {
+		result.WriteString(latexEscaper.Replace(text[last:loc[0]]))
+		last = loc[1]
+		if loc[2] >= 0 {
+			if target, ok := bc.refs.Lookup(text[loc[2]:loc[3]]); ok {
+				result.WriteString(fmt.Sprintf("\\hyperref[%s]{%s}", target.Anchor, latexEscaper.Replace(target.DisplayText())))
+			} else {
+				result.WriteString("??")
+			}
+			continue
+		}
+		if levels := parseIndexTerm(text[loc[4]:loc[5]]); len(levels) > 0 {
+			bc.index.add(bc, levels)
+			result.WriteString(fmt.Sprintf("\\index{%s}", latexIndexKey(levels)))
+		}
+	}
+	result.WriteString(latexEscaper.Replace(text[last:]))
+	return result.String()
+}
+
+// latexMarkupPattern matches the reference and index placeholders latexText rewrites
+var latexMarkupPattern = regexp.MustCompile(refPattern.String() + "|" + indexPattern.String())
+
+// latexFigure writes an aligned or text-wrapped figure as raw LaTeX; the
+// caption is LaTeX already
+func latexFigure(path, caption, anchor string, image blocks.ImageBlock) string {
+	var figure strings.Builder
+	figure.WriteString("```{=latex}\n")
+
+	if image.Float != "" {
+		width := image.Width
+		if width == "" {
+			width = defaultFloatWidth
+		}
+		side := "l"
+		if image.Float == blocks.AlignRight {
+			side = "r"
+		}
+		figure.WriteString(fmt.Sprintf("\\begin{wrapfigure}{%s}{%s}\n\\centering\n", side, latexLength(width)))
+		figure.WriteString(fmt.Sprintf("\\includegraphics[width=\\linewidth]{%s}\n", path))
+	} else {
+		figure.WriteString("\\begin{figure}[htbp]\n")
+		switch image.Align {
+		case blocks.AlignLeft:
+			figure.WriteString("\\raggedright\n")
+		case blocks.AlignRight:
+			figure.WriteString("\\raggedleft\n")
+		default:
+			figure.WriteString("\\centering\n")
+		}
+		width := `\linewidth`
+		if image.Width != "" {
+			width = latexLength(image.Width)
+		}
+		figure.WriteString(fmt.Sprintf("\\includegraphics[width=%s,keepaspectratio]{%s}\n", width, path))
+	}
+
+	if caption != "" {
+		figure.WriteString(fmt.Sprintf("\\caption{%s}\n", caption))
+	}
+	if anchor != "" {
+		figure.WriteString(fmt.Sprintf("\\label{%s}\n", anchor))
+	}
+
+	if image.Float != "" {
+		figure.WriteString("\\end{wrapfigure}\n")
+	} else {
+		figure.WriteString("\\end{figure}\n")
+	}
+	figure.WriteString("```")
+	return figure.String()
+}
+
+// layoutDiv wraps an image in a div carrying its alignment or float for HTML
+func layoutDiv(image blocks.ImageBlock, markdown string) string {
+	switch {
+	case image.Float != "":
+		width := image.Width
+		if width == "" {
+			width = defaultFloatWidth
+		}
+		return fmt.Sprintf("::: {.figure-float-%s style=\"width: %s\"}\n%s\n:::", image.Float, width, markdown)
+	case image.Align != "" && image.Align != blocks.AlignCenter:
+		return fmt.Sprintf("::: {.figure-align-%s}\n%s\n:::", image.Align, markdown)
+	}
+	return markdown
+}
+
+// subfigureLabel returns "(a)", "(b)", ... for the i-th gallery image
+func subfigureLabel(i int) string {
+	label := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		label = string(rune('a'+(i-1)%26)) + label
+	}
+	return "(" + label + ")"
+}
+
+// subfigureCaption prefixes a gallery image caption with its letter
+func subfigureCaption(i int, caption string) string {
+	if caption == "" {
+		return ""
+	}
+	return subfigureLabel(i) + " " + caption
+}
+
+// galleryToMarkdown lays out gallery images in a grid: LaTeX subfigures for PDF,
+// a flexbox grid for HTML, and rows of inline images elsewhere
+func (mb *MarkdownBuilder) galleryToMarkdown(bc *buildContext, blockRef blocks.BlockReference, gallery *blocks.GalleryBlock) string {
+	anchor := blockRef.Label
+	caption := gallery.Caption
+	if target, ok := bc.refs.Numbered(bc.chapterID, blockRef); ok {
+		caption = mb.captionText(bc, target)
+		anchor = target.Anchor
+	}
+	columns := gallery.ColumnCount()
+
+	switch bc.format {
+	case "pdf":
+		return mb.latexGallery(bc, gallery, columns, caption, anchor)
+	case "html":
+		return mb.htmlGallery(bc, gallery, columns, caption, anchor)
+	}
+
+	// Inline images in the same paragraph sit side by side in every writer
+	var result strings.Builder
+	if anchor != "" {
+		result.WriteString(fmt.Sprintf("::: {#%s .gallery-inline}\n", anchor))
+	} else {
+		result.WriteString("::: gallery-inline\n")
+	}
+	width := fmt.Sprintf("%d%%", 100/columns-2)
+	var subcaptions []string
+	for i, img := range gallery.Images {
+		if i > 0 {
+			if i%columns == 0 {
+				result.WriteString("\n\n")
+			} else {
+				result.WriteString(" ")
+			}
+		}
+		alt := img.AltText
+		if alt == "" {
+			alt = bc.plainText(img.Caption)
+		}
+		result.WriteString(fmt.Sprintf("![%s](<%s>){width=%s}", alt, mb.absoluteImagePath(bc.docID, img.Path), width))
+		if sub := subfigureCaption(i, img.Caption); sub != "" {
+			subcaptions = append(subcaptions, bc.resolveText(sub))
+		}
+	}
+	result.WriteString("\n\n")
+	if len(subcaptions) > 0 {
+		result.WriteString(fmt.Sprintf("*%s*\n\n", strings.Join(subcaptions, "; ")))
+	}
+	if caption != "" {
+		result.WriteString(fmt.Sprintf("*%s*\n\n", bc.resolveText(caption)))
+	}
+	result.WriteString(":::")
+	return result.String()
+}
+
+func (mb *MarkdownBuilder) latexGallery(bc *buildContext, gallery *blocks.GalleryBlock, columns int, caption, anchor string) string {
+	const gap = 0.02
+	width := (1-gap*float64(columns-1))/float64(columns) - 0.005
+
+	var figure strings.Builder
+	figure.WriteString("```{=latex}\n\\begin{figure}[htbp]\n\\centering\n")
+	for i, img := range gallery.Images {
+		if i > 0 {
+			if i%columns == 0 {
+				figure.WriteString("\n\\par\\medskip\n")
+			} else {
+				figure.WriteString(fmt.Sprintf("\\hspace{%.2f\\linewidth}\n", gap))
+			}
+		}
+		figure.WriteString(fmt.Sprintf("\\begin{subfigure}[t]{%.3f\\linewidth}\n\\centering\n", width))
+		figure.WriteString(fmt.Sprintf("\\includegraphics[width=\\linewidth]{%s}\n", mb.absoluteImagePath(bc.docID, img.Path)))
+		if sub := subfigureCaption(i, img.Caption); sub != "" {
+			figure.WriteString(fmt.Sprintf("\\caption{%s}\n", bc.latexText(sub)))
+		}
+		figure.WriteString("\\end{subfigure}")
+	}
+	figure.WriteString("\n")
+	if caption != "" {
+		figure.WriteString(fmt.Sprintf("\\caption{%s}\n", bc.latexText(caption)))
+	}
+	if anchor != "" {
+		figure.WriteString(fmt.Sprintf("\\label{%s}\n", anchor))
+	}
+	figure.WriteString("\\end{figure}\n```")
+	return figure.String()
+}
+
+func (mb *MarkdownBuilder) htmlGallery(bc *buildContext, gallery *blocks.GalleryBlock, columns int, caption, anchor string) string {
+	var result strings.Builder
+	id := ""
+	if anchor != "" {
+		id = "#" + anchor + " "
+	}
+	result.WriteString(fmt.Sprintf("::: {%s.gallery style=\"--gallery-columns: %d\"}\n", id, columns))
+	for i, img := range gallery.Images {
+		image := blocks.ImageBlock{Path: img.Path, Caption: bc.resolveText(subfigureCaption(i, img.Caption)), AltText: img.AltText}
+		result.WriteString(fmt.Sprintf("::: gallery-item\n%s\n:::\n", mb.imageToMarkdown(bc.docID, &image)))
+	}
+	if caption != "" {
+		result.WriteString(fmt.Sprintf("::: gallery-caption\n%s\n:::\n", bc.resolveText(caption)))
+	}
+	result.WriteString(":::")
+	return result.String()
+}
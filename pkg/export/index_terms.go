@@ -0,0 +1,190 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// indexPattern matches index markers such as {@index:Pandoc} or {@index:Export!PDF},
+// where "!" separates a main entry from its subentry as in makeindex
+var indexPattern = regexp.MustCompile(`\{@index:([^{}\n]+)\}`)
+
+// indexTitle heads the generated index outside PDF, where \printindex sets its own
+const indexTitle = "Index"
+
+// makeindexQuoter escapes the characters makeindex treats as entry syntax
+var makeindexQuoter = strings.NewReplacer(`"`, `""`, `@`, `"@`, `|`, `"|`, `!`, `"!`)
+
+// indexEntry is one marked occurrence of an index term
+type indexEntry struct {
+	levels  []string // Main entry, then an optional subentry
+	anchor  string
+	section string // Title of the heading the marker follows
+}
+
+// backIndex collects the index markers met while building a document
+type backIndex struct {
+	entries []indexEntry
+}
+
+// parseIndexTerm splits a marked term into its entry levels
+func parseIndexTerm(term string) []string {
+	var levels []string
+	for _, level := range strings.SplitN(term, "!", 2) {
+		if level = strings.TrimSpace(level); level != "" {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// mark replaces the index markers in text with the target format's entry: an
+// \index command for makeindex, an XE field for Word and an anchor for HTML
+func (ix *backIndex) mark(bc *buildContext, text string) string {
+	return indexPattern.ReplaceAllStringFunc(text, func(match string) string {
+		levels := parseIndexTerm(indexPattern.FindStringSubmatch(match)[1])
+		if len(levels) == 0 {
+			return ""
+		}
+		entry := ix.add(bc, levels)
+
+		switch bc.format {
+		case "pdf":
+			return fmt.Sprintf("`\\index{%s}`{=latex}", latexIndexKey(levels))
+		case "docx":
+			key := strings.ReplaceAll(strings.Join(levels, ":"), `"`, "")
+			return fmt.Sprintf("`<w:r><w:fldChar w:fldCharType=\"begin\"/></w:r><w:r><w:instrText xml:space=\"preserve\"> XE \"%s\" </w:instrText></w:r><w:r><w:fldChar w:fldCharType=\"end\"/></w:r>`{=openxml}",
+				html.EscapeString(key))
+		default:
+			return fmt.Sprintf("[]{#%s}", entry.anchor)
+		}
+	})
+}
+
+// add records an entry for the marker met in the current section
+func (ix *backIndex) add(bc *buildContext, levels []string) indexEntry {
+	entry := indexEntry{
+		levels:  levels,
+		anchor:  fmt.Sprintf("index-%d", len(ix.entries)+1),
+		section: bc.section,
+	}
+	ix.entries = append(ix.entries, entry)
+	return entry
+}
+
+// latexIndexKey writes entry levels as a makeindex key. Levels that need LaTeX
+// escaping are sorted by their plain text and printed escaped.
+func latexIndexKey(levels []string) string {
+	keys := make([]string, len(levels))
+	for i, level := range levels {
+		escaped := latexEscaper.Replace(level)
+		keys[i] = makeindexQuoter.Replace(level)
+		if escaped != level {
+			keys[i] += "@" + makeindexQuoter.Replace(escaped)
+		}
+	}
+	return strings.Join(keys, "!")
+}
+
+// section returns the back-of-book index. PDF and DOCX let LaTeX and Word
+// compile it with page numbers; other formats list links to each marker.
+func (ix *backIndex) section(bc *buildContext) string {
+	if len(ix.entries) == 0 {
+		return ""
+	}
+
+	switch bc.format {
+	case "pdf":
+		return rawBlock(rawFormats[bc.format], "\\printindex") + "\n\n"
+	case "docx":
+		var field strings.Builder
+		field.WriteString(`<w:p><w:r><w:fldChar w:fldCharType="begin" w:dirty="true"/></w:r>`)
+		field.WriteString(`<w:r><w:instrText xml:space="preserve">INDEX \c "2" \h "A"</w:instrText></w:r>`)
+		field.WriteString(`<w:r><w:fldChar w:fldCharType="separate"/></w:r>`)
+		field.WriteString(`<w:r><w:t>Update this field to show the index.</w:t></w:r>`)
+		field.WriteString(`<w:r><w:fldChar w:fldCharType="end"/></w:r></w:p>`)
+		return fmt.Sprintf("# %s {#index .unnumbered}\n\n%s\n\n", indexTitle, rawBlock(rawFormats[bc.format], field.String()))
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("# %s {#index .unnumbered}\n\n::: {.index}\n", indexTitle))
+	for _, term := range ix.terms() {
+		result.WriteString(term.markdown(""))
+		for _, sub := range term.subentries {
+			result.WriteString(sub.markdown("    "))
+		}
+	}
+	result.WriteString(":::\n\n")
+	return result.String()
+}
+
+// indexTerm is an entry of the generated index with links to its occurrences
+type indexTerm struct {
+	name       string
+	links      []indexEntry
+	subentries []*indexTerm
+}
+
+// markdown writes the term as a list item linking each section it occurs in once
+func (t *indexTerm) markdown(indent string) string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, entry := range t.links {
+		label := entry.section
+		if label == "" {
+			label = fmt.Sprintf("%d", len(links)+1)
+		}
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+		links = append(links, fmt.Sprintf("[%s](#%s)", label, entry.anchor))
+	}
+	if len(links) == 0 {
+		return fmt.Sprintf("%s- %s\n", indent, t.name)
+	}
+	return fmt.Sprintf("%s- %s: %s\n", indent, t.name, strings.Join(links, ", "))
+}
+
+// terms groups the entries by term and subentry, sorted alphabetically
+func (ix *backIndex) terms() []*indexTerm {
+	byName := make(map[string]*indexTerm)
+	var terms []*indexTerm
+	for _, entry := range ix.entries {
+		key := strings.ToLower(entry.levels[0])
+		term, ok := byName[key]
+		if !ok {
+			term = &indexTerm{name: entry.levels[0]}
+			byName[key] = term
+			terms = append(terms, term)
+		}
+		if len(entry.levels) == 1 {
+			term.links = append(term.links, entry)
+			continue
+		}
+
+		subKey := key + "!" + strings.ToLower(entry.levels[1])
+		sub, ok := byName[subKey]
+		if !ok {
+			sub = &indexTerm{name: entry.levels[1]}
+			byName[subKey] = sub
+			term.subentries = append(term.subentries, sub)
+		}
+		sub.links = append(sub.links, entry)
+	}
+
+	sortTerms(terms)
+	for _, term := range terms {
+		sortTerms(term.subentries)
+	}
+	return terms
+}
+
+func sortTerms(terms []*indexTerm) {
+	sort.SliceStable(terms, func(i, j int) bool {
+		return strings.ToLower(terms[i].name) < strings.ToLower(terms[j].name)
+	})
+}
@@ -0,0 +1,238 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+)
+
+// Word starts a new column with a column break and separates columns by half
+// an inch unless the layout sets a gap
+const (
+	openXMLColumnBreak = `<w:p><w:r><w:br w:type="column"/></w:r></w:p>`
+	defaultColumnGap   = 720 // twips
+)
+
+// twipsPerUnit converts layout gap units to twips (1/20 pt); em assumes 12pt text
+var twipsPerUnit = map[string]float64{
+	"pt": 20,
+	"em": 240,
+	"mm": 1440 / 25.4,
+	"cm": 1440 / 2.54,
+	"in": 1440,
+}
+
+// pageSizesTwips gives portrait page width and height for style page sizes
+var pageSizesTwips = map[string][2]int{
+	"a4":     {11906, 16838},
+	"letter": {12240, 15840},
+	"legal":  {12240, 20160},
+}
+
+// layoutChildren returns the IDs of blocks that layouts in the list export in
+// their place, so processBlocks can skip them at their own position
+func (mb *MarkdownBuilder) layoutChildren(bc *buildContext, blockRefs []blocks.BlockReference) (map[string]bool, error) {
+	children := make(map[string]bool)
+	for _, ref := range blockRefs {
+		if ref.Type != blocks.TypeLayout {
+			continue
+		}
+		block, err := mb.storage.LoadBlock(bc.docID, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load layout %s: %w", ref.ID, err)
+		}
+		for _, id := range block.(*blocks.LayoutBlock).Blocks {
+			children[id] = true
+		}
+	}
+	return children, nil
+}
+
+// layoutToMarkdown sets the children of a layout in columns: a multicols
+// environment for PDF, continuous sections with columns for DOCX, and a CSS
+// grid of column divs for HTML and format-neutral markdown.
+func (mb *MarkdownBuilder) layoutToMarkdown(bc *buildContext, blockRef blocks.BlockReference, layout *blocks.LayoutBlock) (string, error) {
+	leave, err := bc.enter(blockRef.ID)
+	if err != nil {
+		return "", err
+	}
+	defer leave()
+
+	// Children grouped by column; a single group when the columns are balanced
+	var columns [][]string
+	var sizes []int
+	if bc.format == "pdf" {
+		bc.nested++
+	}
+	for _, id := range layout.Blocks {
+		block, ref, _, err := mb.storage.GetBlock(bc.docID, id)
+		if err != nil {
+			return "", fmt.Errorf("failed to load block %s of layout %s: %w", id, blockRef.ID, err)
+		}
+		if !ref.Conditions.Matches(bc.profile) {
+			continue
+		}
+		content, err := mb.blockToMarkdown(bc, ref, block)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert block %s to markdown: %w", id, err)
+		}
+		if len(columns) == 0 || layout.IsColumnBreak(id) {
+			columns = append(columns, nil)
+		}
+		columns[len(columns)-1] = append(columns[len(columns)-1], content)
+		sizes = append(sizes, len(content))
+	}
+	if bc.format == "pdf" {
+		bc.nested--
+	}
+
+	if len(columns) == 0 {
+		return "", nil
+	}
+
+	var content string
+	switch bc.format {
+	case "pdf":
+		content = latexColumns(layout, columns)
+	case "docx":
+		content = docxColumns(bc.pageStyle, layout, columns)
+	default:
+		if len(layout.ColumnBreaks) == 0 {
+			columns = balanceColumns(flatten(columns), sizes, layout.Columns)
+		}
+		return htmlColumns(blockRef.Label, layout, columns), nil
+	}
+	return labelDiv(blockRef.Label, content), nil
+}
+
+// latexColumns uses multicols, which balances the columns, or multicols* when
+// explicit column breaks decide where each column ends
+func latexColumns(layout *blocks.LayoutBlock, columns [][]string) string {
+	env := "multicols"
+	if len(layout.ColumnBreaks) > 0 {
+		env = "multicols*"
+	}
+
+	begin := fmt.Sprintf("\\begin{%s}{%d}", env, layout.Columns)
+	end := fmt.Sprintf("\\end{%s}", env)
+	if layout.Gap != "" {
+		begin = fmt.Sprintf("\\begingroup\\setlength{\\columnsep}{%s}\n%s", layout.Gap, begin)
+		end += "\n\\endgroup"
+	}
+
+	parts := []string{rawBlock(blocks.RawLaTeX, begin)}
+	for i, column := range columns {
+		if i > 0 {
+			parts = append(parts, rawBlock(blocks.RawLaTeX, `\columnbreak`))
+		}
+		parts = append(parts, column...)
+	}
+	parts = append(parts, rawBlock(blocks.RawLaTeX, end))
+	return strings.Join(parts, "\n\n")
+}
+
+// docxColumns closes the preceding section and opens one with columns. Both
+// section breaks are continuous so the columns share the page with the text
+// around them, and Word balances the columns of a section that ends this way.
+func docxColumns(styleConfig style.StyleConfig, layout *blocks.LayoutBlock, columns [][]string) string {
+	geometry := docxPageGeometry(styleConfig)
+	before := fmt.Sprintf(`<w:p><w:pPr><w:sectPr><w:type w:val="continuous"/>%s</w:sectPr></w:pPr></w:p>`, geometry)
+	after := fmt.Sprintf(`<w:p><w:pPr><w:sectPr><w:type w:val="continuous"/>%s<w:cols w:num="%d" w:space="%d"/></w:sectPr></w:pPr></w:p>`,
+		geometry, layout.Columns, columnGapTwips(layout.Gap))
+
+	parts := []string{rawBlock(blocks.RawOpenXML, before)}
+	for i, column := range columns {
+		if i > 0 {
+			parts = append(parts, rawBlock(blocks.RawOpenXML, openXMLColumnBreak))
+		}
+		parts = append(parts, column...)
+	}
+	parts = append(parts, rawBlock(blocks.RawOpenXML, after))
+	return strings.Join(parts, "\n\n")
+}
+
+// docxPageGeometry repeats the document's page size and margins, which every
+// Word section declares for itself. The final section gets the same geometry
+// from the reference document written by writeReferenceDoc.
+func docxPageGeometry(styleConfig style.StyleConfig) string {
+	size, ok := pageSizesTwips[strings.ToLower(styleConfig.Page.Size)]
+	if !ok {
+		size = pageSizesTwips["a4"]
+	}
+	orient := ""
+	if strings.ToLower(styleConfig.Page.Orientation) == "landscape" {
+		size[0], size[1] = size[1], size[0]
+		orient = ` w:orient="landscape"`
+	}
+
+	margins := styleConfig.Page.Margins
+	return fmt.Sprintf(`<w:pgSz w:w="%d" w:h="%d"%s/><w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="720" w:footer="720" w:gutter="0"/>`,
+		size[0], size[1], orient, margins.Top*20, margins.Right*20, margins.Bottom*20, margins.Left*20)
+}
+
+// columnGapTwips converts a layout gap to twips for Word
+func columnGapTwips(gap string) int {
+	if gap == "" {
+		return defaultColumnGap
+	}
+	value, unit, err := blocks.ParseWidth(gap)
+	if err != nil {
+		return defaultColumnGap
+	}
+	return int(value*twipsPerUnit[unit] + 0.5)
+}
+
+// htmlColumns emits a grid div with one div per column. The stylesheet sets up
+// the grid; the gap, if any, is set inline.
+func htmlColumns(label string, layout *blocks.LayoutBlock, columns [][]string) string {
+	attrs := fmt.Sprintf(".layout .columns-%d", layout.Columns)
+	if label != "" {
+		attrs = "#" + label + " " + attrs
+	}
+	if layout.Gap != "" {
+		attrs += fmt.Sprintf(" style=\"column-gap: %s\"", layout.Gap)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(":::: {%s}\n", attrs))
+	for _, column := range columns {
+		sb.WriteString("::: {.column}\n")
+		sb.WriteString(strings.Join(column, "\n\n"))
+		sb.WriteString("\n:::\n\n")
+	}
+	sb.WriteString("::::")
+	return sb.String()
+}
+
+// balanceColumns splits blocks into at most n consecutive columns of similar
+// length, measured in characters of markdown. A block moves to the next column
+// when more than half of it would extend past the column's share.
+func balanceColumns(parts []string, sizes []int, n int) [][]string {
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+	share := float64(total) / float64(n)
+
+	var columns [][]string
+	filled := 0
+	for i, part := range parts {
+		if len(columns) == 0 || (len(columns) < n && float64(filled)+float64(sizes[i])/2 > share*float64(len(columns))) {
+			columns = append(columns, nil)
+		}
+		columns[len(columns)-1] = append(columns[len(columns)-1], part)
+		filled += sizes[i]
+	}
+	return columns
+}
+
+// flatten joins the column groups back into one sequence of blocks
+func flatten(columns [][]string) []string {
+	var parts []string
+	for _, column := range columns {
+		parts = append(parts, column...)
+	}
+	return parts
+}
@@ -2,11 +2,16 @@ package export
 
 import (
 	"fmt"
+	"html"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/chart"
+	"github.com/savant/mcp-servers/docgen2/pkg/diagram"
 	"github.com/savant/mcp-servers/docgen2/pkg/storage"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
 )
 
 // MarkdownBuilder converts document blocks to markdown
@@ -19,28 +24,206 @@ func NewMarkdownBuilder(storage *storage.Storage) *MarkdownBuilder {
 	return &MarkdownBuilder{storage: storage}
 }
 
+// buildContext carries per-build state through block conversion
+type buildContext struct {
+	docID     string
+	chapterID string
+	format    string // Target export format; empty for format-neutral markdown
+	style     style.StyleConfig
+	refs      *ReferenceIndex
+
+	// Engines of diagrams left for the browser to render (HTML only)
+	clientDiagrams map[string]bool
+
+	// Values for {{name}} placeholders
+	vars *variableSet
+
+	// Selects conditional blocks and chapters; nil exports everything
+	profile blocks.Profile
+
+	// Glossary links and index markers met so far, and the title of the
+	// current heading that index entries link to outside PDF and DOCX
+	glossary *glossary
+	index    *backIndex
+	section  string
+
+	// Review comments to show, and the number of Word comments written so far
+	comments     commentsByBlock
+	commentCount int
+
+	// Depth of the multicols and minipages around the content, which cannot hold margin notes
+	nested int
+
+	// Layouts and includes being converted, by document, chapter and block.
+	// Layouts can hold includes that lead back to them, in any document.
+	converting map[[3]string]bool
+
+	// Pending suggestions shown as tracked changes, by chapter and block ID
+	suggestions map[string]*storage.Suggestion
+
+	// Style of the content being converted: the document style with the
+	// overrides of the current chapter and block applied
+	styles    *style.StyleLoader
+	pageStyle style.StyleConfig
+}
+
+// resolveText links glossary terms and replaces the index markers and
+// {@ref:label} placeholders in markdown text
+func (bc *buildContext) resolveText(text string) string {
+	text = bc.glossary.link(text)
+	text = bc.index.mark(bc, text)
+	return bc.refs.Resolve(text)
+}
+
+// plainText drops the index markers from text and spells out its references,
+// for list entries and alt text that cannot hold links
+func (bc *buildContext) plainText(text string) string {
+	text = indexPattern.ReplaceAllString(text, "")
+	return strings.Join(strings.Fields(bc.refs.ResolvePlain(text)), " ")
+}
+
+// BuildOptions tailor the markdown built for an export
+type BuildOptions struct {
+	Variables   map[string]string // Overrides the document's variables
+	Profile     blocks.Profile    // Leaves out content whose conditions the profile does not meet
+	TOC         *bool             // Overrides the style's table of contents setting when set
+	TOCDepth    int               // Overrides the style's table of contents depth when non-zero
+	Comments    string            // Review comments to show: none (default), open or all
+	Suggestions string            // Pending suggestions: none (default) or tracked
+}
+
+// newBuildContext prepares the state needed to convert a document's blocks.
+// Variable overrides take precedence over the document's own variables.
+func (mb *MarkdownBuilder) newBuildContext(docID, format string, opts BuildOptions) (*buildContext, error) {
+	doc, err := mb.storage.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	styleLoader := style.NewStyleLoader(mb.storage.GetConfig().RootFolder)
+	documentStyle, err := styleLoader.LoadStyleForThemedDocument(doc.Theme, doc.Style, doc.StyleSettings)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TOC != nil {
+		documentStyle.TOC.Enabled = *opts.TOC
+	}
+	if opts.TOCDepth != 0 {
+		documentStyle.TOC.Depth = opts.TOCDepth
+	}
+
+	refs, err := BuildReferenceIndexForProfile(mb.storage, docID, documentStyle.Captions, opts.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := loadExportComments(mb.storage, docID, opts.Comments)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := loadExportSuggestions(mb.storage, docID, opts.Suggestions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &buildContext{
+		docID:          docID,
+		format:         format,
+		style:          documentStyle,
+		refs:           refs,
+		clientDiagrams: make(map[string]bool),
+		vars:           newVariableSet(doc.Variables, opts.Variables),
+		profile:        opts.Profile,
+		glossary:       newGlossary(doc.Glossary),
+		index:          &backIndex{},
+		comments:       comments,
+		suggestions:    suggestions,
+		styles:         styleLoader,
+		pageStyle:      documentStyle,
+		converting:     make(map[[3]string]bool),
+	}, nil
+}
+
+// enter marks a layout or include block of the current document and chapter
+// as being converted until leave is called. Meeting it again before then means
+// the content includes itself.
+func (bc *buildContext) enter(blockID string) (leave func(), err error) {
+	key := [3]string{bc.docID, bc.chapterID, blockID}
+	if bc.converting[key] {
+		return nil, fmt.Errorf("include cycle: %s/%s includes itself", bc.docID, blockID)
+	}
+	bc.converting[key] = true
+	return func() { delete(bc.converting, key) }, nil
+}
+
 // BuildMarkdown converts a document to markdown string
 func (mb *MarkdownBuilder) BuildMarkdown(docID string) (string, error) {
+	return mb.BuildMarkdownForFormat(docID, "")
+}
+
+// BuildMarkdownForFormat converts a document to markdown tailored to an export format
+// (pdf, docx or html). Format-specific output such as native lists of figures is only
+// emitted when a format is given.
+func (mb *MarkdownBuilder) BuildMarkdownForFormat(docID, format string) (string, error) {
+	markdown, _, err := mb.BuildMarkdownWithVariables(docID, format, nil)
+	return markdown, err
+}
+
+// BuildMarkdownWithVariables converts a document to markdown for a format with
+// {{name}} placeholders filled from the document's variables and the overrides.
+// It also returns the names of placeholders that had no value; they are left as written.
+func (mb *MarkdownBuilder) BuildMarkdownWithVariables(docID, format string, overrides map[string]string) (string, []string, error) {
+	return mb.BuildMarkdownWithOptions(docID, format, BuildOptions{Variables: overrides})
+}
+
+// BuildMarkdownWithOptions converts a document to markdown for a format, with
+// variables filled in and only the content selected by the profile. Like
+// BuildMarkdownWithVariables, it returns the names of placeholders without a value.
+func (mb *MarkdownBuilder) BuildMarkdownWithOptions(docID, format string, opts BuildOptions) (string, []string, error) {
+	markdown, bc, err := mb.build(docID, format, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	return markdown, bc.vars.Undefined(), nil
+}
+
+func (mb *MarkdownBuilder) build(docID, format string, opts BuildOptions) (string, *buildContext, error) {
 	doc, err := mb.storage.GetDocument(docID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get document: %w", err)
+		return "", nil, fmt.Errorf("failed to get document: %w", err)
 	}
 
+	bc, err := mb.newBuildContext(docID, format, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	bc.section = bc.vars.expand(doc.Title)
+
 	var markdown strings.Builder
 
-	// Add document title and metadata
-	// Quote the title and author to handle special characters like colons
-	markdown.WriteString(fmt.Sprintf("---\ntitle: \"%s\"\n", escapeYAMLString(doc.Title)))
-	if doc.Author != "" {
-		markdown.WriteString(fmt.Sprintf("author: \"%s\"\n", escapeYAMLString(doc.Author)))
+	// Add document title and metadata; a cover page replaces Pandoc's title block
+	if cover := mb.resolveCover(bc, doc); cover != nil {
+		markdown.WriteString(cover.metadata(bc))
+		if bc.format == "docx" {
+			markdown.WriteString(cover.openXML(bc.style))
+		}
+	} else {
+		// Quote the title and author to handle special characters like colons
+		markdown.WriteString(fmt.Sprintf("---\ntitle: \"%s\"\n", escapeYAMLString(bc.vars.expand(doc.Title))))
+		if doc.Author != "" {
+			markdown.WriteString(fmt.Sprintf("author: \"%s\"\n", escapeYAMLString(bc.vars.expand(doc.Author))))
+		}
+		markdown.WriteString("---\n\n")
 	}
-	markdown.WriteString("---\n\n")
+
+	// Generated front matter sections
+	markdown.WriteString(mb.buildListsOfFloats(bc))
 
 	// Process document-level blocks first (if any)
 	if len(doc.Blocks) > 0 {
-		content, err := mb.processBlocks(docID, doc.Blocks)
+		content, err := mb.processBlocks(bc, doc.Blocks)
 		if err != nil {
-			return "", fmt.Errorf("failed to process document blocks: %w", err)
+			return "", nil, fmt.Errorf("failed to process document blocks: %w", err)
 		}
 		markdown.WriteString(content)
 	}
@@ -48,37 +231,63 @@ func (mb *MarkdownBuilder) BuildMarkdown(docID string) (string, error) {
 	// Then process chapters (if any)
 	if len(doc.Chapters) > 0 {
 		for _, chapterRef := range doc.Chapters {
+			if !chapterRef.Conditions.Matches(bc.profile) {
+				continue
+			}
 			chapter, err := mb.storage.GetChapter(docID, chapterRef.ID)
 			if err != nil {
-				return "", fmt.Errorf("failed to get chapter %s: %w", chapterRef.ID, err)
+				return "", nil, fmt.Errorf("failed to get chapter %s: %w", chapterRef.ID, err)
 			}
 
 			// Add chapter title as H1
-			markdown.WriteString(fmt.Sprintf("# %s\n\n", chapter.Title))
+			bc.section = bc.vars.expand(chapter.Title)
+			heading := fmt.Sprintf("# %s\n\n", numberedTitle(bc.refs.ChapterNumber(chapterRef.ID), bc.section))
 
-			// Process chapter blocks
-			chapterContent, err := mb.processBlocks(docID, chapter.Blocks)
+			// Process chapter blocks, in the chapter's style if it has its own
+			bc.chapterID = chapterRef.ID
+			bc.pageStyle = bc.styles.ApplyOverride(bc.style, chapterRef.Style)
+			chapterContent, err := mb.processBlocks(bc, chapter.Blocks)
 			if err != nil {
-				return "", fmt.Errorf("failed to process chapter %s blocks: %w", chapterRef.ID, err)
+				return "", nil, fmt.Errorf("failed to process chapter %s blocks: %w", chapterRef.ID, err)
 			}
-			markdown.WriteString(chapterContent)
+			bc.pageStyle = bc.style
+
+			// A chapter may be longer than a page, so it is never boxed
+			markdown.WriteString(bc.styled(chapterRef.Style, bc.style, strings.TrimRight(heading+chapterContent, "\n"), false) + "\n\n")
 		}
 	}
 
-	return markdown.String(), nil
+	// Generated back matter sections
+	markdown.WriteString(bc.glossary.section(bc))
+	markdown.WriteString(bc.index.section(bc))
+
+	markdown.WriteString(clientDiagramScripts(bc))
+
+	return markdown.String(), bc, nil
 }
 
 // processBlocks converts a list of block references to markdown
-func (mb *MarkdownBuilder) processBlocks(docID string, blockRefs []blocks.BlockReference) (string, error) {
+func (mb *MarkdownBuilder) processBlocks(bc *buildContext, blockRefs []blocks.BlockReference) (string, error) {
 	var result strings.Builder
 
+	// Blocks inside a layout are exported by the layout
+	children, err := mb.layoutChildren(bc, blockRefs)
+	if err != nil {
+		return "", err
+	}
+
 	for _, blockRef := range blockRefs {
-		block, err := mb.storage.LoadBlock(docID, blockRef)
+		// Skip content the export profile leaves out
+		if !blockRef.Conditions.Matches(bc.profile) || children[blockRef.ID] {
+			continue
+		}
+
+		block, err := mb.storage.LoadBlock(bc.docID, blockRef)
 		if err != nil {
 			return "", fmt.Errorf("failed to load block %s: %w", blockRef.ID, err)
 		}
 
-		blockMarkdown, err := mb.blockToMarkdown(docID, block)
+		blockMarkdown, err := mb.blockToMarkdown(bc, blockRef, block)
 		if err != nil {
 			return "", fmt.Errorf("failed to convert block %s to markdown: %w", blockRef.ID, err)
 		}
@@ -90,43 +299,318 @@ func (mb *MarkdownBuilder) processBlocks(docID string, blockRefs []blocks.BlockR
 	return result.String(), nil
 }
 
-// blockToMarkdown converts a single block to markdown
-func (mb *MarkdownBuilder) blockToMarkdown(docID string, block blocks.Block) (string, error) {
+// blockToMarkdown converts a single block to markdown, with its pending
+// suggestion and review comments when the export shows them
+func (mb *MarkdownBuilder) blockToMarkdown(bc *buildContext, blockRef blocks.BlockReference, block blocks.Block) (string, error) {
+	bc.vars.expandBlock(block)
+	if err := mb.trackChanges(bc, blockRef.ID, block); err != nil {
+		return "", err
+	}
+
+	// Notes go before the block, outside the box keep_together sets it in
+	boxed := bc.boxed(blockRef.Style, block)
+	notes := bc.annotateComments(blockRef.ID, block, boxed)
+
+	// Content nested in the block, such as a layout's columns, follows its style
+	surrounding := bc.pageStyle
+	bc.pageStyle = bc.styles.ApplyOverride(surrounding, blockRef.Style)
+	if boxed {
+		bc.nested++
+	}
+	markdown, err := mb.convertBlock(bc, blockRef, block)
+	if boxed {
+		bc.nested--
+	}
+	bc.pageStyle = surrounding
+	if err != nil {
+		return "", err
+	}
+
+	if _, table := block.(*blocks.TableBlock); table && bc.format == "pdf" && blockRef.Style != nil && blockRef.Style.KeepTogether {
+		markdown = keepTable(markdown)
+	}
+	markdown = bc.styled(blockRef.Style, surrounding, markdown, boxed)
+	if notes == "" {
+		return markdown, nil
+	}
+	return notes + "\n\n" + markdown, nil
+}
+
+// convertBlock converts a block's content to markdown
+func (mb *MarkdownBuilder) convertBlock(bc *buildContext, blockRef blocks.BlockReference, block blocks.Block) (string, error) {
 	switch b := block.(type) {
 	case *blocks.HeadingBlock:
-		return mb.headingToMarkdown(b), nil
+		bc.section = b.Text
+		return mb.headingToMarkdown(b, blockRef.Label, bc.refs.SectionNumber(bc.chapterID, blockRef)), nil
 
 	case *blocks.MarkdownBlock:
-		return b.Content, nil
+		content := b.Content
+		if target, ok := bc.refs.Numbered(bc.chapterID, blockRef); ok && target.Kind == RefEquation {
+			content = tagEquation(content, target.Number, bc.format)
+		}
+		return labelDiv(blockRef.Label, bc.resolveText(content)), nil
 
 	case *blocks.ImageBlock:
-		return mb.imageToMarkdown(docID, b), nil
+		return mb.figureToMarkdown(bc, blockRef, *b), nil
+
+	case *blocks.ChartBlock:
+		// Charts are rendered to an image by the exporter before Pandoc runs
+		image := blocks.ImageBlock{
+			Path:    chartImagePath(bc.chapterID, blockRef.ID, bc.format),
+			Caption: b.Caption,
+			AltText: b.Title,
+		}
+		return mb.figureToMarkdown(bc, blockRef, image), nil
+
+	case *blocks.DiagramBlock:
+		return mb.diagramToMarkdown(bc, blockRef, b), nil
+
+	case *blocks.GalleryBlock:
+		return mb.galleryToMarkdown(bc, blockRef, b), nil
 
 	case *blocks.TableBlock:
-		return mb.tableToMarkdown(b), nil
+		table := mb.tableToMarkdown(resolveTableText(bc, b))
+		anchor := blockRef.Label
+		if target, ok := bc.refs.Numbered(bc.chapterID, blockRef); ok {
+			table += fmt.Sprintf("\n: %s\n", bc.resolveText(mb.captionText(bc, target)))
+			anchor = target.Anchor
+		}
+		return labelDiv(anchor, table), nil
+
+	case *blocks.IncludeBlock:
+		return mb.includeToMarkdown(bc, b)
+
+	case *blocks.ListBlock:
+		return labelDiv(blockRef.Label, bc.resolveText(b.ToMarkdown())), nil
+
+	case *blocks.QuoteBlock:
+		return mb.quoteToMarkdown(bc, blockRef, b), nil
+
+	case *blocks.LayoutBlock:
+		return mb.layoutToMarkdown(bc, blockRef, b)
+
+	case *blocks.RawBlock:
+		return rawToMarkdown(bc, b), nil
 
 	case *blocks.PageBreakBlock:
-		// Use LaTeX command for page break (works in PDF and DOCX)
-		return "\\newpage", nil
+		// A bare \newpage would leak into HTML as text, so use each format's own break
+		return pageBreakToMarkdown(bc.format), nil
 
 	default:
 		return "", fmt.Errorf("unsupported block type: %T", block)
 	}
 }
 
-// headingToMarkdown converts a heading block to markdown
-func (mb *MarkdownBuilder) headingToMarkdown(heading *blocks.HeadingBlock) string {
+// includeToMarkdown converts the snippet or block an include points at. Blocks of
+// other documents keep their own assets but are not numbered here, and {@ref}
+// placeholders in included text resolve against the including document.
+func (mb *MarkdownBuilder) includeToMarkdown(bc *buildContext, include *blocks.IncludeBlock) (string, error) {
+	leave, err := bc.enter(include.ID)
+	if err != nil {
+		return "", err
+	}
+	defer leave()
+
+	target, err := mb.storage.ResolveInclude(include)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve include %s: %w", include.Source(), err)
+	}
+
+	included := *bc
+	included.refs = bc.refs.labelsOnly()
+	included.comments = nil // Comments and suggestions belong to the included block's own document
+	included.suggestions = nil
+	if target.DocID != "" {
+		included.docID = target.DocID
+		included.chapterID = target.ChapterID
+	}
+
+	// Labels belong to the source document and would duplicate its anchors
+	ref := target.Ref
+	ref.Label = ""
+	return mb.blockToMarkdown(&included, ref, target.Block)
+}
+
+// captionText returns a figure or table caption, numbered unless numbering is disabled
+func (mb *MarkdownBuilder) captionText(bc *buildContext, target RefTarget) string {
+	// The reference index holds captions as stored, before variable substitution
+	target.Caption = bc.vars.expand(target.Caption)
+	if bc.style.Captions.Numbering == NumberingNone {
+		return target.Caption
+	}
+	return target.CaptionText()
+}
+
+// buildListsOfFloats generates the optional "List of Figures" and "List of Tables"
+// sections. PDF uses LaTeX's native lists (with page numbers); other formats get
+// linked lists built from the reference index.
+func (mb *MarkdownBuilder) buildListsOfFloats(bc *buildContext) string {
+	var result strings.Builder
+
+	lists := []struct {
+		enabled bool
+		title   string
+		class   string
+		command string
+		targets []RefTarget
+	}{
+		{bc.style.Captions.ListOfFigures, "List of Figures", "list-of-figures", "\\listoffigures", bc.refs.Figures()},
+		{bc.style.Captions.ListOfTables, "List of Tables", "list-of-tables", "\\listoftables", bc.refs.Tables()},
+	}
+
+	for _, list := range lists {
+		if !list.enabled || len(list.targets) == 0 {
+			continue
+		}
+
+		if bc.format == "pdf" {
+			result.WriteString(fmt.Sprintf("```{=latex}\n%s\n```\n\n", list.command))
+			continue
+		}
+
+		result.WriteString(fmt.Sprintf("::: {.%s}\n**%s**\n\n", list.class, list.title))
+		for _, target := range list.targets {
+			// Index markers belong to the caption under the float, not to its list entry
+			caption := bc.plainText(mb.captionText(bc, target))
+			result.WriteString(fmt.Sprintf("- [%s](#%s)\n", caption, target.Anchor))
+		}
+		result.WriteString(":::\n\n")
+	}
+
+	return result.String()
+}
+
+// figureToMarkdown writes an image with its numbered caption and anchor
+func (mb *MarkdownBuilder) figureToMarkdown(bc *buildContext, blockRef blocks.BlockReference, image blocks.ImageBlock) string {
+	anchor := blockRef.Label
+	if target, ok := bc.refs.Numbered(bc.chapterID, blockRef); ok {
+		image.Caption = mb.captionText(bc, target)
+		anchor = target.Anchor
+	}
+	if bc.format == "pdf" && (image.Align != "" || image.Float != "") {
+		return latexFigure(mb.absoluteImagePath(bc.docID, image.Path), bc.latexText(image.Caption), anchor, image)
+	}
+	image.Caption = bc.resolveText(image.Caption)
+	markdown := mb.imageToMarkdown(bc.docID, &image) + imageAttributes(anchor, image.Width)
+	return layoutDiv(image, markdown)
+}
+
+// chartImagePath returns the document-relative path a chart is rendered to.
+// HTML gets scalable SVG; PDF and DOCX get PNG, which needs no converter.
+func chartImagePath(chapterID, blockID, format string) string {
+	ext := chart.FormatPNG
+	if format == "html" {
+		ext = chart.FormatSVG
+	}
+	name := blockID
+	if chapterID != "" {
+		name = chapterID + "-" + blockID
+	}
+	return filepath.Join("charts", name+"."+ext)
+}
+
+// diagramImagePath returns the document-relative path a diagram is rendered to,
+// using the same SVG/PNG split as charts
+func diagramImagePath(d *blocks.DiagramBlock, format string) string {
+	if format == "html" {
+		return diagram.CachePath(d, diagram.FormatSVG)
+	}
+	return diagram.CachePath(d, diagram.FormatPNG)
+}
+
+// diagramToMarkdown embeds a diagram rendered by the exporter. Without a rendered
+// image, HTML falls back to client-side rendering where the engine allows it and
+// every other case shows the source as a code block.
+func (mb *MarkdownBuilder) diagramToMarkdown(bc *buildContext, blockRef blocks.BlockReference, d *blocks.DiagramBlock) string {
+	imagePath := diagramImagePath(d, bc.format)
+	fullPath := filepath.Join(mb.storage.GetConfig().GetDocumentFolder(bc.docID), imagePath)
+	if _, err := os.Stat(fullPath); err == nil {
+		image := blocks.ImageBlock{Path: imagePath, Caption: d.Caption, AltText: d.AltText}
+		return mb.figureToMarkdown(bc, blockRef, image)
+	}
+
+	anchor := blockRef.Label
+	caption := d.Caption
+	if target, ok := bc.refs.Numbered(bc.chapterID, blockRef); ok {
+		caption = mb.captionText(bc, target)
+		anchor = target.Anchor
+	}
+
+	if bc.format == "html" && diagram.ClientSide(d.Engine) {
+		bc.clientDiagrams[d.Engine] = true
+		var figure strings.Builder
+		figure.WriteString("```{=html}\n<figure class=\"diagram\"")
+		if anchor != "" {
+			figure.WriteString(fmt.Sprintf(" id=\"%s\"", html.EscapeString(anchor)))
+		}
+		figure.WriteString(fmt.Sprintf(">\n<pre class=\"%s\">%s</pre>\n", d.Engine, html.EscapeString(strings.TrimRight(d.Source, "\n"))))
+		if caption != "" {
+			// The caption leaves the raw block so its links and index anchors are rendered
+			figure.WriteString(fmt.Sprintf("<figcaption>\n```\n\n%s\n\n```{=html}\n</figcaption>\n", bc.resolveText(caption)))
+		}
+		figure.WriteString("</figure>\n```")
+		return figure.String()
+	}
+
+	content := d.ToMarkdown()
+	if caption != "" {
+		content += fmt.Sprintf("\n\n*%s*", bc.resolveText(caption))
+	}
+	return labelDiv(anchor, content)
+}
+
+// clientDiagramScripts loads the browser renderers needed by client-side diagrams
+func clientDiagramScripts(bc *buildContext) string {
+	var scripts strings.Builder
+	if bc.clientDiagrams[blocks.EngineMermaid] {
+		scripts.WriteString("```{=html}\n<script type=\"module\">\nimport mermaid from \"https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.esm.min.mjs\";\nmermaid.initialize({ startOnLoad: true });\n</script>\n```\n\n")
+	}
+	if bc.clientDiagrams[blocks.EngineGraphviz] {
+		scripts.WriteString("```{=html}\n<script src=\"https://cdn.jsdelivr.net/npm/@viz-js/viz@3/lib/viz-standalone.js\"></script>\n<script>\nViz.instance().then(function(viz) {\n  document.querySelectorAll(\"pre.graphviz\").forEach(function(el) {\n    el.replaceWith(viz.renderSVGElement(el.textContent));\n  });\n});\n</script>\n```\n\n")
+	}
+	return scripts.String()
+}
+
+// labelDiv wraps content in a Pandoc fenced div carrying the label as its identifier,
+// so {@ref:label} links have an anchor to jump to
+func labelDiv(label, content string) string {
+	if label == "" {
+		return content
+	}
+	return fmt.Sprintf("::: {#%s}\n%s\n:::", label, strings.TrimRight(content, "\n"))
+}
+
+// headingToMarkdown converts a heading block to markdown, with its section
+// number when sections are numbered
+func (mb *MarkdownBuilder) headingToMarkdown(heading *blocks.HeadingBlock, label, number string) string {
 	hashes := strings.Repeat("#", heading.Level)
-	return fmt.Sprintf("%s %s", hashes, heading.Text)
+	text := numberedTitle(number, heading.Text)
+	if label != "" {
+		return fmt.Sprintf("%s %s {#%s}", hashes, text, label)
+	}
+	return fmt.Sprintf("%s %s", hashes, text)
 }
 
-// imageToMarkdown converts an image block to markdown
-func (mb *MarkdownBuilder) imageToMarkdown(docID string, img *blocks.ImageBlock) string {
-	// Build absolute path to the image file
-	// The image path is relative to the document folder
+// numberedTitle prefixes a heading with its section number. The numbers come
+// from the reference index rather than Pandoc's --number-sections, so they are
+// the ones section references show in every format and in chapter exports.
+func numberedTitle(number, title string) string {
+	if number == "" {
+		return title
+	}
+	return number + " " + title
+}
+
+// absoluteImagePath resolves an image path, which is relative to the document folder
+func (mb *MarkdownBuilder) absoluteImagePath(docID, path string) string {
 	config := mb.storage.GetConfig()
 	docFolder := config.GetDocumentFolder(docID)
-	imagePath := filepath.Join(docFolder, img.Path)
+	return filepath.Join(docFolder, path)
+}
+
+// imageToMarkdown converts an image block to markdown
+func (mb *MarkdownBuilder) imageToMarkdown(docID string, img *blocks.ImageBlock) string {
+	imagePath := mb.absoluteImagePath(docID, img.Path)
 	
 	// For paths with spaces, use angle brackets as per markdown spec
 	// This is the proper way to handle paths with spaces in markdown
@@ -136,11 +620,32 @@ func (mb *MarkdownBuilder) imageToMarkdown(docID string, img *blocks.ImageBlock)
 	return fmt.Sprintf(`![](<%s>)`, imagePath)
 }
 
+// resolveTableText returns a copy of a table whose header and body cells have
+// their glossary terms, index markers and references resolved
+func resolveTableText(bc *buildContext, table *blocks.TableBlock) *blocks.TableBlock {
+	resolved := *table
+	resolved.Headers = make([]string, len(table.Headers))
+	for i, header := range table.Headers {
+		resolved.Headers[i] = bc.resolveText(header)
+	}
+	resolved.Rows = make([][]string, len(table.Rows))
+	for r, row := range table.Rows {
+		resolved.Rows[r] = make([]string, len(row))
+		for c, cell := range row {
+			resolved.Rows[r][c] = bc.resolveText(cell)
+		}
+	}
+	return &resolved
+}
+
 // tableToMarkdown converts a table block to markdown
 func (mb *MarkdownBuilder) tableToMarkdown(table *blocks.TableBlock) string {
 	if len(table.Headers) == 0 {
 		return ""
 	}
+	if !table.IsSimple() {
+		return gridTableToMarkdown(table)
+	}
 
 	var result strings.Builder
 
@@ -153,8 +658,8 @@ func (mb *MarkdownBuilder) tableToMarkdown(table *blocks.TableBlock) string {
 
 	// Write separator
 	result.WriteString("|")
-	for range table.Headers {
-		result.WriteString(" --- |")
+	for i := range table.Headers {
+		result.WriteString(fmt.Sprintf(" %s |", alignmentMarker(columnAlign(table, i))))
 	}
 	result.WriteString("\n")
 
@@ -176,6 +681,11 @@ func (mb *MarkdownBuilder) tableToMarkdown(table *blocks.TableBlock) string {
 	return result.String()
 }
 
+// ValidateReferences reports {@ref:...} placeholders that do not match any block label
+func (mb *MarkdownBuilder) ValidateReferences(docID string) ([]DanglingReference, error) {
+	return FindDanglingReferences(mb.storage, docID)
+}
+
 // escapeYAMLString escapes special characters in YAML strings
 func escapeYAMLString(s string) string {
 	// Escape backslashes and double quotes for YAML quoted strings
@@ -186,15 +696,27 @@ func escapeYAMLString(s string) string {
 
 // BuildChapterMarkdown builds markdown for a specific chapter
 func (mb *MarkdownBuilder) BuildChapterMarkdown(docID, chapterID string) (string, error) {
+	return mb.BuildChapterMarkdownForFormat(docID, chapterID, "")
+}
+
+// BuildChapterMarkdownForFormat builds markdown for a specific chapter, tailored
+// to an export format like BuildMarkdownForFormat
+func (mb *MarkdownBuilder) BuildChapterMarkdownForFormat(docID, chapterID, format string) (string, error) {
 	chapter, err := mb.storage.GetChapter(docID, chapterID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get chapter: %w", err)
 	}
 
+	bc, err := mb.newBuildContext(docID, format, BuildOptions{})
+	if err != nil {
+		return "", err
+	}
+	bc.chapterID = chapterID
+
 	var markdown strings.Builder
-	markdown.WriteString(fmt.Sprintf("# %s\n\n", chapter.Title))
+	markdown.WriteString(fmt.Sprintf("# %s\n\n", numberedTitle(bc.refs.ChapterNumber(chapterID), bc.vars.expand(chapter.Title))))
 
-	content, err := mb.processBlocks(docID, chapter.Blocks)
+	content, err := mb.processBlocks(bc, chapter.Blocks)
 	if err != nil {
 		return "", fmt.Errorf("failed to process chapter blocks: %w", err)
 	}
@@ -251,13 +251,20 @@ func (p *PandocWrapper) ConvertMarkdownToFormatWithStyle(markdownContent string,
 		args = append(args, "--template="+templatePath)
 		
 	case "docx":
-		// DOCX doesn't support custom templates easily, use default for now
-		// TODO: Consider generating a reference.docx with styles
+		// The reference document sets the page geometry; its styles are Pandoc's defaults
+		referencePath := filepath.Join(workingDir, "reference.docx")
+		if err := p.writeReferenceDoc(styleConfig, referencePath); err != nil {
+			return err
+		}
+		args = append(args, "--reference-doc="+referencePath)
 		
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
 
+	// Table of contents (native TOC field in DOCX, \tableofcontents in PDF, linked nav in HTML)
+	args = append(args, p.tocArgs(styleConfig)...)
+
 	// Debug: Print Pandoc command
 	fmt.Printf("DEBUG: Pandoc command: pandoc %s\n", strings.Join(args, " "))
 	fmt.Printf("DEBUG: Working directory: %s\n", workingDir)
@@ -295,6 +302,24 @@ func (p *PandocWrapper) ConvertMarkdownToFormatWithStyle(markdownContent string,
 		return fmt.Errorf("pandoc conversion timed out after %v", p.timeout)
 	}
 }
+// tocArgs returns the Pandoc arguments for the configured table of contents
+func (p *PandocWrapper) tocArgs(styleConfig style.StyleConfig) []string {
+	if !styleConfig.TOC.Enabled {
+		return nil
+	}
+	
+	depth := styleConfig.TOC.Depth
+	if depth < 1 || depth > 6 {
+		depth = 3
+	}
+	
+	args := []string{"--toc", fmt.Sprintf("--toc-depth=%d", depth)}
+	if styleConfig.TOC.Title != "" {
+		args = append(args, "-M", fmt.Sprintf("toc-title=%s", styleConfig.TOC.Title))
+	}
+	return args
+}
+
 // generateLaTeXHeader creates a minimal LaTeX header for advanced styling
 // FIXED VERSION: Uses \AtBeginDocument to ensure proper page number processing
 func (p *PandocWrapper) generateLaTeXHeader(styleConfig style.StyleConfig, title, author string) string {
@@ -317,6 +342,45 @@ func (p *PandocWrapper) generateLaTeXHeader(styleConfig style.StyleConfig, title
 	header.WriteString("\\paragraphfont{\\color{headingcolor}}\n")
 	header.WriteString("\\subparagraphfont{\\color{headingcolor}}\n\n")
 	
+	// Figure and table numbers are generated by MarkdownBuilder so they match
+	// DOCX and HTML output; stop LaTeX from adding its own "Figure 1:" prefix
+	header.WriteString("% Caption numbering\n")
+	header.WriteString("\\usepackage{caption}\n")
+	header.WriteString("\\captionsetup{labelformat=empty}\n\n")
+	
+	// Wrapped (floating) images and gallery subfigures
+	header.WriteString("% Image layout\n")
+	header.WriteString("\\usepackage{wrapfig}\n")
+	header.WriteString("\\usepackage{subcaption}\n\n")
+	
+	// Quote blocks: MarkdownBuilder wraps each style in one of these environments
+	header.WriteString("% Quotes, pull-quotes and epigraphs\n")
+	header.WriteString("\\newenvironment{docgenquote}{\\begin{quote}\\itshape}{\\end{quote}}\n")
+	header.WriteString("\\newenvironment{docgenpullquote}{\\par\\bigskip\\begin{center}\\begin{minipage}{0.8\\linewidth}\\centering\\color{headingcolor}\\rule{\\linewidth}{0.8pt}\\par\\medskip\\Large\\itshape}")
+	header.WriteString("{\\par\\medskip\\rule{\\linewidth}{0.8pt}\\end{minipage}\\end{center}\\bigskip}\n")
+	header.WriteString("\\newenvironment{docgenepigraph}{\\par\\begin{flushright}\\begin{minipage}{0.55\\linewidth}\\raggedleft\\small\\itshape}{\\end{minipage}\\end{flushright}\\bigskip}\n")
+	header.WriteString("\\newenvironment{docgenattribution}{\\par\\smallskip\\raggedleft\\upshape\\normalsize}{\\par}\n\n")
+	
+	// Layout blocks are set in multicols environments
+	header.WriteString("% Column layouts\n")
+	header.WriteString("\\usepackage{multicol}\n\n")
+	
+	// Chapter and block style overrides turn pages with pdflscape and shade backgrounds with framed
+	header.WriteString("% Style overrides\n")
+	header.WriteString("\\usepackage{pdflscape}\n")
+	header.WriteString("\\usepackage{framed}\n")
+	header.WriteString("\\usepackage{needspace}\n\n")
+	
+	// The cover page may be the only image, and its background is drawn with eso-pic
+	header.WriteString("% Cover page\n")
+	header.WriteString("\\usepackage{graphicx}\n")
+	header.WriteString("\\usepackage{eso-pic}\n\n")
+	
+	// {@index:term} markers become \index entries; imakeidx runs makeindex itself
+	header.WriteString("% Back-of-book index\n")
+	header.WriteString("\\usepackage{imakeidx}\n")
+	header.WriteString("\\makeindex[intoc]\n\n")
+	
 	// Headers and footers using fancyhdr - FIXED with AtBeginDocument
 	if styleConfig.Header.Enabled || styleConfig.Footer.Enabled {
 		header.WriteString("% Headers and footers\n")
@@ -1,6 +1,8 @@
 package export
 
 import (
+	"archive/zip"
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -374,4 +376,96 @@ func TestUnicodeHandling(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// TestTOCArgs tests the table of contents arguments passed to Pandoc
+func TestTOCArgs(t *testing.T) {
+	wrapper := NewPandocWrapper()
+
+	styleConfig := style.GetDefaultStyle()
+	if args := wrapper.tocArgs(styleConfig); len(args) != 0 {
+		t.Errorf("Expected no TOC args when disabled, got %v", args)
+	}
+
+	styleConfig.TOC = style.TOCConfig{Enabled: true, Depth: 2, Title: "Inhalt"}
+	args := strings.Join(wrapper.tocArgs(styleConfig), " ")
+	for _, expected := range []string{"--toc", "--toc-depth=2", "toc-title=Inhalt"} {
+		if !strings.Contains(args, expected) {
+			t.Errorf("Expected TOC args to contain %q, got %q", expected, args)
+		}
+	}
+
+	// Out of range depth falls back to 3
+	styleConfig.TOC.Depth = 9
+	args = strings.Join(wrapper.tocArgs(styleConfig), " ")
+	if !strings.Contains(args, "--toc-depth=3") {
+		t.Errorf("Expected fallback depth 3, got %q", args)
+	}
+}
+
+// TestReferenceDocPageGeometry tests that the reference document's final section gets the style's geometry
+func TestReferenceDocPageGeometry(t *testing.T) {
+	geometry := `<w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440"/>`
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "replaces letter geometry after footer",
+			input:    `<w:body><w:p/><w:sectPr><w:footerReference r:id="rId1"/><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="1000"/><w:cols w:space="720"/></w:sectPr></w:body>`,
+			expected: `<w:body><w:p/><w:sectPr><w:footerReference r:id="rId1"/>` + geometry + `<w:cols w:space="720"/></w:sectPr></w:body>`,
+		},
+		{
+			name:     "fills an empty section",
+			input:    `<w:body><w:p/><w:sectPr/></w:body>`,
+			expected: `<w:body><w:p/><w:sectPr>` + geometry + `</w:sectPr></w:body>`,
+		},
+		{
+			name:     "adds a missing section",
+			input:    `<w:body><w:p/></w:body>`,
+			expected: `<w:body><w:p/><w:sectPr>` + geometry + `</w:sectPr></w:body>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setSectionGeometry(tt.input, geometry); got != tt.expected {
+				t.Errorf("setSectionGeometry:\n got %s\nwant %s", got, tt.expected)
+			}
+		})
+	}
+
+	// The rest of the package is copied unchanged
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"word/document.xml": tests[0].input,
+		"word/styles.xml":   "<w:styles/>",
+	} {
+		w, _ := writer.Create(name)
+		w.Write([]byte(content))
+	}
+	writer.Close()
+
+	data, err := withPageGeometry(buf.Bytes(), geometry)
+	if err != nil {
+		t.Fatalf("withPageGeometry failed: %v", err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Result is not a zip: %v", err)
+	}
+	files := map[string]string{}
+	for _, file := range reader.File {
+		rc, _ := file.Open()
+		content, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(content)
+	}
+	if files["word/document.xml"] != tests[0].expected {
+		t.Errorf("document.xml not updated: %s", files["word/document.xml"])
+	}
+	if files["word/styles.xml"] != "<w:styles/>" {
+		t.Errorf("styles.xml changed: %s", files["word/styles.xml"])
+	}
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// quoteLaTeXEnvironments names the environments the LaTeX header defines for
+// each quote style
+var quoteLaTeXEnvironments = map[string]string{
+	blocks.QuoteBlockquote: "docgenquote",
+	blocks.QuotePullQuote:  "docgenpullquote",
+	blocks.QuoteEpigraph:   "docgenepigraph",
+}
+
+// quoteDOCXStyles names the Word paragraph styles used for each quote style.
+// "Block Text" is the style Pandoc's reference document uses for blockquotes.
+var quoteDOCXStyles = map[string]string{
+	blocks.QuoteBlockquote: "Block Text",
+	blocks.QuotePullQuote:  "Pull Quote",
+	blocks.QuoteEpigraph:   "Epigraph",
+}
+
+// quoteToMarkdown emits a quote styled for the export format. PDF wraps the text
+// in an environment from the LaTeX header; other formats use a div whose class
+// the HTML stylesheet styles and whose custom-style names a DOCX paragraph style.
+func (mb *MarkdownBuilder) quoteToMarkdown(bc *buildContext, blockRef blocks.BlockReference, quote *blocks.QuoteBlock) string {
+	text := bc.resolveText(strings.TrimSpace(quote.Text))
+	attribution := bc.resolveText(quote.AttributionText())
+
+	if bc.format == "pdf" {
+		env := quoteLaTeXEnvironments[quote.Style]
+		parts := []string{rawBlock(blocks.RawLaTeX, fmt.Sprintf("\\begin{%s}", env)), text}
+		if attribution != "" {
+			parts = append(parts,
+				rawBlock(blocks.RawLaTeX, "\\begin{docgenattribution}"),
+				attribution,
+				rawBlock(blocks.RawLaTeX, "\\end{docgenattribution}"))
+		}
+		parts = append(parts, rawBlock(blocks.RawLaTeX, fmt.Sprintf("\\end{%s}", env)))
+		return labelDiv(blockRef.Label, strings.Join(parts, "\n\n"))
+	}
+
+	var sb strings.Builder
+	id := ""
+	if blockRef.Label != "" {
+		id = "#" + blockRef.Label + " "
+	}
+	sb.WriteString(fmt.Sprintf(":::: {%s.quote .%s custom-style=\"%s\"}\n", id, quote.Style, quoteDOCXStyles[quote.Style]))
+	sb.WriteString(text + "\n")
+	if attribution != "" {
+		sb.WriteString("\n::: {.attribution custom-style=\"Attribution\"}\n" + attribution + "\n:::\n")
+	}
+	sb.WriteString("::::")
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// rawFormats maps export formats to the Pandoc raw format their writer accepts
+var rawFormats = map[string]string{
+	"pdf":  blocks.RawLaTeX,
+	"html": blocks.RawHTML,
+	"docx": blocks.RawOpenXML,
+}
+
+// Page breaks in each raw format. HTML has no pages, so the break only applies
+// when the page is printed.
+const (
+	latexPageBreak   = `\newpage`
+	htmlPageBreak    = `<div style="page-break-after: always;"></div>`
+	openXMLPageBreak = `<w:p><w:r><w:br w:type="page"/></w:r></w:p>`
+)
+
+// rawBlock wraps content in a Pandoc raw attribute block, which Pandoc passes
+// through to writers of that format and drops for all others
+func rawBlock(format, content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("%s{=%s}\n%s\n%s", fence, format, strings.TrimRight(content, "\n"), fence)
+}
+
+// rawToMarkdown emits a raw block only for the export format it targets.
+// Format-neutral markdown keeps every raw block and lets Pandoc choose.
+func rawToMarkdown(bc *buildContext, raw *blocks.RawBlock) string {
+	if bc.format != "" && rawFormats[bc.format] != raw.Format {
+		return ""
+	}
+	return rawBlock(raw.Format, raw.Content)
+}
+
+// pageBreakToMarkdown emits a page break in the form the export format understands
+func pageBreakToMarkdown(format string) string {
+	switch format {
+	case "pdf":
+		return rawBlock(blocks.RawLaTeX, latexPageBreak)
+	case "html":
+		return rawBlock(blocks.RawHTML, htmlPageBreak)
+	case "docx":
+		return rawBlock(blocks.RawOpenXML, openXMLPageBreak)
+	default:
+		return strings.Join([]string{
+			rawBlock(blocks.RawLaTeX, latexPageBreak),
+			rawBlock(blocks.RawHTML, htmlPageBreak),
+			rawBlock(blocks.RawOpenXML, openXMLPageBreak),
+		}, "\n\n")
+	}
+}
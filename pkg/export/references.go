@@ -0,0 +1,494 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/storage"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+)
+
+// refPattern matches cross-reference placeholders such as {@ref:fig-arch}
+var refPattern = regexp.MustCompile(`\{@ref:([A-Za-z0-9][A-Za-z0-9_.:-]*)\}`)
+
+// RefKind identifies what a labelled block is numbered as
+type RefKind string
+
+const (
+	RefSection  RefKind = "section"
+	RefFigure   RefKind = "figure"
+	RefTable    RefKind = "table"
+	RefEquation RefKind = "equation"
+)
+
+// Caption numbering modes
+const (
+	NumberingGlobal  = "global"
+	NumberingChapter = "chapter"
+	NumberingNone    = "none"
+)
+
+// RefTarget is a numbered or labelled block with its resolved number
+type RefTarget struct {
+	Label     string  `json:"label,omitempty"`
+	BlockID   string  `json:"block_id"`
+	ChapterID string  `json:"chapter_id,omitempty"`
+	Kind      RefKind `json:"kind"`
+	Number    string  `json:"number"`
+	Anchor    string  `json:"anchor"`
+	Caption   string  `json:"caption,omitempty"`
+	Prefix    string  `json:"-"` // Configured caption prefix, e.g. "Abbildung"
+}
+
+// DisplayText returns the human readable reference text, e.g. "Figure 3"
+func (t RefTarget) DisplayText() string {
+	if t.Number == "" {
+		return t.Label
+	}
+	if t.Prefix != "" {
+		return t.Prefix + " " + t.Number
+	}
+	switch t.Kind {
+	case RefFigure:
+		return "Figure " + t.Number
+	case RefTable:
+		return "Table " + t.Number
+	case RefEquation:
+		return "Equation " + t.Number
+	default:
+		return "Section " + t.Number
+	}
+}
+
+// DanglingReference describes a {@ref:...} that points at an unknown label
+type DanglingReference struct {
+	Label     string `json:"label"`
+	BlockID   string `json:"block_id"`
+	ChapterID string `json:"chapter_id,omitempty"`
+}
+
+// ReferenceIndex maps labels to numbered targets for a single document
+type ReferenceIndex struct {
+	targets  map[string]RefTarget // by label
+	numbered map[string]RefTarget // by block anchor
+	figures  []RefTarget
+	tables   []RefTarget
+
+	// Heading numbers by block anchor and chapter ID. They are only kept when
+	// the document cites a section, since the headings then show them.
+	headings map[string]string
+	chapters map[string]string
+}
+
+// blockAnchor returns the identifier emitted for a block. Block IDs are only unique
+// within a chapter, so chapter blocks are prefixed with their chapter ID.
+func blockAnchor(chapterID string, ref blocks.BlockReference) string {
+	if ref.Label != "" {
+		return ref.Label
+	}
+	if chapterID != "" {
+		return chapterID + "-" + ref.ID
+	}
+	return ref.ID
+}
+
+// Numbered returns the figure, table or equation number assigned to a block
+func (ri *ReferenceIndex) Numbered(chapterID string, ref blocks.BlockReference) (RefTarget, bool) {
+	target, ok := ri.numbered[blockAnchor(chapterID, ref)]
+	return target, ok
+}
+
+// SectionNumber returns the number a heading shows, or "" when sections are not numbered
+func (ri *ReferenceIndex) SectionNumber(chapterID string, ref blocks.BlockReference) string {
+	return ri.headings[blockAnchor(chapterID, ref)]
+}
+
+// ChapterNumber returns the number a chapter title shows, or "" when sections are not numbered
+func (ri *ReferenceIndex) ChapterNumber(chapterID string) string {
+	return ri.chapters[chapterID]
+}
+
+// labelsOnly returns an index that resolves labels like ri but numbers no blocks.
+// Blocks included from elsewhere are converted with it, so their IDs cannot pick
+// up the numbers of this document's blocks.
+func (ri *ReferenceIndex) labelsOnly() *ReferenceIndex {
+	return &ReferenceIndex{targets: ri.targets, numbered: map[string]RefTarget{}}
+}
+
+// Figures returns numbered figures in document order
+func (ri *ReferenceIndex) Figures() []RefTarget {
+	return ri.figures
+}
+
+// Tables returns numbered tables in document order
+func (ri *ReferenceIndex) Tables() []RefTarget {
+	return ri.tables
+}
+
+// Lookup returns the target for a label
+func (ri *ReferenceIndex) Lookup(label string) (RefTarget, bool) {
+	target, ok := ri.targets[label]
+	return target, ok
+}
+
+// Resolve replaces {@ref:label} placeholders with links to the numbered target.
+// Unknown labels are rendered as "??" so they stand out in the output, as LaTeX does.
+func (ri *ReferenceIndex) Resolve(text string) string {
+	return refPattern.ReplaceAllStringFunc(text, func(match string) string {
+		label := refPattern.FindStringSubmatch(match)[1]
+		target, ok := ri.Lookup(label)
+		if !ok {
+			return "??"
+		}
+		return fmt.Sprintf("[%s](#%s)", target.DisplayText(), target.Anchor)
+	})
+}
+
+// ResolvePlain replaces {@ref:label} placeholders with the target's display
+// text, for places such as link labels where a link cannot be nested
+func (ri *ReferenceIndex) ResolvePlain(text string) string {
+	return refPattern.ReplaceAllStringFunc(text, func(match string) string {
+		target, ok := ri.Lookup(refPattern.FindStringSubmatch(match)[1])
+		if !ok {
+			return "??"
+		}
+		return target.DisplayText()
+	})
+}
+
+// sectionCounter tracks hierarchical heading numbers such as 4.1.2
+type sectionCounter struct {
+	counts [6]int
+}
+
+// next advances the counter for a heading level and returns its number
+func (sc *sectionCounter) next(level int) string {
+	if level < 1 {
+		level = 1
+	}
+	if level > 6 {
+		level = 6
+	}
+	sc.counts[level-1]++
+	for i := level; i < len(sc.counts); i++ {
+		sc.counts[i] = 0
+	}
+	return sc.current()
+}
+
+// current returns the number of the innermost open section
+func (sc *sectionCounter) current() string {
+	last := -1
+	for i, c := range sc.counts {
+		if c > 0 {
+			last = i
+		}
+	}
+	if last < 0 {
+		return ""
+	}
+
+	// Skip leading unused levels so documents starting at h2 read "1" rather than "0.1"
+	first := 0
+	for first < last && sc.counts[first] == 0 {
+		first++
+	}
+
+	parts := make([]string, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		parts = append(parts, strconv.Itoa(sc.counts[i]))
+	}
+	return strings.Join(parts, ".")
+}
+
+// CaptionText returns the numbered caption, e.g. "Figure 2.3: Architecture"
+func (t RefTarget) CaptionText() string {
+	if t.Caption == "" {
+		return t.DisplayText()
+	}
+	return t.DisplayText() + ": " + t.Caption
+}
+
+// indexWalker visits every block of a document in export order
+type indexWalker struct {
+	captions  style.CaptionConfig
+	chapter   int
+	sections  sectionCounter
+	figures   int
+	tables    int
+	equations int
+}
+
+// enterChapter starts a new top-level section, resets per-chapter counters and
+// returns the chapter's section number
+func (w *indexWalker) enterChapter() string {
+	w.chapter++
+	number := w.sections.next(1)
+	if w.captions.Numbering == NumberingChapter {
+		w.figures, w.tables, w.equations = 0, 0, 0
+	}
+	return number
+}
+
+// format renders a float counter, prefixed by the chapter number in chapter mode
+func (w *indexWalker) format(n int) string {
+	if w.captions.Numbering == NumberingChapter && w.chapter > 0 {
+		return fmt.Sprintf("%d.%d", w.chapter, n)
+	}
+	return strconv.Itoa(n)
+}
+
+// number assigns a kind and number to a block, advancing the relevant counters.
+// Figures and tables are only numbered when they carry a caption or a label,
+// mirroring how LaTeX numbers captioned floats.
+func (w *indexWalker) number(ref blocks.BlockReference, block blocks.Block) (kind RefKind, number string, caption string, counted bool) {
+	switch b := block.(type) {
+	case *blocks.HeadingBlock:
+		return RefSection, w.sections.next(b.Level), "", false
+	case *blocks.ImageBlock:
+		if b.Caption != "" || ref.Label != "" {
+			w.figures++
+			return RefFigure, w.format(w.figures), b.Caption, true
+		}
+	case *blocks.ChartBlock:
+		if b.Caption != "" || ref.Label != "" {
+			w.figures++
+			return RefFigure, w.format(w.figures), b.Caption, true
+		}
+	case *blocks.GalleryBlock:
+		if b.Caption != "" || ref.Label != "" {
+			w.figures++
+			return RefFigure, w.format(w.figures), b.Caption, true
+		}
+	case *blocks.DiagramBlock:
+		if b.Caption != "" || ref.Label != "" {
+			w.figures++
+			return RefFigure, w.format(w.figures), b.Caption, true
+		}
+	case *blocks.TableBlock:
+		if b.Caption != "" || ref.Label != "" {
+			w.tables++
+			return RefTable, w.format(w.tables), b.Caption, true
+		}
+	case *blocks.MarkdownBlock:
+		if ref.Label != "" && isDisplayEquation(b.Content) {
+			w.equations++
+			return RefEquation, w.format(w.equations), "", true
+		}
+	}
+	// Other blocks refer to the section they appear in
+	return RefSection, w.sections.current(), "", false
+}
+
+// isDisplayEquation reports whether markdown content is a single display math block
+func isDisplayEquation(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasPrefix(trimmed, "$$") && strings.HasSuffix(trimmed, "$$") && len(trimmed) > 4
+}
+
+// tagEquation writes an equation's number into its display math: a \tag for
+// LaTeX, and for the other formats, whose math converters do not number
+// equations, the number set after the formula
+func tagEquation(content, number, format string) string {
+	trimmed := strings.TrimSpace(content)
+	math := strings.TrimSpace(trimmed[2 : len(trimmed)-2])
+	if format == "pdf" {
+		return fmt.Sprintf("$$%s \\tag{%s}$$", math, number)
+	}
+	return fmt.Sprintf("$$%s \\qquad (%s)$$", math, number)
+}
+
+// blockText returns the text of a block in which {@ref:...} placeholders are
+// resolved on export
+func blockText(block blocks.Block) []string {
+	switch b := block.(type) {
+	case *blocks.MarkdownBlock:
+		return []string{b.Content}
+	case *blocks.ImageBlock:
+		return []string{b.Caption}
+	case *blocks.ChartBlock:
+		return []string{b.Caption}
+	case *blocks.DiagramBlock:
+		return []string{b.Caption}
+	case *blocks.GalleryBlock:
+		text := []string{b.Caption}
+		for _, image := range b.Images {
+			text = append(text, image.Caption)
+		}
+		return text
+	case *blocks.TableBlock:
+		text := append([]string{b.Caption}, b.Headers...)
+		for _, row := range b.Rows {
+			text = append(text, row...)
+		}
+		return text
+	case *blocks.ListBlock:
+		return listItemText(b.Items)
+	case *blocks.QuoteBlock:
+		return []string{b.Text, b.Attribution, b.Source}
+	}
+	return nil
+}
+
+// listItemText returns the text of list items and their nested items
+func listItemText(items []blocks.ListItem) []string {
+	var text []string
+	for _, item := range items {
+		text = append(text, item.Text)
+		text = append(text, listItemText(item.Items)...)
+	}
+	return text
+}
+
+// citedLabels returns the labels a block refers to
+func citedLabels(block blocks.Block) []string {
+	var labels []string
+	for _, text := range blockText(block) {
+		for _, match := range refPattern.FindAllStringSubmatch(text, -1) {
+			labels = append(labels, match[1])
+		}
+	}
+	return labels
+}
+
+// BuildReferenceIndex numbers every captioned or labelled block in a document.
+// Chapters count as top-level sections, matching how BuildMarkdown emits them as H1.
+func BuildReferenceIndex(stor *storage.Storage, docID string, captions style.CaptionConfig) (*ReferenceIndex, error) {
+	return BuildReferenceIndexForProfile(stor, docID, captions, nil)
+}
+
+// BuildReferenceIndexForProfile numbers the blocks exported for a profile, so
+// numbering has no gaps where conditional content is left out
+func BuildReferenceIndexForProfile(stor *storage.Storage, docID string, captions style.CaptionConfig, profile blocks.Profile) (*ReferenceIndex, error) {
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	index := &ReferenceIndex{
+		targets:  make(map[string]RefTarget),
+		numbered: make(map[string]RefTarget),
+		headings: make(map[string]string),
+		chapters: make(map[string]string),
+	}
+	walker := &indexWalker{captions: captions}
+	var cited []string
+
+	visit := func(chapterID string, refs []blocks.BlockReference) {
+		for _, ref := range refs {
+			if !ref.Conditions.Matches(profile) {
+				continue
+			}
+			block, err := stor.LoadBlock(docID, ref)
+			if err != nil {
+				continue
+			}
+			cited = append(cited, citedLabels(block)...)
+			kind, number, caption, counted := walker.number(ref, block)
+			target := RefTarget{
+				Label:     ref.Label,
+				BlockID:   ref.ID,
+				ChapterID: chapterID,
+				Kind:      kind,
+				Number:    number,
+				Anchor:    blockAnchor(chapterID, ref),
+				Caption:   caption,
+			}
+			switch kind {
+			case RefFigure:
+				target.Prefix = captions.FigurePrefix
+			case RefTable:
+				target.Prefix = captions.TablePrefix
+			}
+			if _, heading := block.(*blocks.HeadingBlock); heading {
+				index.headings[target.Anchor] = number
+			}
+			if counted {
+				index.numbered[target.Anchor] = target
+				switch kind {
+				case RefFigure:
+					index.figures = append(index.figures, target)
+				case RefTable:
+					index.tables = append(index.tables, target)
+				}
+			}
+			if ref.Label != "" {
+				index.targets[ref.Label] = target
+			}
+		}
+	}
+
+	visit("", doc.Blocks)
+	for _, chapterRef := range doc.Chapters {
+		if !chapterRef.Conditions.Matches(profile) {
+			continue
+		}
+		chapter, err := stor.GetChapter(docID, chapterRef.ID)
+		if err != nil {
+			continue
+		}
+		index.chapters[chapterRef.ID] = walker.enterChapter()
+		visit(chapterRef.ID, chapter.Blocks)
+	}
+
+	// Section references point at the numbers headings show, so the headings
+	// are numbered when a section is cited
+	citesSection := false
+	for _, label := range cited {
+		if target, ok := index.targets[label]; ok && target.Kind == RefSection && target.Number != "" {
+			citesSection = true
+			break
+		}
+	}
+	if !citesSection {
+		index.headings, index.chapters = nil, nil
+	}
+
+	return index, nil
+}
+
+// FindDanglingReferences reports every {@ref:...} in block text whose label does not exist
+func FindDanglingReferences(stor *storage.Storage, docID string) ([]DanglingReference, error) {
+	index, err := BuildReferenceIndex(stor, docID, style.GetDefaultStyle().Captions)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	dangling := []DanglingReference{}
+	check := func(chapterID string, refs []blocks.BlockReference) {
+		for _, ref := range refs {
+			block, err := stor.LoadBlock(docID, ref)
+			if err != nil {
+				continue
+			}
+			for _, label := range citedLabels(block) {
+				if _, ok := index.Lookup(label); !ok {
+					dangling = append(dangling, DanglingReference{
+						Label:     label,
+						BlockID:   ref.ID,
+						ChapterID: chapterID,
+					})
+				}
+			}
+		}
+	}
+
+	check("", doc.Blocks)
+	for _, chapterRef := range doc.Chapters {
+		chapter, err := stor.GetChapter(docID, chapterRef.ID)
+		if err != nil {
+			continue
+		}
+		check(chapterRef.ID, chapter.Blocks)
+	}
+
+	return dangling, nil
+}
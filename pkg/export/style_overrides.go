@@ -0,0 +1,158 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+)
+
+// latexAlignments switches LaTeX paragraphs to an alignment; text is justified by default
+var latexAlignments = map[string]string{
+	style.AlignLeft:   `\raggedright`,
+	style.AlignCenter: `\centering`,
+	style.AlignRight:  `\raggedleft`,
+}
+
+// styled wraps a chapter's or block's content in its style override: LaTeX
+// environments and groups for PDF, section breaks for DOCX, and a styled div
+// for HTML and format-neutral markdown. surrounding is the style the content
+// would otherwise have, which decides whether the page orientation changes,
+// and boxed sets the content in a minipage to keep it on one page.
+func (bc *buildContext) styled(override *style.StyleOverride, surrounding style.StyleConfig, content string, boxed bool) string {
+	if override.IsEmpty() || strings.TrimSpace(content) == "" {
+		return content
+	}
+	content = strings.TrimRight(content, "\n")
+	rotated := override.Orientation != "" && override.Orientation != pageOrientation(surrounding)
+
+	switch bc.format {
+	case "pdf":
+		return latexStyled(override, rotated && override.Orientation == style.OrientationLandscape, boxed, content)
+	case "docx":
+		if !rotated {
+			return content
+		}
+		return docxRotated(surrounding, bc.styles.ApplyOverride(surrounding, override), content)
+	default:
+		return htmlStyled(override, rotated && override.Orientation == style.OrientationLandscape, content)
+	}
+}
+
+// boxed tells whether a block's style override sets it in a minipage. Tables
+// are set as longtables, which cannot go in a box, so keepTable keeps them
+// together instead.
+func (bc *buildContext) boxed(override *style.StyleOverride, block blocks.Block) bool {
+	_, table := block.(*blocks.TableBlock)
+	return bc.format == "pdf" && override != nil && override.KeepTogether && !table
+}
+
+// keepTable starts a table on a new page unless the lines it takes still fit
+// on the current one. Each row counts as one line, and a grid table's row as
+// the lines of text it holds.
+func keepTable(content string) string {
+	lines := 2
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "|") || strings.HasPrefix(line, ": ") {
+			lines++
+		}
+	}
+	return rawBlock(blocks.RawLaTeX, fmt.Sprintf(`\needspace{%d\baselineskip}`, lines)) + "\n\n" + content
+}
+
+// pageOrientation reads a style's orientation, which is portrait unless set to landscape
+func pageOrientation(styleConfig style.StyleConfig) string {
+	if strings.EqualFold(styleConfig.Page.Orientation, style.OrientationLandscape) {
+		return style.OrientationLandscape
+	}
+	return style.OrientationPortrait
+}
+
+// latexStyled opens, from the outside in, a landscape page (pdflscape), a
+// minipage that cannot break across pages when boxed, a shaded background
+// (framed), and a group setting the font size, color and alignment. LaTeX
+// cannot turn pages back to portrait inside a landscape document, so only
+// landscape is applied.
+func latexStyled(override *style.StyleOverride, landscape, boxed bool, content string) string {
+	var begin, end []string
+	wrap := func(open, close string) {
+		begin = append(begin, open)
+		end = append([]string{close}, end...)
+	}
+
+	if landscape {
+		wrap(`\begin{landscape}`, `\end{landscape}`)
+	}
+	if boxed {
+		wrap(`\par\noindent\begin{minipage}{\linewidth}`, `\end{minipage}\par`)
+	}
+	if override.Background != "" {
+		wrap(fmt.Sprintf(`\begingroup\definecolor{shadecolor}{RGB}{%s}\begin{snugshade}`, rgb(override.Background)),
+			`\end{snugshade}\endgroup`)
+	}
+
+	var group strings.Builder
+	if override.FontSize != 0 {
+		group.WriteString(fmt.Sprintf(`\fontsize{%d}{%.1f}\selectfont`, override.FontSize, float64(override.FontSize)*1.2))
+	}
+	if override.Color != "" {
+		group.WriteString(fmt.Sprintf(`\color[RGB]{%s}`, rgb(override.Color)))
+	}
+	group.WriteString(latexAlignments[override.Align])
+	if group.Len() > 0 {
+		wrap(`\begingroup`+group.String(), `\par\endgroup`)
+	}
+
+	if len(begin) == 0 {
+		return content
+	}
+	return rawBlock(blocks.RawLaTeX, strings.Join(begin, "\n")) + "\n\n" + content + "\n\n" + rawBlock(blocks.RawLaTeX, strings.Join(end, "\n"))
+}
+
+// docxRotated sets content on pages of its own orientation. Word sections are
+// described by the paragraph that ends them, so the first break ends the
+// preceding pages in the surrounding geometry and the second ends the rotated
+// pages. Other overrides have no Word equivalent without a reference document.
+func docxRotated(surrounding, rotated style.StyleConfig, content string) string {
+	before := fmt.Sprintf(`<w:p><w:pPr><w:sectPr>%s</w:sectPr></w:pPr></w:p>`, docxPageGeometry(surrounding))
+	after := fmt.Sprintf(`<w:p><w:pPr><w:sectPr>%s</w:sectPr></w:pPr></w:p>`, docxPageGeometry(rotated))
+	return rawBlock(blocks.RawOpenXML, before) + "\n\n" + content + "\n\n" + rawBlock(blocks.RawOpenXML, after)
+}
+
+// htmlStyled wraps content in a div with the override as inline CSS. The
+// stylesheet turns .landscape content into landscape pages when printed and
+// keeps .keep-together content on one page.
+func htmlStyled(override *style.StyleOverride, landscape bool, content string) string {
+	attrs := []string{".styled"}
+	if landscape {
+		attrs = append(attrs, ".landscape")
+	}
+	if override.KeepTogether {
+		attrs = append(attrs, ".keep-together")
+	}
+
+	var css []string
+	if override.FontSize != 0 {
+		css = append(css, fmt.Sprintf("font-size: %dpt", override.FontSize))
+	}
+	if override.Color != "" {
+		css = append(css, fmt.Sprintf("color: rgb(%s)", rgb(override.Color)))
+	}
+	if override.Background != "" {
+		css = append(css, fmt.Sprintf("background-color: rgb(%s)", rgb(override.Background)), "padding: 0.5em 1em")
+	}
+	if override.Align != "" {
+		css = append(css, "text-align: "+override.Align)
+	}
+	if len(css) > 0 {
+		attrs = append(attrs, fmt.Sprintf(`style="%s"`, strings.Join(css, "; ")))
+	}
+
+	return fmt.Sprintf("::: {%s}\n%s\n:::", strings.Join(attrs, " "), content)
+}
+
+// rgb normalizes an "R, G, B" color to "R,G,B"
+func rgb(color string) string {
+	return strings.ReplaceAll(color, " ", "")
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/storage"
+	"github.com/savant/mcp-servers/docgen2/pkg/textdiff"
+)
+
+// Suggestion export modes
+const (
+	SuggestionsNone    = "none"    // Export blocks as they are (default)
+	SuggestionsTracked = "tracked" // Show pending suggestions as tracked changes
+)
+
+// ValidateSuggestionsMode checks a suggestion export mode
+func ValidateSuggestionsMode(mode string) error {
+	switch mode {
+	case "", SuggestionsNone, SuggestionsTracked:
+		return nil
+	default:
+		return fmt.Errorf("invalid suggestions mode %q (supported: none, tracked)", mode)
+	}
+}
+
+// blockMarkerPattern matches the markup that opens a markdown line, such as a
+// heading, list or quote marker, which must stay outside an inserted span
+var blockMarkerPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)]|#{1,6}|>)\s+`)
+
+// loadExportSuggestions returns the latest pending suggestion of each block,
+// keyed like comments, when the export shows tracked changes
+func loadExportSuggestions(stor *storage.Storage, docID, mode string) (map[string]*storage.Suggestion, error) {
+	if mode != SuggestionsTracked {
+		return nil, nil
+	}
+
+	suggestions, err := stor.ListSuggestions(docID)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*storage.Suggestion)
+	for i := range suggestions {
+		latest[commentKey(suggestions[i].ChapterID, suggestions[i].BlockID)] = &suggestions[i]
+	}
+	return latest, nil
+}
+
+// trackChanges replaces the text of a markdown or heading block with a word diff
+// against its pending suggestion. Other block types are exported as they are.
+func (mb *MarkdownBuilder) trackChanges(bc *buildContext, blockID string, block blocks.Block) error {
+	suggestion := bc.suggestions[commentKey(bc.chapterID, blockID)]
+	if suggestion == nil {
+		return nil
+	}
+	proposed, err := mb.storage.LoadSuggestedBlock(bc.docID, suggestion)
+	if err != nil {
+		return fmt.Errorf("failed to load suggestion %s: %w", suggestion.ID, err)
+	}
+	bc.vars.expandBlock(proposed)
+
+	switch b := block.(type) {
+	case *blocks.MarkdownBlock:
+		if edit, ok := proposed.(*blocks.MarkdownBlock); ok {
+			b.Content = bc.trackedText(suggestion, b.Content, edit.Content)
+		}
+	case *blocks.HeadingBlock:
+		if edit, ok := proposed.(*blocks.HeadingBlock); ok {
+			b.Text = bc.trackedText(suggestion, b.Text, edit.Text)
+		}
+	}
+	return nil
+}
+
+// trackedText marks the words a suggestion deletes and inserts
+func (bc *buildContext) trackedText(suggestion *storage.Suggestion, current, proposed string) string {
+	var result strings.Builder
+	for _, op := range textdiff.Words(current, proposed) {
+		switch op.Kind {
+		case textdiff.Delete:
+			// Deleted line breaks would split the new text's paragraphs
+			result.WriteString(bc.trackedSpan(suggestion, textdiff.Delete, strings.ReplaceAll(op.Text, "\n", " ")))
+		case textdiff.Insert:
+			lines := strings.Split(op.Text, "\n")
+			for i, line := range lines {
+				if i > 0 {
+					result.WriteString("\n")
+				}
+				marker := blockMarkerPattern.FindString(line)
+				result.WriteString(marker)
+				result.WriteString(bc.trackedSpan(suggestion, textdiff.Insert, line[len(marker):]))
+			}
+		default:
+			result.WriteString(op.Text)
+		}
+	}
+	return result.String()
+}
+
+// trackedSpan renders an insertion or deletion: a Word revision in DOCX, and
+// underlined or struck-through text elsewhere
+func (bc *buildContext) trackedSpan(suggestion *storage.Suggestion, kind, text string) string {
+	if strings.TrimSpace(text) == "" {
+		if kind == textdiff.Delete {
+			return ""
+		}
+		return text
+	}
+
+	switch bc.format {
+	case "docx":
+		class := "insertion"
+		if kind == textdiff.Delete {
+			class = "deletion"
+		}
+		return fmt.Sprintf(`[%s]{.%s author="%s" date="%s"}`, text, class,
+			strings.ReplaceAll(suggestion.Author, `"`, "'"), suggestion.CreatedAt.UTC().Format(time.RFC3339))
+	}
+
+	// Emphasis may not start or end with a space, so spaces stay outside the markup
+	core := strings.TrimSpace(text)
+	start := strings.Index(text, core)
+	lead, trail := text[:start], text[start+len(core):]
+	switch {
+	case bc.format == "pdf" && kind == textdiff.Delete:
+		return lead + "~~" + core + "~~" + trail
+	case bc.format == "pdf":
+		return lead + "[" + core + "]{.underline}" + trail
+	case kind == textdiff.Delete:
+		return lead + "[" + core + "]{.deletion}" + trail
+	default:
+		return lead + "[" + core + "]{.insertion}" + trail
+	}
+}
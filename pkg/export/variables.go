@@ -0,0 +1,230 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+	"github.com/savant/mcp-servers/docgen2/pkg/tableimport"
+)
+
+// variablePattern matches {{name}} placeholders; spaces inside the braces are allowed
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// variableNamePattern restricts variable names to what placeholders can reference
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateVariableName checks that a name can be used as a {{name}} placeholder
+func ValidateVariableName(name string) error {
+	if !variableNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid variable name %q: use letters, digits and '_', not starting with a digit", name)
+	}
+	return nil
+}
+
+// variableSet substitutes {{name}} placeholders. Placeholders without a value are
+// left as written and recorded, so text that merely looks like a placeholder
+// (e.g. a template in a code block) survives export.
+type variableSet struct {
+	values    map[string]string
+	undefined map[string]bool
+}
+
+// newVariableSet combines document variables with per-export overrides, such as
+// one row of a mail-merge dataset
+func newVariableSet(defined, overrides map[string]string) *variableSet {
+	values := make(map[string]string, len(defined)+len(overrides))
+	for name, value := range defined {
+		values[name] = value
+	}
+	for name, value := range overrides {
+		values[name] = value
+	}
+	return &variableSet{values: values, undefined: make(map[string]bool)}
+}
+
+// expand replaces the placeholders in text
+func (vs *variableSet) expand(text string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		value, ok := vs.values[name]
+		if !ok {
+			vs.undefined[name] = true
+			return match
+		}
+		return value
+	})
+}
+
+// expandAll replaces the placeholders in each string of a slice, in place
+func (vs *variableSet) expandAll(texts []string) {
+	for i := range texts {
+		texts[i] = vs.expand(texts[i])
+	}
+}
+
+// Undefined returns the names of placeholders that had no value, sorted
+func (vs *variableSet) Undefined() []string {
+	names := make([]string, 0, len(vs.undefined))
+	for name := range vs.undefined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expandBlock substitutes variables in the text fields of a block, in place.
+// Values are substituted before any format-specific escaping. Diagram source is
+// left alone, since its rendered output is cached by source.
+func (vs *variableSet) expandBlock(block blocks.Block) {
+	switch b := block.(type) {
+	case *blocks.HeadingBlock:
+		b.Text = vs.expand(b.Text)
+	case *blocks.MarkdownBlock:
+		b.Content = vs.expand(b.Content)
+	case *blocks.ImageBlock:
+		b.Caption = vs.expand(b.Caption)
+		b.AltText = vs.expand(b.AltText)
+	case *blocks.TableBlock:
+		vs.expandAll(b.Headers)
+		for _, row := range b.Rows {
+			vs.expandAll(row)
+		}
+		b.Caption = vs.expand(b.Caption)
+	case *blocks.ChartBlock:
+		b.Title = vs.expand(b.Title)
+		b.XLabel = vs.expand(b.XLabel)
+		b.YLabel = vs.expand(b.YLabel)
+		b.Caption = vs.expand(b.Caption)
+		vs.expandAll(b.Categories)
+		for i := range b.Series {
+			b.Series[i].Name = vs.expand(b.Series[i].Name)
+		}
+	case *blocks.DiagramBlock:
+		b.Caption = vs.expand(b.Caption)
+		b.AltText = vs.expand(b.AltText)
+	case *blocks.GalleryBlock:
+		b.Caption = vs.expand(b.Caption)
+		for i := range b.Images {
+			b.Images[i].Caption = vs.expand(b.Images[i].Caption)
+			b.Images[i].AltText = vs.expand(b.Images[i].AltText)
+		}
+	case *blocks.ListBlock:
+		vs.expandListItems(b.Items)
+	case *blocks.QuoteBlock:
+		b.Text = vs.expand(b.Text)
+		b.Attribution = vs.expand(b.Attribution)
+		b.Source = vs.expand(b.Source)
+	}
+}
+
+// expandListItems substitutes variables in list items and their nested items
+func (vs *variableSet) expandListItems(items []blocks.ListItem) {
+	for i := range items {
+		items[i].Text = vs.expand(items[i].Text)
+		vs.expandListItems(items[i].Items)
+	}
+}
+
+// expandStyle substitutes variables in header and footer templates, before
+// processTemplateVariables fills in {title}, {page} and the like
+func (vs *variableSet) expandStyle(styleConfig style.StyleConfig) style.StyleConfig {
+	styleConfig.Header.Content = vs.expand(styleConfig.Header.Content)
+	styleConfig.Footer.Content = vs.expand(styleConfig.Footer.Content)
+	return styleConfig
+}
+
+// LoadDataset reads mail-merge rows from a CSV, TSV or XLSX file whose first row
+// names the variables, or from a JSON array of objects
+func LoadDataset(path string) ([]map[string]string, error) {
+	var rows []map[string]string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dataset: %w", err)
+		}
+		var records []map[string]interface{}
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("failed to parse dataset: expected a JSON array of objects: %w", err)
+		}
+		rows, err = DatasetRows(records)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		table, err := tableimport.Import(blocks.TableSource{Path: path, Header: tableimport.HeaderFirstRow})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dataset: %w", err)
+		}
+		for _, header := range table.Headers {
+			if err := ValidateVariableName(header); err != nil {
+				return nil, fmt.Errorf("dataset column: %w", err)
+			}
+		}
+		for _, cells := range table.Rows {
+			row := make(map[string]string, len(table.Headers))
+			for i, header := range table.Headers {
+				if i < len(cells) {
+					row[header] = cells[i]
+				} else {
+					row[header] = ""
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("dataset %s has no rows", path)
+	}
+	return rows, nil
+}
+
+// VariableValue formats a JSON string, number or boolean as a variable value
+func VariableValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("must be a string, number or boolean")
+	}
+}
+
+// DatasetRows converts JSON objects to mail-merge rows. Values must be strings,
+// numbers, booleans or null.
+func DatasetRows(records []map[string]interface{}) ([]map[string]string, error) {
+	rows := make([]map[string]string, 0, len(records))
+	for i, record := range records {
+		row := make(map[string]string, len(record))
+		for name, value := range record {
+			if err := ValidateVariableName(name); err != nil {
+				return nil, fmt.Errorf("dataset row %d: %w", i+1, err)
+			}
+			if value == nil {
+				row[name] = ""
+				continue
+			}
+			text, err := VariableValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("dataset row %d: value of %q %w", i+1, name, err)
+			}
+			row[name] = text
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
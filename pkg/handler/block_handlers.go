@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/gomcpgo/mcp/pkg/protocol"
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
 	"github.com/savant/mcp-servers/docgen2/pkg/document"
+	"github.com/savant/mcp-servers/docgen2/pkg/tableimport"
 )
 
 // convertBlockToResponse converts a block to response format for JSON serialization
@@ -37,32 +39,753 @@ func (h *Handler) convertBlockToResponse(block blocks.Block, blockRef blocks.Blo
 		}
 		// If already absolute, use as-is for legacy compatibility
 		
-		return map[string]interface{}{
+		result := map[string]interface{}{
 			"id":       blockRef.ID,
 			"type":     "image",
 			"path":     imagePath,
 			"caption":  b.Caption,
 			"alt_text": b.AltText,
 		}
-	case *blocks.TableBlock:
-		return map[string]interface{}{
-			"id":      blockRef.ID,
-			"type":    "table",
-			"headers": b.Headers,
-			"rows":    b.Rows,
+		if b.Width != "" {
+			result["width"] = b.Width
+		}
+		if b.Align != "" {
+			result["align"] = b.Align
+		}
+		if b.Float != "" {
+			result["float"] = b.Float
+		}
+		if info, ok := h.storage.GetAssetInfo(docID, b.Path); ok {
+			result["asset"] = info
+		}
+		return result
+	case *blocks.TableBlock:
+		result := map[string]interface{}{
+			"id":            blockRef.ID,
+			"type":          "table",
+			"headers":       b.Headers,
+			"rows":          b.Rows,
+			"caption":       b.Caption,
+			"repeat_header": b.ShouldRepeatHeader(),
+		}
+		if len(b.Columns) > 0 {
+			columns := make([]map[string]interface{}, 0, len(b.Columns))
+			for _, col := range b.Columns {
+				columns = append(columns, map[string]interface{}{
+					"align": col.Align,
+					"width": col.Width,
+				})
+			}
+			result["columns"] = columns
+		}
+		if b.HeaderRows > 0 {
+			result["header_rows"] = b.HeaderRows
+		}
+		if len(b.Spans) > 0 {
+			result["spans"] = b.Spans
+		}
+		if b.Source != nil {
+			result["source"] = b.Source
+		}
+		return result
+	case *blocks.PageBreakBlock:
+		return map[string]interface{}{
+			"id":   blockRef.ID,
+			"type": "page_break",
+		}
+	case *blocks.ChartBlock:
+		return map[string]interface{}{
+			"id":         blockRef.ID,
+			"type":       "chart",
+			"chart_type": b.ChartType,
+			"title":      b.Title,
+			"x_label":    b.XLabel,
+			"y_label":    b.YLabel,
+			"categories": b.Categories,
+			"series":     b.Series,
+			"caption":    b.Caption,
+		}
+	case *blocks.GalleryBlock:
+		images := make([]map[string]interface{}, 0, len(b.Images))
+		for _, img := range b.Images {
+			image := map[string]interface{}{
+				"path":     img.Path,
+				"caption":  img.Caption,
+				"alt_text": img.AltText,
+			}
+			if info, ok := h.storage.GetAssetInfo(docID, img.Path); ok {
+				image["asset"] = info
+			}
+			images = append(images, image)
+		}
+		return map[string]interface{}{
+			"id":      blockRef.ID,
+			"type":    "gallery",
+			"images":  images,
+			"columns": b.ColumnCount(),
+			"caption": b.Caption,
+		}
+	case *blocks.DiagramBlock:
+		return map[string]interface{}{
+			"id":       blockRef.ID,
+			"type":     "diagram",
+			"engine":   b.Engine,
+			"source":   b.Source,
+			"caption":  b.Caption,
+			"alt_text": b.AltText,
+		}
+	case *blocks.ListBlock:
+		result := map[string]interface{}{
+			"id":    blockRef.ID,
+			"type":  "list",
+			"style": b.Style,
+			"items": listItemsResponse(b.Items, "", b.Style == blocks.ListTask),
+		}
+		if b.Start > 1 {
+			result["start"] = b.Start
+		}
+		return result
+	case *blocks.QuoteBlock:
+		return map[string]interface{}{
+			"id":          blockRef.ID,
+			"type":        "quote",
+			"style":       b.Style,
+			"text":        b.Text,
+			"attribution": b.Attribution,
+			"source":      b.Source,
+		}
+	case *blocks.LayoutBlock:
+		result := map[string]interface{}{
+			"id":      blockRef.ID,
+			"type":    "layout",
+			"columns": b.Columns,
+			"blocks":  b.Blocks,
+		}
+		if b.Gap != "" {
+			result["gap"] = b.Gap
+		}
+		if len(b.ColumnBreaks) > 0 {
+			result["column_breaks"] = b.ColumnBreaks
+		}
+		return result
+	case *blocks.RawBlock:
+		return map[string]interface{}{
+			"id":      blockRef.ID,
+			"type":    "raw",
+			"format":  b.Format,
+			"content": b.Content,
+		}
+	case *blocks.IncludeBlock:
+		result := map[string]interface{}{
+			"id":     blockRef.ID,
+			"type":   "include",
+			"source": b.Source(),
+		}
+		if b.Snippet != "" {
+			result["snippet_id"] = b.Snippet
+		} else {
+			result["source_document_id"] = b.Document
+			result["source_block_id"] = b.Block
+		}
+		// Show what the include currently resolves to
+		if target, err := h.storage.ResolveInclude(b); err != nil {
+			result["error"] = err.Error()
+		} else if target.DocID != "" {
+			result["resolved"] = h.convertBlockToResponse(target.Block, target.Ref, target.DocID)
+		} else {
+			result["resolved"] = h.convertBlockToResponse(target.Block, target.Ref, docID)
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// convertBlockReferenceMetadata adds manifest-level block metadata to a block response
+func convertBlockReferenceMetadata(result map[string]interface{}, blockRef blocks.BlockReference) map[string]interface{} {
+	if result == nil {
+		return nil
+	}
+	if blockRef.Label != "" {
+		result["label"] = blockRef.Label
+	}
+	if len(blockRef.Tags) > 0 {
+		result["tags"] = blockRef.Tags
+	}
+	if len(blockRef.Conditions) > 0 {
+		result["conditions"] = blockRef.Conditions
+	}
+	result["status"] = blocks.StatusOf(blockRef.Status)
+	if blockRef.Locked {
+		result["locked"] = true
+	}
+	if blockRef.Style != nil {
+		result["style"] = blockRef.Style
+	}
+	return result
+}
+
+// handleAddHeading adds a heading block
+func (h *Handler) handleAddHeading(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	chapterID, _ := getString(args, "chapter_id", false)
+	
+	level, err := getInt(args, "level", 1)
+	if err != nil {
+		return nil, err
+	}
+	
+	if level < 1 || level > 6 {
+		return nil, fmt.Errorf("heading level must be between 1 and 6")
+	}
+	
+	text, err := getString(args, "text", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+	
+	heading := &blocks.HeadingBlock{
+		Level: level,
+		Text:  text,
+	}
+	
+	if err := h.storage.AddBlock(docID, chapterID, heading, position); err != nil {
+		return nil, fmt.Errorf("failed to add heading: %w", err)
+	}
+	
+	return successResponse(fmt.Sprintf("Added heading '%s' to document", text)), nil
+}
+
+// handleAddMarkdown adds a markdown block
+func (h *Handler) handleAddMarkdown(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	chapterID, _ := getString(args, "chapter_id", false)
+	
+	content, err := getString(args, "content", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+	
+	markdown := &blocks.MarkdownBlock{
+		Content: content,
+	}
+	
+	if err := h.storage.AddBlock(docID, chapterID, markdown, position); err != nil {
+		return nil, fmt.Errorf("failed to add markdown: %w", err)
+	}
+	
+	return successResponse("Added markdown block to document"), nil
+}
+
+// handleAddImage adds an image block
+func (h *Handler) handleAddImage(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	chapterID, _ := getString(args, "chapter_id", false)
+	
+	caption, _ := getString(args, "caption", false)
+	altText, _ := getString(args, "alt_text", false)
+	
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+	
+	image := &blocks.ImageBlock{
+		Caption: caption,
+		AltText: altText,
+	}
+	
+	if err := parseImageLayout(args, image); err != nil {
+		return nil, err
+	}
+	
+	// Store the image (from a path or base64 data) in the assets folder
+	assetPath, err := h.importImage(docID, args)
+	if err != nil {
+		return nil, err
+	}
+	image.Path = assetPath
+	
+	if err := h.storage.AddBlock(docID, chapterID, image, position); err != nil {
+		h.removeImageAssets(docID, []string{assetPath})
+		return nil, fmt.Errorf("failed to add image: %w", err)
+	}
+	
+	message := fmt.Sprintf("Added image block to document (block ID: %s)", image.ID)
+	if info, ok := h.storage.GetAssetInfo(docID, assetPath); ok {
+		message += fmt.Sprintf(": %s, %dx%d", info.ContentType, info.Width, info.Height)
+		if len(info.Changes) > 0 {
+			message += " (" + strings.Join(info.Changes, ", ") + ")"
+		}
+	}
+	return successResponse(message), nil
+}
+
+// importImage stores the image described by args in the document's assets. The
+// image comes from image_path, a file the server can read, or from image_data,
+// base64 data (optionally a data: URL) with a filename and mime_type.
+func (h *Handler) importImage(docID string, args map[string]interface{}) (string, error) {
+	imagePath := getStringFromMap(args, "image_path", "")
+	imageData := getStringFromMap(args, "image_data", "")
+	
+	switch {
+	case imagePath != "" && imageData != "":
+		return "", fmt.Errorf("provide either image_path or image_data, not both")
+	case imagePath != "":
+		assetPath, err := h.storage.CopyImageToAssets(docID, imagePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to copy image: %w", err)
+		}
+		return assetPath, nil
+	case imageData != "":
+		mimeType := getStringFromMap(args, "mime_type", "")
+		data, urlType, err := decodeImageData(imageData, h.config.MaxImageBytes())
+		if err != nil {
+			return "", err
+		}
+		if urlType != "" {
+			if mimeType != "" && !strings.EqualFold(mimeType, urlType) {
+				return "", fmt.Errorf("mime_type %s does not match data URL type %s", mimeType, urlType)
+			}
+			mimeType = urlType
+		}
+		assetPath, err := h.storage.AddImageAsset(docID, getStringFromMap(args, "filename", "image"), mimeType, data)
+		if err != nil {
+			return "", fmt.Errorf("failed to store image: %w", err)
+		}
+		return assetPath, nil
+	default:
+		return "", fmt.Errorf("image_path or image_data is required")
+	}
+}
+
+// imageSourceName names an image object's source without reading it, so a block
+// can be validated before any image is stored
+func imageSourceName(args map[string]interface{}) (string, error) {
+	if path := getStringFromMap(args, "image_path", ""); path != "" {
+		return path, nil
+	}
+	if getStringFromMap(args, "image_data", "") != "" {
+		return getStringFromMap(args, "filename", "image"), nil
+	}
+	return "", fmt.Errorf("image_path or image_data is required")
+}
+
+// decodeImageData decodes base64 image data, accepting a data: URL prefix whose
+// MIME type is returned. Oversized data is rejected before it is decoded.
+func decodeImageData(encoded string, maxBytes int64) ([]byte, string, error) {
+	mimeType := ""
+	if strings.HasPrefix(encoded, "data:") {
+		comma := strings.Index(encoded, ",")
+		if comma < 0 || !strings.HasSuffix(encoded[:comma], ";base64") {
+			return nil, "", fmt.Errorf("image_data data URL must be base64 encoded")
+		}
+		mimeType = strings.TrimSuffix(strings.TrimPrefix(encoded[:comma], "data:"), ";base64")
+		encoded = encoded[comma+1:]
+	}
+	
+	// Base64 may be wrapped across lines
+	encoded = strings.Join(strings.Fields(encoded), "")
+	if int64(base64.StdEncoding.DecodedLen(len(encoded))) > maxBytes+3 {
+		return nil, "", fmt.Errorf("image data is larger than the %.1f MB limit", float64(maxBytes)/(1<<20))
+	}
+	
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil {
+			return nil, "", fmt.Errorf("image_data is not valid base64: %w", err)
+		}
+	}
+	return data, mimeType, nil
+}
+
+// parseImageLayout reads the optional width, alignment and float of an image
+func parseImageLayout(args map[string]interface{}, image *blocks.ImageBlock) error {
+	image.Width, _ = getString(args, "width", false)
+	image.Align, _ = getString(args, "align", false)
+	image.Float, _ = getString(args, "float", false)
+	if err := image.Validate(); err != nil {
+		return fmt.Errorf("invalid image layout: %w", err)
+	}
+	return nil
+}
+
+// handleAddGallery adds a gallery of images laid out side by side
+func (h *Handler) handleAddGallery(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	chapterID, _ := getString(args, "chapter_id", false)
+	
+	gallery, err := h.importGallery(docID, args)
+	if err != nil {
+		return nil, err
+	}
+	
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+	
+	if err := h.storage.AddBlock(docID, chapterID, gallery, position); err != nil {
+		h.removeImageAssets(docID, blockImagePaths(gallery))
+		return nil, fmt.Errorf("failed to add gallery: %w", err)
+	}
+	
+	return successResponse(fmt.Sprintf("Added gallery with %d images (block ID: %s)", len(gallery.Images), gallery.ID)), nil
+}
+
+// importGallery parses a gallery whose images are given as files or base64 data
+// and stores each image in the document's assets
+func (h *Handler) importGallery(docID string, args map[string]interface{}) (*blocks.GalleryBlock, error) {
+	// Check the gallery before storing any of its images
+	if _, err := parseGallery(args, imageSourceName); err != nil {
+		return nil, err
+	}
+	
+	// Images stored before one fails are removed again
+	var stored []string
+	gallery, err := parseGallery(args, func(image map[string]interface{}) (string, error) {
+		path, err := h.importImage(docID, image)
+		if err == nil {
+			stored = append(stored, path)
+		}
+		return path, err
+	})
+	if err != nil {
+		h.removeImageAssets(docID, stored)
+		return nil, err
+	}
+	return gallery, nil
+}
+
+// removeImageAssets deletes images stored for a block that was not added.
+// Cleanup is best effort; the error that caused it is the one reported.
+func (h *Handler) removeImageAssets(docID string, paths []string) {
+	for _, path := range paths {
+		h.storage.RemoveImageAsset(docID, path)
+	}
+}
+
+// blockImagePaths returns the stored images of an image or gallery block
+func blockImagePaths(block blocks.Block) []string {
+	switch b := block.(type) {
+	case *blocks.ImageBlock:
+		return []string{b.Path}
+	case *blocks.GalleryBlock:
+		paths := make([]string, len(b.Images))
+		for i, image := range b.Images {
+			paths[i] = image.Path
+		}
+		return paths
+	}
+	return nil
+}
+
+// parseGallery reads and validates a gallery. resolve returns the stored path of
+// each image object.
+func parseGallery(args map[string]interface{}, resolve func(image map[string]interface{}) (string, error)) (*blocks.GalleryBlock, error) {
+	imagesData, ok := args["images"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("images must be an array")
+	}
+	
+	gallery := &blocks.GalleryBlock{}
+	for i, item := range imagesData {
+		imageMap, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("images[%d] must be an object", i)
+		}
+		path, err := resolve(imageMap)
+		if err != nil {
+			return nil, fmt.Errorf("images[%d]: %w", i, err)
+		}
+		gallery.Images = append(gallery.Images, blocks.GalleryImage{
+			Path:    path,
+			Caption: getStringFromMap(imageMap, "caption", ""),
+			AltText: getStringFromMap(imageMap, "alt_text", ""),
+		})
+	}
+	columns, err := getInt(args, "columns", 0)
+	if err != nil {
+		return nil, err
+	}
+	gallery.Columns = columns
+	gallery.Caption, _ = getString(args, "caption", false)
+	
+	if err := gallery.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid gallery: %w", err)
+	}
+	return gallery, nil
+}
+
+// handleAddTable adds a table block
+func (h *Handler) handleAddTable(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	chapterID, _ := getString(args, "chapter_id", false)
+	
+	headers, err := getStringArray(args, "headers", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	rows, err := getStringArray2D(args, "rows", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	caption, _ := getString(args, "caption", false)
+	
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+	
+	table := &blocks.TableBlock{
+		Headers: headers,
+		Rows:    rows,
+		Caption: caption,
+	}
+	
+	if err := parseTableLayout(args, table); err != nil {
+		return nil, err
+	}
+	
+	if err := h.storage.AddBlock(docID, chapterID, table, position); err != nil {
+		return nil, fmt.Errorf("failed to add table: %w", err)
+	}
+	
+	return successResponse(fmt.Sprintf("Added table with %d columns and %d rows", len(headers), len(rows))), nil
+}
+
+// parseTableLayout reads the optional column, header row, span and repeat header
+// settings of a table and validates them against its dimensions
+func parseTableLayout(args map[string]interface{}, table *blocks.TableBlock) error {
+	if columns, ok := args["columns"].([]interface{}); ok {
+		for i, item := range columns {
+			column, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("columns[%d] must be an object", i)
+			}
+			table.Columns = append(table.Columns, blocks.TableColumn{
+				Align: getStringFromMap(column, "align", ""),
+				Width: getFloatFromMap(column, "width", 0),
+			})
+		}
+	}
+	
+	headerRows, err := getInt(args, "header_rows", 0)
+	if err != nil {
+		return err
+	}
+	table.HeaderRows = headerRows
+	
+	if spans, ok := args["spans"].([]interface{}); ok {
+		for i, item := range spans {
+			span, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("spans[%d] must be an object", i)
+			}
+			table.Spans = append(table.Spans, blocks.CellSpan{
+				Row:     getIntFromMap(span, "row", 0),
+				Col:     getIntFromMap(span, "col", 0),
+				RowSpan: getIntFromMap(span, "rowspan", 1),
+				ColSpan: getIntFromMap(span, "colspan", 1),
+			})
+		}
+	}
+	
+	if _, ok := args["repeat_header"]; ok {
+		repeat := getBool(args, "repeat_header", true)
+		table.RepeatHeader = &repeat
+	}
+	
+	if err := table.Validate(); err != nil {
+		return fmt.Errorf("invalid table layout: %w", err)
+	}
+	return nil
+}
+
+// handleAddTableFromFile imports a CSV, TSV or XLSX file as a table block
+func (h *Handler) handleAddTableFromFile(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	chapterID, _ := getString(args, "chapter_id", false)
+	
+	source, err := parseTableSource(args)
+	if err != nil {
+		return nil, err
+	}
+	
+	imported, err := tableimport.Import(*source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import table: %w", err)
+	}
+	
+	caption, _ := getString(args, "caption", false)
+	
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+	
+	table := &blocks.TableBlock{
+		Headers: imported.Headers,
+		Rows:    imported.Rows,
+		Caption: caption,
+		Columns: numericColumnLayout(imported.NumericColumns),
+		Source:  source,
+	}
+	
+	if err := h.storage.AddBlock(docID, chapterID, table, position); err != nil {
+		return nil, fmt.Errorf("failed to add table: %w", err)
+	}
+	
+	return successResponse(fmt.Sprintf("Imported table with %d columns and %d rows from %s (block ID: %s)", len(table.Headers), len(table.Rows), filepath.Base(source.Path), table.ID)), nil
+}
+
+// handleRefreshTable re-imports a table block from its recorded source file
+func (h *Handler) handleRefreshTable(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	blockID, err := getString(args, "block_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	block, _, _, err := h.storage.GetBlock(docID, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+	
+	table, ok := block.(*blocks.TableBlock)
+	if !ok {
+		return nil, fmt.Errorf("block %s is not a table", blockID)
+	}
+	if table.Source == nil {
+		return nil, fmt.Errorf("table %s was not imported from a file", blockID)
+	}
+	
+	imported, err := tableimport.Import(*table.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import table: %w", err)
+	}
+	
+	table.Headers = imported.Headers
+	table.Rows = imported.Rows
+	
+	// Keep caption and layout settings, dropping and reporting those that no longer fit the new shape
+	var dropped []string
+	if len(table.Columns) > len(table.Headers) {
+		dropped = append(dropped, fmt.Sprintf("settings of %d removed column(s)", len(table.Columns)-len(table.Headers)))
+		table.Columns = table.Columns[:len(table.Headers)]
+	}
+	if table.HeaderRows > len(table.Rows) {
+		dropped = append(dropped, fmt.Sprintf("header_rows %d", table.HeaderRows))
+		table.HeaderRows = 0
+	}
+	spans := table.Spans
+	table.Spans = nil
+	for _, span := range spans {
+		table.Spans = append(table.Spans, span)
+		if table.Validate() != nil {
+			table.Spans = table.Spans[:len(table.Spans)-1]
+			dropped = append(dropped, fmt.Sprintf("span at row %d, col %d", span.Row, span.Col))
+		}
+	}
+	
+	if err := h.storage.UpdateBlock(docID, blockID, table); err != nil {
+		return nil, fmt.Errorf("failed to update block: %w", err)
+	}
+	
+	message := fmt.Sprintf("Refreshed table %s: %d columns and %d rows from %s", blockID, len(table.Headers), len(table.Rows), filepath.Base(table.Source.Path))
+	if len(dropped) > 0 {
+		message += fmt.Sprintf("; dropped %s, which no longer fit the table", strings.Join(dropped, ", "))
+	}
+	return successResponse(message), nil
+}
+
+// parseTableSource reads the file import settings of add_table_from_file
+func parseTableSource(args map[string]interface{}) (*blocks.TableSource, error) {
+	filePath, err := getString(args, "file_path", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Record an absolute path so refresh_table works regardless of the working directory
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	
+	source := &blocks.TableSource{Path: absPath}
+	source.Format, _ = getString(args, "format", false)
+	source.Sheet, _ = getString(args, "sheet", false)
+	source.Range, _ = getString(args, "range", false)
+	source.Header, _ = getString(args, "header", false)
+	
+	if _, ok := args["columns"]; ok {
+		columns, err := getStringArray(args, "columns", false)
+		if err != nil {
+			return nil, err
+		}
+		source.Columns = columns
+	}
+	
+	if numberFormat, ok := args["number_format"].(map[string]interface{}); ok {
+		if _, ok := numberFormat["decimals"]; ok {
+			decimals := getIntFromMap(numberFormat, "decimals", 0)
+			if decimals < 0 || decimals > 10 {
+				return nil, fmt.Errorf("decimals must be between 0 and 10")
+			}
+			source.NumberFormat.Decimals = &decimals
 		}
-	case *blocks.PageBreakBlock:
-		return map[string]interface{}{
-			"id":   blockRef.ID,
-			"type": "page_break",
+		source.NumberFormat.ThousandsSeparator = getStringFromMap(numberFormat, "thousands_separator", "")
+		source.NumberFormat.DecimalSeparator = getStringFromMap(numberFormat, "decimal_separator", "")
+	}
+	
+	return source, nil
+}
+
+// numericColumnLayout right-aligns columns that contain only numbers
+func numericColumnLayout(numeric []bool) []blocks.TableColumn {
+	var columns []blocks.TableColumn
+	for i, isNumeric := range numeric {
+		if isNumeric {
+			for len(columns) < i {
+				columns = append(columns, blocks.TableColumn{})
+			}
+			columns = append(columns, blocks.TableColumn{Align: "right"})
 		}
-	default:
-		return nil
 	}
+	return columns
 }
 
-// handleAddHeading adds a heading block
-func (h *Handler) handleAddHeading(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+// handleAddChart adds a chart block rendered from data at export time
+func (h *Handler) handleAddChart(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := getString(args, "document_id", true)
 	if err != nil {
 		return nil, err
@@ -70,37 +793,100 @@ func (h *Handler) handleAddHeading(ctx context.Context, args map[string]interfac
 	
 	chapterID, _ := getString(args, "chapter_id", false)
 	
-	level, err := getInt(args, "level", 1)
+	chart, err := parseChart(args)
 	if err != nil {
 		return nil, err
 	}
 	
-	if level < 1 || level > 6 {
-		return nil, fmt.Errorf("heading level must be between 1 and 6")
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+	
+	if err := h.storage.AddBlock(docID, chapterID, chart, position); err != nil {
+		return nil, fmt.Errorf("failed to add chart: %w", err)
 	}
 	
-	text, err := getString(args, "text", true)
+	return successResponse(fmt.Sprintf("Added %s chart with %d series (block ID: %s)", chart.ChartType, len(chart.Series), chart.ID)), nil
+}
+
+// parseChart reads and validates the fields of a chart block
+func parseChart(args map[string]interface{}) (*blocks.ChartBlock, error) {
+	chartType, err := getString(args, "chart_type", true)
 	if err != nil {
 		return nil, err
 	}
 	
-	positionStr, _ := getString(args, "position", false)
-	position := document.ParsePosition(positionStr)
+	chart := &blocks.ChartBlock{ChartType: chartType}
+	chart.Title, _ = getString(args, "title", false)
+	chart.XLabel, _ = getString(args, "x_label", false)
+	chart.YLabel, _ = getString(args, "y_label", false)
+	chart.Caption, _ = getString(args, "caption", false)
 	
-	heading := &blocks.HeadingBlock{
-		Level: level,
-		Text:  text,
+	if _, ok := args["categories"]; ok {
+		categories, err := getStringArray(args, "categories", false)
+		if err != nil {
+			return nil, err
+		}
+		chart.Categories = categories
 	}
 	
-	if err := h.storage.AddBlock(docID, chapterID, heading, position); err != nil {
-		return nil, fmt.Errorf("failed to add heading: %w", err)
+	seriesData, ok := args["series"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("series must be an array")
+	}
+	for i, item := range seriesData {
+		seriesMap, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("series[%d] must be an object", i)
+		}
+		values, err := getNumberArray(seriesMap, "values")
+		if err != nil {
+			return nil, fmt.Errorf("series[%d]: %w", i, err)
+		}
+		x, err := getNumberArray(seriesMap, "x")
+		if err != nil {
+			return nil, fmt.Errorf("series[%d]: %w", i, err)
+		}
+		chart.Series = append(chart.Series, blocks.ChartSeries{
+			Name:   getStringFromMap(seriesMap, "name", ""),
+			Values: values,
+			X:      x,
+		})
 	}
 	
-	return successResponse(fmt.Sprintf("Added heading '%s' to document", text)), nil
+	if err := chart.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid chart: %w", err)
+	}
+	return chart, nil
 }
 
-// handleAddMarkdown adds a markdown block
-func (h *Handler) handleAddMarkdown(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+// getNumberArray reads an optional array of numbers
+func getNumberArray(args map[string]interface{}, key string) ([]float64, error) {
+	val, ok := args[key]
+	if !ok {
+		return nil, nil
+	}
+	
+	arr, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array", key)
+	}
+	
+	numbers := make([]float64, len(arr))
+	for i, item := range arr {
+		switch v := item.(type) {
+		case float64:
+			numbers[i] = v
+		case int:
+			numbers[i] = float64(v)
+		default:
+			return nil, fmt.Errorf("%s[%d] must be a number", key, i)
+		}
+	}
+	return numbers, nil
+}
+
+// handleAddDiagram adds a diagram block whose source is rendered at export time
+func (h *Handler) handleAddDiagram(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := getString(args, "document_id", true)
 	if err != nil {
 		return nil, err
@@ -108,7 +894,7 @@ func (h *Handler) handleAddMarkdown(ctx context.Context, args map[string]interfa
 	
 	chapterID, _ := getString(args, "chapter_id", false)
 	
-	content, err := getString(args, "content", true)
+	diagram, err := parseDiagram(args)
 	if err != nil {
 		return nil, err
 	}
@@ -116,19 +902,36 @@ func (h *Handler) handleAddMarkdown(ctx context.Context, args map[string]interfa
 	positionStr, _ := getString(args, "position", false)
 	position := document.ParsePosition(positionStr)
 	
-	markdown := &blocks.MarkdownBlock{
-		Content: content,
+	if err := h.storage.AddBlock(docID, chapterID, diagram, position); err != nil {
+		return nil, fmt.Errorf("failed to add diagram: %w", err)
 	}
 	
-	if err := h.storage.AddBlock(docID, chapterID, markdown, position); err != nil {
-		return nil, fmt.Errorf("failed to add markdown: %w", err)
+	return successResponse(fmt.Sprintf("Added %s diagram (block ID: %s)", diagram.Engine, diagram.ID)), nil
+}
+
+// parseDiagram reads and validates the fields of a diagram block
+func parseDiagram(args map[string]interface{}) (*blocks.DiagramBlock, error) {
+	engine, err := getString(args, "engine", true)
+	if err != nil {
+		return nil, err
+	}
+	source, err := getString(args, "source", true)
+	if err != nil {
+		return nil, err
 	}
 	
-	return successResponse("Added markdown block to document"), nil
+	diagram := &blocks.DiagramBlock{Engine: engine, Source: source}
+	diagram.Caption, _ = getString(args, "caption", false)
+	diagram.AltText, _ = getString(args, "alt_text", false)
+	
+	if err := diagram.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid diagram: %w", err)
+	}
+	return diagram, nil
 }
 
-// handleAddImage adds an image block
-func (h *Handler) handleAddImage(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+// handleAddRaw adds a block passed through untouched to exports of one format
+func (h *Handler) handleAddRaw(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := getString(args, "document_id", true)
 	if err != nil {
 		return nil, err
@@ -136,38 +939,41 @@ func (h *Handler) handleAddImage(ctx context.Context, args map[string]interface{
 	
 	chapterID, _ := getString(args, "chapter_id", false)
 	
-	imagePath, err := getString(args, "image_path", true)
+	raw, err := parseRaw(args)
 	if err != nil {
 		return nil, err
 	}
 	
-	caption, _ := getString(args, "caption", false)
-	altText, _ := getString(args, "alt_text", false)
-	
 	positionStr, _ := getString(args, "position", false)
 	position := document.ParsePosition(positionStr)
 	
-	// Copy image to assets folder
-	assetPath, err := h.storage.CopyImageToAssets(docID, imagePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to copy image: %w", err)
+	if err := h.storage.AddBlock(docID, chapterID, raw, position); err != nil {
+		return nil, fmt.Errorf("failed to add raw block: %w", err)
 	}
 	
-	image := &blocks.ImageBlock{
-		Path:    assetPath,
-		Caption: caption,
-		AltText: altText,
+	return successResponse(fmt.Sprintf("Added raw %s block (block ID: %s)", raw.Format, raw.ID)), nil
+}
+
+// parseRaw reads and validates the fields of a raw block
+func parseRaw(args map[string]interface{}) (*blocks.RawBlock, error) {
+	format, err := getString(args, "format", true)
+	if err != nil {
+		return nil, err
 	}
-	
-	if err := h.storage.AddBlock(docID, chapterID, image, position); err != nil {
-		return nil, fmt.Errorf("failed to add image: %w", err)
+	content, err := getString(args, "content", true)
+	if err != nil {
+		return nil, err
 	}
 	
-	return successResponse("Added image block to document"), nil
+	raw := &blocks.RawBlock{Format: format, Content: content}
+	if err := raw.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid raw block: %w", err)
+	}
+	return raw, nil
 }
 
-// handleAddTable adds a table block
-func (h *Handler) handleAddTable(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+// handleAddQuote adds a blockquote, pull-quote or epigraph
+func (h *Handler) handleAddQuote(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := getString(args, "document_id", true)
 	if err != nil {
 		return nil, err
@@ -175,29 +981,92 @@ func (h *Handler) handleAddTable(ctx context.Context, args map[string]interface{
 	
 	chapterID, _ := getString(args, "chapter_id", false)
 	
-	headers, err := getStringArray(args, "headers", true)
+	quote, err := parseQuote(args)
 	if err != nil {
 		return nil, err
 	}
 	
-	rows, err := getStringArray2D(args, "rows", true)
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+	
+	if err := h.storage.AddBlock(docID, chapterID, quote, position); err != nil {
+		return nil, fmt.Errorf("failed to add quote: %w", err)
+	}
+	
+	return successResponse(fmt.Sprintf("Added %s (block ID: %s)", quote.Style, quote.ID)), nil
+}
+
+// parseQuote reads and validates the fields of a quote block
+func parseQuote(args map[string]interface{}) (*blocks.QuoteBlock, error) {
+	text, err := getString(args, "text", true)
+	if err != nil {
+		return nil, err
+	}
+	style, _ := getString(args, "style", false)
+	if style == "" {
+		style = blocks.QuoteBlockquote
+	}
+	attribution, _ := getString(args, "attribution", false)
+	source, _ := getString(args, "source", false)
+	
+	quote := &blocks.QuoteBlock{Style: style, Text: text, Attribution: attribution, Source: source}
+	if err := quote.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid quote: %w", err)
+	}
+	return quote, nil
+}
+
+// handleAddLayout adds a layout that sets existing blocks of the chapter in columns
+func (h *Handler) handleAddLayout(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	chapterID, _ := getString(args, "chapter_id", false)
+	
+	layout, err := parseLayout(args)
 	if err != nil {
 		return nil, err
 	}
+	if err := h.storage.CheckLayoutBlocks(docID, chapterID, "", layout); err != nil {
+		return nil, fmt.Errorf("invalid layout: %w", err)
+	}
 	
 	positionStr, _ := getString(args, "position", false)
 	position := document.ParsePosition(positionStr)
 	
-	table := &blocks.TableBlock{
-		Headers: headers,
-		Rows:    rows,
+	if err := h.storage.AddBlock(docID, chapterID, layout, position); err != nil {
+		return nil, fmt.Errorf("failed to add layout: %w", err)
 	}
 	
-	if err := h.storage.AddBlock(docID, chapterID, table, position); err != nil {
-		return nil, fmt.Errorf("failed to add table: %w", err)
+	return successResponse(fmt.Sprintf("Added %d-column layout with %d blocks (block ID: %s)", layout.Columns, len(layout.Blocks), layout.ID)), nil
+}
+
+// parseLayout reads and validates the fields of a layout block
+func parseLayout(args map[string]interface{}) (*blocks.LayoutBlock, error) {
+	columns, err := getInt(args, "columns", 2)
+	if err != nil {
+		return nil, err
+	}
+	gap, _ := getString(args, "gap", false)
+	children, err := getStringArray(args, "blocks", true)
+	if err != nil {
+		return nil, err
+	}
+	breaks, err := getStringArray(args, "column_breaks", false)
+	if err != nil {
+		return nil, err
+	}
+	if len(breaks) == 0 {
+		breaks = nil
 	}
 	
-	return successResponse(fmt.Sprintf("Added table with %d columns and %d rows", len(headers), len(rows))), nil
+	layout := &blocks.LayoutBlock{Columns: columns, Gap: gap, Blocks: children, ColumnBreaks: breaks}
+	if err := layout.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid layout: %w", err)
+	}
+	return layout, nil
 }
 
 // handleAddPageBreak adds a page break block
@@ -276,33 +1145,91 @@ func (h *Handler) handleAddMultipleBlocks(ctx context.Context, args map[string]i
 			}
 		
 		case "image":
-			imagePath, _ := getString(data, "image_path", true)
 			caption, _ := getString(data, "caption", false)
 			altText, _ := getString(data, "alt_text", false)
 			
-			// Copy image to assets
-			assetPath, err := h.storage.CopyImageToAssets(docID, imagePath)
-			if err != nil {
+			image := &blocks.ImageBlock{
+				Caption: caption,
+				AltText: altText,
+			}
+			if err := parseImageLayout(data, image); err != nil {
 				continue
 			}
 			
-			block = &blocks.ImageBlock{
-				Path:    assetPath,
-				Caption: caption,
-				AltText: altText,
+			// Store image (path or base64 data) in assets
+			assetPath, err := h.importImage(docID, data)
+			if err != nil {
+				continue
 			}
+			image.Path = assetPath
+			block = image
 		
 		case "table":
 			headers, _ := getStringArray(data, "headers", true)
 			rows, _ := getStringArray2D(data, "rows", true)
-			block = &blocks.TableBlock{
+			caption, _ := getString(data, "caption", false)
+			table := &blocks.TableBlock{
 				Headers: headers,
 				Rows:    rows,
+				Caption: caption,
+			}
+			if err := parseTableLayout(data, table); err != nil {
+				continue
 			}
+			block = table
 		
 		case "page_break":
 			block = &blocks.PageBreakBlock{}
 		
+		case "chart":
+			chart, err := parseChart(data)
+			if err != nil {
+				continue
+			}
+			block = chart
+		
+		case "diagram":
+			diagram, err := parseDiagram(data)
+			if err != nil {
+				continue
+			}
+			block = diagram
+		
+		case "raw":
+			raw, err := parseRaw(data)
+			if err != nil {
+				continue
+			}
+			block = raw
+		
+		case "list":
+			list, err := parseList(data)
+			if err != nil {
+				continue
+			}
+			block = list
+		
+		case "quote":
+			quote, err := parseQuote(data)
+			if err != nil {
+				continue
+			}
+			block = quote
+		
+		case "gallery":
+			gallery, err := h.importGallery(docID, data)
+			if err != nil {
+				continue
+			}
+			block = gallery
+		
+		case "include":
+			include, err := h.parseInclude(data)
+			if err != nil {
+				continue
+			}
+			block = include
+		
 		default:
 			continue
 		}
@@ -315,7 +1242,9 @@ func (h *Handler) handleAddMultipleBlocks(ctx context.Context, args map[string]i
 			}
 		}
 		
-		if err := h.storage.AddBlock(docID, chapterID, block, position); err == nil {
+		if err := h.storage.AddBlock(docID, chapterID, block, position); err != nil {
+			h.removeImageAssets(docID, blockImagePaths(block))
+		} else {
 			addedCount++
 			// Get the ID of the block we just added (would need to modify AddBlock to return it)
 			// For now, we'll continue with the original position
@@ -431,11 +1360,15 @@ func (h *Handler) handleUpdateBlock(ctx context.Context, args map[string]interfa
 		caption, _ := getString(newContent, "caption", false)
 		altText, _ := getString(newContent, "alt_text", false)
 		
-		newBlock = &blocks.ImageBlock{
+		image := &blocks.ImageBlock{
 			Path:    path,
 			Caption: caption,
 			AltText: altText,
 		}
+		if err := parseImageLayout(newContent, image); err != nil {
+			return nil, err
+		}
+		newBlock = image
 		
 	case blocks.TypeTable:
 		headers, err := getStringArray(newContent, "headers", true)
@@ -448,18 +1381,120 @@ func (h *Handler) handleUpdateBlock(ctx context.Context, args map[string]interfa
 			return nil, fmt.Errorf("rows are required for table: %w", err)
 		}
 		
-		newBlock = &blocks.TableBlock{
+		caption, _ := getString(newContent, "caption", false)
+		
+		table := &blocks.TableBlock{
 			Headers: headers,
 			Rows:    rows,
+			Caption: caption,
 		}
+		if err := parseTableLayout(newContent, table); err != nil {
+			return nil, err
+		}
+		
+		// Edited tables stay linked to their source file unless a new one is given
+		if _, ok := newContent["file_path"]; ok {
+			if table.Source, err = parseTableSource(newContent); err != nil {
+				return nil, err
+			}
+		} else {
+			existing, _, _, err := h.storage.GetBlock(docID, blockID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get block: %w", err)
+			}
+			if existingTable, ok := existing.(*blocks.TableBlock); ok {
+				table.Source = existingTable.Source
+			}
+		}
+		newBlock = table
 		
 	case blocks.TypePageBreak:
 		newBlock = &blocks.PageBreakBlock{}
 		
+	case blocks.TypeChart:
+		chart, err := parseChart(newContent)
+		if err != nil {
+			return nil, err
+		}
+		newBlock = chart
+		
+	case blocks.TypeDiagram:
+		diagram, err := parseDiagram(newContent)
+		if err != nil {
+			return nil, err
+		}
+		newBlock = diagram
+		
+	case blocks.TypeRaw:
+		raw, err := parseRaw(newContent)
+		if err != nil {
+			return nil, err
+		}
+		newBlock = raw
+		
+	case blocks.TypeList:
+		list, err := parseList(newContent)
+		if err != nil {
+			return nil, err
+		}
+		newBlock = list
+		
+	case blocks.TypeQuote:
+		quote, err := parseQuote(newContent)
+		if err != nil {
+			return nil, err
+		}
+		newBlock = quote
+		
+	case blocks.TypeLayout:
+		layout, err := parseLayout(newContent)
+		if err != nil {
+			return nil, err
+		}
+		chapterID, _, err := h.storage.FindBlockLocation(docID, blockID)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.storage.CheckLayoutBlocks(docID, chapterID, blockID, layout); err != nil {
+			return nil, fmt.Errorf("invalid layout: %w", err)
+		}
+		if err := h.storage.CheckIncludeCycle(docID, chapterID, blockID, layout); err != nil {
+			return nil, fmt.Errorf("invalid layout: %w", err)
+		}
+		newBlock = layout
+		
+	case blocks.TypeGallery:
+		gallery, err := parseGallery(newContent, func(image map[string]interface{}) (string, error) {
+			return getStringFromMap(image, "path", ""), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		newBlock = gallery
+		
+	case blocks.TypeInclude:
+		include, err := h.parseInclude(newContent)
+		if err != nil {
+			return nil, err
+		}
+		chapterID, _, err := h.storage.FindBlockLocation(docID, blockID)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.storage.CheckIncludeCycle(docID, chapterID, blockID, include); err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+		newBlock = include
+		
 	default:
 		return nil, fmt.Errorf("unknown block type: %s", blockType)
 	}
 	
+	// In suggestion mode the edit waits for review
+	if getBool(args, "suggest", false) {
+		return h.fileSuggestion(docID, blockID, args, newBlock)
+	}
+	
 	// Update the block
 	if err := h.storage.UpdateBlock(docID, blockID, newBlock); err != nil {
 		return nil, fmt.Errorf("failed to update block: %w", err)
@@ -575,7 +1610,7 @@ func (h *Handler) handleGetBlock(ctx context.Context, args map[string]interface{
 	}
 	
 	// Convert block to response format using shared converter
-	result := h.convertBlockToResponse(block, *blockRef, docID)
+	result := convertBlockReferenceMetadata(h.convertBlockToResponse(block, *blockRef, docID), *blockRef)
 	if result == nil {
 		return nil, fmt.Errorf("failed to convert block to response format")
 	}
@@ -590,12 +1625,16 @@ func (h *Handler) handleGetBlocks(ctx context.Context, args map[string]interface
 		return nil, err
 	}
 	
-	blockIDs, err := getStringArray(args, "block_ids", true)
+	tag, _ := getString(args, "tag", false)
+	if _, ok := args["block_ids"]; !ok && tag == "" {
+		return nil, fmt.Errorf("provide block_ids, tag or both")
+	}
+	blockIDs, err := getStringArray(args, "block_ids", false)
 	if err != nil {
 		return nil, err
 	}
 	
-	if len(blockIDs) == 0 {
+	if len(blockIDs) == 0 && tag == "" {
 		return jsonResponse([]map[string]interface{}{})
 	}
 	
@@ -609,13 +1648,14 @@ func (h *Handler) handleGetBlocks(ctx context.Context, args map[string]interface
 	
 	// Build a map of block ID to reference for quick lookup
 	blockRefMap := make(map[string]*blocks.BlockReference)
+	var tagged []string
 	
 	// Always process document-level blocks first (if they exist)
 	for _, ref := range doc.Blocks {
-		blockRefMap[ref.ID] = &blocks.BlockReference{
-			ID:   ref.ID,
-			Type: ref.Type,
-			File: ref.File,
+		ref := ref
+		blockRefMap[ref.ID] = &ref
+		if tag != "" && ref.HasTag(tag) {
+			tagged = append(tagged, ref.ID)
 		}
 	}
 	
@@ -627,12 +1667,25 @@ func (h *Handler) handleGetBlocks(ctx context.Context, args map[string]interface
 		}
 		
 		for _, ref := range chapter.Blocks {
-			blockRefMap[ref.ID] = &blocks.BlockReference{
-				ID:   ref.ID,
-				Type: ref.Type,
-				File: ref.File,
+			ref := ref
+			blockRefMap[ref.ID] = &ref
+			if tag != "" && ref.HasTag(tag) {
+				tagged = append(tagged, ref.ID)
+			}
+		}
+	}
+	
+	// A tag selects its blocks in document order, or narrows the listed IDs
+	if tag != "" && len(blockIDs) == 0 {
+		blockIDs = tagged
+	} else if tag != "" {
+		var filtered []string
+		for _, blockID := range blockIDs {
+			if ref, found := blockRefMap[blockID]; found && ref.HasTag(tag) {
+				filtered = append(filtered, blockID)
 			}
 		}
+		blockIDs = filtered
 	}
 	
 	
@@ -654,11 +1707,37 @@ func (h *Handler) handleGetBlocks(ctx context.Context, args map[string]interface
 		}
 		
 		// Convert block to response format using shared converter
-		blockData := h.convertBlockToResponse(block, *blockRef, docID)
+		blockData := convertBlockReferenceMetadata(h.convertBlockToResponse(block, *blockRef, docID), *blockRef)
 		if blockData != nil {
 			results = append(results, blockData)
 		}
 	}
 	
 	return jsonResponse(results)
-}
\ No newline at end of file
+}
+// handleSetBlockLabel assigns or clears a block's cross-reference label
+func (h *Handler) handleSetBlockLabel(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	blockID, err := getString(args, "block_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	label, err := getString(args, "label", false)
+	if err != nil {
+		return nil, err
+	}
+	
+	if err := h.storage.SetBlockLabel(docID, blockID, label); err != nil {
+		return nil, fmt.Errorf("failed to set block label: %w", err)
+	}
+	
+	if label == "" {
+		return successResponse(fmt.Sprintf("Removed label from block %s", blockID)), nil
+	}
+	return successResponse(fmt.Sprintf("Labelled block %s as '%s' (reference it with {@ref:%s})", blockID, label, label)), nil
+}
@@ -6,6 +6,7 @@ import (
 	
 	"github.com/gomcpgo/mcp/pkg/protocol"
 	"github.com/savant/mcp-servers/docgen2/pkg/document"
+	"github.com/savant/mcp-servers/docgen2/pkg/export"
 )
 
 // handleAddChapter adds a new chapter to a document
@@ -134,8 +135,75 @@ func (h *Handler) handleExportDocument(ctx context.Context, args map[string]inte
 		return nil, err
 	}
 	
+	var opts export.ExportOptions
+	if _, ok := args["toc"]; ok {
+		toc := getBool(args, "toc", false)
+		opts.TOC = &toc
+	}
+	opts.TOCDepth, err = getInt(args, "toc_depth", 0)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TOCDepth < 0 || opts.TOCDepth > 6 {
+		return nil, fmt.Errorf("toc_depth must be between 1 and 6")
+	}
+	
+	if variables, ok := args["variables"]; ok {
+		values, ok := variables.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("variables must be an object")
+		}
+		rows, err := export.DatasetRows([]map[string]interface{}{values})
+		if err != nil {
+			return nil, fmt.Errorf("invalid variables: %w", err)
+		}
+		opts.Variables = rows[0]
+	}
+	
+	if profile, ok := args["profile"]; ok {
+		opts.Profile, opts.ProfileName, err = h.resolveProfile(docID, profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	
+	opts.Comments, err = getString(args, "comments", false)
+	if err != nil {
+		return nil, err
+	}
+	if err := export.ValidateCommentsMode(opts.Comments); err != nil {
+		return nil, err
+	}
+	opts.Suggestions, err = getString(args, "suggestions", false)
+	if err != nil {
+		return nil, err
+	}
+	if err := export.ValidateSuggestionsMode(opts.Suggestions); err != nil {
+		return nil, err
+	}
+	
+	// A dataset exports one document per row (mail merge)
+	rows, err := parseDataset(args)
+	if err != nil {
+		return nil, err
+	}
+	if rows != nil {
+		outputName, _ := getString(args, "output_name", false)
+		outputs, err := h.exporter.ExportMerged(docID, format, rows, outputName, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export document (%d of %d rows exported): %w", len(outputs), len(rows), err)
+		}
+		
+		return jsonResponse(map[string]interface{}{
+			"document_id":  docID,
+			"format":       format,
+			"output_paths": outputs,
+			"message":      fmt.Sprintf("Exported %d documents, one per dataset row", len(outputs)),
+		})
+	}
+	
 	// Export the document
-	outputPath, err := h.exporter.ExportDocument(docID, format)
+	outputPath, err := h.exporter.ExportDocumentWithOptions(docID, format, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to export document: %w", err)
 	}
@@ -148,4 +216,42 @@ func (h *Handler) handleExportDocument(ctx context.Context, args map[string]inte
 	}
 	
 	return jsonResponse(result)
-}
\ No newline at end of file
+}
+
+// parseDataset reads mail-merge rows from dataset_path or an inline dataset array.
+// It returns nil when neither is given.
+func parseDataset(args map[string]interface{}) ([]map[string]string, error) {
+	path, err := getString(args, "dataset_path", false)
+	if err != nil {
+		return nil, err
+	}
+	inline, hasInline := args["dataset"]
+	if path != "" && hasInline {
+		return nil, fmt.Errorf("provide either dataset_path or dataset, not both")
+	}
+	
+	if path != "" {
+		rows, err := export.LoadDataset(path)
+		if err != nil {
+			return nil, err
+		}
+		return rows, nil
+	}
+	if !hasInline {
+		return nil, nil
+	}
+	
+	items, ok := inline.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("dataset must be a non-empty array of objects")
+	}
+	records := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		record, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("dataset[%d] must be an object", i)
+		}
+		records = append(records, record)
+	}
+	return export.DatasetRows(records)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/storage"
+)
+
+// handleAddComment comments on a block or a passage of its text, or replies to a comment
+func (h *Handler) handleAddComment(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	author, err := getString(args, "author", true)
+	if err != nil {
+		return nil, err
+	}
+	text, err := getString(args, "text", true)
+	if err != nil {
+		return nil, err
+	}
+	blockID, _ := getString(args, "block_id", false)
+	replyTo, _ := getString(args, "reply_to", false)
+	quote, _ := getString(args, "quote", false)
+	if (blockID == "") == (replyTo == "") {
+		return nil, fmt.Errorf("provide either block_id or reply_to")
+	}
+
+	if replyTo != "" {
+		if quote != "" {
+			return nil, fmt.Errorf("quote applies to new comments, not replies")
+		}
+		comment, err := h.storage.ReplyToComment(docID, replyTo, author, text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reply: %w", err)
+		}
+		return jsonResponse(map[string]interface{}{
+			"comment": comment,
+			"message": fmt.Sprintf("Replied to comment %s (%d replies)", comment.ID, len(comment.Replies)),
+		})
+	}
+
+	comment, err := h.storage.AddComment(docID, blockID, author, text, quote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add comment: %w", err)
+	}
+	return jsonResponse(map[string]interface{}{
+		"comment": comment,
+		"message": fmt.Sprintf("Added comment %s on block %s", comment.ID, blockID),
+	})
+}
+
+// handleListComments lists a document's comments, optionally for one block
+func (h *Handler) handleListComments(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	blockID, _ := getString(args, "block_id", false)
+	includeResolved := getBool(args, "include_resolved", false)
+
+	comments, err := h.storage.ListComments(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	result := []storage.Comment{}
+	resolved := 0
+	for _, comment := range comments {
+		if blockID != "" && comment.BlockID != blockID {
+			continue
+		}
+		if comment.Resolved {
+			resolved++
+			if !includeResolved {
+				continue
+			}
+		}
+		result = append(result, comment)
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"document_id": docID,
+		"comments":    result,
+		"resolved":    resolved,
+	})
+}
+
+// handleResolveComment marks a comment resolved, or reopens it
+func (h *Handler) handleResolveComment(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	commentID, err := getString(args, "comment_id", true)
+	if err != nil {
+		return nil, err
+	}
+	resolved := getBool(args, "resolved", true)
+
+	comment, err := h.storage.ResolveComment(docID, commentID, resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve comment: %w", err)
+	}
+
+	if !resolved {
+		return successResponse(fmt.Sprintf("Reopened comment %s", comment.ID)), nil
+	}
+	return successResponse(fmt.Sprintf("Resolved comment %s", comment.ID)), nil
+}
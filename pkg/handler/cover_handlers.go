@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/document"
+)
+
+// handleSetCoverPage creates, updates or removes a document's cover page. Fields
+// that are not given keep their current value; an empty string clears a text
+// field and null removes the logo or background.
+func (h *Handler) handleSetCoverPage(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := h.storage.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	if getBool(args, "remove", false) {
+		if doc.Cover == nil {
+			return nil, fmt.Errorf("document has no cover page")
+		}
+		doc.Cover = nil
+		if err := h.storage.SaveDocument(docID, doc); err != nil {
+			return nil, fmt.Errorf("failed to save document: %w", err)
+		}
+		return successResponse("Removed the cover page; exports use the plain title again"), nil
+	}
+
+	cover := &document.CoverPage{Layout: document.CoverClassic}
+	if doc.Cover != nil {
+		updated := *doc.Cover
+		cover = &updated
+	}
+
+	fields := map[string]*string{
+		"layout":       &cover.Layout,
+		"title":        &cover.Title,
+		"subtitle":     &cover.Subtitle,
+		"author":       &cover.Author,
+		"organization": &cover.Organization,
+		"date":         &cover.Date,
+	}
+	for key, field := range fields {
+		if _, ok := args[key]; !ok {
+			continue
+		}
+		value, err := getString(args, key, false)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+	if cover.Layout == "" {
+		cover.Layout = document.CoverClassic
+	}
+	if err := cover.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Images are stored last so a rejected configuration leaves no assets behind
+	for key, field := range map[string]*string{"logo": &cover.Logo, "background": &cover.Background} {
+		value, ok := args[key]
+		if !ok {
+			continue
+		}
+		if value == nil {
+			*field = ""
+			continue
+		}
+		image, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object with image_path or image_data, or null", key)
+		}
+		assetPath, err := h.importImage(docID, image)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		*field = assetPath
+	}
+
+	doc.Cover = cover
+	if err := h.storage.SaveDocument(docID, doc); err != nil {
+		return nil, fmt.Errorf("failed to save document: %w", err)
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"document_id": docID,
+		"cover":       doc.Cover,
+		"message":     fmt.Sprintf("Cover page uses the %s layout", cover.Layout),
+	})
+}
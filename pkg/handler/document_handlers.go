@@ -8,6 +8,8 @@ import (
 	"github.com/gomcpgo/mcp/pkg/protocol"
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
 	"github.com/savant/mcp-servers/docgen2/pkg/document"
+	"github.com/savant/mcp-servers/docgen2/pkg/export"
+	"github.com/savant/mcp-servers/docgen2/pkg/search"
 	"github.com/savant/mcp-servers/docgen2/pkg/style"
 )
 
@@ -56,6 +58,7 @@ func (h *Handler) handleListDocuments(ctx context.Context, args map[string]inter
 			"title":       doc.Title,
 			"author":      doc.Author,
 			"has_chapters": doc.HasChapters,
+			"theme":       doc.Theme,
 			"created_at":  doc.CreatedAt,
 			"updated_at":  doc.UpdatedAt,
 		})
@@ -70,6 +73,10 @@ func (h *Handler) handleGetDocumentOverview(ctx context.Context, args map[string
 	if err != nil {
 		return nil, err
 	}
+	status, err := getStatusFilter(args)
+	if err != nil {
+		return nil, err
+	}
 	
 	doc, err := h.storage.GetDocument(docID)
 	if err != nil {
@@ -81,13 +88,30 @@ func (h *Handler) handleGetDocumentOverview(ctx context.Context, args map[string
 		Title:       doc.Title,
 		Author:      doc.Author,
 		HasChapters: doc.HasChapters,
+		Variables:   doc.Variables,
+		Profiles:    doc.Profiles,
+		Cover:       doc.Cover,
+		Glossary:    doc.Glossary,
 		Blocks:      []document.BlockOverview{},    // Always initialize as empty array
 		Chapters:    []document.ChapterOverview{},  // Always initialize as empty array
 	}
 	
+	// Count unresolved review comments per block
+	comments, err := h.storage.ListComments(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	openComments := make(map[string]int)
+	for _, comment := range comments {
+		if !comment.Resolved {
+			openComments[comment.ChapterID+"/"+comment.BlockID]++
+			overview.OpenComments++
+		}
+	}
+	
 	// Always process document-level blocks first (if they exist)
 	if len(doc.Blocks) > 0 {
-		overview.Blocks = h.buildBlockOverviews(docID, doc.Blocks)
+		overview.Blocks = h.buildBlockOverviews(docID, "", doc.Blocks, openComments, false, status)
 	}
 	
 	// Then process chapters (if they exist)
@@ -99,9 +123,16 @@ func (h *Handler) handleGetDocumentOverview(ctx context.Context, args map[string
 			}
 			
 			chapterOverview := document.ChapterOverview{
-				ID:     chapter.ID,
-				Title:  chapter.Title,
-				Blocks: h.buildBlockOverviews(docID, chapter.Blocks),
+				ID:         chapter.ID,
+				Title:      chapter.Title,
+				Conditions: chapterRef.Conditions.String(),
+				Status:     blocks.StatusOf(chapterRef.Status),
+				Locked:     chapterRef.Locked,
+				Style:      chapterRef.Style,
+				Blocks:     h.buildBlockOverviews(docID, chapterRef.ID, chapter.Blocks, openComments, chapterRef.Locked, status),
+			}
+			if status != "" && chapterOverview.Status != status && len(chapterOverview.Blocks) == 0 {
+				continue
 			}
 			overview.Chapters = append(overview.Chapters, chapterOverview)
 		}
@@ -110,11 +141,16 @@ func (h *Handler) handleGetDocumentOverview(ctx context.Context, args map[string
 	return jsonResponse(overview)
 }
 
-// buildBlockOverviews creates overview for blocks
-func (h *Handler) buildBlockOverviews(docID string, blockRefs []blocks.BlockReference) []document.BlockOverview {
+// buildBlockOverviews creates overview for blocks; openComments counts unresolved
+// comments by chapter and block ID. A non-empty status lists only blocks with that status.
+func (h *Handler) buildBlockOverviews(docID, chapterID string, blockRefs []blocks.BlockReference, openComments map[string]int, chapterLocked bool, status string) []document.BlockOverview {
 	var overviews []document.BlockOverview
 	
 	for _, ref := range blockRefs {
+		if status != "" && blocks.StatusOf(ref.Status) != status {
+			continue
+		}
+		
 		block, err := h.storage.LoadBlock(docID, ref)
 		if err != nil {
 			continue
@@ -122,9 +158,16 @@ func (h *Handler) buildBlockOverviews(docID string, blockRefs []blocks.BlockRefe
 		
 		preview := h.getBlockPreview(block)
 		overviews = append(overviews, document.BlockOverview{
-			ID:      ref.ID,
-			Type:    string(ref.Type),
-			Preview: preview,
+			ID:         ref.ID,
+			Type:       string(ref.Type),
+			Preview:    preview,
+			Label:      ref.Label,
+			Tags:       ref.Tags,
+			Conditions: ref.Conditions.String(),
+			Comments:   openComments[chapterID+"/"+ref.ID],
+			Status:     blocks.StatusOf(ref.Status),
+			Locked:     ref.Locked || chapterLocked,
+			Style:      ref.Style,
 		})
 	}
 	
@@ -145,7 +188,56 @@ func (h *Handler) getBlockPreview(block blocks.Block) string {
 		}
 		return preview
 	case *blocks.TableBlock:
-		return fmt.Sprintf("Table: %d columns, %d rows", len(b.Headers), len(b.Rows))
+		preview := fmt.Sprintf("Table: %d columns, %d rows", len(b.Headers), len(b.Rows))
+		if b.Caption != "" {
+			preview += " - " + truncateString(b.Caption, 80)
+		}
+		return preview
+	case *blocks.ChartBlock:
+		preview := fmt.Sprintf("Chart (%s): %d series", b.ChartType, len(b.Series))
+		if b.Title != "" {
+			preview += " - " + truncateString(b.Title, 80)
+		}
+		return preview
+	case *blocks.GalleryBlock:
+		preview := fmt.Sprintf("Gallery: %d images", len(b.Images))
+		if b.Caption != "" {
+			preview += " - " + truncateString(b.Caption, 80)
+		}
+		return preview
+	case *blocks.DiagramBlock:
+		preview := fmt.Sprintf("Diagram (%s)", b.Engine)
+		if b.Caption != "" {
+			preview += ": " + truncateString(b.Caption, 80)
+		}
+		return preview
+	case *blocks.IncludeBlock:
+		// Preview the included content rather than the reference
+		target, err := h.storage.ResolveInclude(b)
+		if err != nil {
+			return fmt.Sprintf("Include (%s): unresolved - %s", b.Source(), truncateString(err.Error(), 80))
+		}
+		return fmt.Sprintf("Include (%s): %s", b.Source(), h.getBlockPreview(target.Block))
+	case *blocks.ListBlock:
+		preview := fmt.Sprintf("List (%s): %d items", b.Style, len(b.Items))
+		if b.Style == blocks.ListTask {
+			checked, total := b.Progress()
+			preview = fmt.Sprintf("Task list: %d/%d done", checked, total)
+		}
+		if len(b.Items) > 0 {
+			preview += " - " + truncateString(b.Items[0].Text, 80)
+		}
+		return preview
+	case *blocks.QuoteBlock:
+		preview := fmt.Sprintf("Quote (%s): %s", b.Style, truncateString(b.Text, 80))
+		if attribution := b.AttributionText(); attribution != "" {
+			preview += " " + attribution
+		}
+		return preview
+	case *blocks.LayoutBlock:
+		return fmt.Sprintf("Layout (%d columns): %s", b.Columns, strings.Join(b.Blocks, ", "))
+	case *blocks.RawBlock:
+		return fmt.Sprintf("Raw %s: %s", b.Format, truncateString(b.Content, 80))
 	case *blocks.PageBreakBlock:
 		return "Page Break"
 	default:
@@ -189,9 +281,14 @@ func (h *Handler) handleSearchBlocks(ctx context.Context, args map[string]interf
 	}
 	
 	chapterID, _ := getString(args, "chapter_id", false)
+	status, err := getStatusFilter(args)
+	if err != nil {
+		return nil, err
+	}
+	tag, _ := getString(args, "tag", false)
 	
 	// Use searcher to find results
-	results, err := h.searcher.SearchDocument(docID, query, chapterID)
+	results, err := h.searcher.SearchDocument(docID, query, chapterID, search.Filter{Status: status, Tag: tag})
 	if err != nil {
 		return nil, fmt.Errorf("failed to search document: %w", err)
 	}
@@ -199,6 +296,27 @@ func (h *Handler) handleSearchBlocks(ctx context.Context, args map[string]interf
 	return jsonResponse(results)
 }
 
+// handleValidateReferences reports cross-references to labels that do not exist
+func (h *Handler) handleValidateReferences(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	dangling, err := h.exporter.ValidateReferences(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate references: %w", err)
+	}
+	
+	result := map[string]interface{}{
+		"document_id": docID,
+		"valid":       len(dangling) == 0,
+		"dangling":    dangling,
+	}
+	
+	return jsonResponse(result)
+}
+
 // handleGetDocumentStyle returns the style configuration for a document
 func (h *Handler) handleGetDocumentStyle(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := getString(args, "document_id", true)
@@ -211,6 +329,19 @@ func (h *Handler) handleGetDocumentStyle(ctx context.Context, args map[string]in
 		return nil, fmt.Errorf("failed to get document: %w", err)
 	}
 	
+	// A themed document reports its theme, its own overrides and the result
+	if doc.Theme != "" {
+		effective, err := style.NewStyleLoader(h.config.RootFolder).LoadStyleForThemedDocument(doc.Theme, doc.Style, doc.StyleSettings)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResponse(map[string]interface{}{
+			"theme":     doc.Theme,
+			"overrides": doc.StyleSettings,
+			"effective": effective,
+		})
+	}
+	
 	// Return the style config if it exists, otherwise return default
 	if doc.Style != nil {
 		return jsonResponse(doc.Style)
@@ -240,13 +371,23 @@ func (h *Handler) handleUpdateDocumentStyle(ctx context.Context, args map[string
 		return nil, fmt.Errorf("style configuration is required")
 	}
 	
-	// Update the document's style
-	newStyle, err := h.parseStyleConfig(styleData)
+	// A themed document keeps only the settings given, so the theme still
+	// decides the rest
+	newStyle, err := h.parseStyleConfig(styleData, doc.Theme == "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse style configuration: %w", err)
 	}
-	
-	doc.Style = newStyle
+	if doc.Theme != "" {
+		if _, err := style.ApplySettings(style.GetDefaultStyle(), styleData); err != nil {
+			return nil, err
+		}
+		doc.StyleSettings = styleData
+		if len(doc.StyleSettings) == 0 {
+			doc.StyleSettings = nil
+		}
+	} else {
+		doc.Style = newStyle
+	}
 	
 	// Save the updated document
 	if err := h.storage.SaveDocument(docID, doc); err != nil {
@@ -256,16 +397,90 @@ func (h *Handler) handleUpdateDocumentStyle(ctx context.Context, args map[string
 	return successResponse("Document style updated successfully"), nil
 }
 
-// parseStyleConfig parses style configuration from map to StyleConfig struct
-func (h *Handler) parseStyleConfig(data map[string]interface{}) (*style.StyleConfig, error) {
+// handleSetDocumentVariables sets the values substituted for {{name}} placeholders
+func (h *Handler) handleSetDocumentVariables(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	
+	variables, ok := args["variables"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("variables must be an object")
+	}
+	
+	doc, err := h.storage.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	
+	if getBool(args, "replace", false) || doc.Variables == nil {
+		doc.Variables = make(map[string]string)
+	}
+	for name, value := range variables {
+		if err := export.ValidateVariableName(name); err != nil {
+			return nil, err
+		}
+		if value == nil {
+			// null removes the variable
+			delete(doc.Variables, name)
+			continue
+		}
+		text, err := export.VariableValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("value of variable %s %w, or null", name, err)
+		}
+		doc.Variables[name] = text
+	}
+	if len(doc.Variables) == 0 {
+		doc.Variables = nil
+	}
+	
+	if err := h.storage.SaveDocument(docID, doc); err != nil {
+		return nil, fmt.Errorf("failed to save document: %w", err)
+	}
+	
+	return jsonResponse(map[string]interface{}{
+		"document_id": docID,
+		"variables":   doc.Variables,
+		"message":     fmt.Sprintf("Document has %d variables", len(doc.Variables)),
+	})
+}
+
+// parseStyleConfig parses style configuration from map to StyleConfig struct.
+// Without defaults, settings missing from a given section are left empty.
+func (h *Handler) parseStyleConfig(data map[string]interface{}, withDefaults bool) (*style.StyleConfig, error) {
 	config := &style.StyleConfig{}
 	
+	// Defaults for the settings a given section leaves out
+	text := func(m map[string]interface{}, key, defaultValue string) string {
+		if !withDefaults {
+			defaultValue = ""
+		}
+		return getStringFromMap(m, key, defaultValue)
+	}
+	number := func(m map[string]interface{}, key string, defaultValue int) int {
+		if !withDefaults {
+			defaultValue = 0
+		}
+		return getIntFromMap(m, key, defaultValue)
+	}
+	decimal := func(m map[string]interface{}, key string, defaultValue float64) float64 {
+		if !withDefaults {
+			defaultValue = 0
+		}
+		return getFloatFromMap(m, key, defaultValue)
+	}
+	flag := func(m map[string]interface{}, key string, defaultValue bool) bool {
+		return getBoolFromMap(m, key, defaultValue && withDefaults)
+	}
+	
 	// Parse fonts
 	if fonts, ok := data["fonts"].(map[string]interface{}); ok {
-		config.Fonts.BodyFamily = getStringFromMap(fonts, "body_family", "Times New Roman")
-		config.Fonts.HeadingFamily = getStringFromMap(fonts, "heading_family", "Arial")
-		config.Fonts.MonospaceFamily = getStringFromMap(fonts, "monospace_family", "Courier New")
-		config.Fonts.BodySize = getIntFromMap(fonts, "body_size", 11)
+		config.Fonts.BodyFamily = text(fonts, "body_family", "Times New Roman")
+		config.Fonts.HeadingFamily = text(fonts, "heading_family", "Arial")
+		config.Fonts.MonospaceFamily = text(fonts, "monospace_family", "Courier New")
+		config.Fonts.BodySize = number(fonts, "body_size", 11)
 		
 		// Parse heading sizes
 		if headingSizes, ok := fonts["heading_sizes"].(map[string]interface{}); ok {
@@ -275,7 +490,7 @@ func (h *Handler) parseStyleConfig(data map[string]interface{}) (*style.StyleCon
 					config.Fonts.HeadingSizes[k] = int(size)
 				}
 			}
-		} else {
+		} else if withDefaults {
 			// Use defaults
 			config.Fonts.HeadingSizes = map[string]int{
 				"h1": 20, "h2": 16, "h3": 14, "h4": 12, "h5": 11, "h6": 10,
@@ -285,44 +500,72 @@ func (h *Handler) parseStyleConfig(data map[string]interface{}) (*style.StyleCon
 	
 	// Parse colors
 	if colors, ok := data["colors"].(map[string]interface{}); ok {
-		config.Colors.BodyText = getStringFromMap(colors, "body_text", "0,0,0")
-		config.Colors.HeadingText = getStringFromMap(colors, "heading_text", "0,0,0")
+		config.Colors.BodyText = text(colors, "body_text", "0,0,0")
+		config.Colors.HeadingText = text(colors, "heading_text", "0,0,0")
 	}
 	
 	// Parse page config
 	if page, ok := data["page"].(map[string]interface{}); ok {
-		config.Page.Size = getStringFromMap(page, "size", "a4")
-		config.Page.Orientation = getStringFromMap(page, "orientation", "portrait")
+		config.Page.Size = text(page, "size", "a4")
+		config.Page.Orientation = text(page, "orientation", "portrait")
 		
 		// Parse margins
 		if margins, ok := page["margins"].(map[string]interface{}); ok {
-			config.Page.Margins.Top = getIntFromMap(margins, "top", 72)
-			config.Page.Margins.Bottom = getIntFromMap(margins, "bottom", 72)
-			config.Page.Margins.Left = getIntFromMap(margins, "left", 72)
-			config.Page.Margins.Right = getIntFromMap(margins, "right", 72)
+			config.Page.Margins.Top = number(margins, "top", 72)
+			config.Page.Margins.Bottom = number(margins, "bottom", 72)
+			config.Page.Margins.Left = number(margins, "left", 72)
+			config.Page.Margins.Right = number(margins, "right", 72)
 		}
 	}
 	
 	// Parse spacing
 	if spacing, ok := data["spacing"].(map[string]interface{}); ok {
-		config.Spacing.LineSpacing = getFloatFromMap(spacing, "line_spacing", 1.2)
-		config.Spacing.ParagraphSpacing = getIntFromMap(spacing, "paragraph_spacing", 6)
+		config.Spacing.LineSpacing = decimal(spacing, "line_spacing", 1.2)
+		config.Spacing.ParagraphSpacing = number(spacing, "paragraph_spacing", 6)
 	}
 	
 	// Parse header
 	if header, ok := data["header"].(map[string]interface{}); ok {
-		config.Header.Enabled = getBoolFromMap(header, "enabled", true)
-		config.Header.Content = getStringFromMap(header, "content", "{title}")
-		config.Header.Align = getStringFromMap(header, "align", "center")
-		config.Header.FontSize = getIntFromMap(header, "font_size", 10)
+		config.Header.Enabled = flag(header, "enabled", true)
+		config.Header.Content = text(header, "content", "{title}")
+		config.Header.Align = text(header, "align", "center")
+		config.Header.FontSize = number(header, "font_size", 10)
 	}
 	
 	// Parse footer
 	if footer, ok := data["footer"].(map[string]interface{}); ok {
-		config.Footer.Enabled = getBoolFromMap(footer, "enabled", true)
-		config.Footer.Content = getStringFromMap(footer, "content", "Page {page}")
-		config.Footer.Align = getStringFromMap(footer, "align", "center")
-		config.Footer.FontSize = getIntFromMap(footer, "font_size", 10)
+		config.Footer.Enabled = flag(footer, "enabled", true)
+		config.Footer.Content = text(footer, "content", "Page {page}")
+		config.Footer.Align = text(footer, "align", "center")
+		config.Footer.FontSize = number(footer, "font_size", 10)
+	}
+	
+	// Parse table of contents
+	if toc, ok := data["toc"].(map[string]interface{}); ok {
+		config.TOC.Enabled = flag(toc, "enabled", true)
+		config.TOC.Depth = number(toc, "depth", 3)
+		config.TOC.Title = text(toc, "title", "Contents")
+		
+		if (withDefaults || config.TOC.Depth != 0) && (config.TOC.Depth < 1 || config.TOC.Depth > 6) {
+			return nil, fmt.Errorf("toc depth must be between 1 and 6")
+		}
+	}
+	
+	// Parse captions
+	if captions, ok := data["captions"].(map[string]interface{}); ok {
+		config.Captions.Numbering = text(captions, "numbering", "global")
+		config.Captions.FigurePrefix = text(captions, "figure_prefix", "Figure")
+		config.Captions.TablePrefix = text(captions, "table_prefix", "Table")
+		config.Captions.ListOfFigures = flag(captions, "list_of_figures", false)
+		config.Captions.ListOfTables = flag(captions, "list_of_tables", false)
+		
+		switch config.Captions.Numbering {
+		case "global", "chapter", "none":
+		default:
+			if withDefaults || config.Captions.Numbering != "" {
+				return nil, fmt.Errorf("invalid caption numbering %q (supported: global, chapter, none)", config.Captions.Numbering)
+			}
+		}
 	}
 	
 	return config, nil
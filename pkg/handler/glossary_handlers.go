@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/document"
+)
+
+// handleSetGlossaryTerm adds or updates a glossary term; an empty definition removes it
+func (h *Handler) handleSetGlossaryTerm(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	term, err := getString(args, "term", true)
+	if err != nil {
+		return nil, err
+	}
+	term = strings.TrimSpace(term)
+	definition, err := getString(args, "definition", false)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := getStringArray(args, "aliases", false)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := h.storage.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	// Terms are matched case-insensitively, like the names that link to them
+	existing := -1
+	for i, entry := range doc.Glossary {
+		if strings.EqualFold(entry.Term, term) {
+			existing = i
+			break
+		}
+	}
+
+	if strings.TrimSpace(definition) == "" {
+		if existing < 0 {
+			return nil, fmt.Errorf("glossary term not found: %s", term)
+		}
+		doc.Glossary = append(doc.Glossary[:existing], doc.Glossary[existing+1:]...)
+		if len(doc.Glossary) == 0 {
+			doc.Glossary = nil
+		}
+		if err := h.storage.SaveDocument(docID, doc); err != nil {
+			return nil, fmt.Errorf("failed to save document: %w", err)
+		}
+		return successResponse(fmt.Sprintf("Removed glossary term %q", term)), nil
+	}
+
+	// A name may only link to one definition
+	for i, entry := range doc.Glossary {
+		if i == existing {
+			continue
+		}
+		for _, name := range append([]string{term}, aliases...) {
+			for _, other := range append([]string{entry.Term}, entry.Aliases...) {
+				if strings.EqualFold(name, other) {
+					return nil, fmt.Errorf("%q is already used by glossary term %q", name, entry.Term)
+				}
+			}
+		}
+	}
+
+	entry := document.GlossaryTerm{Term: term, Definition: definition}
+	if len(aliases) > 0 {
+		entry.Aliases = aliases
+	}
+	if existing >= 0 {
+		doc.Glossary[existing] = entry
+	} else {
+		doc.Glossary = append(doc.Glossary, entry)
+	}
+	sort.SliceStable(doc.Glossary, func(i, j int) bool {
+		return strings.ToLower(doc.Glossary[i].Term) < strings.ToLower(doc.Glossary[j].Term)
+	})
+
+	if err := h.storage.SaveDocument(docID, doc); err != nil {
+		return nil, fmt.Errorf("failed to save document: %w", err)
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"document_id": docID,
+		"term":        entry,
+		"message":     fmt.Sprintf("Glossary has %d terms", len(doc.Glossary)),
+	})
+}
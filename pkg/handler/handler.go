@@ -66,6 +66,30 @@ func (h *Handler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (
 		return h.handleGetDocumentStyle(ctx, req.Arguments)
 	case "update_document_style":
 		return h.handleUpdateDocumentStyle(ctx, req.Arguments)
+	case "set_style_override":
+		return h.handleSetStyleOverride(ctx, req.Arguments)
+	case "list_styles":
+		return h.handleListStyles(ctx, req.Arguments)
+	case "apply_style_theme":
+		return h.handleApplyStyleTheme(ctx, req.Arguments)
+	case "save_style_as_theme":
+		return h.handleSaveStyleAsTheme(ctx, req.Arguments)
+	case "delete_style_theme":
+		return h.handleDeleteStyleTheme(ctx, req.Arguments)
+	case "validate_references":
+		return h.handleValidateReferences(ctx, req.Arguments)
+	case "set_document_variables":
+		return h.handleSetDocumentVariables(ctx, req.Arguments)
+	case "set_document_profile":
+		return h.handleSetDocumentProfile(ctx, req.Arguments)
+	case "set_conditions":
+		return h.handleSetConditions(ctx, req.Arguments)
+	case "set_status":
+		return h.handleSetStatus(ctx, req.Arguments)
+	case "set_cover_page":
+		return h.handleSetCoverPage(ctx, req.Arguments)
+	case "set_glossary_term":
+		return h.handleSetGlossaryTerm(ctx, req.Arguments)
 		
 	// Block operations
 	case "add_heading":
@@ -76,6 +100,32 @@ func (h *Handler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (
 		return h.handleAddImage(ctx, req.Arguments)
 	case "add_table":
 		return h.handleAddTable(ctx, req.Arguments)
+	case "add_table_from_file":
+		return h.handleAddTableFromFile(ctx, req.Arguments)
+	case "refresh_table":
+		return h.handleRefreshTable(ctx, req.Arguments)
+	case "add_chart":
+		return h.handleAddChart(ctx, req.Arguments)
+	case "add_gallery":
+		return h.handleAddGallery(ctx, req.Arguments)
+	case "add_diagram":
+		return h.handleAddDiagram(ctx, req.Arguments)
+	case "add_raw":
+		return h.handleAddRaw(ctx, req.Arguments)
+	case "add_list":
+		return h.handleAddList(ctx, req.Arguments)
+	case "add_list_item":
+		return h.handleAddListItem(ctx, req.Arguments)
+	case "toggle_list_item":
+		return h.handleToggleListItem(ctx, req.Arguments)
+	case "move_list_item":
+		return h.handleMoveListItem(ctx, req.Arguments)
+	case "add_quote":
+		return h.handleAddQuote(ctx, req.Arguments)
+	case "add_layout":
+		return h.handleAddLayout(ctx, req.Arguments)
+	case "add_include":
+		return h.handleAddInclude(ctx, req.Arguments)
 	case "add_page_break":
 		return h.handleAddPageBreak(ctx, req.Arguments)
 	case "add_multiple_blocks":
@@ -90,6 +140,24 @@ func (h *Handler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (
 		return h.handleGetBlock(ctx, req.Arguments)
 	case "get_blocks":
 		return h.handleGetBlocks(ctx, req.Arguments)
+	case "set_block_label":
+		return h.handleSetBlockLabel(ctx, req.Arguments)
+	case "tag_block":
+		return h.handleTagBlock(ctx, req.Arguments)
+	case "list_tags":
+		return h.handleListTags(ctx, req.Arguments)
+	case "add_comment":
+		return h.handleAddComment(ctx, req.Arguments)
+	case "list_comments":
+		return h.handleListComments(ctx, req.Arguments)
+	case "resolve_comment":
+		return h.handleResolveComment(ctx, req.Arguments)
+	case "list_suggestions":
+		return h.handleListSuggestions(ctx, req.Arguments)
+	case "accept_suggestion":
+		return h.handleAcceptSuggestion(ctx, req.Arguments)
+	case "reject_suggestion":
+		return h.handleRejectSuggestion(ctx, req.Arguments)
 		
 	// Chapter operations
 	case "add_chapter":
@@ -101,6 +169,18 @@ func (h *Handler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (
 	case "move_chapter":
 		return h.handleMoveChapter(ctx, req.Arguments)
 		
+	// Snippet library operations
+	case "create_snippet":
+		return h.handleCreateSnippet(ctx, req.Arguments)
+	case "update_snippet":
+		return h.handleUpdateSnippet(ctx, req.Arguments)
+	case "get_snippet":
+		return h.handleGetSnippet(ctx, req.Arguments)
+	case "list_snippets":
+		return h.handleListSnippets(ctx, req.Arguments)
+	case "delete_snippet":
+		return h.handleDeleteSnippet(ctx, req.Arguments)
+		
 	// Export operations
 	case "export_document":
 		return h.handleExportDocument(ctx, req.Arguments)
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/document"
+)
+
+// handleAddList adds a bulleted, numbered or task list
+func (h *Handler) handleAddList(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+
+	chapterID, _ := getString(args, "chapter_id", false)
+
+	list, err := parseList(args)
+	if err != nil {
+		return nil, err
+	}
+
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+
+	if err := h.storage.AddBlock(docID, chapterID, list, position); err != nil {
+		return nil, fmt.Errorf("failed to add list: %w", err)
+	}
+
+	return successResponse(fmt.Sprintf("Added %s list with %d items (block ID: %s)", list.Style, len(list.Items), list.ID)), nil
+}
+
+// handleAddListItem inserts one item into a list without rewriting the rest
+func (h *Handler) handleAddListItem(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, blockID, list, err := h.loadList(args)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := getString(args, "text", true)
+	if err != nil {
+		return nil, err
+	}
+	parent, _ := getString(args, "parent", false)
+	position, err := getInt(args, "position", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	item := blocks.ListItem{Text: text, Checked: getBool(args, "checked", false)}
+	path, err := list.InsertItem(parent, position, item)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.saveList(docID, blockID, list, path, fmt.Sprintf("Added item %s to list %s", path, blockID))
+}
+
+// handleToggleListItem checks or unchecks one item of a task list
+func (h *Handler) handleToggleListItem(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, blockID, list, err := h.loadList(args)
+	if err != nil {
+		return nil, err
+	}
+	if list.Style != blocks.ListTask {
+		return nil, fmt.Errorf("list %s is a %s list; only task lists have checkboxes", blockID, list.Style)
+	}
+
+	path, err := getString(args, "item", true)
+	if err != nil {
+		return nil, err
+	}
+	item, err := list.Item(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Toggle unless an explicit state is given, so retries can be made idempotent
+	if _, ok := args["checked"]; ok {
+		item.Checked = getBool(args, "checked", false)
+	} else {
+		item.Checked = !item.Checked
+	}
+
+	state := "Unchecked"
+	if item.Checked {
+		state = "Checked"
+	}
+	return h.saveList(docID, blockID, list, path, fmt.Sprintf("%s item %s: %s", state, path, truncateString(item.Text, 60)))
+}
+
+// handleMoveListItem reorders an item among its siblings
+func (h *Handler) handleMoveListItem(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, blockID, list, err := h.loadList(args)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := getString(args, "item", true)
+	if err != nil {
+		return nil, err
+	}
+	position, err := getInt(args, "position", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	newPath, err := list.MoveItem(path, position)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.saveList(docID, blockID, list, newPath, fmt.Sprintf("Moved item %s to %s", path, newPath))
+}
+
+// loadList loads the list block named by document_id and block_id
+func (h *Handler) loadList(args map[string]interface{}) (string, string, *blocks.ListBlock, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return "", "", nil, err
+	}
+	blockID, err := getString(args, "block_id", true)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	block, _, _, err := h.storage.GetBlock(docID, blockID)
+	if err != nil {
+		return "", "", nil, err
+	}
+	list, ok := block.(*blocks.ListBlock)
+	if !ok {
+		return "", "", nil, fmt.Errorf("block %s is a %s block, not a list", blockID, block.GetType())
+	}
+	return docID, blockID, list, nil
+}
+
+// saveList stores a changed list and reports the affected item
+func (h *Handler) saveList(docID, blockID string, list *blocks.ListBlock, path, message string) (*protocol.CallToolResponse, error) {
+	if err := list.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid list: %w", err)
+	}
+	if err := h.storage.UpdateBlock(docID, blockID, list); err != nil {
+		return nil, fmt.Errorf("failed to update list: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"block_id": blockID,
+		"item":     path,
+		"message":  message,
+	}
+	if list.Style == blocks.ListTask {
+		checked, total := list.Progress()
+		result["progress"] = fmt.Sprintf("%d/%d", checked, total)
+	}
+	return jsonResponse(result)
+}
+
+// parseList reads and validates the fields of a list block
+func parseList(args map[string]interface{}) (*blocks.ListBlock, error) {
+	style, _ := getString(args, "style", false)
+	if style == "" {
+		style = blocks.ListUnordered
+	}
+	start, err := getInt(args, "start", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := parseListItems(args["items"])
+	if err != nil {
+		return nil, err
+	}
+
+	list := &blocks.ListBlock{Style: style, Start: start, Items: items}
+	if err := list.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid list: %w", err)
+	}
+	return list, nil
+}
+
+// parseListItems reads items given as strings or as {text, checked, items} objects
+func parseListItems(value interface{}) ([]blocks.ListItem, error) {
+	if value == nil {
+		return nil, nil
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("items must be an array")
+	}
+
+	items := make([]blocks.ListItem, 0, len(values))
+	for i, v := range values {
+		switch item := v.(type) {
+		case string:
+			items = append(items, blocks.ListItem{Text: item})
+		case map[string]interface{}:
+			children, err := parseListItems(item["items"])
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, blocks.ListItem{
+				Text:    getStringFromMap(item, "text", ""),
+				Checked: getBool(item, "checked", false),
+				Items:   children,
+			})
+		default:
+			return nil, fmt.Errorf("item %d must be a string or an object with text", i+1)
+		}
+	}
+	return items, nil
+}
+
+// listItemsResponse converts list items for JSON responses, numbering them by path
+func listItemsResponse(items []blocks.ListItem, parent string, task bool) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		path := fmt.Sprintf("%d", i+1)
+		if parent != "" {
+			path = parent + "." + path
+		}
+		entry := map[string]interface{}{
+			"item": path,
+			"text": item.Text,
+		}
+		if task {
+			entry["checked"] = item.Checked
+		}
+		if len(item.Items) > 0 {
+			entry["items"] = listItemsResponse(item.Items, path, task)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
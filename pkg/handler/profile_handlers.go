@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// handleSetConditions restricts a block or a chapter to export profiles that meet its conditions
+func (h *Handler) handleSetConditions(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	blockID, _ := getString(args, "block_id", false)
+	chapterID, _ := getString(args, "chapter_id", false)
+	if (blockID == "") == (chapterID == "") {
+		return nil, fmt.Errorf("provide either block_id or chapter_id")
+	}
+
+	conditions, err := parseConditions(args["conditions"])
+	if err != nil {
+		return nil, err
+	}
+
+	target := "block " + blockID
+	if chapterID != "" {
+		target = "chapter " + chapterID
+		err = h.storage.SetChapterConditions(docID, chapterID, conditions)
+	} else {
+		err = h.storage.SetBlockConditions(docID, blockID, conditions)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set conditions: %w", err)
+	}
+
+	if len(conditions) == 0 {
+		return successResponse(fmt.Sprintf("Removed conditions from %s; it is exported for every profile", target)), nil
+	}
+	return successResponse(fmt.Sprintf("Set conditions of %s to %s", target, conditions)), nil
+}
+
+// handleSetDocumentProfile saves a named export profile; an empty profile removes it
+func (h *Handler) handleSetDocumentProfile(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	name, err := getString(args, "name", true)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := parseProfile(args["values"])
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := h.storage.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	if len(profile) == 0 {
+		if _, ok := doc.Profiles[name]; !ok {
+			return nil, fmt.Errorf("profile not found: %s", name)
+		}
+		delete(doc.Profiles, name)
+		if len(doc.Profiles) == 0 {
+			doc.Profiles = nil
+		}
+	} else {
+		if doc.Profiles == nil {
+			doc.Profiles = make(map[string]blocks.Profile)
+		}
+		doc.Profiles[name] = profile
+	}
+
+	if err := h.storage.SaveDocument(docID, doc); err != nil {
+		return nil, fmt.Errorf("failed to save document: %w", err)
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"document_id": docID,
+		"profiles":    doc.Profiles,
+		"message":     fmt.Sprintf("Document has %d profiles", len(doc.Profiles)),
+	})
+}
+
+// resolveProfile reads the profile argument of an export: the name of a profile
+// saved on the document, or an object of values. It also returns the name, if any.
+func (h *Handler) resolveProfile(docID string, value interface{}) (blocks.Profile, string, error) {
+	name, ok := value.(string)
+	if !ok {
+		profile, err := parseProfile(value)
+		return profile, "", err
+	}
+
+	doc, err := h.storage.GetDocument(docID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get document: %w", err)
+	}
+	profile, ok := doc.Profiles[name]
+	if !ok {
+		return nil, "", fmt.Errorf("profile not found: %s", name)
+	}
+	return profile, name, nil
+}
+
+// parseConditions converts {"audience": "internal", "edition": ["pro", "enterprise"]} to conditions
+func parseConditions(value interface{}) (blocks.Conditions, error) {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("conditions must be an object")
+	}
+
+	conditions := make(blocks.Conditions, len(values))
+	for name, allowed := range values {
+		switch v := allowed.(type) {
+		case string:
+			conditions[name] = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("values of condition %s must be strings", name)
+				}
+				conditions[name] = append(conditions[name], s)
+			}
+		default:
+			return nil, fmt.Errorf("condition %s must be a string or an array of strings", name)
+		}
+	}
+
+	if err := conditions.Validate(); err != nil {
+		return nil, err
+	}
+	return conditions, nil
+}
+
+// parseProfile converts {"audience": "external"} to a profile
+func parseProfile(value interface{}) (blocks.Profile, error) {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("profile values must be an object")
+	}
+
+	profile := make(blocks.Profile, len(values))
+	for name, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("profile value of %s must be a string", name)
+		}
+		profile[name] = s
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/document"
+)
+
+// handleCreateSnippet adds a snippet to the workspace library
+func (h *Handler) handleCreateSnippet(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	title, err := getString(args, "title", true)
+	if err != nil {
+		return nil, err
+	}
+	content, err := getString(args, "content", true)
+	if err != nil {
+		return nil, err
+	}
+	description, _ := getString(args, "description", false)
+
+	snippet, err := h.storage.CreateSnippet(title, description, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snippet: %w", err)
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"snippet_id": snippet.ID,
+		"title":      snippet.Title,
+		"message":    fmt.Sprintf("Created snippet '%s'; include it with add_include", snippet.ID),
+	})
+}
+
+// handleUpdateSnippet changes a snippet; every document including it picks up the change on export
+func (h *Handler) handleUpdateSnippet(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	snippetID, err := getString(args, "snippet_id", true)
+	if err != nil {
+		return nil, err
+	}
+
+	snippet, err := h.storage.GetSnippet(snippetID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := args["title"]; ok {
+		if snippet.Title, err = getString(args, "title", true); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := args["description"]; ok {
+		if snippet.Description, err = getString(args, "description", true); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := args["content"]; ok {
+		if snippet.Content, err = getString(args, "content", true); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.storage.SaveSnippet(snippet); err != nil {
+		return nil, fmt.Errorf("failed to update snippet: %w", err)
+	}
+
+	usages, err := h.storage.SnippetUsages(snippetID)
+	if err != nil {
+		return nil, err
+	}
+	return jsonResponse(map[string]interface{}{
+		"snippet_id": snippetID,
+		"used_by":    usages,
+		"message":    fmt.Sprintf("Updated snippet '%s' (included by %d blocks)", snippetID, len(usages)),
+	})
+}
+
+// handleGetSnippet returns a snippet with its content and where it is used
+func (h *Handler) handleGetSnippet(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	snippetID, err := getString(args, "snippet_id", true)
+	if err != nil {
+		return nil, err
+	}
+
+	snippet, err := h.storage.GetSnippet(snippetID)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := h.storage.SnippetUsages(snippetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"snippet": snippet,
+		"used_by": usages,
+	})
+}
+
+// handleListSnippets lists the snippet library with content previews
+func (h *Handler) handleListSnippets(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	snippets, err := h.storage.ListSnippets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snippets: %w", err)
+	}
+
+	result := make([]map[string]interface{}, 0, len(snippets))
+	for _, snippet := range snippets {
+		result = append(result, map[string]interface{}{
+			"id":          snippet.ID,
+			"title":       snippet.Title,
+			"description": snippet.Description,
+			"preview":     truncateString(snippet.Content, 100),
+			"updated_at":  snippet.UpdatedAt,
+		})
+	}
+
+	return jsonResponse(result)
+}
+
+// handleDeleteSnippet removes a snippet. Snippets still included by documents are
+// kept unless force is set, since those documents would no longer export.
+func (h *Handler) handleDeleteSnippet(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	snippetID, err := getString(args, "snippet_id", true)
+	if err != nil {
+		return nil, err
+	}
+	force := getBool(args, "force", false)
+
+	if !force {
+		usages, err := h.storage.SnippetUsages(snippetID)
+		if err != nil {
+			return nil, err
+		}
+		if len(usages) > 0 {
+			var places []string
+			for _, usage := range usages {
+				places = append(places, usage.DocumentID+"/"+usage.BlockID)
+			}
+			return nil, fmt.Errorf("snippet '%s' is included by %s; remove those blocks or set force", snippetID, strings.Join(places, ", "))
+		}
+	}
+
+	if err := h.storage.DeleteSnippet(snippetID); err != nil {
+		return nil, fmt.Errorf("failed to delete snippet: %w", err)
+	}
+
+	return successResponse(fmt.Sprintf("Snippet '%s' deleted successfully", snippetID)), nil
+}
+
+// handleAddInclude adds a block that transcludes a snippet or a block of another document
+func (h *Handler) handleAddInclude(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+
+	chapterID, _ := getString(args, "chapter_id", false)
+
+	include, err := h.parseInclude(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.storage.CheckIncludeCycle(docID, chapterID, "", include); err != nil {
+		return nil, fmt.Errorf("invalid include: %w", err)
+	}
+
+	positionStr, _ := getString(args, "position", false)
+	position := document.ParsePosition(positionStr)
+
+	if err := h.storage.AddBlock(docID, chapterID, include, position); err != nil {
+		return nil, fmt.Errorf("failed to add include: %w", err)
+	}
+
+	return successResponse(fmt.Sprintf("Added include of %s (block ID: %s)", include.Source(), include.ID)), nil
+}
+
+// parseInclude reads an include's source and checks that it resolves
+func (h *Handler) parseInclude(args map[string]interface{}) (*blocks.IncludeBlock, error) {
+	include := &blocks.IncludeBlock{}
+	include.Snippet, _ = getString(args, "snippet_id", false)
+	include.Document, _ = getString(args, "source_document_id", false)
+	include.Block, _ = getString(args, "source_block_id", false)
+
+	if err := include.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid include: %w", err)
+	}
+	if _, err := h.storage.ResolveInclude(include); err != nil {
+		return nil, fmt.Errorf("invalid include: %w", err)
+	}
+	return include, nil
+}
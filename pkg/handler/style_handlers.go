@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+)
+
+// handleSetStyleOverride sets or clears the style override of a block or chapter
+func (h *Handler) handleSetStyleOverride(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	blockID, _ := getString(args, "block_id", false)
+	chapterID, _ := getString(args, "chapter_id", false)
+	if (blockID == "") == (chapterID == "") {
+		return nil, fmt.Errorf("provide either block_id or chapter_id")
+	}
+
+	data, ok := args["style"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("style must be an object")
+	}
+	override := &style.StyleOverride{
+		FontSize:     getIntFromMap(data, "font_size", 0),
+		Color:        getStringFromMap(data, "color", ""),
+		Background:   getStringFromMap(data, "background", ""),
+		Align:        getStringFromMap(data, "align", ""),
+		Orientation:  getStringFromMap(data, "orientation", ""),
+		KeepTogether: getBoolFromMap(data, "keep_together", false),
+	}
+
+	target := "Block " + blockID
+	if chapterID != "" {
+		target = "Chapter " + chapterID
+		err = h.storage.SetChapterStyle(docID, chapterID, override)
+	} else {
+		err = h.storage.SetBlockStyle(docID, blockID, override)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set style override: %w", err)
+	}
+
+	if override.IsEmpty() {
+		return successResponse(fmt.Sprintf("%s follows the document style", target)), nil
+	}
+	return successResponse(fmt.Sprintf("%s style override set", target)), nil
+}
+
+// themeSummary describes a style theme in list_styles
+type themeSummary struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	BodyFamily    string   `json:"body_family"`
+	HeadingFamily string   `json:"heading_family"`
+	BodySize      int      `json:"body_size"`
+	PageSize      string   `json:"page_size"`
+	Documents     []string `json:"documents,omitempty"` // Documents using the theme
+}
+
+// handleListStyles lists the style themes, or returns one theme's full style
+func (h *Handler) handleListStyles(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	loader := style.NewStyleLoader(h.config.RootFolder)
+
+	name, err := getString(args, "name", false)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		theme, err := loader.LoadTheme(name)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResponse(theme)
+	}
+
+	themes, err := loader.ListThemes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list style themes: %w", err)
+	}
+	summaries := make([]themeSummary, 0, len(themes))
+	for _, theme := range themes {
+		documents, err := h.storage.DocumentsUsingTheme(theme.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list style themes: %w", err)
+		}
+		summaries = append(summaries, themeSummary{
+			Name:          theme.Name,
+			Description:   theme.Description,
+			BodyFamily:    theme.Style.Fonts.BodyFamily,
+			HeadingFamily: theme.Style.Fonts.HeadingFamily,
+			BodySize:      theme.Style.Fonts.BodySize,
+			PageSize:      theme.Style.Page.Size,
+			Documents:     documents,
+		})
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"themes": summaries,
+		"count":  len(summaries),
+	})
+}
+
+// handleApplyStyleTheme makes a document use a style theme, or no theme
+func (h *Handler) handleApplyStyleTheme(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	theme, err := getString(args, "theme", false)
+	if err != nil {
+		return nil, err
+	}
+	keepStyle := getBool(args, "keep_overrides", false)
+
+	if err := h.storage.SetDocumentTheme(docID, theme, keepStyle); err != nil {
+		return nil, fmt.Errorf("failed to apply style theme: %w", err)
+	}
+
+	message := fmt.Sprintf("Document %s now uses the %s theme", docID, theme)
+	if theme == "" {
+		message = fmt.Sprintf("Document %s no longer uses a theme", docID)
+	}
+	if keepStyle {
+		message += "; its own style settings still apply on top"
+	} else {
+		message += "; its own style settings were cleared"
+	}
+	return successResponse(message), nil
+}
+
+// handleSaveStyleAsTheme saves a document's effective style as a theme and
+// switches the document to it
+func (h *Handler) handleSaveStyleAsTheme(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	name, err := getString(args, "name", true)
+	if err != nil {
+		return nil, err
+	}
+	description, err := getString(args, "description", false)
+	if err != nil {
+		return nil, err
+	}
+	if err := style.ValidateThemeName(name); err != nil {
+		return nil, err
+	}
+
+	loader := style.NewStyleLoader(h.config.RootFolder)
+	existing, err := loader.LoadTheme(name)
+	exists := err == nil
+	if exists && !getBool(args, "overwrite", false) {
+		return nil, fmt.Errorf("style theme %s already exists; set overwrite to replace it", name)
+	}
+	if exists && description == "" {
+		description = existing.Description
+	}
+
+	doc, err := h.storage.GetDocument(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	effective, err := loader.LoadStyleForThemedDocument(doc.Theme, doc.Style, doc.StyleSettings)
+	if err != nil {
+		return nil, err
+	}
+	if err := loader.SaveTheme(style.Theme{Name: name, Description: description, Style: effective}); err != nil {
+		return nil, err
+	}
+
+	// The theme now holds the document's settings, so it needs none of its own
+	if err := h.storage.SetDocumentTheme(docID, name, false); err != nil {
+		return nil, fmt.Errorf("failed to apply style theme: %w", err)
+	}
+
+	documents, err := h.storage.DocumentsUsingTheme(name)
+	if err != nil {
+		return nil, err
+	}
+	return successResponse(fmt.Sprintf("Saved style theme %s, used by %d document(s): %s", name, len(documents), strings.Join(documents, ", "))), nil
+}
+
+// handleDeleteStyleTheme deletes a theme that no document uses
+func (h *Handler) handleDeleteStyleTheme(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	name, err := getString(args, "name", true)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := h.storage.DocumentsUsingTheme(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete style theme: %w", err)
+	}
+	if len(documents) > 0 {
+		return nil, fmt.Errorf("style theme %s is used by %s; apply another theme to them first", name, strings.Join(documents, ", "))
+	}
+
+	if err := style.NewStyleLoader(h.config.RootFolder).DeleteTheme(name); err != nil {
+		return nil, err
+	}
+	return successResponse(fmt.Sprintf("Deleted style theme %s", name)), nil
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"github.com/savant/mcp-servers/docgen2/pkg/storage"
+	"github.com/savant/mcp-servers/docgen2/pkg/textdiff"
+)
+
+// defaultSuggestionAuthor is recorded for suggestions filed without an author
+const defaultSuggestionAuthor = "Assistant"
+
+// suggestionDiffContext is the number of unchanged lines shown around each change
+const suggestionDiffContext = 2
+
+// suggestionView describes a pending suggestion with its diff against the block as it is now
+type suggestionView struct {
+	storage.Suggestion
+	Diff  string `json:"diff"`
+	Stale bool   `json:"stale,omitempty"` // The block was edited after the suggestion was made
+}
+
+// describeSuggestion compares a suggestion with the current content of its block
+func (h *Handler) describeSuggestion(docID string, suggestion *storage.Suggestion) (*suggestionView, error) {
+	current, _, _, err := h.storage.GetBlock(docID, suggestion.BlockID)
+	if err != nil {
+		return nil, err
+	}
+	proposed, err := h.storage.LoadSuggestedBlock(docID, suggestion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load suggestion %s: %w", suggestion.ID, err)
+	}
+
+	return &suggestionView{
+		Suggestion: *suggestion,
+		Diff:       textdiff.Unified(current.ToMarkdown(), proposed.ToMarkdown(), suggestionDiffContext),
+		Stale:      current.ToMarkdown() != suggestion.Base,
+	}, nil
+}
+
+// fileSuggestion records an edit from update_block as a pending suggestion
+// instead of applying it
+func (h *Handler) fileSuggestion(docID, blockID string, args map[string]interface{}, edit blocks.Block) (*protocol.CallToolResponse, error) {
+	author, err := getString(args, "author", false)
+	if err != nil {
+		return nil, err
+	}
+	if author == "" {
+		author = defaultSuggestionAuthor
+	}
+
+	suggestion, err := h.storage.AddSuggestion(docID, blockID, author, edit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to file suggestion: %w", err)
+	}
+	view, err := h.describeSuggestion(docID, suggestion)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"suggestion": view,
+		"message":    fmt.Sprintf("Filed suggestion %s for block %s; the block is unchanged until the suggestion is accepted", suggestion.ID, blockID),
+	})
+}
+
+// handleListSuggestions lists pending suggestions with their diffs
+func (h *Handler) handleListSuggestions(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	blockID, _ := getString(args, "block_id", false)
+
+	suggestions, err := h.storage.ListSuggestions(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suggestions: %w", err)
+	}
+
+	views := []*suggestionView{}
+	for i := range suggestions {
+		if blockID != "" && suggestions[i].BlockID != blockID {
+			continue
+		}
+		view, err := h.describeSuggestion(docID, &suggestions[i])
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+
+	return jsonResponse(map[string]interface{}{
+		"document_id": docID,
+		"suggestions": views,
+	})
+}
+
+// handleAcceptSuggestion applies a pending suggestion to its block
+func (h *Handler) handleAcceptSuggestion(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	suggestionID, err := getString(args, "suggestion_id", true)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion, err := h.storage.AcceptSuggestion(docID, suggestionID, getBool(args, "force", false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept suggestion: %w", err)
+	}
+	return successResponse(fmt.Sprintf("Accepted suggestion %s by %s; block %s is updated", suggestion.ID, suggestion.Author, suggestion.BlockID)), nil
+}
+
+// handleRejectSuggestion discards a pending suggestion
+func (h *Handler) handleRejectSuggestion(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	suggestionID, err := getString(args, "suggestion_id", true)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion, err := h.storage.RejectSuggestion(docID, suggestionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject suggestion: %w", err)
+	}
+	return successResponse(fmt.Sprintf("Rejected suggestion %s by %s; block %s is unchanged", suggestion.ID, suggestion.Author, suggestion.BlockID)), nil
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// tagSummary counts the blocks carrying a tag
+type tagSummary struct {
+	Tag    string   `json:"tag"`
+	Count  int      `json:"count"`
+	Blocks []string `json:"blocks"` // Block IDs in document order
+}
+
+// handleTagBlock adds and removes tags on a block
+func (h *Handler) handleTagBlock(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	blockID, err := getString(args, "block_id", true)
+	if err != nil {
+		return nil, err
+	}
+	add, err := getStringArray(args, "add", false)
+	if err != nil {
+		return nil, err
+	}
+	remove, err := getStringArray(args, "remove", false)
+	if err != nil {
+		return nil, err
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, fmt.Errorf("provide tags to add or remove")
+	}
+
+	tags, err := h.storage.TagBlock(docID, blockID, add, remove)
+	if err != nil {
+		return nil, fmt.Errorf("failed to tag block: %w", err)
+	}
+
+	if len(tags) == 0 {
+		return successResponse(fmt.Sprintf("Block %s has no tags", blockID)), nil
+	}
+	return successResponse(fmt.Sprintf("Block %s is tagged %s", blockID, strings.Join(tags, ", "))), nil
+}
+
+// handleListTags lists the tags used in a document with the blocks carrying each
+func (h *Handler) handleListTags(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := h.storage.GetAllBlockReferences(docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	byTag := make(map[string]*tagSummary)
+	for _, ref := range refs {
+		for _, tag := range ref.Tags {
+			summary := byTag[tag]
+			if summary == nil {
+				summary = &tagSummary{Tag: tag}
+				byTag[tag] = summary
+			}
+			summary.Count++
+			summary.Blocks = append(summary.Blocks, ref.ID)
+		}
+	}
+
+	tags := make([]*tagSummary, 0, len(byTag))
+	for _, summary := range byTag {
+		tags = append(tags, summary)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+
+	return jsonResponse(map[string]interface{}{
+		"document_id": docID,
+		"tags":        tags,
+	})
+}
@@ -48,11 +48,152 @@ func getAllTools() []protocol.Tool {
 					"document_id": {
 						"type": "string",
 						"description": "The document ID"
+					},
+					"status": {
+						"type": "string",
+						"enum": ["draft", "needs-review", "approved"],
+						"description": "Optional: only list blocks with this workflow status, and chapters with this status or with such blocks"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "set_document_variables",
+			Description: "Set document variables, used as {{name}} placeholders in any block text, titles and headers/footers",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"variables": {
+						"type": "object",
+						"description": "Variable values by name (letters, digits and '_'); numbers and booleans are stored as text. Merged into existing variables, a null value removes a variable",
+						"additionalProperties": {"type": ["string", "number", "boolean", "null"]}
+					},
+					"replace": {
+						"type": "boolean",
+						"description": "Optional: replace all existing variables instead of merging (default: false)"
+					}
+				},
+				"required": ["document_id", "variables"]
+			}`),
+		},
+		{
+			Name:        "set_document_profile",
+			Description: "Save a named export profile, e.g. 'external' = {audience: external}, to select conditional content when exporting",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"name": {
+						"type": "string",
+						"description": "Profile name, also appended to the exported file name"
+					},
+					"values": {
+						"type": "object",
+						"description": "Value of each condition name, e.g. {\"audience\": \"external\", \"edition\": \"pro\"}. Conditions the profile does not name are not filtered. An empty object removes the profile",
+						"additionalProperties": {"type": "string"}
+					}
+				},
+				"required": ["document_id", "name", "values"]
+			}`),
+		},
+		{
+			Name:        "set_cover_page",
+			Description: "Set the document's cover page: a dedicated first page in PDF and DOCX and a hero section at the top of HTML. Replaces the plain title Pandoc prints otherwise. Only the fields given are changed",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"layout": {
+						"type": "string",
+						"enum": ["classic", "modern", "minimal"],
+						"description": "Layout preset: 'classic' centers everything with the title mid-page, 'modern' is left-aligned with a rule under the title, 'minimal' puts title and details at the top and the logo at the bottom (default: classic)"
+					},
+					"title": {
+						"type": "string",
+						"description": "Optional: cover title (default: the document title). May use {{name}} variables"
+					},
+					"subtitle": {
+						"type": "string",
+						"description": "Optional: subtitle shown under the title"
+					},
+					"author": {
+						"type": "string",
+						"description": "Optional: author (default: the document author)"
+					},
+					"organization": {
+						"type": "string",
+						"description": "Optional: organization shown under the author"
+					},
+					"date": {
+						"type": "string",
+						"description": "Optional: date as written, or 'today' for the date of export"
+					},
+					"logo": {
+						"type": ["object", "null"],
+						"description": "Optional: logo image as {image_path} or {image_data, filename, mime_type}, stored like add_image; null removes it",
+						"properties": {
+							"image_path": {"type": "string"},
+							"image_data": {"type": "string"},
+							"filename": {"type": "string"},
+							"mime_type": {"type": "string"}
+						}
+					},
+					"background": {
+						"type": ["object", "null"],
+						"description": "Optional: full-page background image, in the same form as logo (PDF and HTML only); null removes it",
+						"properties": {
+							"image_path": {"type": "string"},
+							"image_data": {"type": "string"},
+							"filename": {"type": "string"},
+							"mime_type": {"type": "string"}
+						}
+					},
+					"remove": {
+						"type": "boolean",
+						"description": "Optional: remove the cover page (default: false)"
 					}
 				},
 				"required": ["document_id"]
 			}`),
 		},
+		{
+			Name:        "set_glossary_term",
+			Description: "Add, update or remove a glossary term. Exports end with a glossary section, and the first use of each term in markdown and list text links to its definition",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"term": {
+						"type": "string",
+						"description": "The term, e.g. 'API'. Matched in text as written, except that the first letter may differ in case"
+					},
+					"definition": {
+						"type": "string",
+						"description": "The definition (markdown, may use {{name}} variables). An empty definition removes the term"
+					},
+					"aliases": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Optional: other forms that also link to the definition, e.g. ['APIs']"
+					}
+				},
+				"required": ["document_id", "term", "definition"]
+			}`),
+		},
 		{
 			Name:        "delete_document",
 			Description: "Delete a document and all its content",
@@ -84,6 +225,15 @@ func getAllTools() []protocol.Tool {
 					"chapter_id": {
 						"type": "string",
 						"description": "Optional: limit search to a specific chapter"
+					},
+					"status": {
+						"type": "string",
+						"enum": ["draft", "needs-review", "approved"],
+						"description": "Optional: only return blocks with this workflow status"
+					},
+					"tag": {
+						"type": "string",
+						"description": "Optional: only return blocks with this tag"
 					}
 				},
 				"required": ["document_id", "query"]
@@ -105,7 +255,7 @@ func getAllTools() []protocol.Tool {
 		},
 		{
 			Name:        "update_document_style",
-			Description: "Update the style configuration for a document",
+			Description: "Update the style configuration for a document. For a document with a theme, only the settings given are stored, replacing any given before, and everything else follows the theme",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -140,6 +290,68 @@ func getAllTools() []protocol.Tool {
 							"footer": {
 								"type": "object",
 								"description": "Footer configuration"
+							},
+							"captions": {
+								"type": "object",
+								"description": "Caption configuration: numbering ('global', 'chapter' or 'none'), figure_prefix, table_prefix, list_of_figures, list_of_tables"
+							},
+							"toc": {
+								"type": "object",
+								"description": "Table of contents configuration: enabled, depth (1-6), title"
+							}
+						}
+					}
+				},
+				"required": ["document_id", "style"]
+			}`),
+		},
+		{
+			Name:        "set_style_override",
+			Description: "Restyle one chapter or block on top of the document style: font size, text and background color, alignment, landscape pages (e.g. for a wide table) and keeping content on one page. Word output applies only the page orientation",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"block_id": {
+						"type": "string",
+						"description": "The block to restyle (give block_id or chapter_id)"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "The chapter to restyle (give block_id or chapter_id)"
+					},
+					"style": {
+						"type": "object",
+						"description": "The override; omitted fields keep the surrounding style, and an empty object removes the override",
+						"properties": {
+							"font_size": {
+								"type": "integer",
+								"description": "Text size in points (4-96)"
+							},
+							"color": {
+								"type": "string",
+								"description": "Text color as R,G,B, e.g. \"200,0,0\""
+							},
+							"background": {
+								"type": "string",
+								"description": "Background color as R,G,B"
+							},
+							"align": {
+								"type": "string",
+								"enum": ["left", "center", "right", "justify"],
+								"description": "Text alignment"
+							},
+							"orientation": {
+								"type": "string",
+								"enum": ["portrait", "landscape"],
+								"description": "Page orientation for the content"
+							},
+							"keep_together": {
+								"type": "boolean",
+								"description": "Avoid page breaks inside a block. PDF starts a table on a new page when it does not fit; chapters cannot be kept together"
 							}
 						}
 					}
@@ -147,6 +359,95 @@ func getAllTools() []protocol.Tool {
 				"required": ["document_id", "style"]
 			}`),
 		},
+		{
+			Name:        "list_styles",
+			Description: "List the named style themes (presets academic, corporate, modern, resume and book, plus saved themes) with the documents using each, or get one theme's full style",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"name": {
+						"type": "string",
+						"description": "Optional: a theme to return in full"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "apply_style_theme",
+			Description: "Make a document use a named style theme. The document follows later changes to the theme; its own style settings, if kept, apply on top",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"theme": {
+						"type": "string",
+						"description": "The theme name; omit to stop using a theme"
+					},
+					"keep_overrides": {
+						"type": "boolean",
+						"description": "Keep the document's own style settings on top of the theme (default: false, they are cleared)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "save_style_as_theme",
+			Description: "Save a document's effective style as a named theme and switch the document to it. Overwriting a theme restyles every document using it",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document whose style to save"
+					},
+					"name": {
+						"type": "string",
+						"description": "Theme name: lowercase letters, digits, '-' and '_'"
+					},
+					"description": {
+						"type": "string",
+						"description": "Optional: what the theme is for"
+					},
+					"overwrite": {
+						"type": "boolean",
+						"description": "Replace an existing theme of the same name (default: false)"
+					}
+				},
+				"required": ["document_id", "name"]
+			}`),
+		},
+		{
+			Name:        "delete_style_theme",
+			Description: "Delete a named style theme that no document uses",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"name": {
+						"type": "string",
+						"description": "The theme to delete"
+					}
+				},
+				"required": ["name"]
+			}`),
+		},
+		{
+			Name:        "validate_references",
+			Description: "Check a document for {@ref:label} cross-references that do not match any block label",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
 		
 		// Block operations
 		{
@@ -171,19 +472,863 @@ func getAllTools() []protocol.Tool {
 					},
 					"text": {
 						"type": "string",
-						"description": "The heading text"
+						"description": "The heading text"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "level", "text"]
+			}`),
+		},
+		{
+			Name:        "add_markdown",
+			Description: "Add a markdown content block to a document",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"content": {
+						"type": "string",
+						"description": "The markdown content. {@index:term} or {@index:term!subterm} marks an entry of the back-of-book index"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "content"]
+			}`),
+		},
+		{
+			Name:        "add_image",
+			Description: "Add an image block to a document. The image is validated and normalized on import: EXIF orientation is applied, oversized images are downscaled and WebP, GIF, BMP and TIFF are converted to PNG",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"image_path": {
+						"type": "string",
+						"description": "Path to a PNG, JPEG, GIF, WebP, BMP, TIFF or SVG file readable by the server (will be copied to document assets). Use image_data instead when the file is not on the server's filesystem"
+					},
+					"image_data": {
+						"type": "string",
+						"description": "Base64-encoded image content, or a data: URL, as an alternative to image_path"
+					},
+					"filename": {
+						"type": "string",
+						"description": "File name for image_data, used to name the stored asset (default: 'image')"
+					},
+					"mime_type": {
+						"type": "string",
+						"description": "Optional MIME type of image_data (e.g. 'image/png'); the data must match it"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Optional image caption, numbered automatically on export (e.g. 'Figure 3: ...')"
+					},
+					"alt_text": {
+						"type": "string",
+						"description": "Optional alt text for accessibility"
+					},
+					"width": {
+						"type": "string",
+						"description": "Optional display width as a percentage of the text width or an absolute size, e.g. '50%', '8cm', '3in', '300px' (default: full width)"
+					},
+					"align": {
+						"type": "string",
+						"enum": ["left", "center", "right"],
+						"description": "Optional horizontal alignment (default: center). Applies to PDF and HTML"
+					},
+					"float": {
+						"type": "string",
+						"enum": ["left", "right"],
+						"description": "Optional: float the image to one side so text wraps around it (PDF and HTML). Cannot be combined with align"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "add_gallery",
+			Description: "Add a gallery block that lays out several images side by side in a grid (LaTeX subfigures in PDF, a flexible grid in HTML, rows of images in DOCX). Image captions are lettered (a), (b), ... and the gallery caption is numbered as a figure",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"images": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"image_path": {"type": "string", "description": "Path to the image file (will be copied to document assets)"},
+								"image_data": {"type": "string", "description": "Base64-encoded image content, as an alternative to image_path"},
+								"filename": {"type": "string", "description": "File name for image_data"},
+								"mime_type": {"type": "string", "description": "Optional MIME type of image_data"},
+								"caption": {"type": "string", "description": "Optional sub-caption"},
+								"alt_text": {"type": "string", "description": "Optional alt text for accessibility"}
+							}
+						},
+						"description": "Images in display order, each given by image_path or image_data"
+					},
+					"columns": {
+						"type": "integer",
+						"minimum": 1,
+						"maximum": 6,
+						"description": "Images per row (default: up to 3)"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Optional gallery caption, numbered automatically on export"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "images"]
+			}`),
+		},
+		{
+			Name:        "add_table",
+			Description: "Add a table block to a document",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"headers": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Table column headers"
+					},
+					"rows": {
+						"type": "array",
+						"items": {
+							"type": "array",
+							"items": {"type": "string"}
+						},
+						"description": "Table rows (2D array of strings)"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Optional table caption, numbered automatically on export (e.g. 'Table 2: ...')"
+					},
+					"columns": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"align": {
+									"type": "string",
+									"enum": ["left", "center", "right"],
+									"description": "Column alignment"
+								},
+								"width": {
+									"type": "number",
+									"description": "Relative column width (e.g. 2 for twice as wide as a column with width 1)"
+								}
+							}
+						},
+						"description": "Optional per-column settings, in column order"
+					},
+					"header_rows": {
+						"type": "integer",
+						"description": "Number of leading rows that belong to the header in addition to 'headers' (default: 0)"
+					},
+					"spans": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"row": {"type": "integer", "description": "Row index; 0 is the header row, 1 the first data row"},
+								"col": {"type": "integer", "description": "Column index, starting at 0"},
+								"rowspan": {"type": "integer", "description": "Number of rows merged (default: 1)"},
+								"colspan": {"type": "integer", "description": "Number of columns merged (default: 1)"}
+							},
+							"required": ["row", "col"]
+						},
+						"description": "Optional merged cells. Text of covered cells is ignored. Cells may contain newlines for multi-line content."
+					},
+					"repeat_header": {
+						"type": "boolean",
+						"description": "Repeat header rows at the top of each page in PDF/DOCX (default: true)"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "headers", "rows"]
+			}`),
+		},
+		{
+			Name:        "add_table_from_file",
+			Description: "Import a CSV, TSV or XLSX sheet as a table block. The source is recorded so refresh_table can re-import it when the data changes",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"file_path": {
+						"type": "string",
+						"description": "Path to the .csv, .tsv or .xlsx file"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["csv", "tsv", "xlsx"],
+						"description": "Optional: file format (default: from the file extension)"
+					},
+					"sheet": {
+						"type": "string",
+						"description": "Optional: XLSX sheet name (default: first sheet)"
+					},
+					"range": {
+						"type": "string",
+						"description": "Optional: cell range to import, e.g. 'B2:F40'"
+					},
+					"header": {
+						"type": "string",
+						"enum": ["auto", "first_row", "none"],
+						"description": "How to find the header row: 'auto' uses the first row when it is all distinct text, 'none' generates 'Column N' headers (default: 'auto')"
+					},
+					"columns": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Optional: columns to keep, in order, by header name or column letter"
+					},
+					"number_format": {
+						"type": "object",
+						"properties": {
+							"decimals": {"type": "integer", "description": "Fixed number of decimal places"},
+							"thousands_separator": {"type": "string", "description": "Thousands separator, e.g. ','"},
+							"decimal_separator": {"type": "string", "description": "Decimal separator (default: '.')"}
+						},
+						"description": "Optional: formatting applied to numeric cells"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Optional table caption, numbered automatically on export"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "file_path"]
+			}`),
+		},
+		{
+			Name:        "refresh_table",
+			Description: "Re-import a table block from the file it was imported from, keeping its caption and layout",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"block_id": {
+						"type": "string",
+						"description": "The table block ID"
+					}
+				},
+				"required": ["document_id", "block_id"]
+			}`),
+		},
+		{
+			Name:        "add_chart",
+			Description: "Add a chart block (bar, line, pie or scatter) drawn from data. It is rendered to an image on export (SVG for HTML, PNG for PDF/DOCX) and numbered as a figure when it has a caption or label",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"chart_type": {
+						"type": "string",
+						"enum": ["bar", "line", "pie", "scatter"],
+						"description": "Chart type"
+					},
+					"title": {
+						"type": "string",
+						"description": "Optional title drawn above the chart"
+					},
+					"x_label": {
+						"type": "string",
+						"description": "Optional X axis label"
+					},
+					"y_label": {
+						"type": "string",
+						"description": "Optional Y axis label"
+					},
+					"categories": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "X axis categories for bar and line charts, or slice names for pie charts. Must match the number of values in each series"
+					},
+					"series": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"name": {"type": "string", "description": "Series name shown in the legend"},
+								"values": {"type": "array", "items": {"type": "number"}, "description": "Values (Y values for scatter charts)"},
+								"x": {"type": "array", "items": {"type": "number"}, "description": "X values, required for scatter charts"}
+							},
+							"required": ["values"]
+						},
+						"description": "Data series. Pie charts take exactly one series"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Optional figure caption, numbered automatically on export"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "chart_type", "series"]
+			}`),
+		},
+		{
+			Name:        "add_diagram",
+			Description: "Add a diagram block from Mermaid, Graphviz or PlantUML source. The source stays editable with update_block; on export it is rendered with the locally installed renderer (mmdc, dot or plantuml) and cached by content hash. Without a renderer, HTML export renders Mermaid and Graphviz in the browser and other formats show the source",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"engine": {
+						"type": "string",
+						"enum": ["mermaid", "graphviz", "plantuml"],
+						"description": "Diagram language"
+					},
+					"source": {
+						"type": "string",
+						"description": "Diagram source text"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Optional figure caption, numbered automatically on export"
+					},
+					"alt_text": {
+						"type": "string",
+						"description": "Optional alternative text for the rendered image"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "engine", "source"]
+			}`),
+		},
+		{
+			Name:        "add_raw",
+			Description: "Add raw HTML, LaTeX or OpenXML that is passed through untouched to exports of that format only (html for HTML, latex for PDF, openxml for DOCX) and left out of the others. Use it for the occasional construct markdown cannot express",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["html", "latex", "openxml"],
+						"description": "Output format the content is written in"
+					},
+					"content": {
+						"type": "string",
+						"description": "Raw content, emitted verbatim"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "format", "content"]
+			}`),
+		},
+		{
+			Name:        "add_quote",
+			Description: "Add a quotation with optional attribution and source, styled as an indented blockquote, a large centered pull-quote, or an epigraph set right-aligned at the start of a chapter",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"text": {
+						"type": "string",
+						"description": "The quoted text (markdown)"
+					},
+					"style": {
+						"type": "string",
+						"enum": ["blockquote", "pullquote", "epigraph"],
+						"description": "How the quote is set (default: blockquote)"
+					},
+					"attribution": {
+						"type": "string",
+						"description": "Optional: who said or wrote it"
+					},
+					"source": {
+						"type": "string",
+						"description": "Optional: the work it is taken from, set in italics"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "text"]
+			}`),
+		},
+		{
+			Name:        "add_layout",
+			Description: "Set existing blocks of the same chapter in two or three columns, e.g. for newsletters and brochures. The blocks keep their IDs and can still be edited; they are exported inside the layout, in the order given, instead of at their own position. Deleting the layout returns them to single-column flow",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents; the blocks must be in this chapter"
+					},
+					"blocks": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "IDs of the blocks to set in columns, in reading order. Layouts cannot be nested and a block can be in one layout only"
+					},
+					"columns": {
+						"type": "integer",
+						"enum": [2, 3],
+						"description": "Number of columns (default: 2)"
+					},
+					"gap": {
+						"type": "string",
+						"description": "Optional: space between columns with a unit of cm, mm, in, pt or em, e.g. '2em' or '8mm'"
+					},
+					"column_breaks": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Optional: IDs of blocks that start a new column. Without breaks the content is balanced across the columns"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the layout: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "blocks"]
+			}`),
+		},
+		{
+			Name:        "add_list",
+			Description: "Add a bulleted, numbered or task (checklist) list. Unlike a markdown block, its items can then be added, checked off and reordered one at a time with add_list_item, toggle_list_item and move_list_item",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"style": {
+						"type": "string",
+						"enum": ["unordered", "ordered", "task"],
+						"description": "List style (default: unordered)"
+					},
+					"items": {
+						"type": "array",
+						"description": "Items as strings, or objects with text, checked and nested items",
+						"items": {
+								"oneOf": [
+									{"type": "string"},
+									{
+										"type": "object",
+										"properties": {
+											"text": {"type": "string"},
+											"checked": {"type": "boolean", "description": "Task lists only"},
+											"items": {"type": "array", "description": "Nested items, in the same form"}
+										},
+										"required": ["text"]
+									}
+								]
+							}
+					},
+					"start": {
+						"type": "integer",
+						"description": "Optional: first number of an ordered list (default: 1)",
+						"minimum": 0
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "items"]
+			}`),
+		},
+		{
+			Name:        "add_list_item",
+			Description: "Add one item to a list block. Items are addressed by 1-based paths such as '2' or '2.1' (the first sub-item of item 2)",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"block_id": {
+						"type": "string",
+						"description": "The list block ID"
+					},
+					"text": {
+						"type": "string",
+						"description": "Item text (inline markdown)"
+					},
+					"parent": {
+						"type": "string",
+						"description": "Optional: path of the item to nest under, e.g. '2' (default: top level)"
+					},
+					"position": {
+						"type": "integer",
+						"description": "Optional: 1-based position among its siblings (default: last)",
+						"minimum": 1
+					},
+					"checked": {
+						"type": "boolean",
+						"description": "Optional: initial state in a task list (default: false)"
+					}
+				},
+				"required": ["document_id", "block_id", "text"]
+			}`),
+		},
+		{
+			Name:        "toggle_list_item",
+			Description: "Check or uncheck one item of a task list",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"block_id": {
+						"type": "string",
+						"description": "The list block ID"
+					},
+					"item": {
+						"type": "string",
+						"description": "Path of the item, e.g. '3' or '2.1'"
+					},
+					"checked": {
+						"type": "boolean",
+						"description": "Optional: state to set; toggles when omitted"
+					}
+				},
+				"required": ["document_id", "block_id", "item"]
+			}`),
+		},
+		{
+			Name:        "move_list_item",
+			Description: "Move a list item, with its sub-items, to another position among its siblings",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"block_id": {
+						"type": "string",
+						"description": "The list block ID"
+					},
+					"item": {
+						"type": "string",
+						"description": "Path of the item to move, e.g. '3' or '2.1'"
+					},
+					"position": {
+						"type": "integer",
+						"description": "New 1-based position among its siblings",
+						"minimum": 1
+					}
+				},
+				"required": ["document_id", "block_id", "item", "position"]
+			}`),
+		},
+		{
+			Name:        "add_include",
+			Description: "Add a block that includes a snippet from the workspace library, or a block of another document, by ID. The content is resolved on export, so later edits to the source appear in every document that includes it",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"snippet_id": {
+						"type": "string",
+						"description": "Snippet to include (see list_snippets)"
+					},
+					"source_document_id": {
+						"type": "string",
+						"description": "Document holding the block to include, instead of a snippet"
+					},
+					"source_block_id": {
+						"type": "string",
+						"description": "ID of the block to include from source_document_id"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "add_page_break",
+			Description: "Add a page break block to a document (only affects PDF/DOCX export)",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "add_multiple_blocks",
+			Description: "Add multiple blocks to a document in one operation",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "Optional: chapter ID for chaptered documents"
+					},
+					"blocks": {
+						"type": "array",
+						"description": "Array of blocks to add",
+						"items": {
+							"type": "object",
+							"properties": {
+								"type": {
+									"type": "string",
+									"enum": ["heading", "markdown", "image", "gallery", "table", "page_break", "chart", "diagram", "include", "raw", "list", "quote"],
+									"description": "Block type"
+								},
+								"data": {
+									"type": "object",
+									"description": "Block data (varies by type)"
+								}
+							},
+							"required": ["type", "data"]
+						}
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to add the blocks: 'start', 'end', or 'after:block-id' (default: 'end')"
+					}
+				},
+				"required": ["document_id", "blocks"]
+			}`),
+		},
+		{
+			Name:        "update_block",
+			Description: "Update the content of an existing block",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"block_id": {
+						"type": "string",
+						"description": "The block ID to update"
+					},
+					"new_content": {
+						"type": "object",
+						"description": "New content for the block (structure depends on block type). Tables stay linked to their source file for refresh_table unless file_path (and its import settings) names a new one"
+					},
+					"suggest": {
+						"type": "boolean",
+						"description": "Optional: file the edit as a pending suggestion with a diff for a human to accept or reject, instead of changing the block (default: false)"
+					},
+					"author": {
+						"type": "string",
+						"description": "Optional: author recorded on a suggestion (default: 'Assistant')"
+					}
+				},
+				"required": ["document_id", "block_id", "new_content"]
+			}`),
+		},
+		{
+			Name:        "list_suggestions",
+			Description: "List pending suggestions with a line diff against each block's current content",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"block_id": {
+						"type": "string",
+						"description": "Optional: only list suggestions for this block"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "accept_suggestion",
+			Description: "Apply a pending suggestion to its block",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"suggestion_id": {
+						"type": "string",
+						"description": "The suggestion ID, e.g. 'sug-001'"
+					},
+					"force": {
+						"type": "boolean",
+						"description": "Optional: apply the suggestion even if the block was edited after it was made, discarding that edit (default: false)"
+					}
+				},
+				"required": ["document_id", "suggestion_id"]
+			}`),
+		},
+		{
+			Name:        "reject_suggestion",
+			Description: "Discard a pending suggestion, leaving its block unchanged",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
+					},
+					"suggestion_id": {
+						"type": "string",
+						"description": "The suggestion ID"
+					}
+				},
+				"required": ["document_id", "suggestion_id"]
+			}`),
+		},
+		{
+			Name:        "delete_block",
+			Description: "Delete a block from a document",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "The document ID"
 					},
-					"position": {
+					"block_id": {
 						"type": "string",
-						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+						"description": "The block ID to delete"
 					}
 				},
-				"required": ["document_id", "level", "text"]
+				"required": ["document_id", "block_id"]
 			}`),
 		},
 		{
-			Name:        "add_markdown",
-			Description: "Add a markdown content block to a document",
+			Name:        "move_block",
+			Description: "Move a block to a new position within the document",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -191,25 +1336,21 @@ func getAllTools() []protocol.Tool {
 						"type": "string",
 						"description": "The document ID"
 					},
-					"chapter_id": {
-						"type": "string",
-						"description": "Optional: chapter ID for chaptered documents"
-					},
-					"content": {
+					"block_id": {
 						"type": "string",
-						"description": "The markdown content"
+						"description": "The block ID to move"
 					},
-					"position": {
+					"new_position": {
 						"type": "string",
-						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+						"description": "New position: 'start', 'end', or 'after:block-id'"
 					}
 				},
-				"required": ["document_id", "content"]
+				"required": ["document_id", "block_id", "new_position"]
 			}`),
 		},
 		{
-			Name:        "add_image",
-			Description: "Add an image block to a document",
+			Name:        "get_block",
+			Description: "Get the content of a specific block",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -217,33 +1358,17 @@ func getAllTools() []protocol.Tool {
 						"type": "string",
 						"description": "The document ID"
 					},
-					"chapter_id": {
-						"type": "string",
-						"description": "Optional: chapter ID for chaptered documents"
-					},
-					"image_path": {
-						"type": "string",
-						"description": "Path to the image file (will be copied to document assets)"
-					},
-					"caption": {
-						"type": "string",
-						"description": "Optional image caption"
-					},
-					"alt_text": {
-						"type": "string",
-						"description": "Optional alt text for accessibility"
-					},
-					"position": {
+					"block_id": {
 						"type": "string",
-						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+						"description": "The block ID"
 					}
 				},
-				"required": ["document_id", "image_path"]
+				"required": ["document_id", "block_id"]
 			}`),
 		},
 		{
-			Name:        "add_table",
-			Description: "Add a table block to a document",
+			Name:        "get_blocks",
+			Description: "Get the content of multiple blocks in a single request, by ID or by tag",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -251,34 +1376,24 @@ func getAllTools() []protocol.Tool {
 						"type": "string",
 						"description": "The document ID"
 					},
-					"chapter_id": {
-						"type": "string",
-						"description": "Optional: chapter ID for chaptered documents"
-					},
-					"headers": {
-						"type": "array",
-						"items": {"type": "string"},
-						"description": "Table column headers"
-					},
-					"rows": {
+					"block_ids": {
 						"type": "array",
 						"items": {
-							"type": "array",
-							"items": {"type": "string"}
+							"type": "string"
 						},
-						"description": "Table rows (2D array of strings)"
+						"description": "Array of block IDs to fetch (give block_ids, tag or both)"
 					},
-					"position": {
+					"tag": {
 						"type": "string",
-						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+						"description": "Optional: fetch the blocks with this tag in document order, or only those of block_ids that have it"
 					}
 				},
-				"required": ["document_id", "headers", "rows"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "add_page_break",
-			Description: "Add a page break block to a document (only affects PDF/DOCX export)",
+			Name:        "set_block_label",
+			Description: "Assign a stable cross-reference label to a block. Markdown blocks can then write {@ref:label} to get 'Figure 3', 'Table 2' or 'Section 4.1' with a link, renumbered automatically on export. Labelled display equations show their number, and headings show theirs once a section is cited",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -286,21 +1401,21 @@ func getAllTools() []protocol.Tool {
 						"type": "string",
 						"description": "The document ID"
 					},
-					"chapter_id": {
+					"block_id": {
 						"type": "string",
-						"description": "Optional: chapter ID for chaptered documents"
+						"description": "The block ID to label"
 					},
-					"position": {
+					"label": {
 						"type": "string",
-						"description": "Where to add the block: 'start', 'end', or 'after:block-id' (default: 'end')"
+						"description": "Unique label such as 'fig-arch' (letters, digits, '-', '_', '.', ':'). Empty string removes the label"
 					}
 				},
-				"required": ["document_id"]
+				"required": ["document_id", "block_id", "label"]
 			}`),
 		},
 		{
-			Name:        "add_multiple_blocks",
-			Description: "Add multiple blocks to a document in one operation",
+			Name:        "tag_block",
+			Description: "Add or remove tags naming a block's role, e.g. 'executive-summary' or 'todo', so blocks can be fetched and searched by tag instead of by ID",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -308,40 +1423,41 @@ func getAllTools() []protocol.Tool {
 						"type": "string",
 						"description": "The document ID"
 					},
-					"chapter_id": {
+					"block_id": {
 						"type": "string",
-						"description": "Optional: chapter ID for chaptered documents"
+						"description": "The block ID to tag"
 					},
-					"blocks": {
+					"add": {
 						"type": "array",
-						"description": "Array of blocks to add",
-						"items": {
-							"type": "object",
-							"properties": {
-								"type": {
-									"type": "string",
-									"enum": ["heading", "markdown", "image", "table", "page_break"],
-									"description": "Block type"
-								},
-								"data": {
-									"type": "object",
-									"description": "Block data (varies by type)"
-								}
-							},
-							"required": ["type", "data"]
-						}
+						"items": {"type": "string"},
+						"description": "Tags to add (letters, digits, '-', '_', '.'; stored lowercase)"
 					},
-					"position": {
+					"remove": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Tags to remove"
+					}
+				},
+				"required": ["document_id", "block_id"]
+			}`),
+		},
+		{
+			Name:        "list_tags",
+			Description: "List the tags used in a document with the number of blocks and the block IDs for each",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
 						"type": "string",
-						"description": "Where to add the blocks: 'start', 'end', or 'after:block-id' (default: 'end')"
+						"description": "The document ID"
 					}
 				},
-				"required": ["document_id", "blocks"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "update_block",
-			Description: "Update the content of an existing block",
+			Name:        "add_comment",
+			Description: "Add a review comment to a block or to a passage of a markdown block's text, or reply to an existing comment. Comments are left out of exports unless export_document asks for them",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -351,19 +1467,31 @@ func getAllTools() []protocol.Tool {
 					},
 					"block_id": {
 						"type": "string",
-						"description": "The block ID to update"
+						"description": "The block to comment on (for a new comment)"
 					},
-					"new_content": {
-						"type": "object",
-						"description": "New content for the block (structure depends on block type)"
+					"reply_to": {
+						"type": "string",
+						"description": "The comment ID to reply to, instead of block_id. Replying reopens a resolved comment"
+					},
+					"quote": {
+						"type": "string",
+						"description": "Optional: exact text of the markdown block the comment refers to (default: the whole block)"
+					},
+					"author": {
+						"type": "string",
+						"description": "Name of the comment's author"
+					},
+					"text": {
+						"type": "string",
+						"description": "The comment"
 					}
 				},
-				"required": ["document_id", "block_id", "new_content"]
+				"required": ["document_id", "author", "text"]
 			}`),
 		},
 		{
-			Name:        "delete_block",
-			Description: "Delete a block from a document",
+			Name:        "list_comments",
+			Description: "List a document's review comments with their replies, oldest first",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -373,15 +1501,19 @@ func getAllTools() []protocol.Tool {
 					},
 					"block_id": {
 						"type": "string",
-						"description": "The block ID to delete"
+						"description": "Optional: only list comments on this block"
+					},
+					"include_resolved": {
+						"type": "boolean",
+						"description": "Optional: include resolved comments (default: false)"
 					}
 				},
-				"required": ["document_id", "block_id"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "move_block",
-			Description: "Move a block to a new position within the document",
+			Name:        "resolve_comment",
+			Description: "Mark a review comment resolved, or reopen it",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -389,21 +1521,21 @@ func getAllTools() []protocol.Tool {
 						"type": "string",
 						"description": "The document ID"
 					},
-					"block_id": {
+					"comment_id": {
 						"type": "string",
-						"description": "The block ID to move"
+						"description": "The comment ID, e.g. 'cmt-001'"
 					},
-					"new_position": {
-						"type": "string",
-						"description": "New position: 'start', 'end', or 'after:block-id'"
+					"resolved": {
+						"type": "boolean",
+						"description": "Optional: false reopens the comment (default: true)"
 					}
 				},
-				"required": ["document_id", "block_id", "new_position"]
+				"required": ["document_id", "comment_id"]
 			}`),
 		},
 		{
-			Name:        "get_block",
-			Description: "Get the content of a specific block",
+			Name:        "set_conditions",
+			Description: "Make a block or chapter conditional, e.g. audience=internal. Conditional content is only exported when the export profile allows it, so one document can produce internal and external versions",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -413,15 +1545,27 @@ func getAllTools() []protocol.Tool {
 					},
 					"block_id": {
 						"type": "string",
-						"description": "The block ID"
+						"description": "The block to make conditional (give block_id or chapter_id)"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "The chapter to make conditional (give block_id or chapter_id)"
+					},
+					"conditions": {
+						"type": "object",
+						"description": "Allowed values by name, e.g. {\"audience\": \"internal\", \"edition\": [\"pro\", \"enterprise\"]}. An empty object removes the conditions",
+						"additionalProperties": {
+							"type": ["string", "array"],
+							"items": {"type": "string"}
+						}
 					}
 				},
-				"required": ["document_id", "block_id"]
+				"required": ["document_id", "conditions"]
 			}`),
 		},
 		{
-			Name:        "get_blocks",
-			Description: "Get the content of multiple blocks in a single request",
+			Name:        "set_status",
+			Description: "Set the review workflow status of a block or chapter (draft, needs-review, approved) and lock or unlock it. Locked blocks, and the blocks of a locked chapter, cannot be updated, moved or deleted until they are unlocked; edits can still be filed as suggestions",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -429,15 +1573,25 @@ func getAllTools() []protocol.Tool {
 						"type": "string",
 						"description": "The document ID"
 					},
-					"block_ids": {
-						"type": "array",
-						"items": {
-							"type": "string"
-						},
-						"description": "Array of block IDs to fetch"
+					"block_id": {
+						"type": "string",
+						"description": "The block to set (give block_id or chapter_id)"
+					},
+					"chapter_id": {
+						"type": "string",
+						"description": "The chapter to set (give block_id or chapter_id)"
+					},
+					"status": {
+						"type": "string",
+						"enum": ["draft", "needs-review", "approved"],
+						"description": "Optional: the new status; content without a status is a draft"
+					},
+					"locked": {
+						"type": "boolean",
+						"description": "Optional: lock against changes, or unlock"
 					}
 				},
-				"required": ["document_id", "block_ids"]
+				"required": ["document_id"]
 			}`),
 		},
 		
@@ -527,6 +1681,96 @@ func getAllTools() []protocol.Tool {
 			}`),
 		},
 		
+		// Snippet library operations
+		{
+			Name:        "create_snippet",
+			Description: "Add reusable markdown (boilerplate, disclaimers, bios) to the workspace snippet library. Documents include it with add_include",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"title": {
+						"type": "string",
+						"description": "Snippet title; the snippet ID is derived from it"
+					},
+					"content": {
+						"type": "string",
+						"description": "Markdown content"
+					},
+					"description": {
+						"type": "string",
+						"description": "Optional: what the snippet is for"
+					}
+				},
+				"required": ["title", "content"]
+			}`),
+		},
+		{
+			Name:        "update_snippet",
+			Description: "Update a snippet's title, description or content. Every document that includes it uses the new content on its next export",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"snippet_id": {
+						"type": "string",
+						"description": "The snippet ID"
+					},
+					"title": {
+						"type": "string",
+						"description": "Optional: new title"
+					},
+					"content": {
+						"type": "string",
+						"description": "Optional: new markdown content"
+					},
+					"description": {
+						"type": "string",
+						"description": "Optional: new description"
+					}
+				},
+				"required": ["snippet_id"]
+			}`),
+		},
+		{
+			Name:        "get_snippet",
+			Description: "Get a snippet's content and the document blocks that include it",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"snippet_id": {
+						"type": "string",
+						"description": "The snippet ID"
+					}
+				},
+				"required": ["snippet_id"]
+			}`),
+		},
+		{
+			Name:        "list_snippets",
+			Description: "List the snippets in the workspace library",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {}
+			}`),
+		},
+		{
+			Name:        "delete_snippet",
+			Description: "Delete a snippet from the library. Fails while documents still include it unless force is set",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"snippet_id": {
+						"type": "string",
+						"description": "The snippet ID"
+					},
+					"force": {
+						"type": "boolean",
+						"description": "Optional: delete even if documents include the snippet (their export will fail until the include is removed)"
+					}
+				},
+				"required": ["snippet_id"]
+			}`),
+		},
+		
 		// Export operations
 		{
 			Name:        "export_document",
@@ -542,6 +1786,47 @@ func getAllTools() []protocol.Tool {
 						"type": "string",
 						"enum": ["pdf", "docx", "html"],
 						"description": "Export format"
+					},
+					"toc": {
+						"type": "boolean",
+						"description": "Optional: include a generated table of contents (overrides the document style)"
+					},
+					"toc_depth": {
+						"type": "integer",
+						"description": "Optional: heading levels to include in the table of contents (1-6, default from style: 3)",
+						"minimum": 1,
+						"maximum": 6
+					},
+					"variables": {
+						"type": "object",
+						"description": "Optional: variable values for this export, overriding the document's variables"
+					},
+					"profile": {
+						"type": ["string", "object"],
+						"description": "Optional: name of a saved profile, or profile values such as {\"audience\": \"external\"}; conditional content the profile does not allow is left out (default: export everything)"
+					},
+					"comments": {
+						"type": "string",
+						"enum": ["none", "open", "all"],
+						"description": "Optional: review comments to include, as margin notes in PDF and HTML and as Word comments in DOCX: 'none', 'open' (unresolved only) or 'all' (default: none)"
+					},
+					"suggestions": {
+						"type": "string",
+						"enum": ["none", "tracked"],
+						"description": "Optional: 'tracked' shows the latest pending suggestion of each markdown and heading block as tracked changes in DOCX, and as underlined and struck-through text in PDF and HTML (default: none, blocks as they are)"
+					},
+					"dataset_path": {
+						"type": "string",
+						"description": "Optional: CSV, TSV, XLSX or JSON file of rows for a mail merge; one document is exported per row, with the columns as variables"
+					},
+					"dataset": {
+						"type": "array",
+						"description": "Optional: inline mail-merge rows, as an alternative to dataset_path",
+						"items": {"type": "object"}
+					},
+					"output_name": {
+						"type": "string",
+						"description": "Optional: file name template for mail-merge outputs, e.g. 'letter-{{client_name}}' (default: the document title)"
 					}
 				},
 				"required": ["document_id", "format"]
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// handleSetStatus sets the workflow status of a block or chapter and locks or unlocks it
+func (h *Handler) handleSetStatus(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := getString(args, "document_id", true)
+	if err != nil {
+		return nil, err
+	}
+	blockID, _ := getString(args, "block_id", false)
+	chapterID, _ := getString(args, "chapter_id", false)
+	if (blockID == "") == (chapterID == "") {
+		return nil, fmt.Errorf("provide either block_id or chapter_id")
+	}
+
+	status, err := getString(args, "status", false)
+	if err != nil {
+		return nil, err
+	}
+	var locked *bool
+	if _, ok := args["locked"]; ok {
+		value := getBool(args, "locked", false)
+		locked = &value
+	}
+	if status == "" && locked == nil {
+		return nil, fmt.Errorf("provide status, locked or both")
+	}
+
+	target := "block " + blockID
+	if chapterID != "" {
+		target = "chapter " + chapterID
+		err = h.storage.SetChapterStatus(docID, chapterID, status, locked)
+	} else {
+		err = h.storage.SetBlockStatus(docID, blockID, status, locked)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set status: %w", err)
+	}
+
+	var changes []string
+	if status != "" {
+		changes = append(changes, "marked "+status)
+	}
+	if locked != nil && *locked {
+		changes = append(changes, "locked against edits, moves and deletion")
+	} else if locked != nil {
+		changes = append(changes, "unlocked")
+	}
+	return successResponse(fmt.Sprintf("%s is %s", strings.ToUpper(target[:1])+target[1:], strings.Join(changes, " and "))), nil
+}
+
+// getStatusFilter reads an optional workflow status to filter by
+func getStatusFilter(args map[string]interface{}) (string, error) {
+	status, err := getString(args, "status", false)
+	if err != nil || status == "" {
+		return "", err
+	}
+	return status, blocks.ValidateStatus(status)
+}
@@ -0,0 +1,383 @@
+// Package imageproc validates and normalizes images as they are added to a
+// document: images are decoded to prove they are readable, EXIF orientation is
+// applied, oversized images are downscaled and formats that Pandoc and LaTeX
+// cannot embed are converted to PNG. Everything is done in pure Go.
+package imageproc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "golang.org/x/image/bmp" // Registers the BMP decoder
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff" // Registers the TIFF decoder
+	_ "golang.org/x/image/webp" // Registers the WebP decoder
+)
+
+// Content types of supported images
+const (
+	TypePNG  = "image/png"
+	TypeJPEG = "image/jpeg"
+	TypeGIF  = "image/gif"
+	TypeWebP = "image/webp"
+	TypeBMP  = "image/bmp"
+	TypeTIFF = "image/tiff"
+	TypeSVG  = "image/svg+xml"
+)
+
+// jpegQuality is used when a JPEG has to be re-encoded
+const jpegQuality = 90
+
+// MaxPixels is the largest image that is decoded. A small, highly compressed
+// file can declare a huge image, and decoding it would take gigabytes of memory.
+const MaxPixels = 64 * 1000 * 1000
+
+// decoders maps image.DecodeConfig format names to content types
+var decoders = map[string]string{
+	"png":  TypePNG,
+	"jpeg": TypeJPEG,
+	"gif":  TypeGIF,
+	"webp": TypeWebP,
+	"bmp":  TypeBMP,
+	"tiff": TypeTIFF,
+}
+
+// Options control how images are normalized
+type Options struct {
+	MaxWidth int // Maximum width in pixels; 0 disables downscaling
+}
+
+// Result is a processed image ready to be written to assets
+type Result struct {
+	Data        []byte
+	ContentType string
+	Ext         string // File extension for the content type, including the dot
+	Width       int
+	Height      int
+	Changes     []string // Human-readable list of what processing did
+}
+
+// Sniff identifies the content type of image data from its contents
+func Sniff(data []byte) (string, error) {
+	if isSVG(data) {
+		return TypeSVG, nil
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("not a supported image (PNG, JPEG, GIF, WebP, BMP, TIFF or SVG): %w", err)
+	}
+	return decoders[format], nil
+}
+
+// NormalizeContentType lowercases a MIME type, drops parameters and maps common
+// aliases such as image/jpg to their registered names
+func NormalizeContentType(contentType string) string {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+	switch contentType {
+	case "image/jpg", "image/pjpeg":
+		return TypeJPEG
+	case "image/x-png":
+		return TypePNG
+	case "image/x-ms-bmp", "image/x-bmp":
+		return TypeBMP
+	case "image/svg":
+		return TypeSVG
+	}
+	return contentType
+}
+
+// Process validates an image by decoding it and normalizes it for export.
+// Images that need no changes are returned byte for byte.
+func Process(data []byte, opts Options) (*Result, error) {
+	if isSVG(data) {
+		width, height, err := svgSize(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Data: data, ContentType: TypeSVG, Ext: ".svg", Width: width, Height: height}, nil
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a supported image (PNG, JPEG, GIF, WebP, BMP, TIFF or SVG): %w", err)
+	}
+	if err := checkPixels(cfg); err != nil {
+		return nil, err
+	}
+	contentType := decoders[format]
+
+	orientation := 1
+	if contentType == TypeJPEG {
+		orientation = exifOrientation(data)
+	}
+	convert := contentType != TypePNG && contentType != TypeJPEG
+	downscale := opts.MaxWidth > 0 && displayWidth(cfg, orientation) > opts.MaxWidth
+
+	// Decode fully even when nothing changes, so truncated files are rejected now
+	// rather than when Pandoc runs
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+
+	if !convert && !downscale && orientation == 1 {
+		return &Result{Data: data, ContentType: contentType, Ext: extension(contentType), Width: cfg.Width, Height: cfg.Height}, nil
+	}
+
+	var changes []string
+	if orientation != 1 {
+		img = orient(img, orientation)
+		changes = append(changes, "applied EXIF orientation")
+	}
+	if downscale {
+		bounds := img.Bounds()
+		img = resize(img, opts.MaxWidth)
+		changes = append(changes, fmt.Sprintf("downscaled from %dx%d", bounds.Dx(), bounds.Dy()))
+	}
+
+	outType := TypePNG
+	if contentType == TypeJPEG {
+		outType = TypeJPEG
+	}
+	if convert {
+		changes = append(changes, fmt.Sprintf("converted from %s to PNG", format))
+	}
+
+	encoded, err := encode(img, outType)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	return &Result{
+		Data:        encoded,
+		ContentType: outType,
+		Ext:         extension(outType),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Changes:     changes,
+	}, nil
+}
+
+// ProcessFile reads and processes an image file
+func ProcessFile(path string, opts Options) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return Process(data, opts)
+}
+
+// Embeddable reports whether Pandoc can embed an image file as-is in every
+// export format. Other images (such as WebP assets stored before ingest-time
+// conversion) must be converted to PNG first.
+func Embeddable(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return true // Let the copy report the error
+	}
+	if isSVG(data) {
+		return true
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return true // Not an image we know (e.g. PDF); copy unchanged
+	}
+	return format == "png" || format == "jpeg"
+}
+
+// checkPixels rejects images larger than MaxPixels before they are decoded
+func checkPixels(cfg image.Config) error {
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return fmt.Errorf("image is %dx%d pixels; images are limited to %d megapixels", cfg.Width, cfg.Height, MaxPixels/1000000)
+	}
+	return nil
+}
+
+// ConvertToPNG decodes any supported raster image and writes it as PNG
+func ConvertToPNG(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	if err := checkPixels(cfg); err != nil {
+		return err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	encoded, err := encode(img, TypePNG)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, encoded, 0644)
+}
+
+func extension(contentType string) string {
+	switch contentType {
+	case TypeJPEG:
+		return ".jpg"
+	case TypeSVG:
+		return ".svg"
+	default:
+		return ".png"
+	}
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == TypeJPEG {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// displayWidth is the image width once its orientation is applied
+func displayWidth(cfg image.Config, orientation int) int {
+	if orientation >= 5 {
+		return cfg.Height
+	}
+	return cfg.Width
+}
+
+// resize scales an image down to the given width, keeping its aspect ratio
+func resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// orient applies an EXIF orientation (2-8) so the image displays upright
+func orient(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // Rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // Transposed
+				sx, sy = y, x
+			case 6: // Rotated 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // Transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // Rotated 90 counter-clockwise
+				sx, sy = w-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// isSVG reports whether data looks like an SVG document
+func isSVG(data []byte) bool {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(string(head), "\ufeff"))
+	return (strings.HasPrefix(text, "<?xml") || strings.HasPrefix(text, "<svg") || strings.HasPrefix(text, "<!")) &&
+		strings.Contains(text, "<svg")
+}
+
+var svgLength = regexp.MustCompile(`^\s*([0-9]+(?:\.[0-9]+)?)\s*(px)?\s*$`)
+
+// svgSize validates an SVG by parsing it and returns its pixel size, taken from width/height or the viewBox. Relative sizes report 0.
+func svgSize(data []byte) (int, int, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid SVG: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "svg" {
+			return 0, 0, fmt.Errorf("invalid SVG: root element is <%s>", start.Name.Local)
+		}
+
+		var width, height int
+		var viewBox string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "width":
+				width = svgPixels(attr.Value)
+			case "height":
+				height = svgPixels(attr.Value)
+			case "viewBox":
+				viewBox = attr.Value
+			}
+		}
+		if (width == 0 || height == 0) && viewBox != "" {
+			fields := strings.Fields(strings.ReplaceAll(viewBox, ",", " "))
+			if len(fields) == 4 {
+				vw, _ := strconv.ParseFloat(fields[2], 64)
+				vh, _ := strconv.ParseFloat(fields[3], 64)
+				width, height = int(vw+0.5), int(vh+0.5)
+			}
+		}
+
+		// Parse the rest of the document so malformed SVGs are rejected
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				return width, height, nil
+			} else if err != nil {
+				return 0, 0, fmt.Errorf("invalid SVG: %w", err)
+			}
+		}
+	}
+}
+
+func svgPixels(value string) int {
+	match := svgLength.FindStringSubmatch(value)
+	if match == nil {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(match[1], 64)
+	return int(v + 0.5)
+}
@@ -0,0 +1,165 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// lossyWebP is a 1x1 grey lossy WebP image
+const lossyWebP = "UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA"
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF APP1 segment carrying an orientation after SOI
+func withOrientation(jpegData []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00*")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{exifOrientationTag, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestProcessKeepsReadyImages(t *testing.T) {
+	data := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	result, err := Process(data, Options{MaxWidth: 100})
+	if err != nil {
+		t.Fatalf("Failed to process PNG: %v", err)
+	}
+	if !bytes.Equal(result.Data, data) || result.ContentType != TypePNG || result.Width != 40 || result.Height != 20 || len(result.Changes) != 0 {
+		t.Errorf("Expected PNG to pass through unchanged, got %+v", result)
+	}
+
+	if _, err := Process([]byte("fake png content"), Options{}); err == nil {
+		t.Error("Expected error for data that is not an image")
+	}
+	if _, err := Process(data[:len(data)/2], Options{}); err == nil {
+		t.Error("Expected error for truncated image")
+	}
+}
+
+func TestProcessRejectsHugeImages(t *testing.T) {
+	// A 1x1 PNG whose header declares 50000x50000 pixels
+	data := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	binary.BigEndian.PutUint32(data[16:], 50000)
+	binary.BigEndian.PutUint32(data[20:], 50000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, err := Process(data, Options{}); err == nil || !strings.Contains(err.Error(), "megapixels") {
+		t.Errorf("Expected an oversized image to be rejected before decoding, got %v", err)
+	}
+}
+
+func TestProcessDownscales(t *testing.T) {
+	data := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 400, 100)))
+	result, err := Process(data, Options{MaxWidth: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Width != 200 || result.Height != 50 {
+		t.Errorf("Expected 200x50 after downscaling, got %dx%d", result.Width, result.Height)
+	}
+	if len(result.Changes) != 1 || result.Changes[0] != "downscaled from 400x100" {
+		t.Errorf("Unexpected changes: %v", result.Changes)
+	}
+}
+
+func TestProcessAppliesEXIFOrientation(t *testing.T) {
+	// A 30x10 image, red on the left; rotating 90 clockwise puts red at the top
+	src := image.NewRGBA(image.Rect(0, 0, 30, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 30; x++ {
+			c := color.RGBA{0, 0, 255, 255}
+			if x < 15 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	data := withOrientation(buf.Bytes(), 6)
+	if got := exifOrientation(data); got != 6 {
+		t.Fatalf("Expected orientation 6, got %d", got)
+	}
+
+	result, err := Process(data, Options{})
+	if err != nil {
+		t.Fatalf("Failed to process JPEG: %v", err)
+	}
+	if result.ContentType != TypeJPEG || result.Width != 10 || result.Height != 30 {
+		t.Fatalf("Expected upright 10x30 JPEG, got %s %dx%d", result.ContentType, result.Width, result.Height)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _, b, _ := img.At(5, 3).RGBA()
+	if r < b {
+		t.Error("Expected red at the top after rotation")
+	}
+	if exifOrientation(result.Data) != 1 {
+		t.Error("Expected orientation to be dropped after normalizing")
+	}
+}
+
+func TestProcessConvertsWebP(t *testing.T) {
+	data, err := base64.StdEncoding.DecodeString(lossyWebP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Process(data, Options{})
+	if err != nil {
+		t.Fatalf("Failed to process WebP: %v", err)
+	}
+	if result.ContentType != TypePNG || result.Ext != ".png" || result.Width != 1 {
+		t.Errorf("Expected WebP converted to PNG, got %+v", result)
+	}
+	if _, err := png.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Errorf("Converted data is not a PNG: %v", err)
+	}
+}
+
+func TestProcessSVG(t *testing.T) {
+	svg := []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 120 80"><rect width="10" height="10"/></svg>`)
+	result, err := Process(svg, Options{MaxWidth: 10})
+	if err != nil {
+		t.Fatalf("Failed to process SVG: %v", err)
+	}
+	if result.ContentType != TypeSVG || result.Width != 120 || result.Height != 80 || !bytes.Equal(result.Data, svg) {
+		t.Errorf("Unexpected SVG result: %+v", result)
+	}
+
+	if _, err := Process([]byte(`<?xml version="1.0"?><svg><rect></svg>`), Options{}); err == nil {
+		t.Error("Expected error for malformed SVG")
+	}
+}
@@ -18,8 +18,22 @@ func NewSearcher(storage *storage.Storage) *Searcher {
 	return &Searcher{storage: storage}
 }
 
-// SearchDocument searches for a query within a document
-func (s *Searcher) SearchDocument(docID, query, chapterID string) ([]document.SearchResult, error) {
+// Filter narrows a search to blocks with a workflow status or a tag; empty fields match everything
+type Filter struct {
+	Status string
+	Tag    string
+}
+
+// Matches reports whether a block passes the filter
+func (f Filter) Matches(ref blocks.BlockReference) bool {
+	if f.Status != "" && blocks.StatusOf(ref.Status) != f.Status {
+		return false
+	}
+	return f.Tag == "" || ref.HasTag(f.Tag)
+}
+
+// SearchDocument searches for a query within a document's blocks that pass the filter
+func (s *Searcher) SearchDocument(docID, query, chapterID string, filter Filter) ([]document.SearchResult, error) {
 	doc, err := s.storage.GetDocument(docID)
 	if err != nil {
 		return nil, err
@@ -30,7 +44,7 @@ func (s *Searcher) SearchDocument(docID, query, chapterID string) ([]document.Se
 	
 	// Search in document-level blocks first (if any)
 	if len(doc.Blocks) > 0 {
-		results = append(results, s.SearchInBlocks(docID, doc.Blocks, queryLower, "")...)
+		results = append(results, s.SearchInBlocks(docID, doc.Blocks, queryLower, "", filter)...)
 	}
 
 	// Then search in chapters (if any and if no specific chapter requested, or if the specific chapter is being searched)
@@ -46,7 +60,7 @@ func (s *Searcher) SearchDocument(docID, query, chapterID string) ([]document.Se
 				continue
 			}
 			
-			results = append(results, s.SearchInBlocks(docID, chapter.Blocks, queryLower, chapterRef.ID)...)
+			results = append(results, s.SearchInBlocks(docID, chapter.Blocks, queryLower, chapterRef.ID, filter)...)
 		}
 	}
 	
@@ -54,10 +68,14 @@ func (s *Searcher) SearchDocument(docID, query, chapterID string) ([]document.Se
 }
 
 // SearchInBlocks searches for query in a list of blocks
-func (s *Searcher) SearchInBlocks(docID string, blockRefs []blocks.BlockReference, query string, chapterID string) []document.SearchResult {
+func (s *Searcher) SearchInBlocks(docID string, blockRefs []blocks.BlockReference, query string, chapterID string, filter Filter) []document.SearchResult {
 	var results []document.SearchResult
 	
 	for i, ref := range blockRefs {
+		if !filter.Matches(ref) {
+			continue
+		}
+		
 		block, err := s.storage.LoadBlock(docID, ref)
 		if err != nil {
 			continue
@@ -72,6 +90,8 @@ func (s *Searcher) SearchInBlocks(docID string, blockRefs []blocks.BlockReferenc
 				BlockID:   ref.ID,
 				BlockType: string(ref.Type),
 				ChapterID: chapterID,
+				Status:    blocks.StatusOf(ref.Status),
+				Tags:      ref.Tags,
 				Snippet:   snippet,
 				Position:  i,
 			}
@@ -97,12 +117,56 @@ func (s *Searcher) GetBlockContent(block blocks.Block) string {
 		for _, row := range b.Rows {
 			content += " " + strings.Join(row, " ")
 		}
+		if b.Caption != "" {
+			content += " " + b.Caption
+		}
 		return content
+	case *blocks.ChartBlock:
+		// Chart text plus category and series names; values are not searchable
+		parts := []string{b.Title, b.XLabel, b.YLabel, b.Caption}
+		parts = append(parts, b.Categories...)
+		for _, series := range b.Series {
+			parts = append(parts, series.Name)
+		}
+		return strings.Join(parts, " ")
+	case *blocks.GalleryBlock:
+		parts := []string{b.Caption}
+		for _, img := range b.Images {
+			parts = append(parts, img.Caption, img.AltText)
+		}
+		return strings.Join(parts, " ")
+	case *blocks.DiagramBlock:
+		return b.Source + " " + b.Caption
+	case *blocks.RawBlock:
+		return b.Content
+	case *blocks.ListBlock:
+		return listText(b.Items)
+	case *blocks.QuoteBlock:
+		return b.Text + " " + b.Attribution + " " + b.Source
+	case *blocks.IncludeBlock:
+		// Included text is found where it appears
+		target, err := s.storage.ResolveInclude(b)
+		if err != nil {
+			return ""
+		}
+		return s.GetBlockContent(target.Block)
 	default:
 		return ""
 	}
 }
 
+// listText joins the text of list items, including nested items
+func listText(items []blocks.ListItem) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, item.Text)
+		if len(item.Items) > 0 {
+			parts = append(parts, listText(item.Items))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 // ExtractSnippet extracts a snippet around the query match
 func (s *Searcher) ExtractSnippet(content, query string) string {
 	contentLower := strings.ToLower(content)
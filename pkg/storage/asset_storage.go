@@ -2,52 +2,232 @@ package storage
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/imageproc"
+	"gopkg.in/yaml.v3"
 )
 
-// CopyImageToAssets copies an image to the document's assets folder
+// assetIndexFile records metadata for every image in a document's assets folder
+const assetIndexFile = "index.yaml"
+
+// AssetInfo describes a processed image asset
+type AssetInfo struct {
+	ContentType string   `yaml:"content_type" json:"content_type"`
+	Width       int      `yaml:"width" json:"width"`
+	Height      int      `yaml:"height" json:"height"`
+	Size        int64    `yaml:"size" json:"size"`
+	Source      string   `yaml:"source,omitempty" json:"source,omitempty"`   // Original file name
+	Changes     []string `yaml:"changes,omitempty" json:"changes,omitempty"` // Processing applied on ingest
+}
+
+// assetIndex maps asset file names to their metadata
+type assetIndex struct {
+	Assets map[string]AssetInfo `yaml:"assets"`
+}
+
+// CopyImageToAssets validates an image and stores it in the document's assets folder.
+// The image is decoded, EXIF orientation is applied, images wider than the configured
+// page width are downscaled and formats other than PNG, JPEG and SVG are converted to PNG.
 func (s *Storage) CopyImageToAssets(docID, sourcePath string) (string, error) {
-	// Open source file
-	source, err := os.Open(sourcePath)
+	stat, err := os.Stat(sourcePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open source image: %w", err)
 	}
-	defer source.Close()
-	
-	// Generate asset filename
-	ext := filepath.Ext(sourcePath)
-	baseName := strings.TrimSuffix(filepath.Base(sourcePath), ext)
-	
+	if err := s.checkImageSize(stat.Size()); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read source image: %w", err)
+	}
+	return s.storeImageAsset(docID, filepath.Base(sourcePath), data)
+}
+
+// AddImageAsset stores image data received directly rather than from a file the
+// server can read. The data is type-sniffed and must match mimeType when one is
+// given; it is then processed like CopyImageToAssets.
+func (s *Storage) AddImageAsset(docID, filename, mimeType string, data []byte) (string, error) {
+	if err := s.checkImageSize(int64(len(data))); err != nil {
+		return "", err
+	}
+
+	sniffed, err := imageproc.Sniff(data)
+	if err != nil {
+		return "", fmt.Errorf("invalid image data: %w", err)
+	}
+	if mimeType != "" && imageproc.NormalizeContentType(mimeType) != sniffed {
+		return "", fmt.Errorf("image data is %s, not %s", sniffed, mimeType)
+	}
+
+	// Only the base name is used, and it gets an extension matching the data
+	name := filepath.Base(filepath.Clean("/" + filename))
+	if name == "/" || name == "." {
+		name = "image"
+	}
+	if filepath.Ext(name) == "" {
+		name += extensionForType(sniffed)
+	}
+	return s.storeImageAsset(docID, name, data)
+}
+
+// checkImageSize enforces the configured image size limit
+func (s *Storage) checkImageSize(size int64) error {
+	if limit := s.config.MaxImageBytes(); size > limit {
+		return fmt.Errorf("image is %.1f MB, larger than the %.1f MB limit", float64(size)/(1<<20), float64(limit)/(1<<20))
+	}
+	return nil
+}
+
+// extensionForType returns the usual file extension for an image content type
+func extensionForType(contentType string) string {
+	switch contentType {
+	case imageproc.TypeJPEG:
+		return ".jpg"
+	case imageproc.TypeSVG:
+		return ".svg"
+	case imageproc.TypeGIF:
+		return ".gif"
+	case imageproc.TypeWebP:
+		return ".webp"
+	case imageproc.TypeBMP:
+		return ".bmp"
+	case imageproc.TypeTIFF:
+		return ".tiff"
+	default:
+		return ".png"
+	}
+}
+
+// storeImageAsset processes image data and writes it to assets under a unique name
+// derived from sourceName, recording its metadata in the asset index
+func (s *Storage) storeImageAsset(docID, sourceName string, data []byte) (string, error) {
+	// The assets folder is only created for a document that exists
+	if _, err := s.GetDocument(docID); err != nil {
+		return "", err
+	}
+
+	result, err := imageproc.Process(data, imageproc.Options{MaxWidth: s.config.MaxImageWidth()})
+	if err != nil {
+		return "", fmt.Errorf("invalid image %s: %w", sourceName, err)
+	}
+
+	// Generate asset filename; the extension follows the stored format
+	ext := filepath.Ext(sourceName)
+	baseName := strings.TrimSuffix(sourceName, ext)
+	if !strings.EqualFold(ext, result.Ext) && !(result.Ext == ".jpg" && strings.EqualFold(ext, ".jpeg")) {
+		ext = result.Ext
+	}
+
+	assetsDir := filepath.Join(s.config.GetDocumentFolder(docID), "assets")
+	if err := os.MkdirAll(assetsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create assets folder: %w", err)
+	}
+
 	// Create a unique filename in assets
 	assetNum := 1
 	var assetName string
 	var assetPath string
-	
+
 	for {
 		assetName = fmt.Sprintf("%s-%03d%s", baseName, assetNum, ext)
-		assetPath = filepath.Join(s.config.GetDocumentFolder(docID), "assets", assetName)
-		
+		assetPath = filepath.Join(assetsDir, assetName)
+
 		if _, err := os.Stat(assetPath); os.IsNotExist(err) {
 			break
 		}
 		assetNum++
 	}
-	
-	// Create destination file
-	dest, err := os.Create(assetPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create asset file: %w", err)
+
+	if err := os.WriteFile(assetPath, result.Data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write asset file: %w", err)
+	}
+
+	info := AssetInfo{
+		ContentType: result.ContentType,
+		Width:       result.Width,
+		Height:      result.Height,
+		Size:        int64(len(result.Data)),
+		Source:      sourceName,
+		Changes:     result.Changes,
 	}
-	defer dest.Close()
-	
-	// Copy file
-	if _, err := io.Copy(dest, source); err != nil {
-		return "", fmt.Errorf("failed to copy image: %w", err)
+	if err := s.updateAssetIndex(docID, func(index *assetIndex) {
+		index.Assets[assetName] = info
+	}); err != nil {
+		os.Remove(assetPath)
+		return "", err
 	}
-	
+
 	// Return relative path for storage - frontend will get absolute path via convertBlockToResponse
 	return filepath.Join("assets", assetName), nil
-}
\ No newline at end of file
+}
+
+// RemoveImageAsset deletes an image stored by storeImageAsset and its index entry,
+// given its document-relative path
+func (s *Storage) RemoveImageAsset(docID, assetPath string) error {
+	if filepath.IsAbs(assetPath) || filepath.Dir(assetPath) != "assets" {
+		return fmt.Errorf("not an asset path: %s", assetPath)
+	}
+	assetName := filepath.Base(assetPath)
+	if err := os.Remove(filepath.Join(s.config.GetDocumentFolder(docID), "assets", assetName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove asset %s: %w", assetName, err)
+	}
+	return s.updateAssetIndex(docID, func(index *assetIndex) {
+		delete(index.Assets, assetName)
+	})
+}
+
+// GetAssetInfo returns the recorded metadata of an asset, given its document-relative
+// path. Assets added before metadata was recorded are not found.
+func (s *Storage) GetAssetInfo(docID, assetPath string) (*AssetInfo, bool) {
+	if filepath.IsAbs(assetPath) || filepath.Dir(assetPath) != "assets" {
+		return nil, false
+	}
+	index, err := s.loadAssetIndex(docID)
+	if err != nil {
+		return nil, false
+	}
+	info, ok := index.Assets[filepath.Base(assetPath)]
+	if !ok {
+		return nil, false
+	}
+	return &info, true
+}
+
+func (s *Storage) loadAssetIndex(docID string) (*assetIndex, error) {
+	index := &assetIndex{Assets: make(map[string]AssetInfo)}
+	data, err := os.ReadFile(filepath.Join(s.config.GetDocumentFolder(docID), "assets", assetIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset index: %w", err)
+	}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse asset index: %w", err)
+	}
+	if index.Assets == nil {
+		index.Assets = make(map[string]AssetInfo)
+	}
+	return index, nil
+}
+
+func (s *Storage) updateAssetIndex(docID string, update func(index *assetIndex)) error {
+	index, err := s.loadAssetIndex(docID)
+	if err != nil {
+		return err
+	}
+	update(index)
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal asset index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.config.GetDocumentFolder(docID), "assets", assetIndexFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write asset index: %w", err)
+	}
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
 	
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
 	"github.com/savant/mcp-servers/docgen2/pkg/document"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,6 +21,10 @@ func (s *Storage) AddBlock(docID string, chapterID string, block blocks.Block, p
 		return err
 	}
 	
+	if err := s.checkChapterUnlocked(docID, chapterID); err != nil {
+		return err
+	}
+	
 	// Generate block ID
 	blockID := s.generateBlockID(docID, chapterID, block.GetType())
 	
@@ -33,6 +40,22 @@ func (s *Storage) AddBlock(docID string, chapterID string, block blocks.Block, p
 		b.ID = blockID
 	case *blocks.PageBreakBlock:
 		b.ID = blockID
+	case *blocks.ChartBlock:
+		b.ID = blockID
+	case *blocks.DiagramBlock:
+		b.ID = blockID
+	case *blocks.GalleryBlock:
+		b.ID = blockID
+	case *blocks.IncludeBlock:
+		b.ID = blockID
+	case *blocks.RawBlock:
+		b.ID = blockID
+	case *blocks.ListBlock:
+		b.ID = blockID
+	case *blocks.QuoteBlock:
+		b.ID = blockID
+	case *blocks.LayoutBlock:
+		b.ID = blockID
 	}
 	
 	// Save block file
@@ -84,6 +107,22 @@ func (s *Storage) generateBlockID(docID, chapterID string, blockType blocks.Bloc
 		prefix = "tbl"
 	case blocks.TypePageBreak:
 		prefix = "pb"
+	case blocks.TypeChart:
+		prefix = "chart"
+	case blocks.TypeDiagram:
+		prefix = "dgm"
+	case blocks.TypeGallery:
+		prefix = "gal"
+	case blocks.TypeInclude:
+		prefix = "inc"
+	case blocks.TypeRaw:
+		prefix = "raw"
+	case blocks.TypeList:
+		prefix = "list"
+	case blocks.TypeQuote:
+		prefix = "quote"
+	case blocks.TypeLayout:
+		prefix = "layout"
 	default:
 		prefix = "blk"
 	}
@@ -260,6 +299,158 @@ func (s *Storage) saveBlockFile(docID, chapterID string, block blocks.Block) (st
 			relativePath = filepath.Join("blocks", filename)
 		}
 		
+	case *blocks.ChartBlock:
+		filename := fmt.Sprintf("%s-chart.yaml", b.ID)
+		fullPath = filepath.Join(basePath, filename)
+		
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+		
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", err
+		}
+		
+		if chapterID != "" {
+			relativePath = filepath.Join("chapters", chapterID, "blocks", filename)
+		} else {
+			relativePath = filepath.Join("blocks", filename)
+		}
+		
+	case *blocks.DiagramBlock:
+		filename := fmt.Sprintf("%s-diagram.yaml", b.ID)
+		fullPath = filepath.Join(basePath, filename)
+		
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+		
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", err
+		}
+		
+		if chapterID != "" {
+			relativePath = filepath.Join("chapters", chapterID, "blocks", filename)
+		} else {
+			relativePath = filepath.Join("blocks", filename)
+		}
+		
+	case *blocks.GalleryBlock:
+		filename := fmt.Sprintf("%s-gallery.yaml", b.ID)
+		fullPath = filepath.Join(basePath, filename)
+		
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+		
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", err
+		}
+		
+		if chapterID != "" {
+			relativePath = filepath.Join("chapters", chapterID, "blocks", filename)
+		} else {
+			relativePath = filepath.Join("blocks", filename)
+		}
+		
+	case *blocks.IncludeBlock:
+		filename := fmt.Sprintf("%s-include.yaml", b.ID)
+		fullPath = filepath.Join(basePath, filename)
+		
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+		
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", err
+		}
+		
+		if chapterID != "" {
+			relativePath = filepath.Join("chapters", chapterID, "blocks", filename)
+		} else {
+			relativePath = filepath.Join("blocks", filename)
+		}
+		
+	case *blocks.RawBlock:
+		filename := fmt.Sprintf("%s-raw.yaml", b.ID)
+		fullPath = filepath.Join(basePath, filename)
+		
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+		
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", err
+		}
+		
+		if chapterID != "" {
+			relativePath = filepath.Join("chapters", chapterID, "blocks", filename)
+		} else {
+			relativePath = filepath.Join("blocks", filename)
+		}
+		
+	case *blocks.ListBlock:
+		filename := fmt.Sprintf("%s-list.yaml", b.ID)
+		fullPath = filepath.Join(basePath, filename)
+		
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+		
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", err
+		}
+		
+		if chapterID != "" {
+			relativePath = filepath.Join("chapters", chapterID, "blocks", filename)
+		} else {
+			relativePath = filepath.Join("blocks", filename)
+		}
+		
+	case *blocks.QuoteBlock:
+		filename := fmt.Sprintf("%s-quote.yaml", b.ID)
+		fullPath = filepath.Join(basePath, filename)
+		
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+		
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", err
+		}
+		
+		if chapterID != "" {
+			relativePath = filepath.Join("chapters", chapterID, "blocks", filename)
+		} else {
+			relativePath = filepath.Join("blocks", filename)
+		}
+		
+	case *blocks.LayoutBlock:
+		filename := fmt.Sprintf("%s-layout.yaml", b.ID)
+		fullPath = filepath.Join(basePath, filename)
+		
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return "", err
+		}
+		
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", err
+		}
+		
+		if chapterID != "" {
+			relativePath = filepath.Join("chapters", chapterID, "blocks", filename)
+		} else {
+			relativePath = filepath.Join("blocks", filename)
+		}
+		
 	default:
 		return "", fmt.Errorf("unknown block type: %T", block)
 	}
@@ -331,6 +522,102 @@ func (s *Storage) LoadBlock(docID string, blockRef blocks.BlockReference) (block
 		}
 		return &pageBreak, nil
 		
+	case blocks.TypeChart:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		
+		var chart blocks.ChartBlock
+		if err := yaml.Unmarshal(data, &chart); err != nil {
+			return nil, err
+		}
+		return &chart, nil
+		
+	case blocks.TypeDiagram:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		
+		var diagram blocks.DiagramBlock
+		if err := yaml.Unmarshal(data, &diagram); err != nil {
+			return nil, err
+		}
+		return &diagram, nil
+		
+	case blocks.TypeGallery:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		
+		var gallery blocks.GalleryBlock
+		if err := yaml.Unmarshal(data, &gallery); err != nil {
+			return nil, err
+		}
+		return &gallery, nil
+		
+	case blocks.TypeInclude:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		
+		var include blocks.IncludeBlock
+		if err := yaml.Unmarshal(data, &include); err != nil {
+			return nil, err
+		}
+		return &include, nil
+		
+	case blocks.TypeRaw:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		
+		var raw blocks.RawBlock
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		return &raw, nil
+		
+	case blocks.TypeList:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		
+		var list blocks.ListBlock
+		if err := yaml.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		return &list, nil
+		
+	case blocks.TypeQuote:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		
+		var quote blocks.QuoteBlock
+		if err := yaml.Unmarshal(data, &quote); err != nil {
+			return nil, err
+		}
+		return &quote, nil
+		
+	case blocks.TypeLayout:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		
+		var layout blocks.LayoutBlock
+		if err := yaml.Unmarshal(data, &layout); err != nil {
+			return nil, err
+		}
+		return &layout, nil
+		
 	default:
 		return nil, fmt.Errorf("unknown block type: %s", blockRef.Type)
 	}
@@ -338,6 +625,10 @@ func (s *Storage) LoadBlock(docID string, blockRef blocks.BlockReference) (block
 
 // UpdateBlock updates an existing block
 func (s *Storage) UpdateBlock(docID, blockID string, newBlock blocks.Block) error {
+	if err := s.checkBlockUnlocked(docID, blockID); err != nil {
+		return err
+	}
+	
 	// Find the block's location
 	chapterID, blockIndex, err := s.FindBlockLocation(docID, blockID)
 	if err != nil {
@@ -385,6 +676,22 @@ func (s *Storage) UpdateBlock(docID, blockID string, newBlock blocks.Block) erro
 		b.ID = blockID
 	case *blocks.PageBreakBlock:
 		b.ID = blockID
+	case *blocks.ChartBlock:
+		b.ID = blockID
+	case *blocks.DiagramBlock:
+		b.ID = blockID
+	case *blocks.GalleryBlock:
+		b.ID = blockID
+	case *blocks.IncludeBlock:
+		b.ID = blockID
+	case *blocks.RawBlock:
+		b.ID = blockID
+	case *blocks.ListBlock:
+		b.ID = blockID
+	case *blocks.QuoteBlock:
+		b.ID = blockID
+	case *blocks.LayoutBlock:
+		b.ID = blockID
 	}
 	
 	// Save the updated block
@@ -399,12 +706,29 @@ func (s *Storage) UpdateBlock(docID, blockID string, newBlock blocks.Block) erro
 
 // DeleteBlock deletes a block from the document
 func (s *Storage) DeleteBlock(docID, blockID string) error {
+	if err := s.checkBlockUnlocked(docID, blockID); err != nil {
+		return err
+	}
+	
 	// Find the block's location
 	chapterID, blockIndex, err := s.FindBlockLocation(docID, blockID)
 	if err != nil {
 		return err
 	}
 	
+	// Layouts must not keep exporting a block that no longer exists
+	if err := s.removeFromLayouts(docID, chapterID, blockID); err != nil {
+		return err
+	}
+	
+	// Comments and suggestions on the block have nothing left to apply to
+	if err := s.deleteBlockComments(docID, chapterID, blockID); err != nil {
+		return err
+	}
+	if err := s.deleteBlockSuggestions(docID, chapterID, blockID); err != nil {
+		return err
+	}
+	
 	// Get the document
 	doc, err := s.GetDocument(docID)
 	if err != nil {
@@ -454,6 +778,10 @@ func (s *Storage) DeleteBlock(docID, blockID string) error {
 
 // MoveBlock moves a block to a new position
 func (s *Storage) MoveBlock(docID, blockID string, newPosition document.Position) error {
+	if err := s.checkBlockUnlocked(docID, blockID); err != nil {
+		return err
+	}
+	
 	// Find the block's current location
 	chapterID, blockIndex, err := s.FindBlockLocation(docID, blockID)
 	if err != nil {
@@ -543,4 +871,195 @@ func (s *Storage) FindBlockLocation(docID, blockID string) (chapterID string, bl
 	}
 	
 	return "", -1, fmt.Errorf("block not found: %s", blockID)
-}
\ No newline at end of file
+}
+
+// GetBlock loads a block by ID along with its manifest reference and chapter
+func (s *Storage) GetBlock(docID, blockID string) (blocks.Block, blocks.BlockReference, string, error) {
+	chapterID, blockIndex, err := s.FindBlockLocation(docID, blockID)
+	if err != nil {
+		return nil, blocks.BlockReference{}, "", err
+	}
+	
+	var refs []blocks.BlockReference
+	if chapterID != "" {
+		chapter, err := s.GetChapter(docID, chapterID)
+		if err != nil {
+			return nil, blocks.BlockReference{}, "", err
+		}
+		refs = chapter.Blocks
+	} else {
+		doc, err := s.GetDocument(docID)
+		if err != nil {
+			return nil, blocks.BlockReference{}, "", err
+		}
+		refs = doc.Blocks
+	}
+	
+	ref := refs[blockIndex]
+	block, err := s.LoadBlock(docID, ref)
+	if err != nil {
+		return nil, blocks.BlockReference{}, "", err
+	}
+	return block, ref, chapterID, nil
+}
+
+// labelPattern restricts cross-reference labels to characters that are safe in
+// Pandoc identifiers and the {@ref:label} syntax
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]*$`)
+
+// SetBlockLabel assigns a cross-reference label to a block. An empty label clears it.
+func (s *Storage) SetBlockLabel(docID, blockID, label string) error {
+	if label != "" {
+		if !labelPattern.MatchString(label) {
+			return fmt.Errorf("invalid label %q: use letters, digits, '-', '_', '.' or ':'", label)
+		}
+		
+		// Labels must be unique within the document
+		refs, err := s.GetAllBlockReferences(docID)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if ref.Label == label && ref.ID != blockID {
+				return fmt.Errorf("label %q is already used by block %s", label, ref.ID)
+			}
+		}
+	}
+	
+	return s.updateBlockReference(docID, blockID, func(ref *blocks.BlockReference) error {
+		ref.Label = label
+		return nil
+	})
+}
+
+// SetBlockConditions restricts a block to export profiles that satisfy the conditions.
+// Empty conditions make the block unconditional again.
+func (s *Storage) SetBlockConditions(docID, blockID string, conditions blocks.Conditions) error {
+	if err := conditions.Validate(); err != nil {
+		return err
+	}
+	if len(conditions) == 0 {
+		conditions = nil
+	}
+	
+	return s.updateBlockReference(docID, blockID, func(ref *blocks.BlockReference) error {
+		ref.Conditions = conditions
+		return nil
+	})
+}
+
+// SetBlockStyle overrides the document style for one block. An empty override removes it.
+func (s *Storage) SetBlockStyle(docID, blockID string, override *style.StyleOverride) error {
+	if override.IsEmpty() {
+		override = nil
+	} else if err := override.Validate(); err != nil {
+		return err
+	}
+	
+	return s.updateBlockReference(docID, blockID, func(ref *blocks.BlockReference) error {
+		ref.Style = override
+		return nil
+	})
+}
+
+// TagBlock adds and removes a block's tags and returns the tags it ends up with.
+// Tags are stored lowercased and sorted.
+func (s *Storage) TagBlock(docID, blockID string, add, remove []string) ([]string, error) {
+	var tags []string
+	err := s.updateBlockReference(docID, blockID, func(ref *blocks.BlockReference) error {
+		set := make(map[string]bool, len(ref.Tags)+len(add))
+		for _, tag := range ref.Tags {
+			set[tag] = true
+		}
+		for _, tag := range add {
+			normalized, err := blocks.NormalizeTag(tag)
+			if err != nil {
+				return err
+			}
+			set[normalized] = true
+		}
+		for _, tag := range remove {
+			delete(set, strings.ToLower(strings.TrimSpace(tag)))
+		}
+		
+		tags = make([]string, 0, len(set))
+		for tag := range set {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		ref.Tags = tags
+		if len(ref.Tags) == 0 {
+			ref.Tags = nil
+		}
+		return nil
+	})
+	return tags, err
+}
+
+// GetAllBlockReferences returns every block reference in document order,
+// document-level blocks first followed by each chapter's blocks
+func (s *Storage) GetAllBlockReferences(docID string) ([]blocks.BlockReference, error) {
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return nil, err
+	}
+	
+	refs := append([]blocks.BlockReference{}, doc.Blocks...)
+	for _, chapterRef := range doc.Chapters {
+		chapter, err := s.GetChapter(docID, chapterRef.ID)
+		if err != nil {
+			continue
+		}
+		refs = append(refs, chapter.Blocks...)
+	}
+	
+	return refs, nil
+}
+
+// updateBlockReference applies a change to the manifest entry of an unlocked block and saves it
+func (s *Storage) updateBlockReference(docID, blockID string, update func(ref *blocks.BlockReference) error) error {
+	if err := s.checkBlockUnlocked(docID, blockID); err != nil {
+		return err
+	}
+	return s.writeBlockReference(docID, blockID, update)
+}
+
+// writeBlockReference applies a change to a block's manifest entry and saves
+// it, whether or not the block is locked
+func (s *Storage) writeBlockReference(docID, blockID string, update func(ref *blocks.BlockReference) error) error {
+	chapterID, blockIndex, err := s.FindBlockLocation(docID, blockID)
+	if err != nil {
+		return err
+	}
+	
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return err
+	}
+	
+	if chapterID != "" {
+		chapter, err := s.GetChapter(docID, chapterID)
+		if err != nil {
+			return err
+		}
+		if blockIndex < 0 || blockIndex >= len(chapter.Blocks) {
+			return fmt.Errorf("block index out of range")
+		}
+		if err := update(&chapter.Blocks[blockIndex]); err != nil {
+			return err
+		}
+		if err := s.SaveChapter(docID, chapterID, chapter); err != nil {
+			return err
+		}
+	} else {
+		if blockIndex < 0 || blockIndex >= len(doc.Blocks) {
+			return fmt.Errorf("block index out of range")
+		}
+		if err := update(&doc.Blocks[blockIndex]); err != nil {
+			return err
+		}
+	}
+	
+	// Update document timestamp
+	return s.SaveDocument(docID, doc)
+}
@@ -8,6 +8,7 @@ import (
 	
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
 	"github.com/savant/mcp-servers/docgen2/pkg/document"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
 	"gopkg.in/yaml.v3"
 )
 
@@ -231,6 +232,10 @@ func (s *Storage) UpdateChapter(docID, chapterID, newTitle string) error {
 		return fmt.Errorf("document does not have chapters")
 	}
 	
+	if err := s.checkChapterUnlocked(docID, chapterID); err != nil {
+		return err
+	}
+	
 	// Find and update chapter in document manifest
 	found := false
 	for i, chapterRef := range doc.Chapters {
@@ -260,6 +265,63 @@ func (s *Storage) UpdateChapter(docID, chapterID, newTitle string) error {
 	return s.SaveDocument(docID, doc)
 }
 
+// SetChapterConditions restricts a chapter to export profiles that satisfy the conditions.
+// Empty conditions make the chapter unconditional again.
+func (s *Storage) SetChapterConditions(docID, chapterID string, conditions blocks.Conditions) error {
+	if err := conditions.Validate(); err != nil {
+		return err
+	}
+	if len(conditions) == 0 {
+		conditions = nil
+	}
+	if err := s.checkChapterUnlocked(docID, chapterID); err != nil {
+		return err
+	}
+	
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return err
+	}
+	
+	for i, chapterRef := range doc.Chapters {
+		if chapterRef.ID == chapterID {
+			doc.Chapters[i].Conditions = conditions
+			return s.SaveDocument(docID, doc)
+		}
+	}
+	
+	return fmt.Errorf("chapter not found: %s", chapterID)
+}
+
+// SetChapterStyle overrides the document style for a whole chapter. An empty
+// override removes it.
+func (s *Storage) SetChapterStyle(docID, chapterID string, override *style.StyleOverride) error {
+	if override.IsEmpty() {
+		override = nil
+	} else if err := override.Validate(); err != nil {
+		return err
+	} else if override.KeepTogether {
+		return fmt.Errorf("keep_together applies to blocks; a chapter may be longer than a page")
+	}
+	if err := s.checkChapterUnlocked(docID, chapterID); err != nil {
+		return err
+	}
+	
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return err
+	}
+	
+	for i, chapterRef := range doc.Chapters {
+		if chapterRef.ID == chapterID {
+			doc.Chapters[i].Style = override
+			return s.SaveDocument(docID, doc)
+		}
+	}
+	
+	return fmt.Errorf("chapter not found: %s", chapterID)
+}
+
 // DeleteChapter deletes a chapter and all its contents
 func (s *Storage) DeleteChapter(docID, chapterID string) error {
 	doc, err := s.GetDocument(docID)
@@ -271,6 +333,18 @@ func (s *Storage) DeleteChapter(docID, chapterID string) error {
 		return fmt.Errorf("document does not have chapters")
 	}
 	
+	// Locked blocks must not disappear with their chapter
+	if err := s.checkChapterUnlocked(docID, chapterID); err != nil {
+		return err
+	}
+	if chapter, err := s.GetChapter(docID, chapterID); err == nil {
+		for _, ref := range chapter.Blocks {
+			if ref.Locked {
+				return fmt.Errorf("block %s in chapter %s is locked; unlock it before deleting the chapter", ref.ID, chapterID)
+			}
+		}
+	}
+	
 	// Find chapter index
 	chapterIndex := -1
 	for i, chapterRef := range doc.Chapters {
@@ -308,6 +382,10 @@ func (s *Storage) MoveChapter(docID, chapterID string, newPosition document.Posi
 		return fmt.Errorf("document does not have chapters")
 	}
 	
+	if err := s.checkChapterUnlocked(docID, chapterID); err != nil {
+		return err
+	}
+	
 	// Find current chapter
 	var targetChapter document.ChapterReference
 	currentIndex := -1
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"gopkg.in/yaml.v3"
+)
+
+// commentsFile holds a document's review comments, next to its manifest
+const commentsFile = "comments.yaml"
+
+// Comment is a review comment on a block, or on a passage of a markdown block's text
+type Comment struct {
+	ID         string         `yaml:"id" json:"id"`
+	BlockID    string         `yaml:"block_id" json:"block_id"`
+	ChapterID  string         `yaml:"chapter_id,omitempty" json:"chapter_id,omitempty"`
+	Quote      string         `yaml:"quote,omitempty" json:"quote,omitempty"` // Commented text; empty for the whole block
+	Author     string         `yaml:"author" json:"author"`
+	Text       string         `yaml:"text" json:"text"`
+	CreatedAt  time.Time      `yaml:"created_at" json:"created_at"`
+	Resolved   bool           `yaml:"resolved,omitempty" json:"resolved"`
+	ResolvedAt *time.Time     `yaml:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	Replies    []CommentReply `yaml:"replies,omitempty" json:"replies,omitempty"`
+}
+
+// CommentReply is a reply in a comment's thread
+type CommentReply struct {
+	Author    string    `yaml:"author" json:"author"`
+	Text      string    `yaml:"text" json:"text"`
+	CreatedAt time.Time `yaml:"created_at" json:"created_at"`
+}
+
+// commentList is the on-disk form of a document's comments
+type commentList struct {
+	Comments []Comment `yaml:"comments"`
+}
+
+// ListComments returns a document's comments in the order they were made
+func (s *Storage) ListComments(docID string) ([]Comment, error) {
+	if _, err := s.GetDocument(docID); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.commentsPath(docID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read comments: %w", err)
+	}
+
+	var list commentList
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse comments: %w", err)
+	}
+	return list.Comments, nil
+}
+
+// AddComment attaches a comment to a block. A quote narrows it to that passage
+// of a markdown block, which must contain the quoted text.
+func (s *Storage) AddComment(docID, blockID, author, text, quote string) (*Comment, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("comment text cannot be empty")
+	}
+
+	block, _, chapterID, err := s.GetBlock(docID, blockID)
+	if err != nil {
+		return nil, err
+	}
+	if quote != "" {
+		markdown, ok := block.(*blocks.MarkdownBlock)
+		if !ok {
+			return nil, fmt.Errorf("block %s is a %s block; only markdown text can be quoted", blockID, block.GetType())
+		}
+		if !strings.Contains(markdown.Content, quote) {
+			return nil, fmt.Errorf("quote not found in block %s", blockID)
+		}
+	}
+
+	comments, err := s.ListComments(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := Comment{
+		ID:        nextCommentID(comments),
+		BlockID:   blockID,
+		ChapterID: chapterID,
+		Quote:     quote,
+		Author:    author,
+		Text:      text,
+		CreatedAt: time.Now(),
+	}
+	comments = append(comments, comment)
+	if err := s.saveComments(docID, comments); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ReplyToComment adds a reply to a comment's thread. Replying reopens a resolved comment.
+func (s *Storage) ReplyToComment(docID, commentID, author, text string) (*Comment, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("reply text cannot be empty")
+	}
+
+	return s.updateComment(docID, commentID, func(comment *Comment) {
+		comment.Replies = append(comment.Replies, CommentReply{Author: author, Text: text, CreatedAt: time.Now()})
+		comment.Resolved = false
+		comment.ResolvedAt = nil
+	})
+}
+
+// ResolveComment marks a comment resolved, or reopens it
+func (s *Storage) ResolveComment(docID, commentID string, resolved bool) (*Comment, error) {
+	return s.updateComment(docID, commentID, func(comment *Comment) {
+		comment.Resolved = resolved
+		comment.ResolvedAt = nil
+		if resolved {
+			now := time.Now()
+			comment.ResolvedAt = &now
+		}
+	})
+}
+
+// updateComment applies a change to one comment and saves the document's comments
+func (s *Storage) updateComment(docID, commentID string, update func(comment *Comment)) (*Comment, error) {
+	comments, err := s.ListComments(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range comments {
+		if comments[i].ID != commentID {
+			continue
+		}
+		update(&comments[i])
+		if err := s.saveComments(docID, comments); err != nil {
+			return nil, err
+		}
+		return &comments[i], nil
+	}
+	return nil, fmt.Errorf("comment not found: %s", commentID)
+}
+
+// deleteBlockComments drops the comments of a block that is being deleted
+func (s *Storage) deleteBlockComments(docID, chapterID, blockID string) error {
+	comments, err := s.ListComments(docID)
+	if err != nil || len(comments) == 0 {
+		return err
+	}
+
+	kept := comments[:0]
+	for _, comment := range comments {
+		if comment.BlockID != blockID || comment.ChapterID != chapterID {
+			kept = append(kept, comment)
+		}
+	}
+	if len(kept) == len(comments) {
+		return nil
+	}
+	return s.saveComments(docID, kept)
+}
+
+// saveComments writes a document's comments, removing the file when none are left
+func (s *Storage) saveComments(docID string, comments []Comment) error {
+	path := s.commentsPath(docID)
+	if len(comments) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove comments: %w", err)
+		}
+		return nil
+	}
+
+	data, err := yaml.Marshal(commentList{Comments: comments})
+	if err != nil {
+		return fmt.Errorf("failed to marshal comments: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write comments: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) commentsPath(docID string) string {
+	return filepath.Join(s.config.GetDocumentFolder(docID), commentsFile)
+}
+
+// nextCommentID returns an ID one higher than any existing comment's, e.g. cmt-004
+func nextCommentID(comments []Comment) string {
+	highest := 0
+	for _, comment := range comments {
+		var n int
+		if _, err := fmt.Sscanf(comment.ID, "cmt-%d", &n); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return fmt.Sprintf("cmt-%03d", highest+1)
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// blockList returns the block references of a chapter, or of the document
+// itself when chapterID is empty
+func (s *Storage) blockList(docID, chapterID string) ([]blocks.BlockReference, error) {
+	if chapterID != "" {
+		chapter, err := s.GetChapter(docID, chapterID)
+		if err != nil {
+			return nil, err
+		}
+		return chapter.Blocks, nil
+	}
+
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Blocks, nil
+}
+
+// CheckLayoutBlocks verifies that the children of a layout are blocks of the
+// same chapter that are neither layouts themselves nor children of another layout,
+// and that no locked block is added to or taken out of the layout.
+// layoutID is empty for a layout that has not been added yet.
+func (s *Storage) CheckLayoutBlocks(docID, chapterID, layoutID string, layout *blocks.LayoutBlock) error {
+	refs, err := s.blockList(docID, chapterID)
+	if err != nil {
+		return err
+	}
+
+	types := make(map[string]blocks.BlockType, len(refs))
+	locked := make(map[string]bool)
+	owners := make(map[string]string)
+	for _, ref := range refs {
+		types[ref.ID] = ref.Type
+		locked[ref.ID] = ref.Locked
+		if ref.Type != blocks.TypeLayout {
+			continue
+		}
+		block, err := s.LoadBlock(docID, ref)
+		if err != nil {
+			return fmt.Errorf("failed to load layout %s: %w", ref.ID, err)
+		}
+		for _, child := range block.(*blocks.LayoutBlock).Blocks {
+			owners[child] = ref.ID
+		}
+	}
+
+	children := make(map[string]bool, len(layout.Blocks))
+	for _, id := range layout.Blocks {
+		children[id] = true
+	}
+	for id, owner := range owners {
+		if owner == layoutID && !children[id] && locked[id] {
+			return fmt.Errorf("block %s is locked; unlock it before taking it out of layout %s", id, layoutID)
+		}
+	}
+
+	for _, id := range layout.Blocks {
+		blockType, ok := types[id]
+		if !ok {
+			if chapterID != "" {
+				return fmt.Errorf("block %s is not in chapter %s", id, chapterID)
+			}
+			return fmt.Errorf("block not found: %s", id)
+		}
+		if blockType == blocks.TypeLayout {
+			return fmt.Errorf("block %s is a layout; layouts cannot be nested", id)
+		}
+		owner, ok := owners[id]
+		if ok && owner != layoutID {
+			return fmt.Errorf("block %s is already in layout %s", id, owner)
+		}
+		if !ok && locked[id] {
+			return fmt.Errorf("block %s is locked; unlock it before adding it to a layout", id)
+		}
+	}
+	return nil
+}
+
+// removeFromLayouts drops a block that is being deleted from any layout listing it
+func (s *Storage) removeFromLayouts(docID, chapterID, blockID string) error {
+	refs, err := s.blockList(docID, chapterID)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if ref.Type != blocks.TypeLayout || ref.ID == blockID {
+			continue
+		}
+		block, err := s.LoadBlock(docID, ref)
+		if err != nil {
+			return fmt.Errorf("failed to load layout %s: %w", ref.ID, err)
+		}
+		layout := block.(*blocks.LayoutBlock)
+
+		kept := layout.Blocks[:0]
+		for _, id := range layout.Blocks {
+			if id != blockID {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) == len(layout.Blocks) {
+			continue
+		}
+		if ref.Locked {
+			return fmt.Errorf("block %s is in locked layout %s; unlock the layout before deleting the block", blockID, ref.ID)
+		}
+		layout.Blocks = kept
+
+		breaks := layout.ColumnBreaks[:0]
+		for _, id := range layout.ColumnBreaks {
+			if id != blockID && (len(kept) == 0 || id != kept[0]) {
+				breaks = append(breaks, id)
+			}
+		}
+		layout.ColumnBreaks = breaks
+		if len(layout.ColumnBreaks) == 0 {
+			layout.ColumnBreaks = nil
+		}
+
+		if _, err := s.saveBlockFile(docID, chapterID, layout); err != nil {
+			return fmt.Errorf("failed to update layout %s: %w", ref.ID, err)
+		}
+	}
+	return nil
+}
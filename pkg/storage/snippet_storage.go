@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"gopkg.in/yaml.v3"
+)
+
+// Snippet is a reusable piece of markdown shared by every document in the
+// workspace, such as a disclaimer or an author bio. Metadata is stored in
+// snippets/<id>.yaml and the content in snippets/<id>.md.
+type Snippet struct {
+	ID          string    `yaml:"id" json:"id"`
+	Title       string    `yaml:"title" json:"title"`
+	Description string    `yaml:"description,omitempty" json:"description,omitempty"`
+	Content     string    `yaml:"-" json:"content"`
+	CreatedAt   time.Time `yaml:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `yaml:"updated_at" json:"updated_at"`
+}
+
+// snippetIDPattern keeps snippet IDs safe to use as file names
+var snippetIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// IncludeTarget is the block an include resolves to. Snippets resolve to a
+// markdown block with no document.
+type IncludeTarget struct {
+	DocID     string
+	ChapterID string
+	Ref       blocks.BlockReference
+	Block     blocks.Block
+}
+
+// IncludeUsage locates an include block that references a snippet
+type IncludeUsage struct {
+	DocumentID string `json:"document_id"`
+	BlockID    string `json:"block_id"`
+}
+
+// CreateSnippet adds a snippet to the library, deriving its ID from the title
+func (s *Storage) CreateSnippet(title, description, content string) (*Snippet, error) {
+	if strings.TrimSpace(title) == "" {
+		return nil, fmt.Errorf("snippet title cannot be empty")
+	}
+	if err := os.MkdirAll(s.config.GetSnippetsFolder(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snippets folder: %w", err)
+	}
+
+	now := time.Now()
+	snippet := &Snippet{
+		ID:          s.generateSnippetID(title),
+		Title:       title,
+		Description: description,
+		Content:     content,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.writeSnippet(snippet); err != nil {
+		return nil, err
+	}
+	return snippet, nil
+}
+
+// generateSnippetID generates a unique snippet ID from the title
+func (s *Storage) generateSnippetID(title string) string {
+	reg := regexp.MustCompile(`[^a-z0-9\-_]+`)
+	cleaned := strings.Trim(reg.ReplaceAllString(strings.ToLower(title), "-"), "-_")
+	if len(cleaned) > 50 {
+		cleaned = strings.TrimRight(cleaned[:50], "-_")
+	}
+	if cleaned == "" {
+		cleaned = "snippet"
+	}
+
+	baseID := cleaned
+	for counter := 1; ; counter++ {
+		if _, err := os.Stat(s.snippetPath(cleaned, ".yaml")); os.IsNotExist(err) {
+			return cleaned
+		}
+		cleaned = fmt.Sprintf("%s-%d", baseID, counter)
+	}
+}
+
+// GetSnippet loads a snippet and its content
+func (s *Storage) GetSnippet(snippetID string) (*Snippet, error) {
+	if !snippetIDPattern.MatchString(snippetID) {
+		return nil, fmt.Errorf("invalid snippet ID: %q", snippetID)
+	}
+
+	data, err := os.ReadFile(s.snippetPath(snippetID, ".yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("snippet not found: %s", snippetID)
+		}
+		return nil, fmt.Errorf("failed to read snippet: %w", err)
+	}
+	var snippet Snippet
+	if err := yaml.Unmarshal(data, &snippet); err != nil {
+		return nil, fmt.Errorf("failed to parse snippet: %w", err)
+	}
+
+	content, err := os.ReadFile(s.snippetPath(snippetID, ".md"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read snippet content: %w", err)
+	}
+	snippet.ID = snippetID
+	snippet.Content = string(content)
+	return &snippet, nil
+}
+
+// SaveSnippet writes changes to an existing snippet
+func (s *Storage) SaveSnippet(snippet *Snippet) error {
+	if _, err := s.GetSnippet(snippet.ID); err != nil {
+		return err
+	}
+	if strings.TrimSpace(snippet.Title) == "" {
+		return fmt.Errorf("snippet title cannot be empty")
+	}
+	snippet.UpdatedAt = time.Now()
+	return s.writeSnippet(snippet)
+}
+
+// ListSnippets returns every snippet in the library, sorted by ID
+func (s *Storage) ListSnippets() ([]*Snippet, error) {
+	entries, err := os.ReadDir(s.config.GetSnippetsFolder())
+	if os.IsNotExist(err) {
+		return []*Snippet{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snippets folder: %w", err)
+	}
+
+	snippets := []*Snippet{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".yaml" {
+			continue
+		}
+		snippet, err := s.GetSnippet(strings.TrimSuffix(name, ".yaml"))
+		if err != nil {
+			continue // Skip unreadable snippets
+		}
+		snippets = append(snippets, snippet)
+	}
+	sort.Slice(snippets, func(i, j int) bool { return snippets[i].ID < snippets[j].ID })
+	return snippets, nil
+}
+
+// DeleteSnippet removes a snippet from the library
+func (s *Storage) DeleteSnippet(snippetID string) error {
+	if _, err := s.GetSnippet(snippetID); err != nil {
+		return err
+	}
+	if err := os.Remove(s.snippetPath(snippetID, ".yaml")); err != nil {
+		return fmt.Errorf("failed to delete snippet: %w", err)
+	}
+	os.Remove(s.snippetPath(snippetID, ".md")) // Ignore error if file doesn't exist
+	return nil
+}
+
+// SnippetUsages finds the include blocks, across all documents, that reference a snippet
+func (s *Storage) SnippetUsages(snippetID string) ([]IncludeUsage, error) {
+	docIDs, err := s.ListDocuments()
+	if err != nil {
+		return nil, err
+	}
+
+	usages := []IncludeUsage{}
+	for _, docID := range docIDs {
+		refs, err := s.GetAllBlockReferences(docID)
+		if err != nil {
+			continue
+		}
+		for _, ref := range refs {
+			if ref.Type != blocks.TypeInclude {
+				continue
+			}
+			block, err := s.LoadBlock(docID, ref)
+			if err != nil {
+				continue
+			}
+			if block.(*blocks.IncludeBlock).Snippet == snippetID {
+				usages = append(usages, IncludeUsage{DocumentID: docID, BlockID: ref.ID})
+			}
+		}
+	}
+	return usages, nil
+}
+
+// ResolveInclude returns the content an include block points at. Includes of
+// blocks that are themselves includes are followed to the final source; a chain
+// that leads back to a source already visited is reported as a cycle.
+func (s *Storage) ResolveInclude(include *blocks.IncludeBlock) (*IncludeTarget, error) {
+	var chain []string
+	for {
+		source := include.Source()
+		for _, visited := range chain {
+			if visited == source {
+				return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(chain, " -> "), source)
+			}
+		}
+		chain = append(chain, source)
+
+		if err := include.Validate(); err != nil {
+			return nil, err
+		}
+
+		if include.Snippet != "" {
+			snippet, err := s.GetSnippet(include.Snippet)
+			if err != nil {
+				return nil, err
+			}
+			return &IncludeTarget{
+				Ref:   blocks.BlockReference{ID: snippet.ID, Type: blocks.TypeMarkdown},
+				Block: &blocks.MarkdownBlock{BaseBlock: blocks.BaseBlock{ID: snippet.ID, Type: blocks.TypeMarkdown}, Content: snippet.Content},
+			}, nil
+		}
+
+		block, ref, chapterID, err := s.GetBlock(include.Document, include.Block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", source, err)
+		}
+		next, ok := block.(*blocks.IncludeBlock)
+		if !ok {
+			return &IncludeTarget{DocID: include.Document, ChapterID: chapterID, Ref: ref, Block: block}, nil
+		}
+		include = next
+	}
+}
+
+// CheckIncludeCycle reports an error when a layout or include block, with the
+// given content, would contain itself: through the blocks a layout sets in its
+// columns and the blocks includes point at, in any document. blockID is empty
+// for a block that has not been added yet.
+func (s *Storage) CheckIncludeCycle(docID, chapterID, blockID string, block blocks.Block) error {
+	start := IncludeTarget{DocID: docID, ChapterID: chapterID, Ref: blocks.BlockReference{ID: blockID}}
+	key := func(t IncludeTarget) string { return t.DocID + "/" + t.ChapterID + "/" + t.Ref.ID }
+
+	onPath := map[string]bool{key(start): true}
+	done := make(map[string]bool)
+	var walk func(from IncludeTarget, path []string) error
+	walk = func(from IncludeTarget, path []string) error {
+		for _, next := range s.containedBlocks(from) {
+			path := append(path, next.DocID+"/"+next.Ref.ID)
+			if onPath[key(next)] {
+				return fmt.Errorf("include cycle: %s", strings.Join(path, " -> "))
+			}
+			if done[key(next)] {
+				continue
+			}
+			onPath[key(next)] = true
+			if err := walk(next, path); err != nil {
+				return err
+			}
+			onPath[key(next)] = false
+			done[key(next)] = true
+		}
+		return nil
+	}
+
+	start.Block = block
+	return walk(start, []string{docID + "/" + blockID})
+}
+
+// containedBlocks returns the blocks a layout sets in its columns, or the block
+// an include points at; other blocks contain none. Blocks that cannot be loaded
+// are left out, as exports report them.
+func (s *Storage) containedBlocks(from IncludeTarget) []IncludeTarget {
+	switch b := from.Block.(type) {
+	case *blocks.LayoutBlock:
+		refs, err := s.blockList(from.DocID, from.ChapterID)
+		if err != nil {
+			return nil
+		}
+		children := make(map[string]bool, len(b.Blocks))
+		for _, id := range b.Blocks {
+			children[id] = true
+		}
+		var contained []IncludeTarget
+		for _, ref := range refs {
+			if !children[ref.ID] {
+				continue
+			}
+			child, err := s.LoadBlock(from.DocID, ref)
+			if err != nil {
+				continue
+			}
+			contained = append(contained, IncludeTarget{DocID: from.DocID, ChapterID: from.ChapterID, Ref: ref, Block: child})
+		}
+		return contained
+	case *blocks.IncludeBlock:
+		if b.Snippet != "" {
+			return nil
+		}
+		block, ref, chapterID, err := s.GetBlock(b.Document, b.Block)
+		if err != nil {
+			return nil
+		}
+		return []IncludeTarget{{DocID: b.Document, ChapterID: chapterID, Ref: ref, Block: block}}
+	}
+	return nil
+}
+
+func (s *Storage) snippetPath(snippetID, ext string) string {
+	return filepath.Join(s.config.GetSnippetsFolder(), snippetID+ext)
+}
+
+func (s *Storage) writeSnippet(snippet *Snippet) error {
+	data, err := yaml.Marshal(snippet)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snippet: %w", err)
+	}
+	if err := os.WriteFile(s.snippetPath(snippet.ID, ".md"), []byte(snippet.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write snippet content: %w", err)
+	}
+	if err := os.WriteFile(s.snippetPath(snippet.ID, ".yaml"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write snippet: %w", err)
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +13,7 @@ import (
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
 	"github.com/savant/mcp-servers/docgen2/pkg/config"
 	"github.com/savant/mcp-servers/docgen2/pkg/document"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
 )
 
 func setupTestStorage(t *testing.T) (*Storage, func()) {
@@ -967,6 +971,52 @@ func TestMoveChapter(t *testing.T) {
 	}
 }
 
+func TestCopyImageToAssetsProcessing(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	storage.config.ImageDPI = 100
+	storage.config.ImagePageWidth = 2
+	
+	docID, err := storage.CreateDocument("Asset Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	dir := t.TempDir()
+	largePath := filepath.Join(dir, "wide.png")
+	if err := os.WriteFile(largePath, testPNG(t, 400, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Images wider than 2in at 100 DPI are downscaled and their metadata recorded
+	assetPath, err := storage.CopyImageToAssets(docID, largePath)
+	if err != nil {
+		t.Fatalf("Failed to copy image: %v", err)
+	}
+	info, ok := storage.GetAssetInfo(docID, assetPath)
+	if !ok {
+		t.Fatal("Expected asset metadata to be recorded")
+	}
+	if info.ContentType != "image/png" || info.Width != 200 || info.Height != 50 || info.Source != "wide.png" {
+		t.Errorf("Unexpected asset info: %+v", info)
+	}
+	
+	// Files that do not decode are rejected without leaving an asset behind
+	badPath := filepath.Join(dir, "broken.png")
+	if err := os.WriteFile(badPath, []byte("fake png content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.CopyImageToAssets(docID, badPath); err == nil {
+		t.Error("Expected error for an image that does not decode")
+	}
+	entries, _ := os.ReadDir(filepath.Join(storage.config.GetDocumentFolder(docID), "assets"))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "broken") {
+			t.Errorf("Unexpected asset for rejected image: %s", entry.Name())
+		}
+	}
+}
+
 func TestAddImageBlock(t *testing.T) {
 	storage, cleanup := setupTestStorage(t)
 	defer cleanup()
@@ -979,7 +1029,7 @@ func TestAddImageBlock(t *testing.T) {
 	
 	// Create a test image file
 	testImagePath := filepath.Join(os.TempDir(), "test-image.png")
-	testImageContent := []byte("fake png content")
+	testImageContent := testPNG(t, 4, 3)
 	err = os.WriteFile(testImagePath, testImageContent, 0644)
 	if err != nil {
 		t.Fatal(err)
@@ -1470,4 +1520,669 @@ func TestImagePathConversion(t *testing.T) {
 	if len(doc.Blocks) != len(testCases) {
 		t.Errorf("Expected %d blocks in document, got %d", len(testCases), len(doc.Blocks))
 	}
-}
\ No newline at end of file
+}
+func TestSetBlockLabel(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	docID, err := storage.CreateDocument("Label Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	end := document.Position{Type: document.PositionEnd}
+	storage.AddBlock(docID, "", &blocks.HeadingBlock{Level: 1, Text: "One"}, end)
+	storage.AddBlock(docID, "", &blocks.HeadingBlock{Level: 1, Text: "Two"}, end)
+	
+	if err := storage.SetBlockLabel(docID, "hd-001", "sec-one"); err != nil {
+		t.Fatalf("Failed to set label: %v", err)
+	}
+	
+	doc, err := storage.GetDocument(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Blocks[0].Label != "sec-one" {
+		t.Errorf("Expected label 'sec-one', got '%s'", doc.Blocks[0].Label)
+	}
+	
+	// Duplicate labels are rejected
+	if err := storage.SetBlockLabel(docID, "hd-002", "sec-one"); err == nil {
+		t.Error("Expected error for duplicate label")
+	}
+	
+	// Invalid characters are rejected
+	if err := storage.SetBlockLabel(docID, "hd-002", "has space"); err == nil {
+		t.Error("Expected error for invalid label")
+	}
+	
+	// Empty label clears it
+	if err := storage.SetBlockLabel(docID, "hd-001", ""); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ = storage.GetDocument(docID)
+	if doc.Blocks[0].Label != "" {
+		t.Errorf("Expected label to be cleared, got '%s'", doc.Blocks[0].Label)
+	}
+}
+
+// testPNG encodes a blank PNG of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSnippetLibrary(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	snippet, err := storage.CreateSnippet("Author Bio", "Short bio", "Jane writes reports.")
+	if err != nil {
+		t.Fatalf("Failed to create snippet: %v", err)
+	}
+	if snippet.ID != "author-bio" {
+		t.Errorf("Expected ID 'author-bio', got '%s'", snippet.ID)
+	}
+	second, err := storage.CreateSnippet("Author Bio", "", "Other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != "author-bio-1" {
+		t.Errorf("Expected ID 'author-bio-1', got '%s'", second.ID)
+	}
+	
+	loaded, err := storage.GetSnippet("author-bio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Content != "Jane writes reports." || loaded.Description != "Short bio" {
+		t.Errorf("Unexpected snippet: %+v", loaded)
+	}
+	if _, err := storage.GetSnippet("../documents"); err == nil {
+		t.Error("Expected error for invalid snippet ID")
+	}
+	
+	snippets, err := storage.ListSnippets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets) != 2 || snippets[0].ID != "author-bio" {
+		t.Errorf("Expected 2 snippets, got %d", len(snippets))
+	}
+	
+	// Includes resolve to the snippet and are found as usages
+	docID, _ := storage.CreateDocument("Bio Doc", false, "")
+	include := &blocks.IncludeBlock{Snippet: "author-bio"}
+	if err := storage.AddBlock(docID, "", include, document.Position{Type: document.PositionEnd}); err != nil {
+		t.Fatal(err)
+	}
+	if include.ID != "inc-001" {
+		t.Errorf("Expected block ID 'inc-001', got '%s'", include.ID)
+	}
+	target, err := storage.ResolveInclude(include)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md, ok := target.Block.(*blocks.MarkdownBlock); !ok || md.Content != "Jane writes reports." || target.DocID != "" {
+		t.Errorf("Unexpected include target: %+v", target)
+	}
+	usages, err := storage.SnippetUsages("author-bio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 1 || usages[0].DocumentID != docID || usages[0].BlockID != "inc-001" {
+		t.Errorf("Unexpected usages: %+v", usages)
+	}
+	
+	// Includes of includes are followed, and self-references are cycles
+	chained := &blocks.IncludeBlock{Document: docID, Block: "inc-001"}
+	if target, err := storage.ResolveInclude(chained); err != nil || target.Block.GetType() != blocks.TypeMarkdown {
+		t.Errorf("Expected chained include to resolve to the snippet, got %v", err)
+	}
+	self := &blocks.IncludeBlock{Document: docID, Block: "inc-002"}
+	if err := storage.AddBlock(docID, "", self, document.Position{Type: document.PositionEnd}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.ResolveInclude(self); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("Expected include cycle error, got %v", err)
+	}
+	
+	if err := storage.DeleteSnippet("author-bio-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.GetSnippet("author-bio-1"); err == nil {
+		t.Error("Expected deleted snippet to be gone")
+	}
+}
+
+func TestSetConditions(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	docID, err := storage.CreateDocument("Conditions Test", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapterID, err := storage.AddChapter(docID, "Internal Notes", document.Position{Type: document.PositionEnd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	storage.AddBlock(docID, chapterID, &blocks.MarkdownBlock{Content: "Pricing"}, end)
+	
+	conditions := blocks.Conditions{"edition": {"pro", "enterprise"}}
+	if err := storage.SetBlockConditions(docID, "md-001", conditions); err != nil {
+		t.Fatalf("Failed to set block conditions: %v", err)
+	}
+	if err := storage.SetChapterConditions(docID, chapterID, blocks.Conditions{"audience": {"internal"}}); err != nil {
+		t.Fatalf("Failed to set chapter conditions: %v", err)
+	}
+	
+	// Conditions survive moving the block
+	if err := storage.MoveBlock(docID, "md-001", document.Position{Type: document.PositionStart}); err != nil {
+		t.Fatal(err)
+	}
+	chapter, err := storage.GetChapter(docID, chapterID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chapter.Blocks[0].Conditions.String(); got != "edition=pro|enterprise" {
+		t.Errorf("Expected block conditions 'edition=pro|enterprise', got '%s'", got)
+	}
+	doc, _ := storage.GetDocument(docID)
+	if got := doc.Chapters[0].Conditions.String(); got != "audience=internal" {
+		t.Errorf("Expected chapter conditions 'audience=internal', got '%s'", got)
+	}
+	
+	// Profiles only filter on the names they set
+	if !conditions.Matches(nil) || !conditions.Matches(blocks.Profile{"audience": "external"}) {
+		t.Error("Expected conditions to match profiles that do not name them")
+	}
+	if !conditions.Matches(blocks.Profile{"edition": "enterprise"}) || conditions.Matches(blocks.Profile{"edition": "basic"}) {
+		t.Error("Expected conditions to match only allowed values")
+	}
+	
+	// Invalid values are rejected
+	if err := storage.SetBlockConditions(docID, "md-001", blocks.Conditions{"audience": {"not valid"}}); err == nil {
+		t.Error("Expected error for invalid condition value")
+	}
+	if err := storage.SetChapterConditions(docID, "missing", blocks.Conditions{"audience": {"internal"}}); err == nil {
+		t.Error("Expected error for unknown chapter")
+	}
+	
+	// Empty conditions make the block unconditional
+	if err := storage.SetBlockConditions(docID, "md-001", blocks.Conditions{}); err != nil {
+		t.Fatal(err)
+	}
+	chapter, _ = storage.GetChapter(docID, chapterID)
+	if chapter.Blocks[0].Conditions != nil {
+		t.Errorf("Expected conditions to be cleared, got %v", chapter.Blocks[0].Conditions)
+	}
+}
+
+func TestLayoutBlocks(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	docID, err := storage.CreateDocument("Layout Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	for _, content := range []string{"Left", "Right", "After"} {
+		if err := storage.AddBlock(docID, "", &blocks.MarkdownBlock{Content: content}, end); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	layout := &blocks.LayoutBlock{Columns: 2, Blocks: []string{"md-001", "md-002"}, ColumnBreaks: []string{"md-002"}}
+	if err := storage.CheckLayoutBlocks(docID, "", "", layout); err != nil {
+		t.Fatalf("Expected valid layout, got: %v", err)
+	}
+	if err := storage.AddBlock(docID, "", layout, end); err != nil {
+		t.Fatal(err)
+	}
+	
+	// A block belongs to one layout, layouts do not nest and children must exist
+	invalid := map[string][]string{
+		"block in another layout": {"md-002"},
+		"nested layout":           {layout.ID},
+		"missing block":           {"md-404"},
+	}
+	for name, children := range invalid {
+		other := &blocks.LayoutBlock{Columns: 2, Blocks: children}
+		if err := storage.CheckLayoutBlocks(docID, "", "", other); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+	
+	// A layout may keep its own children when it is updated
+	if err := storage.CheckLayoutBlocks(docID, "", layout.ID, layout); err != nil {
+		t.Errorf("Expected layout to accept its own children, got: %v", err)
+	}
+	
+	// Deleting a child removes it from the layout, along with a break that would now lead
+	if err := storage.DeleteBlock(docID, "md-001"); err != nil {
+		t.Fatal(err)
+	}
+	block, _, _, err := storage.GetBlock(docID, layout.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := block.(*blocks.LayoutBlock)
+	if len(updated.Blocks) != 1 || updated.Blocks[0] != "md-002" {
+		t.Errorf("Expected layout blocks [md-002], got %v", updated.Blocks)
+	}
+	if len(updated.ColumnBreaks) != 0 {
+		t.Errorf("Expected no column breaks, got %v", updated.ColumnBreaks)
+	}
+	if err := updated.Validate(); err != nil {
+		t.Errorf("Expected layout to stay valid, got: %v", err)
+	}
+}
+
+func TestComments(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	docID, err := storage.CreateDocument("Comments Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	storage.AddBlock(docID, "", &blocks.MarkdownBlock{Content: "The launch is in March."}, end)
+	storage.AddBlock(docID, "", &blocks.HeadingBlock{Level: 2, Text: "Budget"}, end)
+	
+	comment, err := storage.AddComment(docID, "md-001", "Ana", "Which year?", "in March")
+	if err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+	if comment.ID != "cmt-001" || comment.Quote != "in March" || comment.CreatedAt.IsZero() {
+		t.Errorf("Unexpected comment: %+v", comment)
+	}
+	if _, err := storage.AddComment(docID, "hd-001", "Ana", "Rename", ""); err != nil {
+		t.Fatalf("Failed to add block comment: %v", err)
+	}
+	
+	// Quotes must be markdown text the block contains
+	if _, err := storage.AddComment(docID, "md-001", "Ana", "?", "in April"); err == nil {
+		t.Error("Expected error for a quote the block does not contain")
+	}
+	if _, err := storage.AddComment(docID, "hd-001", "Ana", "?", "Budget"); err == nil {
+		t.Error("Expected error for a quote on a heading")
+	}
+	
+	// Replies reopen a resolved comment
+	if _, err := storage.ResolveComment(docID, "cmt-001", true); err != nil {
+		t.Fatal(err)
+	}
+	comment, err = storage.ReplyToComment(docID, "cmt-001", "Ben", "2027")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.Resolved || len(comment.Replies) != 1 || comment.Replies[0].Author != "Ben" {
+		t.Errorf("Expected an open comment with Ben's reply, got %+v", comment)
+	}
+	if _, err := storage.ResolveComment(docID, "cmt-404", true); err == nil {
+		t.Error("Expected error for unknown comment")
+	}
+	
+	// Deleting a block deletes its comments
+	if err := storage.DeleteBlock(docID, "hd-001"); err != nil {
+		t.Fatal(err)
+	}
+	comments, err := storage.ListComments(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].ID != "cmt-001" {
+		t.Errorf("Expected only cmt-001 to remain, got %+v", comments)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	docID, err := storage.CreateDocument("Suggestions Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	storage.AddBlock(docID, "", &blocks.MarkdownBlock{Content: "The launch is in March."}, end)
+	storage.AddBlock(docID, "", &blocks.HeadingBlock{Level: 2, Text: "Budget"}, end)
+	
+	suggestion, err := storage.AddSuggestion(docID, "md-001", "Ana", &blocks.MarkdownBlock{Content: "The launch is in April."})
+	if err != nil {
+		t.Fatalf("Failed to add suggestion: %v", err)
+	}
+	if suggestion.ID != "sug-001" || suggestion.BlockType != blocks.TypeMarkdown || suggestion.Base != "The launch is in March." {
+		t.Errorf("Unexpected suggestion: %+v", suggestion)
+	}
+	
+	// The block is unchanged until the suggestion is accepted
+	block, _, _, err := storage.GetBlock(docID, "md-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.(*blocks.MarkdownBlock).Content != "The launch is in March." {
+		t.Errorf("Expected the block to be unchanged, got %q", block.(*blocks.MarkdownBlock).Content)
+	}
+	
+	// Suggestions must change the block without changing its type
+	if _, err := storage.AddSuggestion(docID, "md-001", "Ana", &blocks.MarkdownBlock{Content: "The launch is in March."}); err == nil {
+		t.Error("Expected error for a suggestion that changes nothing")
+	}
+	if _, err := storage.AddSuggestion(docID, "md-001", "Ana", &blocks.HeadingBlock{Level: 2, Text: "Launch"}); err == nil {
+		t.Error("Expected error for a suggestion that changes the block type")
+	}
+	
+	// A suggestion made against an older version is only accepted with force
+	if err := storage.UpdateBlock(docID, "md-001", &blocks.MarkdownBlock{Content: "The launch is in May."}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.AcceptSuggestion(docID, "sug-001", false); err == nil {
+		t.Error("Expected error accepting a stale suggestion")
+	}
+	if _, err := storage.AcceptSuggestion(docID, "sug-001", true); err != nil {
+		t.Fatalf("Failed to force accept suggestion: %v", err)
+	}
+	block, _, _, _ = storage.GetBlock(docID, "md-001")
+	if block.(*blocks.MarkdownBlock).Content != "The launch is in April." {
+		t.Errorf("Expected the suggestion to be applied, got %q", block.(*blocks.MarkdownBlock).Content)
+	}
+	
+	// IDs are not reused once a suggestion is accepted; rejecting leaves the block as it is
+	rejected, err := storage.AddSuggestion(docID, "hd-001", "Ben", &blocks.HeadingBlock{Level: 2, Text: "Costs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected.ID != "sug-002" {
+		t.Errorf("Expected a new suggestion ID, got %s", rejected.ID)
+	}
+	if _, err := storage.RejectSuggestion(docID, "sug-002"); err != nil {
+		t.Fatalf("Failed to reject suggestion: %v", err)
+	}
+	block, _, _, _ = storage.GetBlock(docID, "hd-001")
+	if block.(*blocks.HeadingBlock).Text != "Budget" {
+		t.Errorf("Expected the heading to be unchanged, got %q", block.(*blocks.HeadingBlock).Text)
+	}
+	
+	// Deleting a block deletes its suggestions
+	if _, err := storage.AddSuggestion(docID, "hd-001", "Ben", &blocks.HeadingBlock{Level: 2, Text: "Costs"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.DeleteBlock(docID, "hd-001"); err != nil {
+		t.Fatal(err)
+	}
+	suggestions, err := storage.ListSuggestions(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 0 {
+		t.Errorf("Expected no suggestions to remain, got %+v", suggestions)
+	}
+	
+	// The counter survives having no pending suggestions
+	next, err := storage.AddSuggestion(docID, "md-001", "Ana", &blocks.MarkdownBlock{Content: "The launch is in May."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ID != "sug-004" {
+		t.Errorf("Expected sug-004 after three suggestions, got %s", next.ID)
+	}
+}
+
+func TestWorkflowStatus(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	docID, err := storage.CreateDocument("Workflow Test", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	chapterID, err := storage.AddChapter(docID, "Terms", end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.AddBlock(docID, chapterID, &blocks.MarkdownBlock{Content: "Liability is limited."}, end)
+	storage.AddBlock(docID, chapterID, &blocks.MarkdownBlock{Content: "Payment is due in 30 days."}, end)
+	
+	if err := storage.SetBlockStatus(docID, "md-001", "reviewed", nil); err == nil {
+		t.Error("Expected error for an unknown status")
+	}
+	
+	// Approving and locking a block refuses updates, moves and deletion
+	locked := true
+	if err := storage.SetBlockStatus(docID, "md-001", blocks.StatusApproved, &locked); err != nil {
+		t.Fatalf("Failed to set status: %v", err)
+	}
+	_, ref, _, err := storage.GetBlock(docID, "md-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Status != blocks.StatusApproved || !ref.Locked {
+		t.Errorf("Expected an approved, locked block, got %+v", ref)
+	}
+	if err := storage.UpdateBlock(docID, "md-001", &blocks.MarkdownBlock{Content: "Liability is unlimited."}); err == nil {
+		t.Error("Expected error updating a locked block")
+	}
+	if err := storage.MoveBlock(docID, "md-001", end); err == nil {
+		t.Error("Expected error moving a locked block")
+	}
+	if err := storage.DeleteBlock(docID, "md-001"); err == nil {
+		t.Error("Expected error deleting a locked block")
+	}
+	if err := storage.DeleteChapter(docID, chapterID); err == nil {
+		t.Error("Expected error deleting a chapter with a locked block")
+	}
+	
+	// Suggestions can still be filed, but not accepted while the block is locked
+	if _, err := storage.AddSuggestion(docID, "md-001", "Ana", &blocks.MarkdownBlock{Content: "Liability is capped."}); err != nil {
+		t.Fatalf("Failed to add suggestion: %v", err)
+	}
+	if _, err := storage.AcceptSuggestion(docID, "sug-001", true); err == nil {
+		t.Error("Expected error accepting a suggestion for a locked block")
+	}
+	
+	// Unlocking keeps the status; draft is stored as no status
+	unlocked := false
+	if err := storage.SetBlockStatus(docID, "md-001", "", &unlocked); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.UpdateBlock(docID, "md-001", &blocks.MarkdownBlock{Content: "Liability is capped."}); err != nil {
+		t.Errorf("Expected an unlocked block to be updated, got %v", err)
+	}
+	if err := storage.SetBlockStatus(docID, "md-001", blocks.StatusDraft, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, ref, _, _ = storage.GetBlock(docID, "md-001")
+	if ref.Status != "" || ref.Locked {
+		t.Errorf("Expected an unlocked draft without stored status, got %+v", ref)
+	}
+	
+	// A locked chapter locks its blocks and refuses new ones
+	if err := storage.SetChapterStatus(docID, chapterID, blocks.StatusApproved, &locked); err != nil {
+		t.Fatalf("Failed to set chapter status: %v", err)
+	}
+	if err := storage.UpdateBlock(docID, "md-002", &blocks.MarkdownBlock{Content: "Payment is due in 60 days."}); err == nil {
+		t.Error("Expected error updating a block of a locked chapter")
+	}
+	if err := storage.AddBlock(docID, chapterID, &blocks.MarkdownBlock{Content: "New clause."}, end); err == nil {
+		t.Error("Expected error adding a block to a locked chapter")
+	}
+	if err := storage.UpdateChapter(docID, chapterID, "Renamed"); err == nil {
+		t.Error("Expected error renaming a locked chapter")
+	}
+	if err := storage.SetChapterStatus(docID, "ch-404", blocks.StatusApproved, nil); err == nil {
+		t.Error("Expected error for unknown chapter")
+	}
+}
+
+func TestWorkflowLocks(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	docID, err := storage.CreateDocument("Lock Test", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	terms, err := storage.AddChapter(docID, "Terms", end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.AddChapter(docID, "Annex", end); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"Liability is limited.", "Payment is due in 30 days.", "Notices go by email."} {
+		if err := storage.AddBlock(docID, terms, &blocks.MarkdownBlock{Content: content}, end); err != nil {
+			t.Fatal(err)
+		}
+	}
+	locked, unlocked := true, false
+	
+	// A locked block refuses label, condition and tag changes
+	if err := storage.SetBlockStatus(docID, "md-001", blocks.StatusApproved, &locked); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SetBlockLabel(docID, "md-001", "liability"); err == nil {
+		t.Error("Expected error labelling a locked block")
+	}
+	if err := storage.SetBlockConditions(docID, "md-001", blocks.Conditions{"audience": {"external"}}); err == nil {
+		t.Error("Expected error setting conditions on a locked block")
+	}
+	if _, err := storage.TagBlock(docID, "md-001", []string{"legal"}, nil); err == nil {
+		t.Error("Expected error tagging a locked block")
+	}
+	if err := storage.SetBlockStyle(docID, "md-001", &style.StyleOverride{FontSize: 9}); err == nil {
+		t.Error("Expected error restyling a locked block")
+	}
+	
+	// Layouts can neither take in nor give up a locked block
+	layout := &blocks.LayoutBlock{Columns: 2, Blocks: []string{"md-001", "md-002"}}
+	if err := storage.CheckLayoutBlocks(docID, terms, "", layout); err == nil {
+		t.Error("Expected error adding a locked block to a new layout")
+	}
+	if err := storage.SetBlockStatus(docID, "md-001", "", &unlocked); err != nil {
+		t.Fatalf("Expected a locked block to be unlocked, got %v", err)
+	}
+	if err := storage.AddBlock(docID, terms, layout, end); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SetBlockStatus(docID, "md-001", "", &locked); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.CheckLayoutBlocks(docID, terms, "layout-001", &blocks.LayoutBlock{Columns: 2, Blocks: []string{"md-002", "md-001"}}); err != nil {
+		t.Errorf("Expected reordering a layout with a locked block to be allowed, got %v", err)
+	}
+	if err := storage.CheckLayoutBlocks(docID, terms, "layout-001", &blocks.LayoutBlock{Columns: 2, Blocks: []string{"md-002"}}); err == nil {
+		t.Error("Expected error taking a locked block out of a layout")
+	}
+	
+	// Deleting a child would rewrite a locked layout
+	if err := storage.SetBlockStatus(docID, "layout-001", "", &locked); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.DeleteBlock(docID, "md-002"); err == nil {
+		t.Error("Expected error deleting a block of a locked layout")
+	}
+	if _, _, _, err := storage.GetBlock(docID, "md-002"); err != nil {
+		t.Errorf("Expected the block to remain, got %v", err)
+	}
+	
+	// A locked chapter refuses condition changes and moves
+	if err := storage.SetChapterStatus(docID, terms, "", &locked); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SetChapterConditions(docID, terms, blocks.Conditions{"audience": {"external"}}); err == nil {
+		t.Error("Expected error setting conditions on a locked chapter")
+	}
+	if err := storage.MoveChapter(docID, terms, end); err == nil {
+		t.Error("Expected error moving a locked chapter")
+	}
+	if err := storage.SetChapterStyle(docID, terms, &style.StyleOverride{Align: style.AlignCenter}); err == nil {
+		t.Error("Expected error restyling a locked chapter")
+	}
+	if err := storage.SetBlockLabel(docID, "md-003", "notices"); err == nil {
+		t.Error("Expected error labelling a block of a locked chapter")
+	}
+	if err := storage.SetChapterStatus(docID, terms, "", &unlocked); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.MoveChapter(docID, terms, end); err != nil {
+		t.Errorf("Expected an unlocked chapter to move, got %v", err)
+	}
+}
+
+func TestDocumentThemes(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+	
+	first, err := storage.CreateDocument("First", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := storage.CreateDocument("Second", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	if err := storage.SetDocumentTheme(first, "missing", false); err == nil {
+		t.Error("Expected error for an unknown theme")
+	}
+	
+	// Applying a theme clears the document's own style unless it is kept
+	doc, _ := storage.GetDocument(first)
+	doc.Style = &style.StyleConfig{Fonts: style.FontConfig{BodySize: 14}}
+	if err := storage.SaveDocument(first, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SetDocumentTheme(first, "modern", true); err != nil {
+		t.Fatalf("Failed to apply theme: %v", err)
+	}
+	doc, _ = storage.GetDocument(first)
+	if doc.Theme != "modern" || doc.Style != nil {
+		t.Errorf("Expected modern theme without a full style, got %q, %+v", doc.Theme, doc.Style)
+	}
+	
+	// A kept style is reduced to what it changes, so the theme still decides the rest
+	fonts, _ := doc.StyleSettings["fonts"].(map[string]interface{})
+	if len(doc.StyleSettings) != 1 || len(fonts) != 1 || fonts["body_size"] != 14 {
+		t.Errorf("Expected only the body size to be kept, got %v", doc.StyleSettings)
+	}
+	if err := storage.SetDocumentTheme(second, "modern", false); err != nil {
+		t.Fatal(err)
+	}
+	
+	using, err := storage.DocumentsUsingTheme("modern")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(using) != 2 {
+		t.Errorf("Expected both documents to use the theme, got %v", using)
+	}
+	
+	// Without the theme the kept settings become a full style again
+	if err := storage.SetDocumentTheme(first, "", true); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ = storage.GetDocument(first)
+	if doc.Style == nil || doc.Style.Fonts.BodySize != 14 || doc.Style.Fonts.BodyFamily != style.GetDefaultStyle().Fonts.BodyFamily || doc.StyleSettings != nil {
+		t.Errorf("Expected a full style with the kept body size, got %+v, %v", doc.Style, doc.StyleSettings)
+	}
+	
+	// An empty name stops using the theme
+	if err := storage.SetDocumentTheme(first, "", false); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ = storage.GetDocument(first)
+	if doc.Theme != "" || doc.Style != nil {
+		t.Errorf("Expected no theme and no style, got %q, %+v", doc.Theme, doc.Style)
+	}
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+	"gopkg.in/yaml.v3"
+)
+
+// Pending suggestions are listed in suggestions.yaml; the proposed version of
+// each block is stored in the suggestions folder in the block file format
+const (
+	suggestionsFile   = "suggestions.yaml"
+	suggestionsFolder = "suggestions"
+)
+
+// Suggestion is a pending edit of a block, kept apart from the block until a
+// reviewer accepts or rejects it
+type Suggestion struct {
+	ID        string           `yaml:"id" json:"id"`
+	BlockID   string           `yaml:"block_id" json:"block_id"`
+	ChapterID string           `yaml:"chapter_id,omitempty" json:"chapter_id,omitempty"`
+	BlockType blocks.BlockType `yaml:"block_type" json:"block_type"`
+	Author    string           `yaml:"author" json:"author"`
+	CreatedAt time.Time        `yaml:"created_at" json:"created_at"`
+	Base      string           `yaml:"base" json:"-"` // Block markdown the suggestion was made against
+	File      string           `yaml:"file" json:"-"` // Proposed block, relative to the document folder
+}
+
+// suggestionList is the on-disk form of a document's pending suggestions. The
+// number of the last suggestion made is kept so that IDs are never reused once
+// a suggestion is accepted or rejected.
+type suggestionList struct {
+	LastID      int          `yaml:"last_id,omitempty"`
+	Suggestions []Suggestion `yaml:"suggestions"`
+}
+
+// ListSuggestions returns a document's pending suggestions, oldest first
+func (s *Storage) ListSuggestions(docID string) ([]Suggestion, error) {
+	list, err := s.loadSuggestions(docID)
+	if err != nil {
+		return nil, err
+	}
+	return list.Suggestions, nil
+}
+
+// loadSuggestions reads a document's suggestions file
+func (s *Storage) loadSuggestions(docID string) (*suggestionList, error) {
+	if _, err := s.GetDocument(docID); err != nil {
+		return nil, err
+	}
+
+	var list suggestionList
+	data, err := os.ReadFile(filepath.Join(s.config.GetDocumentFolder(docID), suggestionsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &list, nil
+		}
+		return nil, fmt.Errorf("failed to read suggestions: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse suggestions: %w", err)
+	}
+	return &list, nil
+}
+
+// GetSuggestion returns a pending suggestion by ID
+func (s *Storage) GetSuggestion(docID, suggestionID string) (*Suggestion, error) {
+	suggestions, err := s.ListSuggestions(docID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range suggestions {
+		if suggestions[i].ID == suggestionID {
+			return &suggestions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("suggestion not found: %s", suggestionID)
+}
+
+// AddSuggestion files an edit of a block for review instead of applying it
+func (s *Storage) AddSuggestion(docID, blockID, author string, proposed blocks.Block) (*Suggestion, error) {
+	current, ref, chapterID, err := s.GetBlock(docID, blockID)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Type != proposed.GetType() {
+		return nil, fmt.Errorf("cannot change block type from %s to %s", ref.Type, proposed.GetType())
+	}
+	if current.ToMarkdown() == proposed.ToMarkdown() {
+		return nil, fmt.Errorf("the suggestion does not change block %s", blockID)
+	}
+
+	list, err := s.loadSuggestions(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	list.LastID = nextSuggestionNumber(list)
+	suggestion := Suggestion{
+		ID:        fmt.Sprintf("sug-%03d", list.LastID),
+		BlockID:   blockID,
+		ChapterID: chapterID,
+		BlockType: ref.Type,
+		Author:    author,
+		CreatedAt: time.Now(),
+		Base:      current.ToMarkdown(),
+	}
+	if suggestion.File, err = s.writeSuggestedBlock(docID, suggestion.ID, proposed); err != nil {
+		return nil, err
+	}
+
+	list.Suggestions = append(list.Suggestions, suggestion)
+	if err := s.saveSuggestions(docID, list); err != nil {
+		return nil, err
+	}
+	return &suggestion, nil
+}
+
+// LoadSuggestedBlock loads the block as the suggestion would leave it
+func (s *Storage) LoadSuggestedBlock(docID string, suggestion *Suggestion) (blocks.Block, error) {
+	return s.LoadBlock(docID, blocks.BlockReference{ID: suggestion.BlockID, Type: suggestion.BlockType, File: suggestion.File})
+}
+
+// AcceptSuggestion applies a suggestion to its block. A block edited since the
+// suggestion was made is only overwritten when force is set.
+func (s *Storage) AcceptSuggestion(docID, suggestionID string, force bool) (*Suggestion, error) {
+	suggestion, err := s.GetSuggestion(docID, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	current, _, _, err := s.GetBlock(docID, suggestion.BlockID)
+	if err != nil {
+		return nil, err
+	}
+	if current.ToMarkdown() != suggestion.Base && !force {
+		return nil, fmt.Errorf("block %s has changed since suggestion %s was made; accept with force to overwrite it, or reject the suggestion", suggestion.BlockID, suggestionID)
+	}
+
+	proposed, err := s.LoadSuggestedBlock(docID, suggestion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load suggested block: %w", err)
+	}
+	if err := s.UpdateBlock(docID, suggestion.BlockID, proposed); err != nil {
+		return nil, err
+	}
+	return suggestion, s.removeSuggestions(docID, func(other Suggestion) bool { return other.ID == suggestionID })
+}
+
+// RejectSuggestion discards a suggestion, leaving its block as it is
+func (s *Storage) RejectSuggestion(docID, suggestionID string) (*Suggestion, error) {
+	suggestion, err := s.GetSuggestion(docID, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	return suggestion, s.removeSuggestions(docID, func(other Suggestion) bool { return other.ID == suggestionID })
+}
+
+// deleteBlockSuggestions drops the suggestions for a block that is being deleted
+func (s *Storage) deleteBlockSuggestions(docID, chapterID, blockID string) error {
+	return s.removeSuggestions(docID, func(suggestion Suggestion) bool {
+		return suggestion.BlockID == blockID && suggestion.ChapterID == chapterID
+	})
+}
+
+// removeSuggestions deletes the suggestions matching a filter along with their block files
+func (s *Storage) removeSuggestions(docID string, matches func(Suggestion) bool) error {
+	list, err := s.loadSuggestions(docID)
+	if err != nil || len(list.Suggestions) == 0 {
+		return err
+	}
+
+	kept := list.Suggestions[:0]
+	for _, suggestion := range list.Suggestions {
+		if !matches(suggestion) {
+			kept = append(kept, suggestion)
+			continue
+		}
+		os.Remove(filepath.Join(s.config.GetDocumentFolder(docID), suggestion.File)) // Ignore error if file doesn't exist
+	}
+	list.Suggestions = kept
+	return s.saveSuggestions(docID, list)
+}
+
+// writeSuggestedBlock stores a proposed block like saveBlockFile does: markdown
+// as its content and every other type as YAML
+func (s *Storage) writeSuggestedBlock(docID, suggestionID string, block blocks.Block) (string, error) {
+	folder := filepath.Join(s.config.GetDocumentFolder(docID), suggestionsFolder)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create suggestions folder: %w", err)
+	}
+
+	var filename string
+	var data []byte
+	if markdown, ok := block.(*blocks.MarkdownBlock); ok {
+		filename = suggestionID + ".md"
+		data = []byte(markdown.Content)
+	} else {
+		var err error
+		if data, err = yaml.Marshal(block); err != nil {
+			return "", fmt.Errorf("failed to marshal suggested block: %w", err)
+		}
+		filename = fmt.Sprintf("%s-%s.yaml", suggestionID, block.GetType())
+	}
+
+	if err := os.WriteFile(filepath.Join(folder, filename), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write suggested block: %w", err)
+	}
+	return filepath.Join(suggestionsFolder, filename), nil
+}
+
+// saveSuggestions writes a document's suggestions. The file stays when none
+// are left, as it holds the number of the last suggestion.
+func (s *Storage) saveSuggestions(docID string, list *suggestionList) error {
+	path := filepath.Join(s.config.GetDocumentFolder(docID), suggestionsFile)
+	data, err := yaml.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal suggestions: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write suggestions: %w", err)
+	}
+	return nil
+}
+
+// nextSuggestionNumber returns the number of the next suggestion, one higher
+// than the last one made. Files written before the counter was kept fall back
+// to the highest pending suggestion.
+func nextSuggestionNumber(list *suggestionList) int {
+	highest := list.LastID
+	for _, suggestion := range list.Suggestions {
+		var n int
+		if _, err := fmt.Sscanf(suggestion.ID, "sug-%d", &n); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest + 1
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
+)
+
+// SetDocumentTheme makes a document use a style theme, or no theme when the
+// name is empty. Unless keepStyle is set, the document's own style is cleared
+// so the theme applies unchanged. A kept style becomes the settings it changes
+// from the defaults when a theme is applied, and a full style again when the
+// theme is removed.
+func (s *Storage) SetDocumentTheme(docID, theme string, keepStyle bool) error {
+	loader := style.NewStyleLoader(s.config.RootFolder)
+	if theme != "" {
+		if _, err := loader.LoadTheme(theme); err != nil {
+			return err
+		}
+	}
+
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !keepStyle:
+		doc.Style = nil
+		doc.StyleSettings = nil
+	case theme != "" && doc.Theme == "" && doc.Style != nil:
+		settings, err := style.SettingsDiff(loader.LoadStyleForDocument(nil), loader.LoadStyleForDocument(doc.Style))
+		if err != nil {
+			return err
+		}
+		doc.Style = nil
+		doc.StyleSettings = settings
+	case theme == "" && doc.Theme != "" && doc.StyleSettings != nil:
+		full, err := style.ApplySettings(loader.LoadStyleForDocument(nil), doc.StyleSettings)
+		if err != nil {
+			return err
+		}
+		doc.Style = &full
+		doc.StyleSettings = nil
+	}
+	doc.Theme = theme
+	return s.SaveDocument(docID, doc)
+}
+
+// DocumentsUsingTheme returns the IDs of the documents using a style theme
+func (s *Storage) DocumentsUsingTheme(theme string) ([]string, error) {
+	docIDs, err := s.ListDocuments()
+	if err != nil {
+		return nil, err
+	}
+
+	var using []string
+	for _, docID := range docIDs {
+		doc, err := s.GetDocument(docID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read document %s: %w", docID, err)
+		}
+		if doc.Theme == theme {
+			using = append(using, docID)
+		}
+	}
+	return using, nil
+}
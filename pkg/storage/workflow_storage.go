@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// SetBlockStatus sets a block's workflow status and lock. An empty status or a
+// nil locked keeps the current value.
+func (s *Storage) SetBlockStatus(docID, blockID, status string, locked *bool) error {
+	if status != "" {
+		if err := blocks.ValidateStatus(status); err != nil {
+			return err
+		}
+	}
+
+	// The lock itself is set here, so a locked block can still be unlocked
+	return s.writeBlockReference(docID, blockID, func(ref *blocks.BlockReference) error {
+		if status != "" {
+			ref.Status = storedStatus(status)
+		}
+		if locked != nil {
+			ref.Locked = *locked
+		}
+		return nil
+	})
+}
+
+// SetChapterStatus sets a chapter's workflow status and lock. An empty status
+// or a nil locked keeps the current value.
+func (s *Storage) SetChapterStatus(docID, chapterID, status string, locked *bool) error {
+	if status != "" {
+		if err := blocks.ValidateStatus(status); err != nil {
+			return err
+		}
+	}
+
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return err
+	}
+
+	for i, chapterRef := range doc.Chapters {
+		if chapterRef.ID == chapterID {
+			if status != "" {
+				doc.Chapters[i].Status = storedStatus(status)
+			}
+			if locked != nil {
+				doc.Chapters[i].Locked = *locked
+			}
+			return s.SaveDocument(docID, doc)
+		}
+	}
+
+	return fmt.Errorf("chapter not found: %s", chapterID)
+}
+
+// storedStatus keeps draft, the default, out of the manifest
+func storedStatus(status string) string {
+	if status == blocks.StatusDraft {
+		return ""
+	}
+	return status
+}
+
+// checkBlockUnlocked refuses changes to a locked block or a block of a locked chapter
+func (s *Storage) checkBlockUnlocked(docID, blockID string) error {
+	chapterID, blockIndex, err := s.FindBlockLocation(docID, blockID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkChapterUnlocked(docID, chapterID); err != nil {
+		return err
+	}
+
+	refs, err := s.blockList(docID, chapterID)
+	if err != nil {
+		return err
+	}
+	if blockIndex >= 0 && blockIndex < len(refs) && refs[blockIndex].Locked {
+		return fmt.Errorf("block %s is locked; unlock it before changing it", blockID)
+	}
+	return nil
+}
+
+// checkChapterUnlocked refuses changes to a locked chapter. An empty chapter ID
+// stands for the document's own blocks, which cannot be locked as a whole.
+func (s *Storage) checkChapterUnlocked(docID, chapterID string) error {
+	if chapterID == "" {
+		return nil
+	}
+
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return err
+	}
+	for _, chapterRef := range doc.Chapters {
+		if chapterRef.ID == chapterID && chapterRef.Locked {
+			return fmt.Errorf("chapter %s is locked; unlock it before changing it", chapterID)
+		}
+	}
+	return nil
+}
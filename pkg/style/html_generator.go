@@ -69,6 +69,63 @@ func (g *HTMLCSSGenerator) GenerateCSS(style StyleConfig) string {
 	css.WriteString("  margin: 1em auto;\n")
 	css.WriteString("}\n\n")
 	
+	// Image layout: alignment, text wrap and galleries
+	css.WriteString("/* Image layout */\n")
+	css.WriteString(".figure-align-left img {\n")
+	css.WriteString("  margin-left: 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".figure-align-right img {\n")
+	css.WriteString("  margin-right: 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".figure-align-left figcaption {\n")
+	css.WriteString("  text-align: left;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".figure-align-right figcaption {\n")
+	css.WriteString("  text-align: right;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".figure-float-left {\n")
+	css.WriteString("  float: left;\n")
+	css.WriteString("  margin: 0 1.5em 1em 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".figure-float-right {\n")
+	css.WriteString("  float: right;\n")
+	css.WriteString("  margin: 0 0 1em 1.5em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".figure-float-left img, .figure-float-right img, .gallery-item img {\n")
+	css.WriteString("  width: 100% !important;\n")
+	css.WriteString("  margin: 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".figure-float-left figure, .figure-float-right figure, .gallery-item figure {\n")
+	css.WriteString("  margin: 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString("h1, h2, h3, h4, h5, h6, table, .gallery {\n")
+	css.WriteString("  clear: both;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".gallery {\n")
+	css.WriteString("  display: flex;\n")
+	css.WriteString("  flex-wrap: wrap;\n")
+	css.WriteString("  justify-content: center;\n")
+	css.WriteString("  gap: 1em;\n")
+	css.WriteString("  margin: 1em 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".gallery-item {\n")
+	css.WriteString("  flex: 0 0 calc((100% - (var(--gallery-columns, 3) - 1) * 1em) / var(--gallery-columns, 3));\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".gallery-caption {\n")
+	css.WriteString("  flex-basis: 100%;\n")
+	css.WriteString("  font-size: 0.9em;\n")
+	css.WriteString("  font-style: italic;\n")
+	css.WriteString("  text-align: center;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".gallery-inline {\n")
+	css.WriteString("  text-align: center;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".gallery-inline img {\n")
+	css.WriteString("  display: inline-block;\n")
+	css.WriteString("  margin: 0.5em 0.5%;\n")
+	css.WriteString("  vertical-align: top;\n")
+	css.WriteString("}\n\n")
+	
 	// Table styles
 	css.WriteString("/* Table styles */\n")
 	css.WriteString("table {\n")
@@ -87,6 +144,32 @@ func (g *HTMLCSSGenerator) GenerateCSS(style StyleConfig) string {
 	css.WriteString("  background-color: #f5f5f5;\n")
 	css.WriteString("}\n\n")
 	
+	// Table of contents styles
+	css.WriteString("/* Table of contents styles */\n")
+	css.WriteString("nav#TOC {\n")
+	css.WriteString("  margin-bottom: 2em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString("nav#TOC ul {\n")
+	css.WriteString("  list-style: none;\n")
+	css.WriteString("  padding-left: 1.5em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString("nav#TOC > ul {\n")
+	css.WriteString("  padding-left: 0;\n")
+	css.WriteString("}\n\n")
+	
+	// Caption styles
+	css.WriteString("/* Caption styles */\n")
+	css.WriteString("figcaption, caption {\n")
+	css.WriteString("  font-size: 0.9em;\n")
+	css.WriteString("  font-style: italic;\n")
+	css.WriteString("  text-align: center;\n")
+	css.WriteString("  margin: 0.5em 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".list-of-figures ul, .list-of-tables ul {\n")
+	css.WriteString("  list-style: none;\n")
+	css.WriteString("  padding-left: 0;\n")
+	css.WriteString("}\n\n")
+	
 	// List styles
 	css.WriteString("/* List styles */\n")
 	css.WriteString("ul, ol {\n")
@@ -104,6 +187,204 @@ func (g *HTMLCSSGenerator) GenerateCSS(style StyleConfig) string {
 	css.WriteString("  font-style: italic;\n")
 	css.WriteString("}\n\n")
 	
+	// Quote block styles
+	css.WriteString("/* Quote block styles */\n")
+	css.WriteString(".quote p {\n")
+	css.WriteString("  margin: 0.25em 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".quote.blockquote {\n")
+	css.WriteString("  margin: 1em 2em;\n")
+	css.WriteString("  padding: 0.5em 1em;\n")
+	css.WriteString("  border-left: 3px solid #ccc;\n")
+	css.WriteString("  background-color: #f9f9f9;\n")
+	css.WriteString("  font-style: italic;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".quote.pullquote {\n")
+	css.WriteString("  clear: both;\n")
+	css.WriteString("  margin: 2em 10%;\n")
+	css.WriteString("  padding: 0.75em 0;\n")
+	css.WriteString(fmt.Sprintf("  border-top: 2px solid rgb(%s);\n", style.Colors.HeadingText))
+	css.WriteString(fmt.Sprintf("  border-bottom: 2px solid rgb(%s);\n", style.Colors.HeadingText))
+	css.WriteString(fmt.Sprintf("  color: rgb(%s);\n", style.Colors.HeadingText))
+	css.WriteString("  font-size: 1.4em;\n")
+	css.WriteString("  font-style: italic;\n")
+	css.WriteString("  text-align: center;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".quote.epigraph {\n")
+	css.WriteString("  margin: 1em 0 2em auto;\n")
+	css.WriteString("  max-width: 55%;\n")
+	css.WriteString("  font-size: 0.95em;\n")
+	css.WriteString("  font-style: italic;\n")
+	css.WriteString("  text-align: right;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".quote .attribution {\n")
+	css.WriteString("  margin-top: 0.5em;\n")
+	css.WriteString("  font-size: 0.9rem;\n")
+	css.WriteString("  font-style: normal;\n")
+	css.WriteString("  text-align: right;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".quote.pullquote .attribution {\n")
+	css.WriteString("  text-align: center;\n")
+	css.WriteString("}\n\n")
+	
+	// Multi-column layouts: each column is a grid cell holding a run of blocks
+	css.WriteString("/* Column layout styles */\n")
+	css.WriteString(".layout {\n")
+	css.WriteString("  display: grid;\n")
+	css.WriteString("  column-gap: 2em;\n")
+	css.WriteString("  align-items: start;\n")
+	css.WriteString("  margin: 1em 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".layout.columns-2 {\n")
+	css.WriteString("  grid-template-columns: repeat(2, minmax(0, 1fr));\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".layout.columns-3 {\n")
+	css.WriteString("  grid-template-columns: repeat(3, minmax(0, 1fr));\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".layout .column > :first-child {\n")
+	css.WriteString("  margin-top: 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".layout img {\n")
+	css.WriteString("  max-width: 100%;\n")
+	css.WriteString("}\n\n")
+	css.WriteString("@media screen and (max-width: 600px) {\n")
+	css.WriteString("  .layout.columns-2, .layout.columns-3 {\n")
+	css.WriteString("    grid-template-columns: 1fr;\n")
+	css.WriteString("  }\n")
+	css.WriteString("}\n\n")
+	
+	// Chapter and block style overrides; colors and sizes are set inline
+	css.WriteString("/* Style override styles */\n")
+	css.WriteString(".keep-together {\n")
+	css.WriteString("  break-inside: avoid;\n")
+	css.WriteString("  page-break-inside: avoid;\n")
+	css.WriteString("}\n\n")
+	css.WriteString("@page landscape {\n")
+	css.WriteString("  size: landscape;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".landscape {\n")
+	css.WriteString("  page: landscape;\n")
+	css.WriteString("}\n\n")
+	
+	// Generated glossary and index
+	css.WriteString("/* Glossary and index styles */\n")
+	css.WriteString(".glossary dt {\n")
+	css.WriteString("  font-weight: bold;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".index ul {\n")
+	css.WriteString("  list-style: none;\n")
+	css.WriteString("  padding-left: 0;\n")
+	css.WriteString("  columns: 2;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".index ul ul {\n")
+	css.WriteString("  padding-left: 1.5em;\n")
+	css.WriteString("  columns: 1;\n")
+	css.WriteString("}\n\n")
+	
+	// Review comments exported as margin notes
+	css.WriteString("/* Review comment styles */\n")
+	css.WriteString(".commented {\n")
+	css.WriteString("  background-color: #fff3bf;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".margin-note {\n")
+	css.WriteString("  float: right;\n")
+	css.WriteString("  clear: right;\n")
+	css.WriteString("  width: 30%;\n")
+	css.WriteString("  margin: 0 0 0.5em 1em;\n")
+	css.WriteString("  padding: 0.4em 0.6em;\n")
+	css.WriteString("  font-size: 0.8em;\n")
+	css.WriteString("  border-left: 3px solid #f0c000;\n")
+	css.WriteString("  background-color: #fffbe6;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".margin-note p {\n")
+	css.WriteString("  margin: 0 0 0.3em 0;\n")
+	css.WriteString("}\n\n")
+	
+	// Pending suggestions exported as tracked changes
+	css.WriteString(".insertion {\n")
+	css.WriteString("  color: #1a7f37;\n")
+	css.WriteString("  text-decoration: underline;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".deletion {\n")
+	css.WriteString("  color: #cf222e;\n")
+	css.WriteString("  text-decoration: line-through;\n")
+	css.WriteString("}\n\n")
+	
+	// Cover page hero section, laid out per cover layout
+	css.WriteString("/* Cover page styles */\n")
+	css.WriteString(".cover {\n")
+	css.WriteString("  position: relative;\n")
+	css.WriteString("  isolation: isolate;\n")
+	css.WriteString("  overflow: hidden;\n")
+	css.WriteString("  display: flex;\n")
+	css.WriteString("  flex-direction: column;\n")
+	css.WriteString("  min-height: 60vh;\n")
+	css.WriteString("  padding: 3em 2em;\n")
+	css.WriteString("  margin-bottom: 3em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover .cover-background {\n")
+	css.WriteString("  position: absolute;\n")
+	css.WriteString("  inset: 0;\n")
+	css.WriteString("  width: 100%;\n")
+	css.WriteString("  height: 100%;\n")
+	css.WriteString("  max-width: none;\n")
+	css.WriteString("  margin: 0;\n")
+	css.WriteString("  object-fit: cover;\n")
+	css.WriteString("  z-index: -1;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover .cover-logo {\n")
+	css.WriteString("  max-height: 4em;\n")
+	css.WriteString("  width: auto;\n")
+	css.WriteString("  margin: 0 0 2em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-title {\n")
+	css.WriteString(fmt.Sprintf("  font-family: '%s', sans-serif;\n", style.Fonts.HeadingFamily))
+	css.WriteString("  font-size: 2.5em;\n")
+	css.WriteString("  font-weight: bold;\n")
+	css.WriteString(fmt.Sprintf("  color: rgb(%s);\n", style.Colors.HeadingText))
+	css.WriteString("  line-height: 1.2;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-subtitle {\n")
+	css.WriteString("  font-size: 1.4em;\n")
+	css.WriteString("  margin-top: 0.5em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-details {\n")
+	css.WriteString("  margin-top: 2em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-classic {\n")
+	css.WriteString("  align-items: center;\n")
+	css.WriteString("  text-align: center;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-classic .cover-title, .cover-classic .cover-details {\n")
+	css.WriteString("  margin-top: auto;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-modern .cover-title {\n")
+	css.WriteString("  margin-top: 15vh;\n")
+	css.WriteString("  padding-bottom: 0.25em;\n")
+	css.WriteString(fmt.Sprintf("  border-bottom: 3px solid rgb(%s);\n", style.Colors.HeadingText))
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-modern .cover-details {\n")
+	css.WriteString("  margin-top: auto;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-modern .cover-details > :first-child {\n")
+	css.WriteString("  font-weight: bold;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-minimal {\n")
+	css.WriteString("  min-height: 0;\n")
+	css.WriteString("  padding: 2em 0;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-minimal .cover-title {\n")
+	css.WriteString("  font-size: 2em;\n")
+	css.WriteString(fmt.Sprintf("  color: rgb(%s);\n", style.Colors.BodyText))
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-minimal .cover-details {\n")
+	css.WriteString("  font-size: 0.9em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".cover-minimal .cover-logo {\n")
+	css.WriteString("  align-self: flex-end;\n")
+	css.WriteString("  margin: 2em 0 0;\n")
+	css.WriteString("}\n\n")
+	
 	// Print styles for PDF generation from HTML
 	css.WriteString("/* Print styles */\n")
 	css.WriteString("@media print {\n")
@@ -122,6 +403,20 @@ func (g *HTMLCSSGenerator) GenerateCSS(style StyleConfig) string {
 		style.Page.Margins.Top, style.Page.Margins.Right, 
 		style.Page.Margins.Bottom, style.Page.Margins.Left))
 	css.WriteString("  }\n")
+	css.WriteString("  \n")
+	css.WriteString("  thead {\n")
+	css.WriteString("    display: table-header-group;\n")
+	css.WriteString("  }\n")
+	css.WriteString("  \n")
+	css.WriteString("  tr {\n")
+	css.WriteString("    break-inside: avoid;\n")
+	css.WriteString("  }\n")
+	css.WriteString("  \n")
+	css.WriteString("  .cover {\n")
+	css.WriteString("    min-height: 100vh;\n")
+	css.WriteString("    margin: 0;\n")
+	css.WriteString("    break-after: page;\n")
+	css.WriteString("  }\n")
 	css.WriteString("}\n\n")
 	
 	return css.String()
@@ -146,6 +441,11 @@ func (g *HTMLCSSGenerator) GenerateHTMLTemplate(style StyleConfig, title, author
 	html.WriteString("</head>\n")
 	html.WriteString("<body>\n")
 	
+	// The cover page's hero section arrives as include-before metadata
+	html.WriteString("$for(include-before)$\n")
+	html.WriteString("$include-before$\n")
+	html.WriteString("$endfor$\n")
+	
 	// Add header if enabled
 	if style.Header.Enabled {
 		headerContent := g.processHTMLTemplateVariables(style.Header.Content, title, author)
@@ -156,6 +456,16 @@ func (g *HTMLCSSGenerator) GenerateHTMLTemplate(style StyleConfig, title, author
 		html.WriteString("  </header>\n")
 	}
 	
+	// Pandoc fills $table-of-contents$ with linked anchors when run with --toc
+	html.WriteString("$if(toc)$\n")
+	html.WriteString("  <nav id=\"TOC\" role=\"doc-toc\">\n")
+	html.WriteString("$if(toc-title)$\n")
+	html.WriteString("    <h2 id=\"toc-title\">$toc-title$</h2>\n")
+	html.WriteString("$endif$\n")
+	html.WriteString("$table-of-contents$\n")
+	html.WriteString("  </nav>\n")
+	html.WriteString("$endif$\n")
+	
 	html.WriteString("$body$\n")
 	
 	// Add footer if enabled
@@ -93,6 +93,19 @@ func (sl *StyleLoader) loadGlobalDefaultStyle() (StyleConfig, error) {
 	return wrapper.Style, nil
 }
 
+// ApplyOverride returns the style of a chapter or block with an override: its
+// font size, text color and orientation merged over the surrounding style
+func (sl *StyleLoader) ApplyOverride(base StyleConfig, override *StyleOverride) StyleConfig {
+	if override.IsEmpty() {
+		return base
+	}
+	return sl.mergeStyles(base, StyleConfig{
+		Fonts:  FontConfig{BodySize: override.FontSize},
+		Colors: ColorConfig{BodyText: override.Color},
+		Page:   PageConfig{Orientation: override.Orientation},
+	})
+}
+
 // mergeStyles merges two style configurations, with override taking precedence
 func (sl *StyleLoader) mergeStyles(base, override StyleConfig) StyleConfig {
 	result := base
@@ -178,5 +191,27 @@ func (sl *StyleLoader) mergeStyles(base, override StyleConfig) StyleConfig {
 	// Note: Enabled is merged even if false, as it's a valid override
 	result.Footer.Enabled = override.Footer.Enabled || base.Footer.Enabled
 	
+	// Merge caption config
+	if override.Captions.Numbering != "" {
+		result.Captions.Numbering = override.Captions.Numbering
+	}
+	if override.Captions.FigurePrefix != "" {
+		result.Captions.FigurePrefix = override.Captions.FigurePrefix
+	}
+	if override.Captions.TablePrefix != "" {
+		result.Captions.TablePrefix = override.Captions.TablePrefix
+	}
+	result.Captions.ListOfFigures = override.Captions.ListOfFigures || base.Captions.ListOfFigures
+	result.Captions.ListOfTables = override.Captions.ListOfTables || base.Captions.ListOfTables
+	
+	// Merge table of contents config
+	if override.TOC.Depth != 0 {
+		result.TOC.Depth = override.TOC.Depth
+	}
+	if override.TOC.Title != "" {
+		result.TOC.Title = override.TOC.Title
+	}
+	result.TOC.Enabled = override.TOC.Enabled || base.TOC.Enabled
+	
 	return result
 }
\ No newline at end of file
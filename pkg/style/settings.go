@@ -0,0 +1,98 @@
+package style
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Style settings are a sparse style, such as {"fonts": {"body_size": 12}}, in
+// the layout of StyleConfig's YAML. A themed document keeps only the settings
+// it changes, so later changes to the theme still reach everything else, and a
+// setting such as header.enabled can be turned off as well as on.
+
+// ApplySettings returns base with sparse settings merged over it. Sections and
+// fields the settings leave out keep their value in base.
+func ApplySettings(base StyleConfig, settings map[string]interface{}) (StyleConfig, error) {
+	if len(settings) == 0 {
+		return base, nil
+	}
+
+	merged, err := styleSettings(base)
+	if err != nil {
+		return StyleConfig{}, err
+	}
+	mergeSettings(merged, settings)
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return StyleConfig{}, fmt.Errorf("failed to apply style settings: %w", err)
+	}
+	var result StyleConfig
+	if err := yaml.Unmarshal(data, &result); err != nil {
+		return StyleConfig{}, fmt.Errorf("invalid style settings: %w", err)
+	}
+	return result, nil
+}
+
+// SettingsDiff returns the settings that turn base into changed
+func SettingsDiff(base, changed StyleConfig) (map[string]interface{}, error) {
+	from, err := styleSettings(base)
+	if err != nil {
+		return nil, err
+	}
+	to, err := styleSettings(changed)
+	if err != nil {
+		return nil, err
+	}
+	return diffSettings(from, to), nil
+}
+
+// styleSettings returns every setting of a style
+func styleSettings(styleConfig StyleConfig) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(styleConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read style settings: %w", err)
+	}
+	settings := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to read style settings: %w", err)
+	}
+	return settings, nil
+}
+
+// mergeSettings copies settings into target, descending into sections both have
+func mergeSettings(target, settings map[string]interface{}) {
+	for key, value := range settings {
+		section, isSection := value.(map[string]interface{})
+		existing, hasSection := target[key].(map[string]interface{})
+		if isSection && hasSection {
+			mergeSettings(existing, section)
+			continue
+		}
+		target[key] = value
+	}
+}
+
+// diffSettings returns the settings of to that differ from from, or nil when none do
+func diffSettings(from, to map[string]interface{}) map[string]interface{} {
+	diff := make(map[string]interface{})
+	for key, value := range to {
+		section, isSection := value.(map[string]interface{})
+		previous, hasSection := from[key].(map[string]interface{})
+		if isSection && hasSection {
+			if changed := diffSettings(previous, section); changed != nil {
+				diff[key] = changed
+			}
+			continue
+		}
+		if !reflect.DeepEqual(from[key], value) {
+			diff[key] = value
+		}
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	return diff
+}
@@ -2,6 +2,7 @@ package style
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -187,4 +188,134 @@ func TestHTMLCSSGeneration(t *testing.T) {
 	if !strings.Contains(template, "Test Document") {
 		t.Error("Expected template to contain document title")
 	}
-}
\ No newline at end of file
+	if !strings.Contains(template, "$for(include-before)$") {
+		t.Error("Expected template to place include-before content, such as the cover page")
+	}
+}
+func TestStyleOverride(t *testing.T) {
+	valid := &StyleOverride{FontSize: 9, Color: "200, 0, 0", Align: AlignCenter, Orientation: OrientationLandscape}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected override to be valid, got: %v", err)
+	}
+	for _, invalid := range []*StyleOverride{
+		{FontSize: 200},
+		{Color: "red"},
+		{Background: "0,0,256"},
+		{Align: "middle"},
+		{Orientation: "sideways"},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", *invalid)
+		}
+	}
+
+	var none *StyleOverride
+	if !none.IsEmpty() || !(&StyleOverride{}).IsEmpty() || valid.IsEmpty() {
+		t.Error("Expected only nil and zero overrides to be empty")
+	}
+
+	// Only the fields an override sets replace the base style
+	loader := NewStyleLoader(t.TempDir())
+	base := GetDefaultStyle()
+	merged := loader.ApplyOverride(base, valid)
+	if merged.Fonts.BodySize != 9 || merged.Colors.BodyText != "200, 0, 0" || merged.Page.Orientation != OrientationLandscape {
+		t.Errorf("Expected override to be merged, got %+v", merged)
+	}
+	if merged.Fonts.BodyFamily != base.Fonts.BodyFamily || merged.Page.Margins != base.Page.Margins {
+		t.Errorf("Expected base style to be kept, got %+v", merged)
+	}
+	if loader.ApplyOverride(base, nil).Page.Orientation != base.Page.Orientation {
+		t.Error("Expected nil override to keep the base style")
+	}
+}
+
+func TestStyleThemes(t *testing.T) {
+	loader := NewStyleLoader(t.TempDir())
+
+	// The presets are written on first use
+	themes, err := loader.ListThemes()
+	if err != nil {
+		t.Fatalf("Failed to list themes: %v", err)
+	}
+	var names []string
+	for _, theme := range themes {
+		names = append(names, theme.Name)
+	}
+	if strings.Join(names, ",") != "academic,book,corporate,modern,resume" {
+		t.Errorf("Expected the preset themes, got %v", names)
+	}
+
+	// Settings a theme turns off stay off
+	resume, err := loader.LoadTheme("resume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resume.Style.Header.Enabled || resume.Style.Fonts.BodySize != 10 {
+		t.Errorf("Expected a compact theme without header, got %+v", resume.Style)
+	}
+
+	if _, err := loader.LoadTheme("missing"); err == nil {
+		t.Error("Expected error for an unknown theme")
+	}
+	if err := loader.SaveTheme(Theme{Name: "Bad Name"}); err == nil {
+		t.Error("Expected error for an invalid theme name")
+	}
+
+	// A document's own settings apply over its theme, including turning settings off
+	settings := map[string]interface{}{
+		"colors": map[string]interface{}{"body_text": "50,50,50"},
+		"fonts":  map[string]interface{}{"body_size": float64(12)},
+		"toc":    map[string]interface{}{"enabled": false},
+	}
+	effective, err := loader.LoadStyleForThemedDocument("corporate", nil, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if effective.Colors.BodyText != "50,50,50" || effective.Fonts.BodySize != 12 || effective.TOC.Enabled {
+		t.Errorf("Expected the document's settings, got %+v", effective)
+	}
+	if effective.Colors.HeadingText != "0,51,102" || effective.Fonts.BodyFamily != "Arial" || effective.Page.Size != "letter" {
+		t.Errorf("Expected the rest of the corporate theme, got %+v", effective)
+	}
+	if _, err := loader.LoadStyleForThemedDocument("corporate", nil, map[string]interface{}{"fonts": map[string]interface{}{"body_size": "large"}}); err == nil {
+		t.Error("Expected error for a setting of the wrong type")
+	}
+
+	// Changing a theme changes the style of documents using it, except what they set themselves
+	corporate, _ := loader.LoadTheme("corporate")
+	corporate.Style.Colors.HeadingText = "0,128,0"
+	corporate.Style.Fonts.BodyFamily = "Verdana"
+	corporate.Style.Colors.BodyText = "0,0,0"
+	if err := loader.SaveTheme(corporate); err != nil {
+		t.Fatal(err)
+	}
+	effective, _ = loader.LoadStyleForThemedDocument("corporate", nil, settings)
+	if effective.Colors.HeadingText != "0,128,0" || effective.Fonts.BodyFamily != "Verdana" || effective.Colors.BodyText != "50,50,50" {
+		t.Errorf("Expected the saved theme under the document's settings, got %+v", effective)
+	}
+
+	// Settings record what a style changes from another
+	diff, err := SettingsDiff(GetDefaultStyle(), effective)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip, err := ApplySettings(GetDefaultStyle(), diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(roundTrip, effective) {
+		t.Errorf("Expected the settings to reproduce the style, got %+v from %v", roundTrip, diff)
+	}
+
+	// Deleted presets are not written again
+	if err := loader.DeleteTheme("book"); err != nil {
+		t.Fatal(err)
+	}
+	themes, _ = loader.ListThemes()
+	if len(themes) != 4 {
+		t.Errorf("Expected 4 themes after deleting one, got %d", len(themes))
+	}
+	if err := loader.DeleteTheme("book"); err == nil {
+		t.Error("Expected error deleting a missing theme")
+	}
+}
@@ -0,0 +1,260 @@
+package style
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Theme is a named style that documents use by name, so changing the theme
+// restyles every document using it
+type Theme struct {
+	Name        string      `yaml:"-" json:"name"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Style       StyleConfig `yaml:"style" json:"style"`
+}
+
+// themeNamePattern keeps theme names usable as file names
+var themeNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// presetThemes are written to the themes folder the first time it is used
+var presetThemes = map[string]func() Theme{
+	"academic":  academicTheme,
+	"corporate": corporateTheme,
+	"modern":    modernTheme,
+	"resume":    resumeTheme,
+	"book":      bookTheme,
+}
+
+// ValidateThemeName checks that a theme name is lowercase letters, digits, - and _
+func ValidateThemeName(name string) error {
+	if !themeNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid theme name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// ListThemes returns the themes in the themes folder, sorted by name
+func (sl *StyleLoader) ListThemes() ([]Theme, error) {
+	if err := sl.ensureThemes(); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(sl.themesPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read themes folder: %w", err)
+	}
+
+	var themes []Theme
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !ok || ValidateThemeName(name) != nil {
+			continue
+		}
+		theme, err := sl.LoadTheme(name)
+		if err != nil {
+			return nil, err
+		}
+		themes = append(themes, theme)
+	}
+	sort.Slice(themes, func(i, j int) bool { return themes[i].Name < themes[j].Name })
+	return themes, nil
+}
+
+// LoadTheme reads a theme. Settings missing from the theme file keep their
+// hard-coded defaults; default_style.yaml does not apply to themed documents.
+func (sl *StyleLoader) LoadTheme(name string) (Theme, error) {
+	if err := ValidateThemeName(name); err != nil {
+		return Theme{}, err
+	}
+	if err := sl.ensureThemes(); err != nil {
+		return Theme{}, err
+	}
+
+	data, err := os.ReadFile(sl.themePath(name))
+	if os.IsNotExist(err) {
+		return Theme{}, fmt.Errorf("style theme not found: %s", name)
+	}
+	if err != nil {
+		return Theme{}, fmt.Errorf("failed to read style theme %s: %w", name, err)
+	}
+
+	theme := Theme{Style: GetDefaultStyle()}
+	if err := yaml.Unmarshal(data, &theme); err != nil {
+		return Theme{}, fmt.Errorf("failed to parse style theme %s: %w", name, err)
+	}
+	theme.Name = name
+	return theme, nil
+}
+
+// SaveTheme writes a theme, replacing any theme of the same name
+func (sl *StyleLoader) SaveTheme(theme Theme) error {
+	if err := ValidateThemeName(theme.Name); err != nil {
+		return err
+	}
+	if err := sl.ensureThemes(); err != nil {
+		return err
+	}
+	return sl.writeTheme(theme)
+}
+
+// DeleteTheme removes a theme from the themes folder
+func (sl *StyleLoader) DeleteTheme(name string) error {
+	if err := ValidateThemeName(name); err != nil {
+		return err
+	}
+	if err := sl.ensureThemes(); err != nil {
+		return err
+	}
+
+	err := os.Remove(sl.themePath(name))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("style theme not found: %s", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete style theme %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadStyleForThemedDocument loads the effective style of a document using a
+// theme: the theme with the document's own settings applied over it. Without a
+// theme it resolves like LoadStyleForDocument, and settings are not used.
+func (sl *StyleLoader) LoadStyleForThemedDocument(themeName string, documentStyle *StyleConfig, settings map[string]interface{}) (StyleConfig, error) {
+	if themeName == "" {
+		return sl.LoadStyleForDocument(documentStyle), nil
+	}
+
+	theme, err := sl.LoadTheme(themeName)
+	if err != nil {
+		return StyleConfig{}, err
+	}
+	return ApplySettings(theme.Style, settings)
+}
+
+// ensureThemes creates the themes folder with the preset themes. Presets are
+// written only once, so they can be edited or deleted like any other theme.
+func (sl *StyleLoader) ensureThemes() error {
+	if _, err := os.Stat(sl.themesPath()); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(sl.themesPath(), 0755); err != nil {
+		return fmt.Errorf("failed to create themes folder: %w", err)
+	}
+	for name, preset := range presetThemes {
+		theme := preset()
+		theme.Name = name
+		if err := sl.writeTheme(theme); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTheme saves a theme file in the same layout as default_style.yaml
+func (sl *StyleLoader) writeTheme(theme Theme) error {
+	data, err := yaml.Marshal(theme)
+	if err != nil {
+		return fmt.Errorf("failed to marshal style theme %s: %w", theme.Name, err)
+	}
+	if err := os.WriteFile(sl.themePath(theme.Name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write style theme %s: %w", theme.Name, err)
+	}
+	return nil
+}
+
+func (sl *StyleLoader) themesPath() string {
+	return filepath.Join(sl.rootPath, "themes")
+}
+
+func (sl *StyleLoader) themePath(name string) string {
+	return filepath.Join(sl.themesPath(), name+".yaml")
+}
+
+// academicTheme: serif text at 12pt with generous line spacing, a table of
+// contents and captions numbered by chapter
+func academicTheme() Theme {
+	s := GetDefaultStyle()
+	s.Fonts.HeadingFamily = "Times New Roman"
+	s.Fonts.BodySize = 12
+	s.Fonts.HeadingSizes = map[string]int{"h1": 18, "h2": 15, "h3": 13, "h4": 12, "h5": 12, "h6": 11}
+	s.Spacing.LineSpacing = 1.5
+	s.Header.Align = "right"
+	s.Header.FontSize = 9
+	s.Footer.Content = "{page}"
+	s.Captions.Numbering = "chapter"
+	s.TOC.Enabled = true
+	return Theme{Description: "Serif text at 12pt, 1.5 line spacing, contents and chapter-numbered captions", Style: s}
+}
+
+// corporateTheme: sans-serif text with navy headings and page counts in the footer
+func corporateTheme() Theme {
+	s := GetDefaultStyle()
+	s.Fonts.BodyFamily = "Arial"
+	s.Colors.HeadingText = "0,51,102"
+	s.Page.Size = "letter"
+	s.Header.Content = "{title}"
+	s.Header.Align = "left"
+	s.Footer.Content = "Page {page} of {total_pages}"
+	s.Footer.Align = "right"
+	s.TOC.Enabled = true
+	return Theme{Description: "Sans-serif text, navy headings, US Letter, page x of y footer", Style: s}
+}
+
+// modernTheme: sans-serif throughout with slate headings, narrower margins and airy spacing
+func modernTheme() Theme {
+	s := GetDefaultStyle()
+	s.Fonts.BodyFamily = "Helvetica"
+	s.Fonts.HeadingFamily = "Helvetica"
+	s.Fonts.HeadingSizes = map[string]int{"h1": 24, "h2": 18, "h3": 15, "h4": 13, "h5": 11, "h6": 10}
+	s.Colors.BodyText = "33,37,41"
+	s.Colors.HeadingText = "44,62,80"
+	s.Page.Margins = MarginConfig{Top: 60, Bottom: 60, Left: 60, Right: 60}
+	s.Spacing.LineSpacing = 1.4
+	s.Spacing.ParagraphSpacing = 8
+	s.Header.Enabled = false
+	s.Footer.Content = "{page}"
+	s.Footer.Align = "right"
+	s.Footer.FontSize = 9
+	return Theme{Description: "Sans-serif, slate headings, narrow margins, no header", Style: s}
+}
+
+// resumeTheme: compact single pages without headers, footers or captions
+func resumeTheme() Theme {
+	s := GetDefaultStyle()
+	s.Fonts.BodyFamily = "Calibri"
+	s.Fonts.HeadingFamily = "Calibri"
+	s.Fonts.BodySize = 10
+	s.Fonts.HeadingSizes = map[string]int{"h1": 18, "h2": 13, "h3": 11, "h4": 10, "h5": 10, "h6": 10}
+	s.Colors.HeadingText = "31,56,100"
+	s.Page.Margins = MarginConfig{Top: 48, Bottom: 48, Left: 54, Right: 54}
+	s.Spacing.LineSpacing = 1.1
+	s.Spacing.ParagraphSpacing = 4
+	s.Header.Enabled = false
+	s.Footer.Enabled = false
+	s.Captions.Numbering = "none"
+	return Theme{Description: "Compact 10pt text, narrow margins, no header or footer", Style: s}
+}
+
+// bookTheme: Georgia text with chapter-numbered captions and a table of contents
+func bookTheme() Theme {
+	s := GetDefaultStyle()
+	s.Fonts.BodyFamily = "Georgia"
+	s.Fonts.HeadingFamily = "Georgia"
+	s.Fonts.HeadingSizes = map[string]int{"h1": 24, "h2": 16, "h3": 13, "h4": 12, "h5": 11, "h6": 11}
+	s.Page.Margins = MarginConfig{Top: 72, Bottom: 72, Left: 81, Right: 63}
+	s.Spacing.LineSpacing = 1.3
+	s.Spacing.ParagraphSpacing = 4
+	s.Header.Content = "{title}"
+	s.Header.FontSize = 9
+	s.Footer.Content = "{page}"
+	s.Captions.Numbering = "chapter"
+	s.TOC.Enabled = true
+	s.TOC.Depth = 2
+	return Theme{Description: "Georgia text, wider inner margin, contents to two levels", Style: s}
+}
@@ -1,5 +1,11 @@
 package style
 
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
 // StyleConfig represents the complete styling configuration for a document
 type StyleConfig struct {
 	Fonts   FontConfig   `yaml:"fonts"`
@@ -8,6 +14,8 @@ type StyleConfig struct {
 	Spacing SpacingConfig `yaml:"spacing"`
 	Header  HeaderConfig `yaml:"header"`
 	Footer  FooterConfig `yaml:"footer"`
+	Captions CaptionConfig `yaml:"captions"`
+	TOC      TOCConfig     `yaml:"toc"`
 }
 
 // FontConfig defines font settings
@@ -62,6 +70,84 @@ type FooterConfig struct {
 	FontSize int    `yaml:"font_size"`
 }
 
+// CaptionConfig defines figure and table caption numbering
+type CaptionConfig struct {
+	Numbering     string `yaml:"numbering"`      // global, chapter, none
+	FigurePrefix  string `yaml:"figure_prefix"`  // Label before figure numbers, e.g. "Figure"
+	TablePrefix   string `yaml:"table_prefix"`   // Label before table numbers, e.g. "Table"
+	ListOfFigures bool   `yaml:"list_of_figures"` // Generate a "List of Figures" section
+	ListOfTables  bool   `yaml:"list_of_tables"`  // Generate a "List of Tables" section
+}
+
+// TOCConfig defines the generated table of contents
+type TOCConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Depth   int    `yaml:"depth"` // Heading levels to include (1-6)
+	Title   string `yaml:"title"`
+}
+
+// Alignments and orientations a style override can set
+const (
+	AlignLeft    = "left"
+	AlignCenter  = "center"
+	AlignRight   = "right"
+	AlignJustify = "justify"
+
+	OrientationPortrait  = "portrait"
+	OrientationLandscape = "landscape"
+)
+
+// StyleOverride restyles one chapter or block; unset fields keep the surrounding style
+type StyleOverride struct {
+	FontSize     int    `yaml:"font_size,omitempty" json:"font_size,omitempty"`         // Points
+	Color        string `yaml:"color,omitempty" json:"color,omitempty"`                 // Text color as "R,G,B"
+	Background   string `yaml:"background,omitempty" json:"background,omitempty"`       // Background color as "R,G,B"
+	Align        string `yaml:"align,omitempty" json:"align,omitempty"`                 // left, center, right, justify
+	Orientation  string `yaml:"orientation,omitempty" json:"orientation,omitempty"`     // portrait, landscape
+	KeepTogether bool   `yaml:"keep_together,omitempty" json:"keep_together,omitempty"` // Avoid page breaks inside
+}
+
+// rgbPattern matches colors written like ColorConfig's, e.g. "200,0,0"
+var rgbPattern = regexp.MustCompile(`^(\d{1,3}),\s*(\d{1,3}),\s*(\d{1,3})$`)
+
+// Validate checks the override's values
+func (o *StyleOverride) Validate() error {
+	if o.FontSize != 0 && (o.FontSize < 4 || o.FontSize > 96) {
+		return fmt.Errorf("font size must be between 4 and 96 points, got %d", o.FontSize)
+	}
+	for _, field := range [][2]string{{"color", o.Color}, {"background", o.Background}} {
+		name, color := field[0], field[1]
+		if color == "" {
+			continue
+		}
+		match := rgbPattern.FindStringSubmatch(color)
+		if match == nil {
+			return fmt.Errorf("invalid %s %q: use R,G,B such as \"200,0,0\"", name, color)
+		}
+		for _, component := range match[1:] {
+			if value, _ := strconv.Atoi(component); value > 255 {
+				return fmt.Errorf("invalid %s %q: components range from 0 to 255", name, color)
+			}
+		}
+	}
+	switch o.Align {
+	case "", AlignLeft, AlignCenter, AlignRight, AlignJustify:
+	default:
+		return fmt.Errorf("invalid alignment %q (supported: left, center, right, justify)", o.Align)
+	}
+	switch o.Orientation {
+	case "", OrientationPortrait, OrientationLandscape:
+	default:
+		return fmt.Errorf("invalid orientation %q (supported: portrait, landscape)", o.Orientation)
+	}
+	return nil
+}
+
+// IsEmpty reports whether the override changes nothing
+func (o *StyleOverride) IsEmpty() bool {
+	return o == nil || *o == StyleOverride{}
+}
+
 // GetDefaultStyle returns the hard-coded default style configuration
 func GetDefaultStyle() StyleConfig {
 	return StyleConfig{
@@ -109,5 +195,15 @@ func GetDefaultStyle() StyleConfig {
 			Align:    "center",
 			FontSize: 10,
 		},
+		Captions: CaptionConfig{
+			Numbering:    "global",
+			FigurePrefix: "Figure",
+			TablePrefix:  "Table",
+		},
+		TOC: TOCConfig{
+			Enabled: false,
+			Depth:   3,
+			Title:   "Contents",
+		},
 	}
 }
\ No newline at end of file
@@ -0,0 +1,405 @@
+// Package tableimport reads tabular data from CSV, TSV and XLSX files
+// so it can be stored as a table block.
+package tableimport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+// Supported source formats
+const (
+	FormatCSV  = "csv"
+	FormatTSV  = "tsv"
+	FormatXLSX = "xlsx"
+)
+
+// Header detection modes
+const (
+	HeaderAuto     = "auto"
+	HeaderFirstRow = "first_row"
+	HeaderNone     = "none"
+)
+
+// Result is an imported table
+type Result struct {
+	Headers        []string
+	Rows           [][]string
+	NumericColumns []bool // Columns whose data cells are all numbers
+}
+
+// Import reads a table from the source file and applies its range, header,
+// column and number format settings
+func Import(opts blocks.TableSource) (*Result, error) {
+	format, err := detectFormat(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Header {
+	case "", HeaderAuto, HeaderFirstRow, HeaderNone:
+	default:
+		return nil, fmt.Errorf("invalid header mode %q (supported: auto, first_row, none)", opts.Header)
+	}
+
+	var grid [][]string
+	switch format {
+	case FormatCSV:
+		grid, err = readDelimited(opts.Path, ',')
+	case FormatTSV:
+		grid, err = readDelimited(opts.Path, '\t')
+	case FormatXLSX:
+		grid, err = readXLSX(opts.Path, opts.Sheet)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Range != "" {
+		grid, err = applyRange(grid, opts.Range)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	grid = trimGrid(grid)
+	if len(grid) == 0 {
+		return nil, fmt.Errorf("no data found in %s", opts.Path)
+	}
+
+	result := splitHeader(grid, opts.Header)
+
+	if len(opts.Columns) > 0 {
+		if err := selectColumns(result, opts.Columns); err != nil {
+			return nil, err
+		}
+	}
+
+	formatNumbers(result, opts.NumberFormat)
+	return result, nil
+}
+
+// detectFormat returns the explicit format or derives it from the file extension
+func detectFormat(opts blocks.TableSource) (string, error) {
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(opts.Path)), ".")
+		if format == "txt" {
+			format = FormatTSV
+		}
+	}
+
+	switch format {
+	case FormatCSV, FormatTSV, FormatXLSX:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported table format %q (supported: csv, tsv, xlsx)", format)
+	}
+}
+
+// readDelimited reads a CSV or TSV file into a grid
+func readDelimited(path string, delimiter rune) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open table file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse table file: %w", err)
+	}
+
+	// Strip a UTF-8 byte order mark written by spreadsheet exports
+	if len(records) > 0 && len(records[0]) > 0 {
+		records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	}
+
+	return records, nil
+}
+
+// trimGrid pads rows to equal width and drops empty trailing rows and columns
+func trimGrid(grid [][]string) [][]string {
+	for len(grid) > 0 && isEmptyRow(grid[len(grid)-1]) {
+		grid = grid[:len(grid)-1]
+	}
+
+	width := 0
+	for _, row := range grid {
+		for i := len(row) - 1; i >= 0; i-- {
+			if strings.TrimSpace(row[i]) != "" {
+				if i+1 > width {
+					width = i + 1
+				}
+				break
+			}
+		}
+	}
+
+	for r, row := range grid {
+		padded := make([]string, width)
+		for c := 0; c < width && c < len(row); c++ {
+			padded[c] = strings.TrimSpace(row[c])
+		}
+		grid[r] = padded
+	}
+	return grid
+}
+
+// isEmptyRow reports whether every cell of a row is blank
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// splitHeader separates the header row from the data rows
+func splitHeader(grid [][]string, mode string) *Result {
+	useFirstRow := false
+	switch mode {
+	case HeaderFirstRow:
+		useFirstRow = true
+	case HeaderNone:
+		useFirstRow = false
+	default:
+		useFirstRow = looksLikeHeader(grid)
+	}
+
+	result := &Result{}
+	if useFirstRow {
+		result.Headers = grid[0]
+		result.Rows = grid[1:]
+	} else {
+		result.Headers = make([]string, len(grid[0]))
+		for i := range result.Headers {
+			result.Headers[i] = fmt.Sprintf("Column %d", i+1)
+		}
+		result.Rows = grid
+	}
+
+	result.NumericColumns = make([]bool, len(result.Headers))
+	for c := range result.Headers {
+		numeric, seen := true, false
+		for _, row := range result.Rows {
+			if row[c] == "" {
+				continue
+			}
+			seen = true
+			if !isNumber(row[c]) {
+				numeric = false
+				break
+			}
+		}
+		result.NumericColumns[c] = numeric && seen
+	}
+
+	return result
+}
+
+// looksLikeHeader treats the first row as a header when its cells are all
+// filled, unique and non-numeric, which is how spreadsheet exports are written
+func looksLikeHeader(grid [][]string) bool {
+	seen := make(map[string]bool)
+	for _, cell := range grid[0] {
+		if cell == "" || isNumber(cell) || seen[cell] {
+			return false
+		}
+		seen[cell] = true
+	}
+	return true
+}
+
+// isNumber reports whether a cell holds a plain number
+func isNumber(s string) bool {
+	// ParseFloat also accepts words such as "Inf" and "NaN"
+	if !strings.ContainsAny(s, "0123456789") {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// selectColumns keeps the requested columns, matched by header name first and column letter second
+func selectColumns(result *Result, columns []string) error {
+	indices := make([]int, 0, len(columns))
+	for _, name := range columns {
+		index := -1
+		for i, header := range result.Headers {
+			if strings.EqualFold(header, name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			if col, ok := columnIndex(name); ok && col < len(result.Headers) {
+				index = col
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("column %q not found (available: %s)", name, strings.Join(result.Headers, ", "))
+		}
+		indices = append(indices, index)
+	}
+
+	pick := func(row []string) []string {
+		picked := make([]string, len(indices))
+		for i, index := range indices {
+			picked[i] = row[index]
+		}
+		return picked
+	}
+
+	result.Headers = pick(result.Headers)
+	for r, row := range result.Rows {
+		result.Rows[r] = pick(row)
+	}
+	numeric := make([]bool, len(indices))
+	for i, index := range indices {
+		numeric[i] = result.NumericColumns[index]
+	}
+	result.NumericColumns = numeric
+	return nil
+}
+
+// formatNumbers applies the number format to numeric cells
+func formatNumbers(result *Result, format blocks.NumberFormat) {
+	if format.Decimals == nil && format.ThousandsSeparator == "" && format.DecimalSeparator == "" {
+		return
+	}
+	for _, row := range result.Rows {
+		for c, cell := range row {
+			if isNumber(cell) {
+				row[c] = FormatNumber(cell, format)
+			}
+		}
+	}
+}
+
+// FormatNumber formats a numeric string, e.g. "1234.5" with 2 decimals and ","
+// thousands separator becomes "1,234.50". Non-numeric input is returned unchanged.
+func FormatNumber(value string, format blocks.NumberFormat) string {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+
+	text := value
+	if format.Decimals != nil {
+		text = strconv.FormatFloat(f, 'f', *format.Decimals, 64)
+	} else if strings.ContainsAny(text, "eE") {
+		text = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	sign := ""
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+		if sign == "+" {
+			sign = ""
+		}
+	}
+
+	intPart, fracPart := text, ""
+	if dot := strings.Index(text, "."); dot >= 0 {
+		intPart, fracPart = text[:dot], text[dot+1:]
+	}
+
+	if format.ThousandsSeparator != "" && len(intPart) > 3 {
+		var grouped strings.Builder
+		lead := len(intPart) % 3
+		if lead > 0 {
+			grouped.WriteString(intPart[:lead])
+		}
+		for i := lead; i < len(intPart); i += 3 {
+			if grouped.Len() > 0 {
+				grouped.WriteString(format.ThousandsSeparator)
+			}
+			grouped.WriteString(intPart[i : i+3])
+		}
+		intPart = grouped.String()
+	}
+
+	decimalSeparator := format.DecimalSeparator
+	if decimalSeparator == "" {
+		decimalSeparator = "."
+	}
+	if fracPart != "" {
+		return sign + intPart + decimalSeparator + fracPart
+	}
+	return sign + intPart
+}
+
+// columnIndex converts a column letter such as "C" or "AA" to a zero-based index
+func columnIndex(letters string) (int, bool) {
+	if letters == "" {
+		return 0, false
+	}
+	index := 0
+	for _, r := range strings.ToUpper(letters) {
+		if r < 'A' || r > 'Z' {
+			return 0, false
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index - 1, true
+}
+
+// parseCellRef splits a reference such as "B12" into zero-based row and column
+func parseCellRef(ref string) (row, col int, err error) {
+	ref = strings.ToUpper(strings.ReplaceAll(ref, "$", ""))
+	split := strings.IndexFunc(ref, func(r rune) bool { return r >= '0' && r <= '9' })
+	if split <= 0 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	col, ok := columnIndex(ref[:split])
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	row, err = strconv.Atoi(ref[split:])
+	if err != nil || row < 1 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return row - 1, col, nil
+}
+
+// applyRange cuts a rectangular range such as "A1:D20" out of the grid
+func applyRange(grid [][]string, cellRange string) ([][]string, error) {
+	parts := strings.Split(cellRange, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid range %q (expected e.g. A1:D20)", cellRange)
+	}
+	startRow, startCol, err := parseCellRef(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	endRow, endCol, err := parseCellRef(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if endRow < startRow || endCol < startCol {
+		return nil, fmt.Errorf("invalid range %q: end is before start", cellRange)
+	}
+
+	var result [][]string
+	for r := startRow; r <= endRow && r < len(grid); r++ {
+		row := make([]string, endCol-startCol+1)
+		for c := startCol; c <= endCol && c < len(grid[r]); c++ {
+			row[c-startCol] = grid[r][c]
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
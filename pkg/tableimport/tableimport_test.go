@@ -0,0 +1,183 @@
+package tableimport
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportCSV(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "sales.csv", "\ufeffRegion,Units,Revenue\nNorth,12,1234.5\nSouth,7,98765.25\n,,\n")
+
+	result, err := Import(blocks.TableSource{Path: path})
+	if err != nil {
+		t.Fatalf("Failed to import CSV: %v", err)
+	}
+
+	if !reflect.DeepEqual(result.Headers, []string{"Region", "Units", "Revenue"}) {
+		t.Errorf("Unexpected headers: %v", result.Headers)
+	}
+	if len(result.Rows) != 2 {
+		t.Fatalf("Expected trailing empty row to be dropped, got %d rows", len(result.Rows))
+	}
+	if !reflect.DeepEqual(result.NumericColumns, []bool{false, true, true}) {
+		t.Errorf("Unexpected numeric columns: %v", result.NumericColumns)
+	}
+
+	// Column selection by name and letter, with number formatting
+	decimals := 2
+	result, err = Import(blocks.TableSource{
+		Path:         path,
+		Columns:      []string{"revenue", "A"},
+		NumberFormat: blocks.NumberFormat{Decimals: &decimals, ThousandsSeparator: ","},
+	})
+	if err != nil {
+		t.Fatalf("Failed to import CSV with options: %v", err)
+	}
+	if !reflect.DeepEqual(result.Rows[1], []string{"98,765.25", "South"}) {
+		t.Errorf("Unexpected formatted row: %v", result.Rows[1])
+	}
+
+	if _, err := Import(blocks.TableSource{Path: path, Columns: []string{"Missing"}}); err == nil {
+		t.Error("Expected error for unknown column")
+	}
+}
+
+func TestImportTSVRangeWithoutHeader(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "data.tsv", "skip\tskip\tskip\nx\t1\t2\ny\t3\t4\n")
+
+	result, err := Import(blocks.TableSource{Path: path, Range: "B2:C3", Header: HeaderNone})
+	if err != nil {
+		t.Fatalf("Failed to import TSV: %v", err)
+	}
+
+	if !reflect.DeepEqual(result.Headers, []string{"Column 1", "Column 2"}) {
+		t.Errorf("Unexpected generated headers: %v", result.Headers)
+	}
+	if !reflect.DeepEqual(result.Rows, [][]string{{"1", "2"}, {"3", "4"}}) {
+		t.Errorf("Unexpected rows: %v", result.Rows)
+	}
+}
+
+func TestImportXLSX(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.xlsx")
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="Data" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Name</t></si><si><t>Date</t></si><si><r><t>Wid</t></r><r><t>get</t></r></si></sst>`,
+		"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/></numFmts>
+<cellXfs><xf numFmtId="0"/><xf numFmtId="164"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>Active</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" s="1"><v>45292</v></c><c r="C2" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+	}
+
+	writeXLSX(t, path, parts)
+
+	result, err := Import(blocks.TableSource{Path: path, Sheet: "data"})
+	if err != nil {
+		t.Fatalf("Failed to import XLSX: %v", err)
+	}
+
+	if !reflect.DeepEqual(result.Headers, []string{"Name", "Date", "Active"}) {
+		t.Errorf("Unexpected headers: %v", result.Headers)
+	}
+	if !reflect.DeepEqual(result.Rows, [][]string{{"Widget", "2024-01-01", "TRUE"}}) {
+		t.Errorf("Unexpected rows: %v", result.Rows)
+	}
+
+	if _, err := Import(blocks.TableSource{Path: path, Sheet: "Missing"}); err == nil {
+		t.Error("Expected error for unknown sheet")
+	}
+}
+
+func TestImportXLSXFarOffCells(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hostile.xlsx")
+
+	// A hundred rows with a value in the last column take 1.6 million cells
+	var wide strings.Builder
+	for r := 1; r <= 100; r++ {
+		fmt.Fprintf(&wide, `<row r="%d"><c r="XFD%d"><v>1</v></c></row>`, r, r)
+	}
+	sheets := map[string]string{
+		"row beyond the sheet":    `<row r="2000000000"><c><v>1</v></c></row>`,
+		"column beyond the sheet": `<row r="1"><c r="XFE1"><v>1</v></c></row>`,
+		"too many cells":          wide.String(),
+	}
+	for name, rows := range sheets {
+		writeXLSX(t, path, map[string]string{
+			"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Data" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+			"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+			"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`,
+		})
+		if _, err := Import(blocks.TableSource{Path: path}); err == nil {
+			t.Errorf("Expected an error for a %s", name)
+		}
+	}
+}
+
+// writeXLSX writes the given parts as a zip archive
+func writeXLSX(t *testing.T, path string, parts map[string]string) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(file)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	file.Close()
+}
+
+func TestFormatNumber(t *testing.T) {
+	one := 1
+	tests := []struct {
+		value  string
+		format blocks.NumberFormat
+		want   string
+	}{
+		{"1234567", blocks.NumberFormat{ThousandsSeparator: ","}, "1,234,567"},
+		{"-1234.56", blocks.NumberFormat{Decimals: &one, ThousandsSeparator: ".", DecimalSeparator: ","}, "-1.234,6"},
+		{"12", blocks.NumberFormat{Decimals: &one}, "12.0"},
+		{"n/a", blocks.NumberFormat{Decimals: &one}, "n/a"},
+	}
+
+	for _, tc := range tests {
+		if got := FormatNumber(tc.value, tc.format); got != tc.want {
+			t.Errorf("FormatNumber(%q) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package tableimport
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSX files are zip archives of SpreadsheetML parts. Only the parts needed to
+// read cell values are decoded: the workbook, its relationships, shared strings,
+// number formats (to recognise dates) and the selected worksheet.
+
+// Cell references point anywhere in the sheet, so they are checked before the
+// grid grows to hold them: against Excel's own limits, and against a budget for
+// the cells of the grid, which a handful of far-off references could exhaust
+const (
+	xlsxMaxRows    = 1048576
+	xlsxMaxColumns = 16384
+	xlsxMaxCells   = 1000000
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+// xlsxRichText is a string that is either plain (<t>) or a list of formatted runs (<r><t>)
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String joins plain text and runs
+func (rt xlsxRichText) String() string {
+	if len(rt.Runs) == 0 {
+		return rt.Text
+	}
+	var sb strings.Builder
+	sb.WriteString(rt.Text)
+	for _, run := range rt.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Style  int          `xml:"s,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads a worksheet into a grid. An empty sheet name selects the first sheet.
+func readXLSX(filePath, sheetName string) ([][]string, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx file: %w", err)
+	}
+	defer archive.Close()
+
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("xlsx file has no sheets")
+	}
+
+	rid := ""
+	names := make([]string, 0, len(workbook.Sheets))
+	for _, sheet := range workbook.Sheets {
+		names = append(names, sheet.Name)
+		if rid == "" && (sheetName == "" || strings.EqualFold(sheet.Name, sheetName)) {
+			rid = sheet.RID
+		}
+	}
+	if rid == "" {
+		return nil, fmt.Errorf("sheet %q not found (available: %s)", sheetName, strings.Join(names, ", "))
+	}
+
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == rid {
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPath = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", rel.Target)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, fmt.Errorf("worksheet relationship %s not found", rid)
+	}
+
+	// Shared strings and styles are optional parts
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var styles xlsxStyles
+	if _, ok := files["xl/styles.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+	}
+	dateStyles := dateStyleIndexes(styles)
+
+	var sheet xlsxWorksheet
+	if err := decodeXLSXPart(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var grid [][]string
+	cells := 0
+	nextRow := 0
+	for _, row := range sheet.Rows {
+		r := nextRow
+		if row.Ref > 0 {
+			r = row.Ref - 1
+		}
+		if r >= xlsxMaxRows {
+			return nil, fmt.Errorf("invalid xlsx file: row %d is beyond the last row of a sheet (%d)", r+1, xlsxMaxRows)
+		}
+		nextRow = r + 1
+		for len(grid) <= r {
+			grid = append(grid, nil)
+		}
+
+		nextCol := 0
+		for _, cell := range row.Cells {
+			c := nextCol
+			if cell.Ref != "" {
+				if _, col, err := parseCellRef(cell.Ref); err == nil {
+					c = col
+				}
+			}
+			nextCol = c + 1
+			if c >= xlsxMaxColumns {
+				return nil, fmt.Errorf("invalid xlsx file: column %d is beyond the last column of a sheet (%d)", c+1, xlsxMaxColumns)
+			}
+			if len(grid[r]) <= c {
+				cells += c + 1 - len(grid[r])
+				if cells > xlsxMaxCells {
+					return nil, fmt.Errorf("sheet has more than %d cells; save the table in a smaller sheet", xlsxMaxCells)
+				}
+			}
+			for len(grid[r]) <= c {
+				grid[r] = append(grid[r], "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err == nil && index >= 0 && index < len(shared.Items) {
+					grid[r][c] = shared.Items[index].String()
+				}
+			case "inlineStr":
+				grid[r][c] = cell.Inline.String()
+			case "b":
+				if cell.Value == "1" {
+					grid[r][c] = "TRUE"
+				} else {
+					grid[r][c] = "FALSE"
+				}
+			case "str", "e":
+				grid[r][c] = cell.Value
+			default:
+				grid[r][c] = cell.Value
+				if dateStyles[cell.Style] && cell.Value != "" {
+					if serial, err := strconv.ParseFloat(cell.Value, 64); err == nil {
+						grid[r][c] = excelDate(serial)
+					}
+				}
+			}
+		}
+	}
+
+	return grid, nil
+}
+
+// decodeXLSXPart unmarshals one XML part of the archive
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("invalid xlsx file: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// dateStyleIndexes returns the cell style indexes whose number format is a date.
+// Built-in formats 14-22 and 45-47 are dates; custom formats are recognised by
+// date tokens outside quoted literals.
+func dateStyleIndexes(styles xlsxStyles) map[int]bool {
+	custom := make(map[int]string)
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = f.Code
+	}
+
+	dates := make(map[int]bool)
+	for i, xf := range styles.CellXfs {
+		id := xf.NumFmtID
+		if (id >= 14 && id <= 22) || (id >= 45 && id <= 47) {
+			dates[i] = true
+			continue
+		}
+		if code, ok := custom[id]; ok && isDateFormat(code) {
+			dates[i] = true
+		}
+	}
+	return dates
+}
+
+// isDateFormat reports whether a custom number format code formats dates
+func isDateFormat(code string) bool {
+	inQuote := false
+	for i := 0; i < len(code); i++ {
+		switch ch := code[i]; {
+		case ch == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case ch == '\\':
+			i++
+		case ch == '[':
+			// Skip colour and locale sections such as [Red] or [$-409]
+			for i < len(code) && code[i] != ']' {
+				i++
+			}
+		case strings.ContainsRune("dDyY", rune(ch)):
+			return true
+		}
+	}
+	return false
+}
+
+// excelDate converts a spreadsheet date serial (1900 date system) to ISO format
+func excelDate(serial float64) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	days := int(serial)
+	seconds := int((serial-float64(days))*86400 + 0.5)
+	t := epoch.AddDate(0, 0, days).Add(time.Duration(seconds) * time.Second)
+	if seconds == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04")
+}
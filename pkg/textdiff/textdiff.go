@@ -0,0 +1,268 @@
+// Package textdiff compares two versions of a block's text, line by line for
+// reviewing a suggestion and word by word for tracked changes on export.
+package textdiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operation kinds
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// Op is a run of text that is unchanged, inserted or deleted
+type Op struct {
+	Kind string
+	Text string
+}
+
+// wordPattern splits text into words and the whitespace between them
+var wordPattern = regexp.MustCompile(`\S+|\s+`)
+
+// Words diffs two texts by words, keeping whitespace as tokens of its own so
+// joining the texts of the operations reproduces both versions exactly
+func Words(old, new string) []Op {
+	return mergeChanges(diff(wordPattern.FindAllString(old, -1), wordPattern.FindAllString(new, -1)))
+}
+
+// mergeChanges folds whitespace that only happens to match between two changes
+// into them, and turns each run of changes into one deletion followed by one
+// insertion, so "in March" -> "for April" reads as a single replacement
+func mergeChanges(ops []Op) []Op {
+	var result []Op
+	var deleted, inserted strings.Builder
+	flush := func() {
+		if deleted.Len() > 0 {
+			result = append(result, Op{Kind: Delete, Text: deleted.String()})
+		}
+		if inserted.Len() > 0 {
+			result = append(result, Op{Kind: Insert, Text: inserted.String()})
+		}
+		deleted.Reset()
+		inserted.Reset()
+	}
+
+	for i, op := range ops {
+		between := i > 0 && i < len(ops)-1 && ops[i-1].Kind != Equal && ops[i+1].Kind != Equal
+		switch {
+		case op.Kind == Equal && between && strings.TrimSpace(op.Text) == "":
+			deleted.WriteString(op.Text)
+			inserted.WriteString(op.Text)
+		case op.Kind == Equal:
+			flush()
+			result = append(result, op)
+		case op.Kind == Delete:
+			deleted.WriteString(op.Text)
+		default:
+			inserted.WriteString(op.Text)
+		}
+	}
+	flush()
+	return result
+}
+
+// Lines diffs two texts line by line
+func Lines(old, new string) []Op {
+	return diff(splitLines(old), splitLines(new))
+}
+
+// Unified renders a line diff with "-" and "+" prefixes for changed lines and
+// two spaces for unchanged ones. Unchanged runs longer than context lines on
+// either side of a change are elided.
+func Unified(old, new string, context int) string {
+	var lines []Op
+	for _, op := range Lines(old, new) {
+		for _, line := range strings.SplitAfter(op.Text, "\n") {
+			if line != "" {
+				lines = append(lines, Op{Kind: op.Kind, Text: strings.TrimSuffix(line, "\n")})
+			}
+		}
+	}
+
+	var result strings.Builder
+	skipped := 0
+	for i, line := range lines {
+		if line.Kind == Equal && !nearChange(lines, i, context) {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			result.WriteString(fmt.Sprintf("@@ %d unchanged lines @@\n", skipped))
+			skipped = 0
+		}
+		switch line.Kind {
+		case Insert:
+			result.WriteString("+ ")
+		case Delete:
+			result.WriteString("- ")
+		default:
+			result.WriteString("  ")
+		}
+		result.WriteString(line.Text)
+		result.WriteString("\n")
+	}
+	if skipped > 0 {
+		result.WriteString(fmt.Sprintf("@@ %d unchanged lines @@\n", skipped))
+	}
+	return result.String()
+}
+
+// nearChange reports whether a changed line is within context lines of line i
+func nearChange(lines []Op, i, context int) bool {
+	for j := i - context; j <= i+context; j++ {
+		if j >= 0 && j < len(lines) && lines[j].Kind != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines splits text after each newline, so every token keeps its line ending
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// diff finds the shortest edit script that turns a into b with Myers' algorithm
+// in linear space. Unchanged tokens at either end are matched up front, so
+// small edits to long texts cost little. Within each run of changes the
+// deletions come before the insertions.
+func diff(a, b []string) []Op {
+	var e editScript
+	e.compare(a, b)
+
+	ops := make([]Op, len(e.runs))
+	for i, run := range e.runs {
+		ops[i] = Op{Kind: run.kind, Text: strings.Join(run.tokens, "")}
+	}
+	return ops
+}
+
+// editRun is a run of tokens of the same kind, joined once the diff is done
+type editRun struct {
+	kind   string
+	tokens []string
+}
+
+// editScript collects the edits found by compare
+type editScript struct {
+	runs []editRun
+}
+
+// add appends a token to the run of its kind, moving a deletion ahead of the
+// insertions that end the current run of changes
+func (e *editScript) add(kind, token string) {
+	n := len(e.runs)
+	if kind == Delete && n > 0 && e.runs[n-1].kind == Insert {
+		if n > 1 && e.runs[n-2].kind == Delete {
+			e.runs[n-2].tokens = append(e.runs[n-2].tokens, token)
+			return
+		}
+		e.runs = append(e.runs[:n-1], editRun{kind: Delete, tokens: []string{token}}, e.runs[n-1])
+		return
+	}
+	if n > 0 && e.runs[n-1].kind == kind {
+		e.runs[n-1].tokens = append(e.runs[n-1].tokens, token)
+		return
+	}
+	e.runs = append(e.runs, editRun{kind: kind, tokens: []string{token}})
+}
+
+// addAll appends every token of a list
+func (e *editScript) addAll(kind string, tokens []string) {
+	for _, token := range tokens {
+		e.add(kind, token)
+	}
+}
+
+// compare adds the edits that turn a into b, splitting the problem at the
+// middle snake of an optimal path until one side is empty
+func (e *editScript) compare(a, b []string) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	e.addAll(Equal, a[:prefix])
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		e.addAll(Insert, b)
+	case len(b) == 0:
+		e.addAll(Delete, a)
+	default:
+		// With both ends trimmed at least two edits remain, so each half has fewer
+		x, y, u, v := middleSnake(a, b)
+		e.compare(a[:x], b[:y])
+		e.addAll(Equal, a[x:u])
+		e.compare(a[u:], b[v:])
+	}
+	e.addAll(Equal, common)
+}
+
+// middleSnake runs the forward and reverse searches of Myers' algorithm until
+// they meet, and returns the start (x, y) and end (u, v) of the snake where
+// they overlap. Diagonal k holds the points where x-y = k; the reverse search
+// works on the reversed texts, where the same point lies on diagonal delta-k.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+	forward := make([]int, 2*limit+3)
+	reverse := make([]int, 2*limit+3)
+
+	// furthest returns the x reached on diagonal k with d edits, before its snake
+	furthest := func(far []int, k, d int) int {
+		if k == -d || (k != d && far[k-1+offset] < far[k+1+offset]) {
+			return far[k+1+offset]
+		}
+		return far[k-1+offset] + 1
+	}
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			startX := furthest(forward, k, d)
+			endX := startX
+			for endX < n && endX-k < m && a[endX] == b[endX-k] {
+				endX++
+			}
+			forward[k+offset] = endX
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && endX+reverse[c+offset] >= n {
+				return startX, startX - k, endX, endX - k
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			startX := furthest(reverse, k, d)
+			endX := startX
+			for endX < n && endX-k < m && a[n-1-endX] == b[m-1-endX+k] {
+				endX++
+			}
+			reverse[k+offset] = endX
+			if c := delta - k; !odd && c >= -d && c <= d && forward[c+offset]+endX >= n {
+				return n - endX, m - endX + k, n - startX, m - startX + k
+			}
+		}
+	}
+
+	// Unreachable: the searches meet after at most half of n+m edits each
+	return 0, 0, n, m
+}
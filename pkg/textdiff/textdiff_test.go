@@ -0,0 +1,120 @@
+package textdiff
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	old := "The launch is in March."
+	new := "The launch is planned for April."
+
+	ops := Words(old, new)
+	expected := []Op{
+		{Equal, "The launch is "},
+		{Delete, "in March."},
+		{Insert, "planned for April."},
+	}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("Expected %v, got %v", expected, ops)
+	}
+
+	// Joining the runs of each side reproduces both versions
+	var before, after strings.Builder
+	for _, op := range ops {
+		if op.Kind != Insert {
+			before.WriteString(op.Text)
+		}
+		if op.Kind != Delete {
+			after.WriteString(op.Text)
+		}
+	}
+	if before.String() != old || after.String() != new {
+		t.Errorf("Expected %q and %q, got %q and %q", old, new, before.String(), after.String())
+	}
+}
+
+func TestUnified(t *testing.T) {
+	old := "one\ntwo\nthree\nfour\nfive\nsix\n"
+	new := "one\ntwo\nthree\nfour\nFIVE\nsix\n"
+
+	expected := "@@ 3 unchanged lines @@\n  four\n- five\n+ FIVE\n  six\n"
+	if got := Unified(old, new, 1); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+	if got := Unified(old, old, 1); got != "@@ 6 unchanged lines @@\n" {
+		t.Errorf("Expected only an elided run for equal texts, got:\n%s", got)
+	}
+}
+
+func TestDiffIsShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tokens := func() []string {
+		list := make([]string, rng.Intn(12))
+		for i := range list {
+			list[i] = string(rune('a' + rng.Intn(3)))
+		}
+		return list
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := tokens(), tokens()
+		var before, after []string
+		edits := 0
+		for _, op := range diff(a, b) {
+			for _, token := range strings.Split(op.Text, "") {
+				if op.Kind != Insert {
+					before = append(before, token)
+				}
+				if op.Kind != Delete {
+					after = append(after, token)
+				}
+				if op.Kind != Equal {
+					edits++
+				}
+			}
+		}
+		if strings.Join(before, "") != strings.Join(a, "") || strings.Join(after, "") != strings.Join(b, "") {
+			t.Fatalf("diff(%v, %v) does not reproduce both sides", a, b)
+		}
+		if expected := len(a) + len(b) - 2*lcsLength(a, b); edits != expected {
+			t.Fatalf("diff(%v, %v) made %d edits, expected %d", a, b, edits, expected)
+		}
+	}
+}
+
+func TestLinesLongText(t *testing.T) {
+	var old strings.Builder
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&old, "line %d\n", i)
+	}
+	new := strings.Replace(old.String(), "line 25000\n", "changed\n", 1)
+
+	ops := Lines(old.String(), new)
+	if len(ops) != 4 || ops[1] != (Op{Delete, "line 25000\n"}) || ops[2] != (Op{Insert, "changed\n"}) {
+		t.Errorf("Expected a single replaced line, got %d operations", len(ops))
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] > lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return lcs[0][0]
+}
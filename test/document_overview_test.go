@@ -1141,4 +1141,196 @@ func TestDocumentOverviewEmptyChapters(t *testing.T) {
 	if !contains(content, "\"chapters\":") {
 		t.Error("Overview should have chapters section")
 	}
-}
\ No newline at end of file
+}
+func TestDocumentOverviewIncludePreview(t *testing.T) {
+	h, cleanup := setupOverviewTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	call := func(name string, args map[string]interface{}) (string, error) {
+		resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			return "", err
+		}
+		return resp.Content[0].Text, nil
+	}
+
+	if _, err := call("create_snippet", map[string]interface{}{
+		"title":   "Disclaimer",
+		"content": "Figures are unaudited.",
+	}); err != nil {
+		t.Fatalf("Failed to create snippet: %v", err)
+	}
+	if _, err := call("create_document", map[string]interface{}{"title": "Quarterly Report"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("add_include", map[string]interface{}{
+		"document_id": "quarterly-report",
+		"snippet_id":  "disclaimer",
+	}); err != nil {
+		t.Fatalf("Failed to add include: %v", err)
+	}
+
+	// Unknown snippets and ambiguous sources are rejected when the include is added
+	if _, err := call("add_include", map[string]interface{}{
+		"document_id": "quarterly-report",
+		"snippet_id":  "missing",
+	}); err == nil {
+		t.Error("Expected error for unknown snippet")
+	}
+	if _, err := call("add_include", map[string]interface{}{
+		"document_id":        "quarterly-report",
+		"snippet_id":         "disclaimer",
+		"source_document_id": "quarterly-report",
+		"source_block_id":    "inc-001",
+	}); err == nil {
+		t.Error("Expected error when both a snippet and a block are given")
+	}
+
+	// The overview previews the included text
+	content, err := call("get_document_overview", map[string]interface{}{"document_id": "quarterly-report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(content, "Include (snippet:disclaimer): Figures are unaudited.") {
+		t.Errorf("Expected include preview with snippet content, got: %s", content)
+	}
+
+	// Snippets in use are not deleted without force
+	if _, err := call("delete_snippet", map[string]interface{}{"snippet_id": "disclaimer"}); err == nil || !strings.Contains(err.Error(), "quarterly-report/inc-001") {
+		t.Errorf("Expected delete to report the including block, got %v", err)
+	}
+	if _, err := call("update_snippet", map[string]interface{}{
+		"snippet_id": "disclaimer",
+		"content":    "Figures are audited.",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	content, err = call("get_document_overview", map[string]interface{}{"document_id": "quarterly-report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(content, "Figures are audited.") {
+		t.Errorf("Expected updated snippet in preview, got: %s", content)
+	}
+}
+
+func TestDocumentOverviewVariables(t *testing.T) {
+	h, cleanup := setupOverviewTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	call := func(name string, args map[string]interface{}) (string, error) {
+		resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			return "", err
+		}
+		return resp.Content[0].Text, nil
+	}
+
+	if _, err := call("create_document", map[string]interface{}{"title": "Offer Letter"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("set_document_variables", map[string]interface{}{
+		"document_id": "offer-letter",
+		"variables":   map[string]interface{}{"client_name": "Acme Corp", "start_date": "1 May"},
+	}); err != nil {
+		t.Fatalf("Failed to set variables: %v", err)
+	}
+
+	// Variables merge by default and null removes one
+	if _, err := call("set_document_variables", map[string]interface{}{
+		"document_id": "offer-letter",
+		"variables":   map[string]interface{}{"client_name": "Globex", "start_date": nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("set_document_variables", map[string]interface{}{
+		"document_id": "offer-letter",
+		"variables":   map[string]interface{}{"client name": "x"},
+	}); err == nil {
+		t.Error("Expected error for invalid variable name")
+	}
+	
+	// Numbers and booleans are accepted as in export variables, and stored as text
+	if _, err := call("set_document_variables", map[string]interface{}{
+		"document_id": "offer-letter",
+		"variables":   map[string]interface{}{"seats": float64(25), "remote": true},
+	}); err != nil {
+		t.Fatalf("Failed to set number and boolean variables: %v", err)
+	}
+
+	content, err := call("get_document_overview", map[string]interface{}{"document_id": "offer-letter"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(content, `"client_name": "Globex"`) || contains(content, "start_date") {
+		t.Errorf("Expected client_name without start_date in overview variables, got: %s", content)
+	}
+	if !contains(content, `"seats": "25"`) || !contains(content, `"remote": "true"`) {
+		t.Errorf("Expected number and boolean variables as text, got: %s", content)
+	}
+}
+
+func TestDocumentOverviewConditions(t *testing.T) {
+	h, cleanup := setupOverviewTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	call := func(name string, args map[string]interface{}) (string, error) {
+		resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			return "", err
+		}
+		return resp.Content[0].Text, nil
+	}
+
+	if _, err := call("create_document", map[string]interface{}{"title": "Pricing"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("add_markdown", map[string]interface{}{"document_id": "pricing", "content": "Enterprise discounts"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("set_conditions", map[string]interface{}{
+		"document_id": "pricing",
+		"block_id":    "md-001",
+		"conditions":  map[string]interface{}{"edition": []interface{}{"pro", "enterprise"}, "audience": "internal"},
+	}); err != nil {
+		t.Fatalf("Failed to set conditions: %v", err)
+	}
+	if _, err := call("set_document_profile", map[string]interface{}{
+		"document_id": "pricing",
+		"name":        "external",
+		"values":      map[string]interface{}{"audience": "external"},
+	}); err != nil {
+		t.Fatalf("Failed to set profile: %v", err)
+	}
+
+	// A block or a chapter must be named, not both
+	if _, err := call("set_conditions", map[string]interface{}{
+		"document_id": "pricing",
+		"conditions":  map[string]interface{}{"audience": "internal"},
+	}); err == nil {
+		t.Error("Expected error without block_id or chapter_id")
+	}
+
+	content, err := call("get_document_overview", map[string]interface{}{"document_id": "pricing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(content, `"conditions": "audience=internal; edition=pro|enterprise"`) {
+		t.Errorf("Expected block conditions in overview, got: %s", content)
+	}
+	if !contains(content, `"external": {`) {
+		t.Errorf("Expected external profile in overview, got: %s", content)
+	}
+
+	// Exporting with an unknown profile name fails before anything is written
+	if _, err := call("export_document", map[string]interface{}{
+		"document_id": "pricing",
+		"format":      "html",
+		"profile":     "partners",
+	}); err == nil || !strings.Contains(err.Error(), "profile not found") {
+		t.Errorf("Expected unknown profile error, got %v", err)
+	}
+}
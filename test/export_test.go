@@ -1,6 +1,7 @@
 package test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -8,9 +9,11 @@ import (
 
 	"github.com/savant/mcp-servers/docgen2/pkg/blocks"
 	"github.com/savant/mcp-servers/docgen2/pkg/config"
+	"github.com/savant/mcp-servers/docgen2/pkg/diagram"
 	"github.com/savant/mcp-servers/docgen2/pkg/document"
 	"github.com/savant/mcp-servers/docgen2/pkg/export"
 	"github.com/savant/mcp-servers/docgen2/pkg/storage"
+	"github.com/savant/mcp-servers/docgen2/pkg/style"
 )
 
 func setupTestDir(t *testing.T) (string, func()) {
@@ -358,6 +361,40 @@ func TestExportNonExistentDocument(t *testing.T) {
 	}
 }
 
+func TestExportRequireVariablesInHeader(t *testing.T) {
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	exp := export.NewExporter(cfg, stor)
+
+	docID, err := stor.CreateDocument("Letter", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: "Dear {{client_name}}"}, document.Position{Type: document.PositionEnd})
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docStyle := style.GetDefaultStyle()
+	docStyle.Header.Content = "{{company}} confidential"
+	doc.Style = &docStyle
+	if err := stor.SaveDocument(docID, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// The header placeholder is reported along with the one in the text
+	_, err = exp.ExportDocumentWithOptions(docID, "html", export.ExportOptions{
+		Variables:        map[string]string{"client_name": "Acme"},
+		RequireVariables: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "no value for variables: company") {
+		t.Errorf("Expected error naming the header variable, got: %v", err)
+	}
+}
+
 func TestGetSupportedFormats(t *testing.T) {
 	tmpDir, cleanup := setupTestDir(t)
 	defer cleanup()
@@ -386,4 +423,1636 @@ func TestGetSupportedFormats(t *testing.T) {
 			t.Errorf("Expected format '%s' not found", expected)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestMarkdownBuilderCrossReferences(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Reference Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end := document.Position{Type: document.PositionEnd}
+	stor.AddBlock(docID, "", &blocks.HeadingBlock{Level: 1, Text: "Intro"}, end)
+	stor.AddBlock(docID, "", &blocks.HeadingBlock{Level: 2, Text: "Design"}, end)
+	stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: "See {@ref:tbl-cost} and {@ref:sec-design}, not {@ref:missing}."}, end)
+	stor.AddBlock(docID, "", &blocks.TableBlock{Headers: []string{"A"}, Rows: [][]string{{"1"}}, Caption: "Inputs"}, end)
+	stor.AddBlock(docID, "", &blocks.TableBlock{Headers: []string{"B"}, Rows: [][]string{{"2"}}}, end)
+
+	if err := stor.SetBlockLabel(docID, "hd-002", "sec-design"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stor.SetBlockLabel(docID, "tbl-002", "tbl-cost"); err != nil {
+		t.Fatal(err)
+	}
+
+	markdown, err := mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+
+	if !strings.Contains(markdown, "[Table 2](#tbl-cost)") {
+		t.Errorf("Table reference not resolved, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "[Section 1.1](#sec-design)") {
+		t.Errorf("Section reference not resolved, got:\n%s", markdown)
+	}
+	// Headings show the numbers section references point at
+	if !strings.Contains(markdown, "## 1.1 Design {#sec-design}") || !strings.Contains(markdown, "# 1 Intro") {
+		t.Errorf("Heading numbers or anchor not emitted, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "::: {#tbl-cost}") {
+		t.Error("Table anchor div not emitted")
+	}
+	if !strings.Contains(markdown, "not ??.") {
+		t.Error("Dangling reference should render as ??")
+	}
+
+	// Moving the labelled table first renumbers the reference
+	if err := stor.MoveBlock(docID, "tbl-002", document.Position{Type: document.PositionStart}); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err = mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(markdown, "[Table 1](#tbl-cost)") {
+		t.Error("Reference not renumbered after move")
+	}
+
+	dangling, err := mb.ValidateReferences(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dangling) != 1 || dangling[0].Label != "missing" || dangling[0].BlockID != "md-001" {
+		t.Errorf("Expected one dangling reference to 'missing' in md-001, got %+v", dangling)
+	}
+
+	// Labelled equations carry the number references show
+	stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: "$$E = mc^2$$"}, end)
+	if err := stor.SetBlockLabel(docID, "md-002", "eq-energy"); err != nil {
+		t.Fatal(err)
+	}
+	stor.AddBlock(docID, "", &blocks.ListBlock{Style: "unordered", Items: []blocks.ListItem{{Text: "By {@ref:eq-energy}, not {@ref:gone}"}}}, end)
+	pdfMarkdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(pdfMarkdown, "$$E = mc^2 \\tag{1}$$") || !strings.Contains(pdfMarkdown, "[Equation 1](#eq-energy)") {
+		t.Errorf("Expected a tagged equation and its reference, got:\n%s", pdfMarkdown)
+	}
+
+	// References outside markdown blocks are checked too
+	dangling, err = mb.ValidateReferences(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dangling) != 2 || dangling[1].Label != "gone" || dangling[1].BlockID != "list-001" {
+		t.Errorf("Expected a dangling reference to 'gone' in list-001, got %+v", dangling)
+	}
+}
+
+func TestMarkdownBuilderCaptionNumbering(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Caption Test", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, _ := stor.GetDocument(docID)
+	doc.Style = &style.StyleConfig{
+		Captions: style.CaptionConfig{Numbering: "chapter", ListOfTables: true},
+	}
+	if err := stor.SaveDocument(docID, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	end := document.Position{Type: document.PositionEnd}
+	ch1, _ := stor.AddChapter(docID, "One", end)
+	ch2, _ := stor.AddChapter(docID, "Two", end)
+	stor.AddBlock(docID, ch1, &blocks.TableBlock{Headers: []string{"A"}, Rows: [][]string{{"1"}}, Caption: "First"}, end)
+	stor.AddBlock(docID, ch2, &blocks.TableBlock{Headers: []string{"A"}, Rows: [][]string{{"1"}}}, end)
+	stor.AddBlock(docID, ch2, &blocks.TableBlock{Headers: []string{"B"}, Rows: [][]string{{"2"}}, Caption: "Second"}, end)
+	stor.AddBlock(docID, ch2, &blocks.ImageBlock{Path: "assets/x.png", Caption: "Diagram"}, end)
+
+	markdown, err := mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+
+	if !strings.Contains(markdown, ": Table 1.1: First") {
+		t.Errorf("Expected chapter-numbered caption for first table, got:\n%s", markdown)
+	}
+	// Uncaptioned tables are not numbered
+	if !strings.Contains(markdown, ": Table 2.1: Second") {
+		t.Errorf("Expected uncaptioned table to be skipped in numbering, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "![Figure 2.1: Diagram]") {
+		t.Errorf("Expected numbered figure caption, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "- [Table 2.1: Second](#ch-002-tbl-002)") {
+		t.Errorf("Expected linked list of tables entry, got:\n%s", markdown)
+	}
+
+	pdfMarkdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(pdfMarkdown, "\\listoftables") {
+		t.Error("Expected native LaTeX list of tables for PDF")
+	}
+}
+
+func TestMarkdownBuilderRichTables(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Table Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end := document.Position{Type: document.PositionEnd}
+
+	// Alignment alone still fits a pipe table
+	aligned := &blocks.TableBlock{
+		Headers: []string{"Item", "Qty"},
+		Rows:    [][]string{{"Apples", "3"}},
+		Columns: []blocks.TableColumn{{Align: "left"}, {Align: "right"}},
+	}
+	stor.AddBlock(docID, "", aligned, end)
+
+	// Spans and multi-line cells need a grid table
+	spanned := &blocks.TableBlock{
+		Headers: []string{"Property", "", "Earth"},
+		Rows: [][]string{
+			{"Temperature\n1961-1990", "min", "-89.2 °C"},
+			{"", "mean", "14 °C"},
+			{"", "max", "56.7 °C"},
+		},
+		Columns: []blocks.TableColumn{{Width: 2}, {Width: 1}, {Width: 1, Align: "center"}},
+		Spans: []blocks.CellSpan{
+			{Row: 0, Col: 0, ColSpan: 2},
+			{Row: 1, Col: 0, RowSpan: 3},
+		},
+	}
+	if err := spanned.Validate(); err != nil {
+		t.Fatalf("Expected valid spans: %v", err)
+	}
+	stor.AddBlock(docID, "", spanned, end)
+
+	markdown, err := mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+
+	if !strings.Contains(markdown, "| :--- | ---: |") {
+		t.Errorf("Expected aligned pipe table separator, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "+===") {
+		t.Errorf("Expected grid table header separator, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, ":+\n") {
+		t.Errorf("Expected center alignment colons on the header separator, got:\n%s", markdown)
+	}
+
+	// A single weighted column is wider than the others, which count as width 1
+	weightedDoc, err := stor.CreateDocument("Weighted Table Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	weighted := &blocks.TableBlock{
+		Headers: []string{"Name", "A", "B"},
+		Rows:    [][]string{{"x", "y", "z"}},
+		Columns: []blocks.TableColumn{{Width: 2}},
+	}
+	stor.AddBlock(weightedDoc, "", weighted, end)
+	markdown, err = mb.BuildMarkdown(weightedDoc)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	// 62 characters of content split 2:1:1, plus a space either side of each cell
+	expected := "+" + strings.Repeat("-", 33) + "+" + strings.Repeat("-", 17) + "+" + strings.Repeat("-", 17) + "+"
+	if !strings.Contains(markdown, expected) {
+		t.Errorf("Expected columns weighted 2:1:1, got:\n%s", markdown)
+	}
+
+	// Overlapping spans are rejected
+	bad := &blocks.TableBlock{
+		Headers: []string{"A", "B"},
+		Rows:    [][]string{{"1", "2"}},
+		Spans:   []blocks.CellSpan{{Row: 1, Col: 0, ColSpan: 2}, {Row: 1, Col: 1}},
+	}
+	if err := bad.Validate(); err == nil {
+		t.Error("Expected overlapping spans to be rejected")
+	}
+}
+
+func TestMarkdownBuilderCharts(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Chart Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end := document.Position{Type: document.PositionEnd}
+	stor.AddBlock(docID, "", &blocks.ImageBlock{Path: "assets/x.png", Caption: "Photo"}, end)
+	chart := &blocks.ChartBlock{
+		ChartType:  blocks.ChartBar,
+		Title:      "Revenue",
+		Categories: []string{"Q1", "Q2"},
+		Series:     []blocks.ChartSeries{{Values: []float64{1, 2}}},
+		Caption:    "Quarterly revenue",
+	}
+	if err := stor.AddBlock(docID, "", chart, end); err != nil {
+		t.Fatalf("Failed to add chart: %v", err)
+	}
+
+	// Charts round-trip through storage
+	loaded, _, _, err := stor.GetBlock(docID, chart.ID)
+	if err != nil {
+		t.Fatalf("Failed to load chart: %v", err)
+	}
+	if c, ok := loaded.(*blocks.ChartBlock); !ok || c.Series[0].Values[1] != 2 {
+		t.Errorf("Chart did not round-trip: %+v", loaded)
+	}
+
+	markdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "![Figure 2: Quarterly revenue](<") || !strings.Contains(markdown, "charts/chart-001.png>)") {
+		t.Errorf("Expected chart as numbered PNG figure, got:\n%s", markdown)
+	}
+
+	html, err := mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "charts/chart-001.svg>)") {
+		t.Errorf("Expected SVG chart for HTML, got:\n%s", html)
+	}
+}
+
+func TestMarkdownBuilderDiagrams(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Diagram Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end := document.Position{Type: document.PositionEnd}
+	dgm := &blocks.DiagramBlock{Engine: blocks.EngineMermaid, Source: "graph TD\n  A --> B", Caption: "Flow"}
+	if err := stor.AddBlock(docID, "", dgm, end); err != nil {
+		t.Fatalf("Failed to add diagram: %v", err)
+	}
+
+	// Without a rendered image, PDF shows the source and HTML renders client-side
+	markdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "```mermaid\ngraph TD\n  A --> B\n```") || !strings.Contains(markdown, "*Figure 1: Flow*") {
+		t.Errorf("Expected diagram source fallback, got:\n%s", markdown)
+	}
+
+	html, err := mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "<pre class=\"mermaid\">graph TD\n  A --&gt; B</pre>") || !strings.Contains(html, "mermaid.initialize") {
+		t.Errorf("Expected client-side mermaid diagram, got:\n%s", html)
+	}
+
+	// A cached render is embedded as a numbered figure
+	cached := filepath.Join(cfg.GetDocumentFolder(docID), diagram.CachePath(dgm, diagram.FormatPNG))
+	os.MkdirAll(filepath.Dir(cached), 0755)
+	if err := os.WriteFile(cached, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err = mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(markdown, "![Figure 1: Flow](<"+cached+">)") {
+		t.Errorf("Expected cached diagram image, got:\n%s", markdown)
+	}
+
+	// Editing the source changes the cache key
+	edited := &blocks.DiagramBlock{Engine: blocks.EngineMermaid, Source: "graph TD\n  A --> C"}
+	if diagram.CachePath(edited, diagram.FormatPNG) == diagram.CachePath(dgm, diagram.FormatPNG) {
+		t.Error("Expected edited source to change the cache path")
+	}
+}
+
+func TestMarkdownBuilderImageLayout(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Layout Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docFolder := cfg.GetDocumentFolder(docID)
+
+	end := document.Position{Type: document.PositionEnd}
+	stor.AddBlock(docID, "", &blocks.ImageBlock{Path: "assets/half.png", Caption: "Half", Width: "50%"}, end)
+	stor.AddBlock(docID, "", &blocks.ImageBlock{Path: "assets/side.png", Caption: "Side", Float: "right", Width: "40%"}, end)
+	gallery := &blocks.GalleryBlock{
+		Images: []blocks.GalleryImage{
+			{Path: "assets/a.png", Caption: "Before"},
+			{Path: "assets/b.png", Caption: "After"},
+		},
+		Caption: "Comparison",
+	}
+	if err := stor.AddBlock(docID, "", gallery, end); err != nil {
+		t.Fatalf("Failed to add gallery: %v", err)
+	}
+
+	loaded, _, _, err := stor.GetBlock(docID, gallery.ID)
+	if err != nil {
+		t.Fatalf("Failed to load gallery: %v", err)
+	}
+	if g, ok := loaded.(*blocks.GalleryBlock); !ok || len(g.Images) != 2 || g.ColumnCount() != 2 {
+		t.Errorf("Gallery did not round-trip: %+v", loaded)
+	}
+
+	// Width maps to a Pandoc attribute in every format
+	docx, err := mb.BuildMarkdownForFormat(docID, "docx")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(docx, filepath.Join(docFolder, "assets/half.png")+">){#img-001 width=50%}") {
+		t.Errorf("Expected width attribute, got:\n%s", docx)
+	}
+	if !strings.Contains(docx, "){width=48%} ![After](<") || !strings.Contains(docx, "*(a) Before; (b) After*") {
+		t.Errorf("Expected inline gallery row with sub-captions, got:\n%s", docx)
+	}
+
+	// PDF uses wrapfigure and subfigures
+	pdf, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\\begin{wrapfigure}{r}{0.4\\linewidth}",
+		"\\caption{Figure 2: Side}",
+		"\\begin{subfigure}[t]{0.485\\linewidth}",
+		"\\caption{(b) After}",
+		"\\caption{Figure 3: Comparison}\n\\label{gal-001}",
+	} {
+		if !strings.Contains(pdf, want) {
+			t.Errorf("Expected %q in PDF markdown, got:\n%s", want, pdf)
+		}
+	}
+
+	// HTML uses layout classes
+	html, err := mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "::: {.figure-float-right style=\"width: 40%\"}") || !strings.Contains(html, "::: {#gal-001 .gallery style=\"--gallery-columns: 2\"}") {
+		t.Errorf("Expected HTML layout divs, got:\n%s", html)
+	}
+
+	if err := (&blocks.ImageBlock{Path: "x.png", Width: "150%"}).Validate(); err == nil {
+		t.Error("Expected error for width over 100%")
+	}
+	if err := (&blocks.ImageBlock{Path: "x.png", Align: "left", Float: "left"}).Validate(); err == nil {
+		t.Error("Expected error for combined align and float")
+	}
+}
+
+func TestMarkdownBuilderIncludes(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	snippet, err := stor.CreateSnippet("Legal Disclaimer", "", "This report is not financial advice.")
+	if err != nil {
+		t.Fatalf("Failed to create snippet: %v", err)
+	}
+
+	end := document.Position{Type: document.PositionEnd}
+	sourceID, err := stor.CreateDocument("Source", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := &blocks.ImageBlock{Path: "assets/logo.png", Caption: "Logo"}
+	if err := stor.AddBlock(sourceID, "", img, end); err != nil {
+		t.Fatal(err)
+	}
+
+	docID, err := stor.CreateDocument("Report", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := &blocks.ImageBlock{Path: "assets/chart.png", Caption: "Local"}
+	for _, block := range []blocks.Block{
+		local,
+		&blocks.IncludeBlock{Snippet: snippet.ID},
+		&blocks.IncludeBlock{Document: sourceID, Block: img.ID},
+	} {
+		if err := stor.AddBlock(docID, "", block, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+
+	markdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "This report is not financial advice.") {
+		t.Errorf("Expected snippet content, got:\n%s", markdown)
+	}
+	// The included image keeps its own document's path and does not take a figure number
+	sourceImage := filepath.Join(cfg.GetDocumentFolder(sourceID), "assets/logo.png")
+	if !strings.Contains(markdown, "![Logo](<"+sourceImage+">)") || strings.Contains(markdown, "Figure 2") {
+		t.Errorf("Expected unnumbered image from the source document, got:\n%s", markdown)
+	}
+
+	// Updating the snippet updates every document that includes it
+	snippet.Content = "Updated disclaimer."
+	if err := stor.SaveSnippet(snippet); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err = mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(markdown, "Updated disclaimer.") {
+		t.Errorf("Expected updated snippet content, got:\n%s", markdown)
+	}
+
+	// Includes that lead back to themselves are reported instead of recursing
+	loopA := &blocks.IncludeBlock{Document: docID, Block: "inc-003"}
+	if err := stor.AddBlock(sourceID, "", loopA, end); err != nil {
+		t.Fatal(err)
+	}
+	loopB := &blocks.IncludeBlock{Document: sourceID, Block: loopA.ID}
+	if err := stor.AddBlock(docID, "", loopB, end); err != nil {
+		t.Fatal(err)
+	}
+	if loopB.ID != "inc-003" {
+		t.Fatalf("Expected inc-003, got %s", loopB.ID)
+	}
+	if _, err := mb.BuildMarkdown(docID); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("Expected include cycle error, got %v", err)
+	}
+
+	// So are cycles through layouts, across documents and within one
+	loops := map[string]string{"Loop A": "loop-b", "Loop B": "loop-a", "Loop C": "loop-c"}
+	for title, target := range loops {
+		loopID, err := stor.CreateDocument(title, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, block := range []blocks.Block{
+			&blocks.MarkdownBlock{Content: "Column text"},
+			&blocks.IncludeBlock{Document: target, Block: "layout-001"},
+			&blocks.LayoutBlock{Columns: 2, Blocks: []string{"md-001", "inc-001"}},
+		} {
+			if err := stor.AddBlock(loopID, "", block, end); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, loopID := range []string{"loop-a", "loop-c"} {
+		if _, err := mb.BuildMarkdown(loopID); err == nil || !strings.Contains(err.Error(), "include cycle") {
+			t.Errorf("Expected include cycle error for %s, got %v", loopID, err)
+		}
+		layout, _, _, err := stor.GetBlock(loopID, "layout-001")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stor.CheckIncludeCycle(loopID, "", "layout-001", layout); err == nil {
+			t.Errorf("Expected the layout of %s to be reported as a cycle", loopID)
+		}
+	}
+	if err := stor.CheckIncludeCycle("loop-a", "", "layout-001", &blocks.LayoutBlock{Columns: 2, Blocks: []string{"md-001"}}); err != nil {
+		t.Errorf("Expected a layout without the include to be accepted, got %v", err)
+	}
+}
+
+func TestMarkdownBuilderVariables(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Proposal for {{client_name}}", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Variables = map[string]string{"client_name": "Acme Corp", "amount": "$10,000"}
+	if err := stor.SaveDocument(docID, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	end := document.Position{Type: document.PositionEnd}
+	for _, block := range []blocks.Block{
+		&blocks.HeadingBlock{Level: 1, Text: "Scope for {{ client_name }}"},
+		&blocks.MarkdownBlock{Content: "The fee is {{amount}}. Signed: {{signatory}}."},
+		&blocks.ImageBlock{Path: "assets/logo.png", Caption: "{{client_name}} logo"},
+	} {
+		if err := stor.AddBlock(docID, "", block, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+
+	markdown, undefined, err := mb.BuildMarkdownWithVariables(docID, "pdf", nil)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, want := range []string{"Proposal for Acme Corp", "# Scope for Acme Corp", "The fee is $10,000.", "Acme Corp logo"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Expected %q, got:\n%s", want, markdown)
+		}
+	}
+	// Placeholders without a value are kept and reported
+	if !strings.Contains(markdown, "{{signatory}}") {
+		t.Errorf("Expected undefined placeholder to be kept, got:\n%s", markdown)
+	}
+	if len(undefined) != 1 || undefined[0] != "signatory" {
+		t.Errorf("Expected undefined [signatory], got %v", undefined)
+	}
+
+	// Overrides, such as a mail-merge row, take precedence over document variables
+	markdown, undefined, err = mb.BuildMarkdownWithVariables(docID, "pdf", map[string]string{"client_name": "Globex", "signatory": "J. Smith"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(markdown, "# Scope for Globex") || !strings.Contains(markdown, "Signed: J. Smith.") {
+		t.Errorf("Expected overridden values, got:\n%s", markdown)
+	}
+	if len(undefined) != 0 {
+		t.Errorf("Expected no undefined variables, got %v", undefined)
+	}
+}
+
+func TestLoadDataset(t *testing.T) {
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	csvPath := filepath.Join(tmpDir, "clients.csv")
+	if err := os.WriteFile(csvPath, []byte("client_name,amount\nAcme,100\nGlobex,200\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := export.LoadDataset(csvPath)
+	if err != nil {
+		t.Fatalf("Failed to load CSV dataset: %v", err)
+	}
+	if len(rows) != 2 || rows[1]["client_name"] != "Globex" || rows[1]["amount"] != "200" {
+		t.Errorf("Unexpected CSV rows: %v", rows)
+	}
+
+	jsonPath := filepath.Join(tmpDir, "clients.json")
+	if err := os.WriteFile(jsonPath, []byte(`[{"client_name": "Acme", "amount": 100, "vip": true}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = export.LoadDataset(jsonPath)
+	if err != nil {
+		t.Fatalf("Failed to load JSON dataset: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["amount"] != "100" || rows[0]["vip"] != "true" {
+		t.Errorf("Unexpected JSON rows: %v", rows)
+	}
+
+	// Column names must be usable as placeholders
+	badPath := filepath.Join(tmpDir, "bad.csv")
+	if err := os.WriteFile(badPath, []byte("client name\nAcme\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := export.LoadDataset(badPath); err == nil {
+		t.Error("Expected error for invalid column name")
+	}
+}
+
+func TestMarkdownBuilderProfiles(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Quarterly Report", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	summaryID, err := stor.AddChapter(docID, "Summary", end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notesID, err := stor.AddChapter(docID, "Internal Notes", end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internalImage := &blocks.ImageBlock{Path: "assets/margins.png", Caption: "Margins"}
+	publicImage := &blocks.ImageBlock{Path: "assets/revenue.png", Caption: "Revenue"}
+	for _, block := range []blocks.Block{
+		&blocks.MarkdownBlock{Content: "Revenue grew."},
+		internalImage,
+		publicImage,
+	} {
+		if err := stor.AddBlock(docID, summaryID, block, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+	if err := stor.AddBlock(docID, notesID, &blocks.MarkdownBlock{Content: "Do not share."}, end); err != nil {
+		t.Fatal(err)
+	}
+	internal := blocks.Conditions{"audience": {"internal"}}
+	if err := stor.SetBlockConditions(docID, internalImage.ID, internal); err != nil {
+		t.Fatal(err)
+	}
+	if err := stor.SetChapterConditions(docID, notesID, internal); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a profile everything is exported
+	markdown, err := mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "Margins") || !strings.Contains(markdown, "Do not share.") || !strings.Contains(markdown, "Figure 2: Revenue") {
+		t.Errorf("Expected all content without a profile, got:\n%s", markdown)
+	}
+
+	// The external profile leaves out internal content and renumbers what remains
+	markdown, _, err = mb.BuildMarkdownWithOptions(docID, "", export.BuildOptions{Profile: blocks.Profile{"audience": "external"}})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if strings.Contains(markdown, "Margins") || strings.Contains(markdown, "Internal Notes") || strings.Contains(markdown, "Do not share.") {
+		t.Errorf("Expected internal content to be left out, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "Revenue grew.") || !strings.Contains(markdown, "Figure 1: Revenue") {
+		t.Errorf("Expected public content numbered from 1, got:\n%s", markdown)
+	}
+}
+
+func TestMarkdownBuilderRawBlocks(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Raw Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	for _, block := range []blocks.Block{
+		&blocks.RawBlock{Format: blocks.RawLaTeX, Content: `\begin{center}\LaTeX\end{center}`},
+		&blocks.RawBlock{Format: blocks.RawHTML, Content: "<details><summary>More</summary>```</details>"},
+		&blocks.PageBreakBlock{},
+	} {
+		if err := stor.AddBlock(docID, "", block, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+
+	// PDF keeps only the LaTeX and breaks pages with \newpage
+	markdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "```{=latex}\n\\begin{center}\\LaTeX\\end{center}\n```") || !strings.Contains(markdown, "```{=latex}\n\\newpage\n```") {
+		t.Errorf("Expected raw LaTeX and a LaTeX page break, got:\n%s", markdown)
+	}
+	if strings.Contains(markdown, "<details>") {
+		t.Errorf("Expected raw HTML to be left out of PDF, got:\n%s", markdown)
+	}
+
+	// HTML gets no LaTeX, so \newpage no longer leaks into the page as text.
+	// A fence longer than any backtick run in the content keeps it intact.
+	markdown, err = mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(markdown, "newpage") || strings.Contains(markdown, "LaTeX") {
+		t.Errorf("Expected no LaTeX in HTML markdown, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "````{=html}\n<details><summary>More</summary>```</details>\n````") || !strings.Contains(markdown, "page-break-after: always") {
+		t.Errorf("Expected raw HTML and a print page break, got:\n%s", markdown)
+	}
+
+	// DOCX breaks pages with OpenXML
+	markdown, err = mb.BuildMarkdownForFormat(docID, "docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(markdown, "```{=openxml}\n<w:p><w:r><w:br w:type=\"page\"/></w:r></w:p>\n```") || strings.Contains(markdown, "newpage") {
+		t.Errorf("Expected an OpenXML page break only, got:\n%s", markdown)
+	}
+}
+
+func TestMarkdownBuilderLists(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("List Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	ordered := &blocks.ListBlock{Style: blocks.ListOrdered, Start: 3, Items: []blocks.ListItem{
+		{Text: "Third", Items: []blocks.ListItem{{Text: "Nested"}}},
+		{Text: "Fourth\nwith a second line"},
+	}}
+	task := &blocks.ListBlock{Style: blocks.ListTask, Items: []blocks.ListItem{
+		{Text: "Done", Checked: true},
+		{Text: "Open"},
+	}}
+	for _, block := range []blocks.Block{ordered, task} {
+		if err := stor.AddBlock(docID, "", block, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+
+	markdown, err := mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	// Nested lists restart at 1 and continuation lines stay inside the item
+	if !strings.Contains(markdown, "3. Third\n    1. Nested\n4. Fourth\n    with a second line") {
+		t.Errorf("Expected numbered list from 3 with nesting, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "- [x] Done\n- [ ] Open") {
+		t.Errorf("Expected task list checkboxes, got:\n%s", markdown)
+	}
+}
+
+func TestMarkdownBuilderQuotes(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Quote Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	for _, block := range []blocks.Block{
+		&blocks.QuoteBlock{Style: blocks.QuoteEpigraph, Text: "Begin at the beginning.", Attribution: "The King", Source: "Alice in Wonderland"},
+		&blocks.QuoteBlock{Style: blocks.QuotePullQuote, Text: "Less is more."},
+	} {
+		if err := stor.AddBlock(docID, "", block, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+
+	// PDF uses the environments defined in the LaTeX header
+	markdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		"```{=latex}\n\\begin{docgenepigraph}\n```\n\nBegin at the beginning.",
+		"\\begin{docgenattribution}\n```\n\n— The King, *Alice in Wonderland*",
+		"\\begin{docgenpullquote}",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in PDF markdown, got:\n%s", expected, markdown)
+		}
+	}
+
+	// HTML and DOCX share a div carrying both the CSS class and the Word style
+	markdown, err = mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, ":::: {.quote .epigraph custom-style=\"Epigraph\"}\nBegin at the beginning.\n\n::: {.attribution custom-style=\"Attribution\"}\n— The King, *Alice in Wonderland*\n:::\n::::") {
+		t.Errorf("Expected epigraph div with attribution, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, ":::: {.quote .pullquote custom-style=\"Pull Quote\"}\nLess is more.\n::::") {
+		t.Errorf("Expected pull-quote div without attribution, got:\n%s", markdown)
+	}
+	if strings.Contains(markdown, "docgenepigraph") {
+		t.Errorf("Expected no LaTeX in HTML markdown, got:\n%s", markdown)
+	}
+}
+
+func TestMarkdownBuilderLayouts(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Layout Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	for _, content := range []string{"Intro", "Alpha", "Beta", "Gamma", "Outro"} {
+		if err := stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: content}, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+	// The layout is added last but placed after the intro; its children follow it in the manifest
+	layout := &blocks.LayoutBlock{Columns: 3, Gap: "8mm", Blocks: []string{"md-003", "md-002", "md-004"}}
+	if err := stor.AddBlock(docID, "", layout, document.Position{Type: document.PositionAfter, BlockID: "md-001"}); err != nil {
+		t.Fatalf("Failed to add layout: %v", err)
+	}
+
+	// HTML: a grid with the children balanced one per column, in layout order, exported once
+	markdown, err := mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	expected := ":::: {.layout .columns-3 style=\"column-gap: 8mm\"}\n::: {.column}\nBeta\n:::\n\n::: {.column}\nAlpha\n:::\n\n::: {.column}\nGamma\n:::\n\n::::"
+	if !strings.Contains(markdown, expected) {
+		t.Errorf("Expected three-column grid, got:\n%s", markdown)
+	}
+	if strings.Count(markdown, "Alpha") != 1 {
+		t.Errorf("Expected layout children to be exported once, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "Intro\n\n"+expected+"\n\nOutro") {
+		t.Errorf("Expected layout between intro and outro, got:\n%s", markdown)
+	}
+
+	// PDF: multicols balances the columns; explicit breaks switch to multicols*
+	markdown, err = mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "\\begingroup\\setlength{\\columnsep}{8mm}\n\\begin{multicols}{3}\n```\n\nBeta\n\nAlpha\n\nGamma") {
+		t.Errorf("Expected balanced multicols, got:\n%s", markdown)
+	}
+
+	layout.ColumnBreaks = []string{"md-004"}
+	if err := stor.UpdateBlock(docID, layout.ID, layout); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err = mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "\\begin{multicols*}{3}\n```\n\nBeta\n\nAlpha\n\n```{=latex}\n\\columnbreak\n```\n\nGamma") {
+		t.Errorf("Expected column break before Gamma, got:\n%s", markdown)
+	}
+
+	// DOCX: continuous sections, the second one with columns and the gap in twips
+	markdown, err = mb.BuildMarkdownForFormat(docID, "docx")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, `<w:cols w:num="3" w:space="454"/>`) {
+		t.Errorf("Expected three-column section, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, `<w:br w:type="column"/>`) {
+		t.Errorf("Expected column break, got:\n%s", markdown)
+	}
+
+	// Deleting the layout returns its children to the normal flow
+	if err := stor.DeleteBlock(docID, layout.ID); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err = mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "Intro\n\nAlpha\n\nBeta\n\nGamma\n\nOutro") {
+		t.Errorf("Expected children back in document order, got:\n%s", markdown)
+	}
+}
+
+func TestMarkdownBuilderCoverPage(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Annual Report", false, "Jane Doe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: "Body text"}, document.Position{Type: document.PositionEnd}); err != nil {
+		t.Fatalf("Failed to add block: %v", err)
+	}
+
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Variables = map[string]string{"year": "2026"}
+	doc.Cover = &document.CoverPage{
+		Layout:       document.CoverModern,
+		Subtitle:     "Results for {{year}}",
+		Organization: "R&D",
+		Logo:         "assets/logo-001.png",
+	}
+	doc.Style = &style.StyleConfig{}
+	*doc.Style = style.GetDefaultStyle()
+	doc.Style.TOC.Enabled = true
+	if err := stor.SaveDocument(docID, doc); err != nil {
+		t.Fatal(err)
+	}
+	logoPath := filepath.Join(cfg.GetDocumentFolder(docID), "assets", "logo-001.png")
+
+	// PDF: a titlepage through include-before, without Pandoc's title block
+	markdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		"title-meta: \"Annual Report\"\nauthor-meta: \"Jane Doe\"\ninclude-before: |\n  ```{=latex}\n  \\begin{titlepage}\n  \\raggedright\n",
+		fmt.Sprintf("\\includegraphics[height=2cm,keepaspectratio]{%s}", logoPath),
+		"{\\sffamily\\Huge\\bfseries\\color{headingcolor} Annual Report\\par}",
+		"{\\Large Results for 2026\\par}",
+		"{\\large\\bfseries Jane Doe\\par}\n  {R\\&D\\par}",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in PDF markdown, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Contains(markdown, "\ntitle:") {
+		t.Errorf("Expected no title block next to the cover, got:\n%s", markdown)
+	}
+
+	// HTML: a hero section
+	markdown, err = mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		"pagetitle: \"Annual Report\"",
+		"<section class=\"cover cover-modern\">",
+		fmt.Sprintf("<img class=\"cover-logo\" src=\"%s\" alt=\"\">", logoPath),
+		"<div class=\"cover-subtitle\">Results for 2026</div>",
+		"<div>R&amp;D</div>",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in HTML markdown, got:\n%s", expected, markdown)
+		}
+	}
+
+	// DOCX: the cover opens the body and is followed by a page break and the table of contents
+	markdown, err = mb.BuildMarkdownForFormat(docID, "docx")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		`<w:pStyle w:val="Title"/><w:pBdr><w:bottom w:val="single" w:sz="16" w:space="4" w:color="000000"/></w:pBdr>`,
+		`<w:pStyle w:val="Author"/><w:spacing w:before="2880"/><w:jc w:val="left"/></w:pPr><w:r><w:t xml:space="preserve">Jane Doe</w:t>`,
+		`<w:br w:type="page"/>`,
+		`TOC \o "1-3" \h \z \u`,
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in DOCX markdown, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Index(markdown, "TOC \\o") > strings.Index(markdown, "Body text") {
+		t.Errorf("Expected the table of contents before the content, got:\n%s", markdown)
+	}
+
+	// Format-neutral markdown keeps Pandoc's title block
+	markdown, err = mb.BuildMarkdown(docID)
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.HasPrefix(markdown, "---\ntitle: \"Annual Report\"\nsubtitle: \"Results for 2026\"\nauthor: \"Jane Doe\"\n---") {
+		t.Errorf("Expected title block metadata, got:\n%s", markdown)
+	}
+}
+
+func TestMarkdownBuilderGlossaryAndIndex(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Manual", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range []blocks.Block{
+		&blocks.HeadingBlock{Level: 2, Text: "Setup"},
+		&blocks.MarkdownBlock{Content: "Call the `API` first.{@index:API}\n\nThe API{@index:API!keys} and our APIs share it."},
+		&blocks.HeadingBlock{Level: 2, Text: "Usage"},
+		&blocks.MarkdownBlock{Content: "Use the API and the SDK.{@index:API} See [the API docs](https://example.com/API). It works.{@index:Rate_limit}"},
+	} {
+		if err := stor.AddBlock(docID, "", block, document.Position{Type: document.PositionEnd}); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Glossary = []document.GlossaryTerm{
+		{Term: "SDK", Definition: "Software development kit"},
+		{Term: "API", Definition: "Application programming interface", Aliases: []string{"APIs"}},
+		{Term: "IT", Definition: "Information technology"},
+	}
+	if err := stor.SaveDocument(docID, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// HTML: first use linked, glossary sorted, index with section links
+	markdown, err := mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		"Call the `API` first.[]{#index-1}",
+		"The [API](#glossary-api)[]{#index-2} and our APIs share it.",
+		"Use the API and the [SDK](#glossary-sdk).[]{#index-3}",
+		"[the API docs](https://example.com/API)",
+		"# Glossary {#glossary .unnumbered}\n\n::: {.glossary}\n[API]{#glossary-api}\n:   Application programming interface\n\n[IT]{#glossary-it}",
+		"# Index {#index .unnumbered}\n\n::: {.index}\n- API: [Setup](#index-1), [Usage](#index-3)\n    - keys: [Setup](#index-2)\n- Rate_limit: [Usage](#index-4)\n:::",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in HTML markdown, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Contains(markdown, "[it](#glossary-it)") || strings.Contains(markdown, "{@index:") {
+		t.Errorf("Expected no lowercase acronym link or leftover markers, got:\n%s", markdown)
+	}
+
+	// PDF: makeindex entries and \printindex
+	markdown, err = mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		"`\\index{API}`{=latex}",
+		"`\\index{API!keys}`{=latex}",
+		"`\\index{Rate_limit@Rate\\_limit}`{=latex}",
+		"```{=latex}\n\\printindex\n```",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in PDF markdown, got:\n%s", expected, markdown)
+		}
+	}
+
+	// DOCX: XE fields and an INDEX field
+	markdown, err = mb.BuildMarkdownForFormat(docID, "docx")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		`<w:instrText xml:space="preserve"> XE "API:keys" </w:instrText>`,
+		`INDEX \c "2"`,
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in DOCX markdown, got:\n%s", expected, markdown)
+		}
+	}
+}
+
+func TestMarkdownBuilderGlossaryInQuotesAndTables(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Manual", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range []blocks.Block{
+		&blocks.QuoteBlock{Style: blocks.QuoteBlockquote, Text: "The SDK{@index:SDK} saves time.", Attribution: "An API{@index:API} user"},
+		&blocks.TableBlock{
+			Headers: []string{"Tool{@index:Tools}", "Kind"},
+			Rows:    [][]string{{"CLI", "Uses the API"}},
+			Caption: "Tools of the CLI{@index:CLI}",
+		},
+	} {
+		if err := stor.AddBlock(docID, "", block, document.Position{Type: document.PositionEnd}); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Glossary = []document.GlossaryTerm{
+		{Term: "SDK", Definition: "Software development kit"},
+		{Term: "API", Definition: "Application programming interface"},
+		{Term: "CLI", Definition: "Command-line interface"},
+	}
+	if err := stor.SaveDocument(docID, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	markdown, err := mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		"The [SDK](#glossary-sdk)[]{#index-1} saves time.",
+		"An [API](#glossary-api)[]{#index-2} user",
+		"| Tool[]{#index-3} | Kind |",
+		"| [CLI](#glossary-cli) | Uses the API |",
+		": Table 1: Tools of the CLI[]{#index-4}",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in HTML markdown, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Contains(markdown, "{@index:") {
+		t.Errorf("Expected no leftover index markers, got:\n%s", markdown)
+	}
+}
+
+func TestMarkdownBuilderFigureCaptions(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Palette", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	for _, block := range []blocks.Block{
+		&blocks.MarkdownBlock{Content: "The API Gateway routes the API Gateway calls."},
+		&blocks.ImageBlock{Path: "assets/red.png", Caption: "Red {@index:Red} see {@ref:fig-blue}", Align: blocks.AlignLeft},
+		&blocks.ImageBlock{Path: "assets/blue.png", Caption: "Blue"},
+	} {
+		if err := stor.AddBlock(docID, "", block, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+	if err := stor.SetBlockLabel(docID, "img-002", "fig-blue"); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Style = &style.StyleConfig{Captions: style.CaptionConfig{ListOfFigures: true}}
+	doc.Glossary = []document.GlossaryTerm{
+		{Term: "API", Definition: "Application programming interface"},
+		{Term: "API Gateway", Definition: "Entry point for API calls"},
+	}
+	if err := stor.SaveDocument(docID, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	markdown, err := mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		// A linked term falls back to the shorter term it starts with
+		"The [API Gateway](#glossary-api-gateway) routes the [API](#glossary-api) Gateway calls.",
+		"![Figure 1: Red []{#index-1} see [Figure 2](#fig-blue)]",
+		"- [Figure 1: Red see Figure 2](#",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in HTML markdown, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Contains(markdown, "{@") {
+		t.Errorf("Expected no leftover placeholders, got:\n%s", markdown)
+	}
+
+	markdown, err = mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if expected := `\caption{Figure 1: Red \index{Red} see \hyperref[fig-blue]{Figure 2}}`; !strings.Contains(markdown, expected) {
+		t.Errorf("Expected %q in PDF markdown, got:\n%s", expected, markdown)
+	}
+}
+
+func TestMarkdownBuilderComments(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Plan", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	if err := stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: "The launch is in March."}, end); err != nil {
+		t.Fatal(err)
+	}
+	if err := stor.AddBlock(docID, "", &blocks.HeadingBlock{Level: 2, Text: "Budget"}, end); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stor.AddComment(docID, "md-001", "Ana", "Which [year]?", "in March"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stor.ReplyToComment(docID, "cmt-001", "Ben", "2027"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stor.AddComment(docID, "hd-001", "Ana", "Rename to Costs", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stor.ResolveComment(docID, "cmt-002", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stripped by default
+	markdown, _, err := mb.BuildMarkdownWithOptions(docID, "html", export.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if strings.Contains(markdown, "Which") || strings.Contains(markdown, "margin-note") {
+		t.Errorf("Expected no comments by default, got:\n%s", markdown)
+	}
+
+	// HTML: open comments as margin notes beside the quoted passage
+	markdown, _, err = mb.BuildMarkdownWithOptions(docID, "html", export.BuildOptions{Comments: export.CommentsOpen})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	expected := "The launch is [in March]{.commented}[**Ana:** Which \\[year\\]? **Ben:** 2027]{.margin-note}."
+	if !strings.Contains(markdown, expected) {
+		t.Errorf("Expected %q in HTML markdown, got:\n%s", expected, markdown)
+	}
+	if strings.Contains(markdown, "Rename") {
+		t.Errorf("Expected resolved comments to be left out, got:\n%s", markdown)
+	}
+
+	// All comments include resolved ones, as notes before their block
+	markdown, _, err = mb.BuildMarkdownWithOptions(docID, "pdf", export.BuildOptions{Comments: export.CommentsAll})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		"in March`\\marginpar{\\footnotesize\\raggedright \\textbf{Ana:} Which [year]?\\par \\textbf{Ben:} 2027}`{=latex}.",
+		"`\\marginpar{\\footnotesize\\raggedright \\textbf{Ana:} Rename to Costs\\par\\textit{(resolved)}}`{=latex}\n\n## Budget",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in PDF markdown, got:\n%s", expected, markdown)
+		}
+	}
+
+	// DOCX: a Word comment per message on the quoted range
+	markdown, _, err = mb.BuildMarkdownWithOptions(docID, "docx", export.BuildOptions{Comments: export.CommentsOpen})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		`[Which \[year\]?]{.comment-start id="0" author="Ana"`,
+		`[2027]{.comment-start id="1" author="Ben"`,
+		`in March[]{.comment-end id="1"}[]{.comment-end id="0"}.`,
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in DOCX markdown, got:\n%s", expected, markdown)
+		}
+	}
+}
+
+func TestMarkdownBuilderNestedComments(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Plan", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	for _, content := range []string{"Left column", "Right column", "Kept together"} {
+		if err := stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: content}, end); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stor.AddBlock(docID, "", &blocks.LayoutBlock{Columns: 2, Blocks: []string{"md-001", "md-002"}}, end); err != nil {
+		t.Fatal(err)
+	}
+	if err := stor.SetBlockStyle(docID, "md-003", &style.StyleOverride{KeepTogether: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, comment := range []struct{ blockID, text, quote string }{
+		{"md-001", "Inside columns", "Left"},
+		{"md-003", "Inside the box", "Kept"},
+		{"md-003", "Before the box", ""},
+	} {
+		if _, err := stor.AddComment(docID, comment.blockID, "Ana", comment.text, comment.quote); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Margin notes cannot float out of multicols or a minipage, so those become footnotes
+	markdown, _, err := mb.BuildMarkdownWithOptions(docID, "pdf", export.BuildOptions{Comments: export.CommentsOpen})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		"Left`\\footnote{\\textbf{Ana:} Inside columns}`{=latex} column",
+		"Kept`\\footnote{\\textbf{Ana:} Inside the box}`{=latex} together",
+		"`\\marginpar{\\footnotesize\\raggedright \\textbf{Ana:} Before the box}`{=latex}",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in PDF markdown, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Count(markdown, "\\marginpar") != 1 {
+		t.Errorf("Expected one margin note, got:\n%s", markdown)
+	}
+}
+
+func TestMarkdownBuilderSuggestions(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Plan", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	if err := stor.AddBlock(docID, "", &blocks.HeadingBlock{Level: 2, Text: "Budget"}, end); err != nil {
+		t.Fatal(err)
+	}
+	if err := stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: "The launch is in March."}, end); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stor.AddSuggestion(docID, "md-001", "Ana", &blocks.MarkdownBlock{Content: "The launch is planned for April."}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stor.AddSuggestion(docID, "hd-001", "Ben", &blocks.HeadingBlock{Level: 2, Text: "Costs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Blocks as they are by default
+	markdown, _, err := mb.BuildMarkdownWithOptions(docID, "docx", export.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, "The launch is in March.") || strings.Contains(markdown, "April") {
+		t.Errorf("Expected blocks without suggestions by default, got:\n%s", markdown)
+	}
+
+	// DOCX: Word revisions by the suggestion's author
+	markdown, _, err = mb.BuildMarkdownWithOptions(docID, "docx", export.BuildOptions{Suggestions: export.SuggestionsTracked})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	for _, expected := range []string{
+		`The launch is [in March.]{.deletion author="Ana" date="`,
+		`[planned for April.]{.insertion author="Ana" date="`,
+		`## [Budget]{.deletion author="Ben" date="`,
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in DOCX markdown, got:\n%s", expected, markdown)
+		}
+	}
+
+	// PDF and HTML: underlined insertions and struck-through deletions
+	markdown, _, err = mb.BuildMarkdownWithOptions(docID, "pdf", export.BuildOptions{Suggestions: export.SuggestionsTracked})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	expected := "The launch is ~~in March.~~[planned for April.]{.underline}"
+	if !strings.Contains(markdown, expected) {
+		t.Errorf("Expected %q in PDF markdown, got:\n%s", expected, markdown)
+	}
+	markdown, _, err = mb.BuildMarkdownWithOptions(docID, "html", export.BuildOptions{Suggestions: export.SuggestionsTracked})
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	expected = "## [Budget]{.deletion}[Costs]{.insertion}"
+	if !strings.Contains(markdown, expected) {
+		t.Errorf("Expected %q in HTML markdown, got:\n%s", expected, markdown)
+	}
+}
+
+func TestMarkdownBuilderStyleOverrides(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Style Override Test", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	for _, content := range []string{"Intro", "Wide table", "Outro"} {
+		if err := stor.AddBlock(docID, "", &blocks.MarkdownBlock{Content: content}, end); err != nil {
+			t.Fatalf("Failed to add block: %v", err)
+		}
+	}
+
+	if err := stor.SetBlockStyle(docID, "md-002", &style.StyleOverride{Align: "middle"}); err == nil {
+		t.Error("Expected invalid alignment to be rejected")
+	}
+	override := &style.StyleOverride{FontSize: 9, Background: "240, 240, 240", Align: style.AlignCenter, Orientation: style.OrientationLandscape, KeepTogether: true}
+	if err := stor.SetBlockStyle(docID, "md-002", override); err != nil {
+		t.Fatalf("Failed to set style override: %v", err)
+	}
+
+	// HTML: a div with classes for printing and the rest inline
+	markdown, err := mb.BuildMarkdownForFormat(docID, "html")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	expected := "Intro\n\n::: {.styled .landscape .keep-together style=\"font-size: 9pt; background-color: rgb(240,240,240); padding: 0.5em 1em; text-align: center\"}\nWide table\n:::\n\nOutro"
+	if !strings.Contains(markdown, expected) {
+		t.Errorf("Expected styled div, got:\n%s", markdown)
+	}
+
+	// PDF: landscape outermost, then the minipage, the shading and the text group
+	markdown, err = mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	expected = "```{=latex}\n\\begin{landscape}\n\\par\\noindent\\begin{minipage}{\\linewidth}\n" +
+		"\\begingroup\\definecolor{shadecolor}{RGB}{240,240,240}\\begin{snugshade}\n" +
+		"\\begingroup\\fontsize{9}{10.8}\\selectfont\\centering\n```\n\nWide table\n\n" +
+		"```{=latex}\n\\par\\endgroup\n\\end{snugshade}\\endgroup\n\\end{minipage}\\par\n\\end{landscape}\n```"
+	if !strings.Contains(markdown, expected) {
+		t.Errorf("Expected LaTeX style environments, got:\n%s", markdown)
+	}
+
+	// DOCX: only the orientation, as a section of landscape pages
+	markdown, err = mb.BuildMarkdownForFormat(docID, "docx")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if !strings.Contains(markdown, `<w:pgSz w:w="11906" w:h="16838"/>`) || !strings.Contains(markdown, `<w:pgSz w:w="16838" w:h="11906" w:orient="landscape"/>`) {
+		t.Errorf("Expected portrait and landscape sections, got:\n%s", markdown)
+	}
+	if strings.Contains(markdown, "styled") {
+		t.Errorf("Expected no div in Word markdown, got:\n%s", markdown)
+	}
+
+	// A landscape block in a landscape document needs no section of its own
+	if err := stor.SetBlockStyle(docID, "md-002", &style.StyleOverride{Orientation: style.OrientationLandscape}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := stor.GetDocument(docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docStyle := style.GetDefaultStyle()
+	docStyle.Page.Orientation = style.OrientationLandscape
+	doc.Style = &docStyle
+	if err := stor.SaveDocument(docID, doc); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err = mb.BuildMarkdownForFormat(docID, "docx")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	if strings.Contains(markdown, "sectPr") {
+		t.Errorf("Expected no section breaks, got:\n%s", markdown)
+	}
+
+	// An empty override clears it
+	if err := stor.SetBlockStyle(docID, "md-002", &style.StyleOverride{}); err != nil {
+		t.Fatal(err)
+	}
+	_, ref, _, err := stor.GetBlock(docID, "md-002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Style != nil {
+		t.Errorf("Expected override to be cleared, got %+v", ref.Style)
+	}
+}
+
+func TestMarkdownBuilderKeepTableTogether(t *testing.T) {
+	// Setup
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	cfg := &config.Config{RootFolder: tmpDir}
+	stor := storage.NewStorage(cfg)
+	mb := export.NewMarkdownBuilder(stor)
+
+	docID, err := stor.CreateDocument("Keep Test", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := document.Position{Type: document.PositionEnd}
+	chapterID, err := stor.AddChapter(docID, "Results", end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := &blocks.TableBlock{
+		Headers: []string{"Region", "Sales"},
+		Rows:    [][]string{{"North", "120"}, {"South", "95"}, {"East", "80"}},
+		Caption: "Sales by region",
+	}
+	if err := stor.AddBlock(docID, chapterID, table, end); err != nil {
+		t.Fatal(err)
+	}
+	if err := stor.SetBlockStyle(docID, "tbl-001", &style.StyleOverride{KeepTogether: true, FontSize: 9}); err != nil {
+		t.Fatalf("Failed to set style override: %v", err)
+	}
+
+	// Chapters may run over a page, so they cannot be kept together
+	if err := stor.SetChapterStyle(docID, chapterID, &style.StyleOverride{KeepTogether: true}); err == nil {
+		t.Error("Expected keep_together to be rejected for a chapter")
+	}
+
+	// The longtable is not boxed; it starts a new page unless its rows fit
+	markdown, err := mb.BuildMarkdownForFormat(docID, "pdf")
+	if err != nil {
+		t.Fatalf("Failed to build markdown: %v", err)
+	}
+	expected := "```{=latex}\n\\begingroup\\fontsize{9}{10.8}\\selectfont\n```\n\n```{=latex}\n\\needspace{8\\baselineskip}\n```\n\n::: {#ch-001-tbl-001}\n| Region | Sales |"
+	if !strings.Contains(markdown, expected) {
+		t.Errorf("Expected %q, got:\n%s", expected, markdown)
+	}
+	if strings.Contains(markdown, "minipage") {
+		t.Errorf("Expected no minipage around a table, got:\n%s", markdown)
+	}
+}
@@ -1,8 +1,13 @@
 package test
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
@@ -703,7 +708,7 @@ func TestGetBlocksImageBlock(t *testing.T) {
 	// Create a dummy image file for testing
 	tempDir := "/tmp"
 	imagePath := filepath.Join(tempDir, "test-image.png")
-	err = os.WriteFile(imagePath, []byte("fake image data"), 0644)
+	err = os.WriteFile(imagePath, testPNGData(t), 0644)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -802,4 +807,316 @@ func TestGetBlocksInvalidInput(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for missing block_ids")
 	}
-}
\ No newline at end of file
+}
+
+func TestAddImageFromBase64(t *testing.T) {
+	h, cleanup := setupGetBlocksTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := h.CallTool(ctx, &protocol.CallToolRequest{
+		Name:      "create_document",
+		Arguments: map[string]interface{}{"title": "Base64 Image Test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(testPNGData(t))
+	addImage := func(args map[string]interface{}) error {
+		args["document_id"] = "base64-image-test"
+		_, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: "add_image", Arguments: args})
+		return err
+	}
+
+	// Raw base64 with a MIME type, and a data URL without one
+	if err := addImage(map[string]interface{}{"image_data": encoded, "filename": "chart.png", "mime_type": "image/png"}); err != nil {
+		t.Fatalf("Failed to add image from base64: %v", err)
+	}
+	if err := addImage(map[string]interface{}{"image_data": "data:image/png;base64," + encoded, "filename": "../../escape"}); err != nil {
+		t.Fatalf("Failed to add image from data URL: %v", err)
+	}
+
+	resp, err := h.CallTool(ctx, &protocol.CallToolRequest{
+		Name: "get_blocks",
+		Arguments: map[string]interface{}{
+			"document_id": "base64-image-test",
+			"block_ids":   []interface{}{"img-001", "img-002"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := resp.Content[0].Text
+	if !contains(content, "assets/chart-001.png") || !contains(content, "assets/escape-001.png") {
+		t.Errorf("Expected images stored in assets, got: %s", content)
+	}
+	if !contains(content, "\"content_type\": \"image/png\"") {
+		t.Errorf("Expected asset metadata in response, got: %s", content)
+	}
+
+	// Mismatched type, invalid data and missing sources are rejected
+	if err := addImage(map[string]interface{}{"image_data": encoded, "mime_type": "image/jpeg"}); err == nil {
+		t.Error("Expected error for mismatched MIME type")
+	}
+	if err := addImage(map[string]interface{}{"image_data": base64.StdEncoding.EncodeToString([]byte("not an image"))}); err == nil {
+		t.Error("Expected error for data that is not an image")
+	}
+	if err := addImage(map[string]interface{}{"image_data": "%%%"}); err == nil {
+		t.Error("Expected error for invalid base64")
+	}
+	if err := addImage(map[string]interface{}{}); err == nil {
+		t.Error("Expected error when neither image_path nor image_data is given")
+	}
+
+	// An image for a block that cannot be added is not kept
+	_, err = h.CallTool(ctx, &protocol.CallToolRequest{
+		Name:      "create_document",
+		Arguments: map[string]interface{}{"title": "Chaptered Image Test", "has_chapters": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = h.CallTool(ctx, &protocol.CallToolRequest{
+		Name:      "add_image",
+		Arguments: map[string]interface{}{"document_id": "chaptered-image-test", "chapter_id": "ch-404", "image_data": encoded, "filename": "orphan.png"},
+	})
+	if err == nil {
+		t.Error("Expected error for an unknown chapter")
+	}
+	config := h.GetStorage().GetConfig()
+	if _, err := os.Stat(filepath.Join(config.GetDocumentFolder("chaptered-image-test"), "assets", "orphan-001.png")); !os.IsNotExist(err) {
+		t.Error("Expected the image of a block that was not added to be removed")
+	}
+
+	// Nor is anything written for a document that does not exist
+	_, err = h.CallTool(ctx, &protocol.CallToolRequest{
+		Name:      "add_image",
+		Arguments: map[string]interface{}{"document_id": "no-such-document", "image_data": encoded},
+	})
+	if err == nil {
+		t.Error("Expected error for an unknown document")
+	}
+	if _, err := os.Stat(config.GetDocumentFolder("no-such-document")); !os.IsNotExist(err) {
+		t.Error("Expected no folder for an unknown document")
+	}
+}
+
+func TestAddGalleryCleansUpOnError(t *testing.T) {
+	h, cleanup := setupGetBlocksTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := h.CallTool(ctx, &protocol.CallToolRequest{
+		Name:      "create_document",
+		Arguments: map[string]interface{}{"title": "Gallery Cleanup Test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first image is valid and stored; the second is not an image
+	encoded := base64.StdEncoding.EncodeToString(testPNGData(t))
+	_, err = h.CallTool(ctx, &protocol.CallToolRequest{
+		Name: "add_gallery",
+		Arguments: map[string]interface{}{
+			"document_id": "gallery-cleanup-test",
+			"images": []interface{}{
+				map[string]interface{}{"image_data": encoded, "filename": "first.png"},
+				map[string]interface{}{"image_data": base64.StdEncoding.EncodeToString([]byte("not an image")), "filename": "second.png"},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected error for a gallery with an invalid image")
+	}
+
+	assetsDir := filepath.Join(h.GetStorage().GetConfig().GetDocumentFolder("gallery-cleanup-test"), "assets")
+	if _, err := os.Stat(filepath.Join(assetsDir, "first-001.png")); !os.IsNotExist(err) {
+		t.Error("Expected the stored image to be removed")
+	}
+	if index, err := os.ReadFile(filepath.Join(assetsDir, "index.yaml")); err == nil && strings.Contains(string(index), "first-001.png") {
+		t.Errorf("Expected the asset index entry to be removed, got:\n%s", index)
+	}
+}
+
+// testPNGData encodes a small blank PNG
+func testPNGData(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestListBlockItems(t *testing.T) {
+	h, cleanup := setupGetBlocksTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	call := func(name string, args map[string]interface{}) (string, error) {
+		args["document_id"] = "launch-plan"
+		resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			return "", err
+		}
+		return resp.Content[0].Text, nil
+	}
+
+	if _, err := call("create_document", map[string]interface{}{"title": "Launch Plan"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("add_list", map[string]interface{}{
+		"style": "task",
+		"items": []interface{}{
+			"Write release notes",
+			map[string]interface{}{"text": "Update docs", "items": []interface{}{"API reference"}},
+			map[string]interface{}{"text": "Tag release", "checked": true},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to add list: %v", err)
+	}
+
+	// Tick one nested item without touching the rest of the list
+	content, err := call("toggle_list_item", map[string]interface{}{"block_id": "list-001", "item": "2.1"})
+	if err != nil {
+		t.Fatalf("Failed to toggle item: %v", err)
+	}
+	if !strings.Contains(content, `"progress": "2/4"`) {
+		t.Errorf("Expected 2/4 progress, got: %s", content)
+	}
+	if _, err := call("toggle_list_item", map[string]interface{}{"block_id": "list-001", "item": "9"}); err == nil {
+		t.Error("Expected error for missing item")
+	}
+
+	if _, err := call("add_list_item", map[string]interface{}{"block_id": "list-001", "text": "Announce", "position": float64(1)}); err != nil {
+		t.Fatalf("Failed to add item: %v", err)
+	}
+	if _, err := call("move_list_item", map[string]interface{}{"block_id": "list-001", "item": "1", "position": float64(4)}); err != nil {
+		t.Fatalf("Failed to move item: %v", err)
+	}
+
+	content, err = call("get_block", map[string]interface{}{"block_id": "list-001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	items, _ := result["items"].([]interface{})
+	if len(items) != 4 {
+		t.Fatalf("Expected 4 items, got: %s", content)
+	}
+	var order []string
+	for _, item := range items {
+		order = append(order, item.(map[string]interface{})["text"].(string))
+	}
+	if strings.Join(order, ", ") != "Write release notes, Update docs, Tag release, Announce" {
+		t.Errorf("Unexpected item order: %v", order)
+	}
+	nested := items[1].(map[string]interface{})["items"].([]interface{})[0].(map[string]interface{})
+	if nested["item"] != "2.1" || nested["checked"] != true {
+		t.Errorf("Expected checked item 2.1, got %v", nested)
+	}
+
+	// Only task lists have checkboxes
+	if _, err := call("add_list", map[string]interface{}{"style": "ordered", "items": []interface{}{"One"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("toggle_list_item", map[string]interface{}{"block_id": "list-002", "item": "1"}); err == nil {
+		t.Error("Expected error toggling an ordered list")
+	}
+}
+
+func TestGetBlocksByTag(t *testing.T) {
+	h, cleanup := setupGetBlocksTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	call := func(name string, args map[string]interface{}) (string, error) {
+		args["document_id"] = "quarterly-report"
+		resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			return "", err
+		}
+		return resp.Content[0].Text, nil
+	}
+
+	if _, err := call("create_document", map[string]interface{}{"title": "Quarterly Report"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"Revenue grew 12%.", "Costs are flat.", "Hiring is on hold."} {
+		if _, err := call("add_markdown", map[string]interface{}{"content": content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := call("tag_block", map[string]interface{}{"block_id": "md-003", "add": []interface{}{"Executive-Summary", "todo"}}); err != nil {
+		t.Fatalf("Failed to tag block: %v", err)
+	}
+	if _, err := call("tag_block", map[string]interface{}{"block_id": "md-001", "add": []interface{}{"executive-summary"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("tag_block", map[string]interface{}{"block_id": "md-002", "add": []interface{}{"not a tag"}}); err == nil {
+		t.Error("Expected error for an invalid tag")
+	}
+
+	// Tagged blocks come back in document order, whatever the case of the tag
+	content, err := call("get_blocks", map[string]interface{}{"tag": "EXECUTIVE-SUMMARY"})
+	if err != nil {
+		t.Fatalf("Failed to get blocks by tag: %v", err)
+	}
+	var results []map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &results); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(results) != 2 || results[0]["id"] != "md-001" || results[1]["id"] != "md-003" {
+		t.Errorf("Expected md-001 and md-003, got: %s", content)
+	}
+
+	// A tag narrows a list of IDs
+	content, err = call("get_blocks", map[string]interface{}{"block_ids": []interface{}{"md-001", "md-002", "md-003"}, "tag": "todo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = nil
+	json.Unmarshal([]byte(content), &results)
+	if len(results) != 1 || results[0]["id"] != "md-003" {
+		t.Errorf("Expected only md-003, got: %s", content)
+	}
+
+	// Search by tag
+	content, err = call("search_blocks", map[string]interface{}{"query": "is", "tag": "todo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "md-003") || strings.Contains(content, "md-002") {
+		t.Errorf("Expected only md-003 in search results, got: %s", content)
+	}
+
+	// Counts per tag, and removing a tag
+	if _, err := call("tag_block", map[string]interface{}{"block_id": "md-003", "remove": []interface{}{"todo"}}); err != nil {
+		t.Fatal(err)
+	}
+	content, err = call("list_tags", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Failed to list tags: %v", err)
+	}
+	var listed struct {
+		Tags []struct {
+			Tag    string   `json:"tag"`
+			Count  int      `json:"count"`
+			Blocks []string `json:"blocks"`
+		} `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(content), &listed); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(listed.Tags) != 1 || listed.Tags[0].Tag != "executive-summary" || listed.Tags[0].Count != 2 {
+		t.Errorf("Expected executive-summary on 2 blocks, got: %s", content)
+	}
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -1061,4 +1062,139 @@ func findIndex(s, substr string) int {
 		}
 	}
 	return -1
-}
\ No newline at end of file
+}
+func TestRefreshTableFlow(t *testing.T) {
+	h, cleanup := setupTestHandler(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	call := func(name string, args map[string]interface{}) (string, error) {
+		resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			return "", err
+		}
+		return resp.Content[0].Text, nil
+	}
+	
+	if _, err := call("create_document", map[string]interface{}{"title": "Refresh Test"}); err != nil {
+		t.Fatal(err)
+	}
+	csvPath := filepath.Join(t.TempDir(), "sales.csv")
+	if err := os.WriteFile(csvPath, []byte("Region,Q1,Q2\nNorth,1,2\nSouth,3,4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("add_table_from_file", map[string]interface{}{"document_id": "refresh-test", "file_path": csvPath}); err != nil {
+		t.Fatalf("Failed to import table: %v", err)
+	}
+	
+	// A manual edit keeps the source, adding a span over the second row
+	_, err := call("update_block", map[string]interface{}{
+		"document_id": "refresh-test",
+		"block_id":    "tbl-001",
+		"new_content": map[string]interface{}{
+			"headers": []interface{}{"Region", "Q1", "Q2"},
+			"rows":    []interface{}{[]interface{}{"North", "1", "2"}, []interface{}{"South", "3", "4"}},
+			"spans":   []interface{}{map[string]interface{}{"row": 2, "col": 1, "colspan": 2}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update table: %v", err)
+	}
+	
+	// The source loses a row, so the span no longer fits and is reported
+	if err := os.WriteFile(csvPath, []byte("Region,Q1,Q2\nNorth,5,6\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	message, err := call("refresh_table", map[string]interface{}{"document_id": "refresh-test", "block_id": "tbl-001"})
+	if err != nil {
+		t.Fatalf("Expected the edited table to refresh, got: %v", err)
+	}
+	if !strings.Contains(message, "dropped span at row 2, col 1") {
+		t.Errorf("Expected the dropped span to be reported, got: %s", message)
+	}
+}
+
+func TestThemedDocumentStyleFlow(t *testing.T) {
+	h, cleanup := setupTestHandler(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	call := func(name string, args map[string]interface{}) (string, error) {
+		resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			return "", err
+		}
+		return resp.Content[0].Text, nil
+	}
+	
+	if _, err := call("create_document", map[string]interface{}{"title": "Brand Test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("apply_style_theme", map[string]interface{}{"document_id": "brand-test", "theme": "corporate"}); err != nil {
+		t.Fatalf("Failed to apply theme: %v", err)
+	}
+	
+	// A partial update sets only what it names and can turn the theme's TOC off
+	_, err := call("update_document_style", map[string]interface{}{
+		"document_id": "brand-test",
+		"style": map[string]interface{}{
+			"colors": map[string]interface{}{"body_text": "50,50,50"},
+			"toc":    map[string]interface{}{"enabled": false},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update style: %v", err)
+	}
+	if _, err := call("update_document_style", map[string]interface{}{
+		"document_id": "brand-test",
+		"style":       map[string]interface{}{"toc": map[string]interface{}{"depth": float64(9)}},
+	}); err == nil {
+		t.Error("Expected an invalid TOC depth to be rejected")
+	}
+	
+	// Re-branding the theme reaches everything the document did not set
+	if _, err := call("create_document", map[string]interface{}{"title": "Brand Source"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = call("update_document_style", map[string]interface{}{
+		"document_id": "brand-source",
+		"style": map[string]interface{}{
+			"fonts":  map[string]interface{}{"body_family": "Verdana"},
+			"colors": map[string]interface{}{"heading_text": "0,128,0"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update style: %v", err)
+	}
+	_, err = call("save_style_as_theme", map[string]interface{}{"document_id": "brand-source", "name": "corporate", "overwrite": true})
+	if err != nil {
+		t.Fatalf("Failed to save theme: %v", err)
+	}
+	
+	text, err := call("get_document_style", map[string]interface{}{"document_id": "brand-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Theme     string                 `json:"theme"`
+		Overrides map[string]interface{} `json:"overrides"`
+		Effective struct {
+			Fonts  struct{ BodyFamily string }
+			Colors struct{ BodyText, HeadingText string }
+			TOC    struct{ Enabled bool }
+		} `json:"effective"`
+	}
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("Failed to parse style: %v\n%s", err, text)
+	}
+	effective := result.Effective
+	if effective.Fonts.BodyFamily != "Verdana" || effective.Colors.HeadingText != "0,128,0" {
+		t.Errorf("Expected the re-branded theme, got %s", text)
+	}
+	if effective.Colors.BodyText != "50,50,50" || effective.TOC.Enabled {
+		t.Errorf("Expected the document's own settings, got %s", text)
+	}
+	if len(result.Overrides) != 2 {
+		t.Errorf("Expected only the two sections set, got %v", result.Overrides)
+	}
+}